@@ -0,0 +1,417 @@
+// Command encryption manages the keys that protect encrypted columns and
+// rewrites existing rows.
+//
+// Rotating without downtime:
+//
+//  1. `rotate` adds a key and makes it active. Running servers reload the
+//     key file within a minute and wrap new values with it; values wrapped
+//     with older keys stay readable.
+//  2. `reencrypt` rewrites rows that are still plaintext or use an older
+//     key. Run it again until it reports nothing left to do.
+//  3. `remove-key <id>` drops a retired key once no row uses it.
+//
+// `decrypt` turns every value back into plaintext before rolling back the
+// encryption migration.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/joho/godotenv"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/encryption"
+)
+
+// table lists the encrypted columns of one table. Field names bound into
+// the ciphertext are "<table>.<column>", matching the repositories.
+type table struct {
+	name    string
+	columns []string
+	// uuidIDs is set for tables keyed by CHAR(36) instead of INT
+	uuidIDs bool
+	// keepUpdatedAt stops rewrites from bumping ON UPDATE timestamps
+	keepUpdatedAt bool
+}
+
+var tables = []table{
+	{
+		name:          "significant_notes",
+		columns:       []string{"note", "remarks"},
+		uuidIDs:       true,
+		keepUpdatedAt: true,
+	},
+	{
+		name:    "significant_note_versions",
+		columns: []string{"note", "remarks"},
+	},
+	{
+		name: "student_health_records",
+		columns: []string{
+			"vision_details",
+			"hearing_details",
+			"speech_details",
+			"general_health_details",
+		},
+		keepUpdatedAt: true,
+	},
+	{
+		name:          "student_consultations",
+		columns:       []string{"when_date", "for_what"},
+		keepUpdatedAt: true,
+	},
+	{
+		name:          "user_two_factor",
+		columns:       []string{"secret"},
+		keepUpdatedAt: true,
+	},
+}
+
+// rewriteFunc returns the new stored value and whether it changed.
+type rewriteFunc func(
+	ctx context.Context,
+	field string,
+	value string,
+) (string, bool, error)
+
+func main() {
+	_ = godotenv.Load()
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	keysFile := os.Getenv("ENCRYPTION_KEYS_FILE")
+	if keysFile == "" {
+		keysFile = encryption.DefaultKeysFile
+	}
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	batchSize := flags.Int("batch", 500, "rows read per query")
+	dryRun := flags.Bool("dry-run", false, "count rows without changing them")
+	_ = flags.Parse(os.Args[2:])
+
+	ctx := context.Background()
+
+	switch os.Args[1] {
+	case "rotate":
+		id, err := encryption.AddKey(keysFile)
+		if err != nil {
+			log.Fatal("failed to add key: ", err)
+		}
+		log.Printf("Key %s is now active. Run `reencrypt` once servers reload it.", id)
+
+	case "reencrypt", "decrypt":
+		cipher, err := loadCipher(keysFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		db := connect()
+		defer db.Close()
+
+		// Everything runs on one connection: the note version trigger only
+		// lets connections that set @allow_version_rewrite change history
+		conn, err := db.Connx(ctx)
+		if err != nil {
+			log.Fatal("failed to connect to db: ", err)
+		}
+		defer conn.Close()
+		_, err = conn.ExecContext(ctx, "SET @allow_version_rewrite = 1")
+		if err != nil {
+			log.Fatal("failed to allow version rewrites: ", err)
+		}
+
+		rewrite := reencryptValue(cipher)
+		if os.Args[1] == "decrypt" {
+			rewrite = decryptValue(cipher)
+		}
+
+		for _, t := range tables {
+			updated, skipped, err := rewriteTable(
+				ctx,
+				conn,
+				t,
+				rewrite,
+				*batchSize,
+				*dryRun,
+			)
+			if err != nil {
+				log.Fatalf("failed to rewrite %s: %v", t.name, err)
+			}
+			log.Printf(
+				"%s: %d rows rewritten, %d changed concurrently and skipped",
+				t.name,
+				updated,
+				skipped,
+			)
+		}
+
+	case "remove-key":
+		if flags.NArg() != 1 {
+			usage()
+		}
+		keyID := flags.Arg(0)
+
+		db := connect()
+		defer db.Close()
+
+		inUse, err := countKeyUsage(ctx, db, keyID)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if inUse > 0 {
+			log.Fatalf("key %s still protects %d rows; run `reencrypt` first", keyID, inUse)
+		}
+		if err := encryption.RemoveKey(keysFile, keyID); err != nil {
+			log.Fatal("failed to remove key: ", err)
+		}
+		log.Printf("Key %s removed.", keyID)
+
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: encryption rotate")
+	fmt.Fprintln(os.Stderr, "       encryption reencrypt [-batch N] [-dry-run]")
+	fmt.Fprintln(os.Stderr, "       encryption decrypt [-batch N] [-dry-run]")
+	fmt.Fprintln(os.Stderr, "       encryption remove-key <id>")
+	os.Exit(2)
+}
+
+func loadCipher(keysFile string) (*encryption.Cipher, error) {
+	provider, err := encryption.NewFileKeyProvider(keysFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load encryption keys: %w", err)
+	}
+
+	return encryption.NewCipher(provider), nil
+}
+
+// reencryptValue encrypts plaintext values and values wrapped with a key
+// other than the active one.
+func reencryptValue(cipher *encryption.Cipher) rewriteFunc {
+	return func(ctx context.Context, field, value string) (string, bool, error) {
+		needed, err := cipher.NeedsReencrypt(ctx, value)
+		if err != nil || !needed {
+			return value, false, err
+		}
+
+		plaintext, err := cipher.Decrypt(ctx, field, value)
+		if err != nil {
+			return "", false, err
+		}
+		encrypted, err := cipher.Encrypt(ctx, field, plaintext)
+		if err != nil {
+			return "", false, err
+		}
+
+		return encrypted, true, nil
+	}
+}
+
+func decryptValue(cipher *encryption.Cipher) rewriteFunc {
+	return func(ctx context.Context, field, value string) (string, bool, error) {
+		if !encryption.IsEncrypted(value) {
+			return value, false, nil
+		}
+
+		plaintext, err := cipher.Decrypt(ctx, field, value)
+		if err != nil {
+			return "", false, err
+		}
+
+		return plaintext, true, nil
+	}
+}
+
+// rewriteTable walks the table in id order. Each row is only updated if
+// its values are unchanged since they were read; a row edited in between
+// was already written with the active key and is skipped.
+func rewriteTable(
+	ctx context.Context,
+	db *sqlx.Conn,
+	t table,
+	rewrite rewriteFunc,
+	batchSize int,
+	dryRun bool,
+) (int, int, error) {
+	selectQuery := fmt.Sprintf(
+		"SELECT id, %s FROM %s WHERE id > ? ORDER BY id LIMIT ?",
+		strings.Join(t.columns, ", "),
+		t.name,
+	)
+
+	sets := make([]string, 0, len(t.columns)+1)
+	conds := make([]string, 0, len(t.columns)+1)
+	conds = append(conds, "id = ?")
+	for _, col := range t.columns {
+		sets = append(sets, col+" = ?")
+		conds = append(conds, col+" <=> ?")
+	}
+	if t.keepUpdatedAt {
+		sets = append(sets, "updated_at = updated_at")
+	}
+	updateQuery := fmt.Sprintf(
+		"UPDATE %s SET %s WHERE %s",
+		t.name,
+		strings.Join(sets, ", "),
+		strings.Join(conds, " AND "),
+	)
+
+	updated, skipped := 0, 0
+	var lastID any = 0
+	if t.uuidIDs {
+		lastID = ""
+	}
+
+	for {
+		rows, err := readBatch(ctx, db, selectQuery, lastID, batchSize, len(t.columns))
+		if err != nil {
+			return updated, skipped, err
+		}
+		if len(rows) == 0 {
+			return updated, skipped, nil
+		}
+
+		for _, row := range rows {
+			lastID = row[0].String
+			values := row[1:]
+
+			newValues := make([]any, len(values))
+			changed := false
+			for i, value := range values {
+				newValues[i] = value
+				if !value.Valid {
+					continue
+				}
+
+				field := t.name + "." + t.columns[i]
+				newValue, rewritten, err := rewrite(ctx, field, value.String)
+				if err != nil {
+					return updated, skipped, fmt.Errorf(
+						"row %s, %s: %w",
+						row[0].String,
+						t.columns[i],
+						err,
+					)
+				}
+				if rewritten {
+					newValues[i] = newValue
+					changed = true
+				}
+			}
+			if !changed {
+				continue
+			}
+			if dryRun {
+				updated++
+				continue
+			}
+
+			args := append(newValues, row[0].String)
+			for _, value := range values {
+				args = append(args, value)
+			}
+			res, err := db.ExecContext(ctx, updateQuery, args...)
+			if err != nil {
+				return updated, skipped, fmt.Errorf("row %s: %w", row[0].String, err)
+			}
+			if n, _ := res.RowsAffected(); n == 0 {
+				skipped++
+				continue
+			}
+			updated++
+		}
+	}
+}
+
+func readBatch(
+	ctx context.Context,
+	db *sqlx.Conn,
+	query string,
+	afterID any,
+	limit int,
+	columns int,
+) ([][]sql.NullString, error) {
+	rows, err := db.QueryContext(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batch [][]sql.NullString
+	for rows.Next() {
+		row := make([]sql.NullString, columns+1)
+		dest := make([]any, len(row))
+		for i := range row {
+			dest[i] = &row[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		batch = append(batch, row)
+	}
+
+	return batch, rows.Err()
+}
+
+// countKeyUsage counts rows with at least one value wrapped with keyID.
+func countKeyUsage(ctx context.Context, db *sqlx.DB, keyID string) (int, error) {
+	pattern := "enc:v1:" + keyID + ":%"
+
+	total := 0
+	for _, t := range tables {
+		conds := make([]string, len(t.columns))
+		args := make([]any, len(t.columns))
+		for i, col := range t.columns {
+			conds[i] = col + " LIKE ?"
+			args[i] = pattern
+		}
+
+		var count int
+		query := fmt.Sprintf(
+			"SELECT COUNT(*) FROM %s WHERE %s",
+			t.name,
+			strings.Join(conds, " OR "),
+		)
+		if err := db.GetContext(ctx, &count, query, args...); err != nil {
+			return 0, fmt.Errorf("failed to count %s: %w", t.name, err)
+		}
+		total += count
+	}
+
+	return total, nil
+}
+
+func connect() *sqlx.DB {
+	db, err := sqlx.Connect("mysql", buildDSNFromEnv())
+	if err != nil {
+		log.Fatal("failed to connect to db:", err)
+	}
+
+	return db
+}
+
+func buildDSNFromEnv() string {
+	dsn := fmt.Sprintf(
+		"%s:%s@tcp(%s:%s)/%s?parseTime=true",
+		os.Getenv("DB_USER"),
+		os.Getenv("DB_PASS"),
+		os.Getenv("DB_HOST"),
+		os.Getenv("DB_PORT"),
+		os.Getenv("DB_NAME"),
+	)
+	if os.Getenv("DB_TLS") == "true" {
+		dsn += "&tls=true"
+	}
+
+	return dsn
+}
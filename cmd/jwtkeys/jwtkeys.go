@@ -0,0 +1,119 @@
+// Command jwtkeys manages the keys access and refresh tokens are signed
+// with.
+//
+// Rotating without logging anyone out or failing partner verification:
+//
+//  1. `rotate` activates the next key, which has been in
+//     /.well-known/jwks.json for at least a few minutes, and publishes a
+//     new next key. Running servers reload the key file within a minute and
+//     sign new tokens with the activated key. The previous key is retired:
+//     it still verifies the tokens it signed and stays published. The first
+//     rotate on a file without a next key only publishes one; run it again
+//     once the printed time has passed.
+//  2. `prune` removes keys retired longer ago than the longest token
+//     lifetime plus a margin, once nothing they signed can still be valid.
+//
+// `list` shows the keys and their state.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/tokens"
+)
+
+func main() {
+	_ = godotenv.Load()
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	keysFile := os.Getenv("JWT_KEYS_FILE")
+	if keysFile == "" {
+		keysFile = tokens.DefaultKeysFile
+	}
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	grace := flags.Duration(
+		"grace",
+		tokens.MinPruneAge,
+		"how long a key must have been retired before it is pruned",
+	)
+	_ = flags.Parse(os.Args[2:])
+
+	switch os.Args[1] {
+	case "rotate":
+		activated, next, err := tokens.RotateKeys(keysFile)
+		if err != nil {
+			log.Fatal("failed to rotate keys: ", err)
+		}
+		if activated != "" {
+			log.Printf(
+				"Key %s is now active. Run `prune` after %s to drop the retired key.",
+				activated,
+				tokens.MinPruneAge,
+			)
+		}
+		log.Printf(
+			"Key %s is published and can be activated by `rotate` after %s.",
+			next,
+			tokens.MinPublishAge,
+		)
+
+	case "prune":
+		if *grace < tokens.MinPruneAge {
+			log.Fatalf(
+				"grace must be at least %s; a shorter one would reject tokens that are still valid",
+				tokens.MinPruneAge,
+			)
+		}
+		removed, err := tokens.PruneKeys(keysFile, *grace)
+		if err != nil {
+			log.Fatal("failed to prune keys: ", err)
+		}
+		if len(removed) == 0 {
+			log.Print("No retired keys are old enough to remove.")
+			return
+		}
+		log.Printf("Removed keys: %v", removed)
+
+	case "list":
+		keys, err := tokens.ListKeys(keysFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, key := range keys {
+			state := "verifying only"
+			switch {
+			case key.Active:
+				state = "active"
+			case key.Next:
+				state = "published, next to activate"
+			case key.RetiredAt != nil:
+				state = "retired " + key.RetiredAt.Format(time.RFC3339)
+			}
+			fmt.Printf(
+				"%s  created %s  %s\n",
+				key.ID,
+				key.CreatedAt.Format(time.RFC3339),
+				state,
+			)
+		}
+
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: jwtkeys rotate")
+	fmt.Fprintln(os.Stderr, "       jwtkeys prune [-grace 25h]")
+	fmt.Fprintln(os.Stderr, "       jwtkeys list")
+	os.Exit(2)
+}
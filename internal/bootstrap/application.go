@@ -3,12 +3,16 @@ package bootstrap
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/config"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/tokens"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/email"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/encryption"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/scanner"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/storage"
 )
 
@@ -19,6 +23,9 @@ type Application struct {
 func Initialize(db *sqlx.DB, cfg *config.Config) (*Application, error) {
 	var fileStorage storage.FileStorage
 	var emailer email.Emailer
+	var virusScanner scanner.Scanner
+	// localFiles serves signed download URLs when files are kept on disk
+	var localFiles http.Handler
 
 	if cfg.IsProduction {
 		{
@@ -47,10 +54,18 @@ func Initialize(db *sqlx.DB, cfg *config.Config) (*Application, error) {
 		{
 			emailer = email.NewSendGrid(cfg.SendGridAPIKey)
 		}
+		{
+			virusScanner = scanner.NewClamAV(cfg.ClamAVAddress)
+		}
 	} else {
 		{
-			uploadDir := cfg.LocalUploadDIR
-			fileStorage = storage.NewDiskStorage(uploadDir)
+			diskStorage := storage.NewDiskStorage(
+				cfg.LocalUploadDIR,
+				cfg.LocalFilesURL,
+				cfg.LocalFilesSigningKey,
+			)
+			fileStorage = diskStorage
+			localFiles = diskStorage
 		}
 		{
 			mailpit, err := email.NewMailPit(cfg.MailPitHost, cfg.MailPitPort)
@@ -62,17 +77,62 @@ func Initialize(db *sqlx.DB, cfg *config.Config) (*Application, error) {
 			}
 			emailer = mailpit
 		}
+		{
+			if cfg.ClamAVAddress != "" {
+				virusScanner = scanner.NewClamAV(cfg.ClamAVAddress)
+			} else {
+				virusScanner = scanner.NewStub()
+			}
+		}
+	}
+
+	// Development gets a key file on first run; production keys are
+	// provisioned and a missing file fails startup
+	if !cfg.IsProduction {
+		if err := encryption.EnsureKeyFile(cfg.EncryptionKeysFile); err != nil {
+			return nil, fmt.Errorf("failed to create encryption keys: %w", err)
+		}
+	}
+	keyProvider, err := encryption.NewFileKeyProvider(cfg.EncryptionKeysFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load encryption keys: %w", err)
 	}
 
-	repos := getRepositories(db)
+	repos := getRepositories(db, encryption.NewCipher(keyProvider))
+
+	if !cfg.IsProduction {
+		if err := tokens.EnsureKeyFile(cfg.JWTKeysFile); err != nil {
+			return nil, fmt.Errorf("failed to create signing keys: %w", err)
+		}
+	}
+	signingKeys, err := tokens.NewKeySet(cfg.JWTKeysFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load signing keys: %w", err)
+	}
+	legacySecret := ""
+	if cfg.JWTAcceptHS256 {
+		legacySecret = cfg.JWTSecret
+	}
+	tokenService := tokens.NewService(signingKeys, legacySecret)
 
 	redis, err := datastore.NewRedisClient(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize Redis: %w", err)
 	}
 
-	services := getServices(repos, fileStorage, cfg, redis, emailer)
+	services := getServices(
+		repos,
+		fileStorage,
+		cfg,
+		redis,
+		tokenService,
+		emailer,
+		virusScanner,
+	)
 	handlers := getHandlers(services, cfg, redis)
+	handlers.LocalFiles = localFiles
+
+	startJobs(context.Background(), repos, services, cfg)
 
 	return &Application{
 		Handlers: handlers,
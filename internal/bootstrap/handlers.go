@@ -1,11 +1,16 @@
 package bootstrap
 
 import (
+	"net/http"
+
 	"github.com/jmoiron/sqlx"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/config"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/tokens"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/analytics"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/appointments"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/auth"
+	"github.com/olazo-johnalbert/duckload-api/internal/features/calendar"
+	"github.com/olazo-johnalbert/duckload-api/internal/features/counselors"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/locations"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/logs"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/m2mclients"
@@ -14,6 +19,7 @@ import (
 	"github.com/olazo-johnalbert/duckload-api/internal/features/slips"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/students"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/students/integrations"
+	"github.com/olazo-johnalbert/duckload-api/internal/features/twofactor"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/users"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
 )
@@ -27,12 +33,19 @@ type Handlers struct {
 	NoteHandler               *notes.Handler
 	IntegrationStudentHandler *integrations.Handler
 	AppointmentHandler        *appointments.Handler
+	CounselorHandler          *counselors.Handler
+	CalendarHandler           *calendar.Handler
 	SlipHandler               *slips.Handler
 	AnalyticsHandler          *analytics.Handler
 	M2MClientHandler          *m2mclients.Handler
 	NotificationsHandler      *notifications.Handler
 	SystemLogHandler          *logs.Handler
+	TwoFactorHandler          *twofactor.Handler
 	Redis                     *datastore.RedisClient
+	Tokens                    *tokens.Service
+	// LocalFiles serves signed downloads from local storage; nil when files
+	// are kept in Azure.
+	LocalFiles http.Handler
 }
 
 func getHandlers(
@@ -50,8 +63,14 @@ func getHandlers(
 		AuthHandler: auth.NewHandler(
 			services.AuthService,
 			services.SystemLogService,
+			services.TokenService,
 			cfg,
 		),
+		TwoFactorHandler: twofactor.NewHandler(
+			services.TwoFactorService,
+			services.SessionService,
+			services.SystemLogService,
+		),
 		UserHandler: users.NewHandler(
 			services.UserService,
 			services.SessionService,
@@ -69,11 +88,14 @@ func getHandlers(
 		AppointmentHandler: appointments.NewHandler(
 			services.AppointmentService,
 		),
+		CounselorHandler:     counselors.NewHandler(services.CounselorService),
+		CalendarHandler:      calendar.NewHandler(services.CalendarService),
 		SlipHandler:          slips.NewHandler(services.SlipService),
 		AnalyticsHandler:     analyticsHandler,
 		M2MClientHandler:     m2mclients.NewHandler(services.M2MClientService),
 		NotificationsHandler: notificationsHandler,
 		SystemLogHandler:     systemLogHandler,
 		Redis:                redis,
+		Tokens:               services.TokenService,
 	}
 }
@@ -0,0 +1,49 @@
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/olazo-johnalbert/duckload-api/internal/core/config"
+	"github.com/olazo-johnalbert/duckload-api/internal/features/appointments"
+	"github.com/olazo-johnalbert/duckload-api/internal/features/notifications"
+	"github.com/olazo-johnalbert/duckload-api/internal/features/slips"
+)
+
+// startJobs launches the background workers that run alongside the API.
+// They stop when ctx is cancelled.
+func startJobs(
+	ctx context.Context,
+	repos *Repositories,
+	services *Services,
+	cfg *config.Config,
+) {
+	reminderScheduler := appointments.NewReminderScheduler(
+		repos.AppointmentRepo,
+		services.NotificationsService,
+		services.SystemLogService,
+		cfg.AppointmentReminderOffsets,
+		cfg.AppointmentReminderInterval,
+	)
+	go reminderScheduler.Run(ctx)
+
+	waitlistScheduler := appointments.NewWaitlistScheduler(
+		services.AppointmentService,
+		cfg.AppointmentWaitlistInterval,
+	)
+	go waitlistScheduler.Run(ctx)
+
+	attachmentScanScheduler := slips.NewAttachmentScanScheduler(
+		services.SlipService,
+		cfg.AttachmentScanInterval,
+	)
+	go attachmentScanScheduler.Run(ctx)
+
+	go services.NotificationBroker.Run(ctx)
+
+	digestScheduler := notifications.NewDigestScheduler(
+		services.NotificationsService,
+		cfg.NotificationDigestHour,
+		cfg.NotificationDigestInterval,
+	)
+	go digestScheduler.Run(ctx)
+}
@@ -4,6 +4,8 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/analytics"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/appointments"
+	"github.com/olazo-johnalbert/duckload-api/internal/features/calendar"
+	"github.com/olazo-johnalbert/duckload-api/internal/features/counselors"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/locations"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/logs"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/m2mclients"
@@ -12,7 +14,9 @@ import (
 	"github.com/olazo-johnalbert/duckload-api/internal/features/slips"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/students"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/students/integrations"
+	"github.com/olazo-johnalbert/duckload-api/internal/features/twofactor"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/users"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/encryption"
 )
 
 type Repositories struct {
@@ -21,26 +25,32 @@ type Repositories struct {
 	NoteRepo               *notes.Repository
 	IntegrationStudentRepo *integrations.Repository
 	AppointmentRepo        *appointments.Repository
+	CounselorRepo          *counselors.Repository
+	CalendarRepo           *calendar.Repository
 	SlipRepo               *slips.Repository
 	LocationsRepo          *locations.Repository
 	AnalyticsRepo          *analytics.Repository
 	M2MClientRepo          *m2mclients.Repository
 	NotificationRepo       *notifications.Repository
 	SystemLogRepo          *logs.Repository
+	TwoFactorRepo          *twofactor.Repository
 }
 
-func getRepositories(db *sqlx.DB) *Repositories {
+func getRepositories(db *sqlx.DB, cipher *encryption.Cipher) *Repositories {
 	return &Repositories{
 		UserRepo:               users.NewRepository(db),
-		StudentRepo:            students.NewRepository(db),
-		NoteRepo:               notes.NewRepository(db),
+		StudentRepo:            students.NewRepository(db, cipher),
+		NoteRepo:               notes.NewRepository(db, cipher),
 		IntegrationStudentRepo: integrations.NewRepository(db),
 		AppointmentRepo:        appointments.NewRepository(db),
+		CounselorRepo:          counselors.NewRepository(db),
+		CalendarRepo:           calendar.NewRepository(db),
 		SlipRepo:               slips.NewRepository(db),
 		LocationsRepo:          locations.NewRepository(db),
 		AnalyticsRepo:          analytics.NewRepository(db),
 		M2MClientRepo:          m2mclients.NewRepository(db),
 		NotificationRepo:       notifications.NewRepository(db),
 		SystemLogRepo:          logs.NewRepository(db),
+		TwoFactorRepo:          twofactor.NewRepository(db, cipher),
 	}
 }
@@ -2,12 +2,15 @@ package bootstrap
 
 import (
 	"github.com/olazo-johnalbert/duckload-api/internal/core/config"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/lockout"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/pdf"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/sessions"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/tokens"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/analytics"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/appointments"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/auth"
+	"github.com/olazo-johnalbert/duckload-api/internal/features/calendar"
+	"github.com/olazo-johnalbert/duckload-api/internal/features/counselors"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/locations"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/logs"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/m2mclients"
@@ -16,10 +19,12 @@ import (
 	"github.com/olazo-johnalbert/duckload-api/internal/features/slips"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/students"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/students/integrations"
+	"github.com/olazo-johnalbert/duckload-api/internal/features/twofactor"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/users"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/email"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/gotenberg"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/scanner"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/storage"
 )
 
@@ -31,12 +36,17 @@ type Services struct {
 	NoteService               notes.ServiceInterface
 	IntegrationStudentService integrations.ServiceInterface
 	AppointmentService        appointments.ServiceInterface
+	CounselorService          counselors.ServiceInterface
+	CalendarService           calendar.ServiceInterface
 	SlipService               slips.ServiceInterface
 	AnalyticsService          analytics.ServiceInterface
 	M2MClientService          m2mclients.ServiceInterface
 	NotificationsService      notifications.ServiceInterface
+	NotificationBroker        *notifications.Broker
 	SystemLogService          logs.ServiceInterface
 	SessionService            *sessions.Service
+	TokenService              *tokens.Service
+	TwoFactorService          twofactor.ServiceInterface
 }
 
 func getServices(
@@ -44,16 +54,29 @@ func getServices(
 	fileStorage storage.FileStorage,
 	cfg *config.Config,
 	redis *datastore.RedisClient,
+	tokenService *tokens.Service,
 	emailer email.Emailer,
+	virusScanner scanner.Scanner,
 ) *Services {
-	notificationsService := notifications.NewService(repos.NotificationRepo)
-	userService := users.NewService(repos.UserRepo)
+	notificationBroker := notifications.NewBroker(redis)
+	notificationsService := notifications.NewService(
+		repos.NotificationRepo,
+		notificationBroker,
+		emailer,
+	)
+	lockoutService := lockout.NewService(redis, lockout.Policy{
+		AccountThreshold: cfg.LoginAccountFailureLimit,
+		IPThreshold:      cfg.LoginIPFailureLimit,
+		BaseDelay:        cfg.LoginLockoutBase,
+		MaxDelay:         cfg.LoginLockoutMax,
+		Window:           cfg.LoginFailureWindow,
+	})
+	userService := users.NewService(repos.UserRepo, lockoutService)
 	systemLogService := logs.NewService(
 		repos.SystemLogRepo,
 		notificationsService,
 		userService,
 	)
-	tokenService := tokens.NewService()
 	sessionService := sessions.NewService(redis)
 	m2mClientService := m2mclients.NewService(
 		repos.M2MClientRepo,
@@ -62,10 +85,18 @@ func getServices(
 		tokenService,
 		sessionService,
 	)
+	twoFactorService := twofactor.NewService(
+		repos.TwoFactorRepo,
+		redis,
+		cfg.TwoFactorRequiredRoles,
+	)
 	authService := auth.NewService(
 		repos.UserRepo,
 		redis,
 		sessionService,
+		tokenService,
+		lockoutService,
+		twoFactorService,
 		emailer,
 	)
 	locationsService := locations.NewService(repos.LocationsRepo)
@@ -89,6 +120,22 @@ func getServices(
 	)
 	integrationStudentService := integrations.NewService(
 		repos.IntegrationStudentRepo,
+		sessionService,
+		emailer,
+		systemLogService,
+		notificationsService,
+	)
+	counselorService := counselors.NewService(
+		repos.CounselorRepo,
+		systemLogService,
+		notificationsService,
+		userService,
+	)
+	calendarService := calendar.NewService(
+		repos.CalendarRepo,
+		systemLogService,
+		notificationsService,
+		counselorService,
 	)
 	appointmentService := appointments.NewService(
 		repos.AppointmentRepo,
@@ -96,6 +143,10 @@ func getServices(
 		systemLogService,
 		userService,
 		noteService,
+		counselorService,
+		calendarService,
+		emailer,
+		cfg,
 	)
 	slipService := slips.NewService(
 		repos.SlipRepo,
@@ -103,6 +154,9 @@ func getServices(
 		notificationsService,
 		fileStorage,
 		userService,
+		pdfService,
+		virusScanner,
+		cfg,
 	)
 	analyticsService := analytics.NewService(repos.AnalyticsRepo, redis)
 
@@ -114,11 +168,16 @@ func getServices(
 		NoteService:               noteService,
 		IntegrationStudentService: integrationStudentService,
 		AppointmentService:        appointmentService,
+		CounselorService:          counselorService,
+		CalendarService:           calendarService,
 		SlipService:               slipService,
 		AnalyticsService:          analyticsService,
 		M2MClientService:          m2mClientService,
 		NotificationsService:      notificationsService,
+		NotificationBroker:        notificationBroker,
 		SystemLogService:          systemLogService,
 		SessionService:            sessionService,
+		TokenService:              tokenService,
+		TwoFactorService:          twoFactorService,
 	}
 }
@@ -1,6 +1,9 @@
 package audit
 
-import "context"
+import (
+	"context"
+	"strconv"
+)
 
 type contextKey string
 
@@ -56,3 +59,11 @@ func ExtractUserID(ctx context.Context) string {
 	id, _, _, _, _, _ := ExtractMeta(ctx)
 	return id
 }
+
+// ExtractRoleID returns the numeric role of the caller, or 0 when the context
+// carries none.
+func ExtractRoleID(ctx context.Context) int {
+	_, _, _, _, role, _ := ExtractMeta(ctx)
+	id, _ := strconv.Atoi(role)
+	return id
+}
@@ -45,15 +45,41 @@ const (
 	ActionAppointmentDeleted      = "APPOINTMENT_DELETED"
 	ActionAppointmentDeleteFailed = "APPOINTMENT_DELETE_FAILED"
 	ActionAppointmentFailed       = "APPOINTMENT_FAILED"
-
-	ActionSlipCreated       = "SLIP_CREATED"
-	ActionSlipCreateFailed  = "SLIP_CREATE_FAILED"
-	ActionSlipStatusUpdated = "SLIP_STATUS_UPDATED"
-	ActionSlipUpdated       = "SLIP_UPDATED"
-	ActionSlipUpdateFailed  = "SLIP_UPDATE_FAILED"
-	ActionSlipDeleted       = "SLIP_DELETED"
-	ActionSlipDeleteFailed  = "SLIP_DELETE_FAILED"
-	ActionSlipFailed        = "SLIP_FAILED"
+	ActionAppointmentReminderSent = "APPOINTMENT_REMINDER_SENT"
+
+	ActionRescheduleRequested     = "RESCHEDULE_REQUESTED"
+	ActionRescheduleRequestFailed = "RESCHEDULE_REQUEST_FAILED"
+	ActionRescheduleApproved      = "RESCHEDULE_APPROVED"
+	ActionRescheduleApproveFailed = "RESCHEDULE_APPROVE_FAILED"
+	ActionRescheduleDeclined      = "RESCHEDULE_DECLINED"
+	ActionRescheduleDeclineFailed = "RESCHEDULE_DECLINE_FAILED"
+
+	ActionWaitlistJoined            = "WAITLIST_JOINED"
+	ActionWaitlistJoinFailed        = "WAITLIST_JOIN_FAILED"
+	ActionWaitlistLeft              = "WAITLIST_LEFT"
+	ActionWaitlistOffered           = "WAITLIST_OFFERED"
+	ActionWaitlistOfferAccepted     = "WAITLIST_OFFER_ACCEPTED"
+	ActionWaitlistOfferAcceptFailed = "WAITLIST_OFFER_ACCEPT_FAILED"
+	ActionWaitlistOfferDeclined     = "WAITLIST_OFFER_DECLINED"
+	ActionWaitlistOfferExpired      = "WAITLIST_OFFER_EXPIRED"
+
+	ActionSlipCreated        = "SLIP_CREATED"
+	ActionSlipCreateFailed   = "SLIP_CREATE_FAILED"
+	ActionSlipStatusUpdated  = "SLIP_STATUS_UPDATED"
+	ActionSlipUpdated        = "SLIP_UPDATED"
+	ActionSlipUpdateFailed   = "SLIP_UPDATE_FAILED"
+	ActionSlipDeleted        = "SLIP_DELETED"
+	ActionSlipDeleteFailed   = "SLIP_DELETE_FAILED"
+	ActionSlipFailed         = "SLIP_FAILED"
+	ActionSlipResubmitted    = "SLIP_RESUBMITTED"
+	ActionSlipCommentCreated = "SLIP_COMMENT_CREATED"
+
+	ActionSlipCertificateIssued      = "SLIP_CERTIFICATE_ISSUED"
+	ActionSlipCertificateIssueFailed = "SLIP_CERTIFICATE_ISSUE_FAILED"
+	ActionSlipAttachmentInfected     = "SLIP_ATTACHMENT_INFECTED"
+
+	ActionSlipUrgencyPolicyUpdated      = "SLIP_URGENCY_POLICY_UPDATED"
+	ActionSlipUrgencyPolicyUpdateFailed = "SLIP_URGENCY_POLICY_UPDATE_FAILED"
 
 	ActionNoteCreated      = "NOTE_CREATED"
 	ActionNoteCreateFailed = "NOTE_CREATE_FAILED"
@@ -62,6 +88,18 @@ const (
 	ActionNoteDeleted      = "NOTE_DELETED"
 	ActionNoteDeleteFailed = "NOTE_DELETE_FAILED"
 
+	ActionCounselorCreated      = "COUNSELOR_CREATED"
+	ActionCounselorCreateFailed = "COUNSELOR_CREATE_FAILED"
+	ActionCounselorUpdated      = "COUNSELOR_UPDATED"
+	ActionCounselorUpdateFailed = "COUNSELOR_UPDATE_FAILED"
+	ActionCounselorDeleted      = "COUNSELOR_DELETED"
+	ActionCounselorDeleteFailed = "COUNSELOR_DELETE_FAILED"
+
+	ActionClosureCreated      = "CLOSURE_CREATED"
+	ActionClosureCreateFailed = "CLOSURE_CREATE_FAILED"
+	ActionClosureDeleted      = "CLOSURE_DELETED"
+	ActionClosureDeleteFailed = "CLOSURE_DELETE_FAILED"
+
 	ActionIIRCreated      = "IIR_CREATED"
 	ActionIIRCreateFailed = "IIR_CREATE_FAILED"
 	ActionIIRUpdated      = "IIR_UPDATED"
@@ -100,6 +138,25 @@ const (
 	ActionM2MAuthSuccess    = "M2M_AUTH_SUCCESS"
 	ActionM2MAuthFailed     = "M2M_AUTH_FAILED"
 	ActionM2MTokenRefreshed = "M2M_TOKEN_REFRESHED" // nolint:gosec
+	ActionStudentLinked     = "STUDENT_LINKED"
+	ActionStudentLinkFailed = "STUDENT_LINK_FAILED"
+
+	ActionPasswordResetRequested = "PASSWORD_RESET_REQUESTED"
+	ActionPasswordReset          = "PASSWORD_RESET"
+	ActionPasswordResetFailed    = "PASSWORD_RESET_FAILED"
+	ActionPasswordChanged        = "PASSWORD_CHANGED"
+	ActionPasswordChangeFailed   = "PASSWORD_CHANGE_FAILED"
+
+	ActionLoginLocked   = "LOGIN_LOCKED"
+	ActionLoginUnlocked = "LOGIN_UNLOCKED"
+
+	ActionTwoFactorEnabled         = "TWO_FACTOR_ENABLED"
+	ActionTwoFactorDisabled        = "TWO_FACTOR_DISABLED"
+	ActionTwoFactorFailed          = "TWO_FACTOR_FAILED"
+	ActionRecoveryCodeUsed         = "RECOVERY_CODE_USED"
+	ActionRecoveryCodesRegenerated = "RECOVERY_CODES_REGENERATED"
+
+	ActionRefreshTokenReused = "REFRESH_TOKEN_REUSED" // nolint:gosec
 )
 
 // LogEntry is the input struct used by other services to record a log.
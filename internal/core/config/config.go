@@ -1,8 +1,16 @@
 package config
 
 import (
+	"log"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/tokens"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/encryption"
 )
 
 type Config struct {
@@ -14,7 +22,16 @@ type Config struct {
 	DBTLS   bool
 	DBTLSCA string
 
+	// JWTSecret verified the HS256 tokens issued before signing keys and is
+	// only needed while JWTAcceptHS256 is on.
 	JWTSecret string
+	// JWTKeysFile holds the Ed25519 keys access and refresh tokens are
+	// signed with. Local development creates it on first run.
+	JWTKeysFile string
+	// JWTAcceptHS256 keeps accepting tokens signed with JWTSecret before
+	// signing moved to JWTKeysFile. It is off unless enabled, and should
+	// only be enabled until those tokens have expired.
+	JWTAcceptHS256 bool
 
 	WebsitesPort string
 
@@ -22,6 +39,13 @@ type Config struct {
 	AzureStorageConnectionString string
 	AzureContainerName           string
 
+	// FileURLExpiry is how long signed download URLs stay valid.
+	FileURLExpiry time.Duration
+	// LocalFilesURL is where local storage serves signed downloads in
+	// development. LocalFilesSigningKey signs those URLs.
+	LocalFilesURL        string
+	LocalFilesSigningKey string
+
 	IsProduction bool
 
 	IDPClientID     string
@@ -39,6 +63,57 @@ type Config struct {
 
 	MailPitHost string
 	MailPitPort int
+
+	// AppointmentSlotCapacity fixes how many bookings a time slot accepts.
+	// Zero derives the capacity from the number of available counselors.
+	AppointmentSlotCapacity int
+
+	// AppointmentReminderOffsets are how long before an appointment the
+	// student is reminded, largest first.
+	AppointmentReminderOffsets  []time.Duration
+	AppointmentReminderInterval time.Duration
+
+	// AppointmentWaitlistHold is how long a freed slot is held for a
+	// waitlisted student before it is offered to the next one.
+	AppointmentWaitlistHold     time.Duration
+	AppointmentWaitlistInterval time.Duration
+
+	// SlipVerifyURL is the public page the QR code on an approved slip
+	// links to; the verification code is appended as a path segment.
+	SlipVerifyURL string
+	// SlipSigningKey signs issued slips.
+	SlipSigningKey string
+
+	// ClamAVAddress is the host:port of the clamd daemon that scans slip
+	// attachments. Local development falls back to a stub when unset.
+	ClamAVAddress string
+	// AttachmentScanInterval is how often attachments still in quarantine
+	// are scanned again, e.g. after clamd was unreachable.
+	AttachmentScanInterval time.Duration
+
+	// EncryptionKeysFile holds the keys that protect counseling notes and
+	// health records. Local development creates it on first run.
+	EncryptionKeysFile string
+
+	// NotificationDigestHour is the hour of the day, server time, from
+	// which daily notification digests are sent.
+	NotificationDigestHour     int
+	NotificationDigestInterval time.Duration
+
+	// LoginAccountFailureLimit and LoginIPFailureLimit are how many failed
+	// logins an account or a client IP may have within LoginFailureWindow
+	// before logins are locked, starting at LoginLockoutBase and doubling
+	// with each further failure up to LoginLockoutMax.
+	LoginAccountFailureLimit int
+	LoginIPFailureLimit      int
+	LoginLockoutBase         time.Duration
+	LoginLockoutMax          time.Duration
+	LoginFailureWindow       time.Duration
+
+	// TwoFactorRequiredRoles lists the roles whose native logins must use
+	// two-factor authentication. Until they enroll, their sessions can only
+	// reach the /auth routes.
+	TwoFactorRequiredRoles []int
 }
 
 func LoadConfig() *Config {
@@ -52,6 +127,15 @@ func LoadConfig() *Config {
 		DBTLSCA: os.Getenv("DB_TLS_CA"),
 
 		JWTSecret: os.Getenv("JWT_SECRET"),
+		JWTKeysFile: func() string {
+			path := os.Getenv("JWT_KEYS_FILE")
+			if path == "" {
+				return tokens.DefaultKeysFile
+			}
+
+			return path
+		}(),
+		JWTAcceptHS256: os.Getenv("JWT_ACCEPT_HS256") == "true",
 
 		WebsitesPort: os.Getenv("WEBSITES_PORT"),
 
@@ -61,6 +145,24 @@ func LoadConfig() *Config {
 		),
 		AzureContainerName: os.Getenv("AZURE_CONTAINER_NAME"),
 
+		FileURLExpiry: func() time.Duration {
+			expiry, err := time.ParseDuration(os.Getenv("FILE_URL_EXPIRY"))
+			if err != nil || expiry <= 0 {
+				return 5 * time.Minute
+			}
+
+			return expiry
+		}(),
+		LocalFilesURL: func() string {
+			url := strings.TrimRight(os.Getenv("LOCAL_FILES_URL"), "/")
+			if url == "" {
+				return "http://localhost:8080/api/v1/files"
+			}
+
+			return url
+		}(),
+		LocalFilesSigningKey: os.Getenv("LOCAL_FILES_SIGNING_KEY"),
+
 		IsProduction: os.Getenv("IS_PRODUCTION") == "true",
 
 		IDPClientID:     os.Getenv("IDP_CLIENT_ID"),
@@ -91,6 +193,152 @@ func LoadConfig() *Config {
 
 			return port
 		}(),
+
+		AppointmentSlotCapacity: func() int {
+			capacity, err := strconv.Atoi(
+				os.Getenv("APPOINTMENT_SLOT_CAPACITY"),
+			)
+			if err != nil || capacity < 0 {
+				return 0
+			}
+
+			return capacity
+		}(),
+
+		AppointmentReminderOffsets: parseDurations(
+			os.Getenv("APPOINTMENT_REMINDER_OFFSETS"),
+			[]time.Duration{24 * time.Hour, time.Hour},
+		),
+		AppointmentReminderInterval: func() time.Duration {
+			interval, err := time.ParseDuration(
+				os.Getenv("APPOINTMENT_REMINDER_INTERVAL"),
+			)
+			if err != nil || interval <= 0 {
+				return time.Minute
+			}
+
+			return interval
+		}(),
+
+		AppointmentWaitlistHold: func() time.Duration {
+			hold, err := time.ParseDuration(
+				os.Getenv("APPOINTMENT_WAITLIST_HOLD"),
+			)
+			if err != nil || hold <= 0 {
+				return 2 * time.Hour
+			}
+
+			return hold
+		}(),
+		AppointmentWaitlistInterval: func() time.Duration {
+			interval, err := time.ParseDuration(
+				os.Getenv("APPOINTMENT_WAITLIST_INTERVAL"),
+			)
+			if err != nil || interval <= 0 {
+				return time.Minute
+			}
+
+			return interval
+		}(),
+
+		SlipVerifyURL: func() string {
+			url := strings.TrimRight(os.Getenv("SLIP_VERIFY_URL"), "/")
+			if url == "" {
+				return "http://localhost:8080/api/v1/slips/verify"
+			}
+
+			return url
+		}(),
+		SlipSigningKey: os.Getenv("SLIP_SIGNING_KEY"),
+
+		ClamAVAddress: os.Getenv("CLAMAV_ADDRESS"),
+		AttachmentScanInterval: func() time.Duration {
+			interval, err := time.ParseDuration(
+				os.Getenv("ATTACHMENT_SCAN_INTERVAL"),
+			)
+			if err != nil || interval <= 0 {
+				return 5 * time.Minute
+			}
+
+			return interval
+		}(),
+
+		EncryptionKeysFile: func() string {
+			path := os.Getenv("ENCRYPTION_KEYS_FILE")
+			if path == "" {
+				return encryption.DefaultKeysFile
+			}
+
+			return path
+		}(),
+
+		NotificationDigestHour: func() int {
+			hour, err := strconv.Atoi(os.Getenv("NOTIFICATION_DIGEST_HOUR"))
+			if err != nil || hour < 0 || hour > 23 {
+				return 7
+			}
+
+			return hour
+		}(),
+		NotificationDigestInterval: func() time.Duration {
+			interval, err := time.ParseDuration(
+				os.Getenv("NOTIFICATION_DIGEST_INTERVAL"),
+			)
+			if err != nil || interval <= 0 {
+				return 15 * time.Minute
+			}
+
+			return interval
+		}(),
+
+		LoginAccountFailureLimit: func() int {
+			limit, err := strconv.Atoi(os.Getenv("LOGIN_ACCOUNT_FAILURE_LIMIT"))
+			if err != nil || limit <= 0 {
+				return 5
+			}
+
+			return limit
+		}(),
+		LoginIPFailureLimit: func() int {
+			limit, err := strconv.Atoi(os.Getenv("LOGIN_IP_FAILURE_LIMIT"))
+			if err != nil || limit <= 0 {
+				return 50
+			}
+
+			return limit
+		}(),
+		LoginLockoutBase: func() time.Duration {
+			delay, err := time.ParseDuration(os.Getenv("LOGIN_LOCKOUT_BASE"))
+			if err != nil || delay <= 0 {
+				return time.Minute
+			}
+
+			return delay
+		}(),
+		LoginLockoutMax: func() time.Duration {
+			delay, err := time.ParseDuration(os.Getenv("LOGIN_LOCKOUT_MAX"))
+			if err != nil || delay <= 0 {
+				return time.Hour
+			}
+
+			return delay
+		}(),
+		LoginFailureWindow: func() time.Duration {
+			window, err := time.ParseDuration(os.Getenv("LOGIN_FAILURE_WINDOW"))
+			if err != nil || window <= 0 {
+				return 24 * time.Hour
+			}
+
+			return window
+		}(),
+
+		TwoFactorRequiredRoles: parseInts(
+			os.Getenv("TWO_FACTOR_REQUIRED_ROLES"),
+			[]int{
+				int(constants.AdminRoleID),
+				int(constants.SuperAdminRoleID),
+			},
+		),
 	}
 
 	validateConfig(config)
@@ -98,6 +346,54 @@ func LoadConfig() *Config {
 	return config
 }
 
+// parseDurations reads a comma-separated list such as "24h,1h", dropping
+// invalid entries. The result is sorted from largest to smallest.
+func parseDurations(raw string, fallback []time.Duration) []time.Duration {
+	if strings.TrimSpace(raw) == "" {
+		return fallback
+	}
+
+	var durations []time.Duration
+	for _, part := range strings.Split(raw, ",") {
+		d, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil || d <= 0 {
+			log.Printf("[Config] {Parse Duration}: ignoring %q", part)
+			continue
+		}
+		durations = append(durations, d)
+	}
+
+	sort.Slice(durations, func(i, j int) bool {
+		return durations[i] > durations[j]
+	})
+
+	return durations
+}
+
+// parseInts reads a comma-separated list such as "2,3", dropping invalid
+// entries. "none" gives an empty list.
+func parseInts(raw string, fallback []int) []int {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return fallback
+	}
+	if raw == "none" {
+		return []int{}
+	}
+
+	var values []int
+	for _, part := range strings.Split(raw, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			log.Printf("[Config] {Parse Int}: ignoring %q", part)
+			continue
+		}
+		values = append(values, n)
+	}
+
+	return values
+}
+
 func validateConfig(config *Config) {
 	validateDBConfig(config)
 	validateCoreConfig(config)
@@ -124,8 +420,16 @@ func validateDBConfig(config *Config) {
 }
 
 func validateCoreConfig(config *Config) {
-	if config.JWTSecret == "" {
-		panic("JWT_SECRET is required")
+	if config.JWTAcceptHS256 && config.JWTSecret == "" {
+		panic("JWT_SECRET is required while JWT_ACCEPT_HS256 is on")
+	}
+	if config.SlipSigningKey == "" {
+		panic("SLIP_SIGNING_KEY is required")
+	}
+	// A key shared with the legacy token secret would let its holders
+	// forge slips and download URLs as well
+	if config.JWTSecret != "" && config.SlipSigningKey == config.JWTSecret {
+		panic("SLIP_SIGNING_KEY must differ from JWT_SECRET")
 	}
 	if config.WebsitesPort == "" {
 		panic("WEBSITES_PORT is required")
@@ -152,6 +456,13 @@ func validateStorageConfig(config *Config) {
 		if config.LocalUploadDIR == "" {
 			panic("UPLOAD_DIR is required for local storage")
 		}
+		if config.LocalFilesSigningKey == "" {
+			panic("LOCAL_FILES_SIGNING_KEY is required for local storage")
+		}
+		if config.JWTSecret != "" &&
+			config.LocalFilesSigningKey == config.JWTSecret {
+			panic("LOCAL_FILES_SIGNING_KEY must differ from JWT_SECRET")
+		}
 	}
 }
 
@@ -160,6 +471,9 @@ func validateProviderConfig(config *Config) {
 		if config.SendGridAPIKey == "" {
 			panic("SENDGRID_API_KEY is required for production")
 		}
+		if config.ClamAVAddress == "" {
+			panic("CLAMAV_ADDRESS is required for production")
+		}
 	} else {
 		if config.MailPitHost == "" {
 			panic("MAILPIT_HOST is required for local development")
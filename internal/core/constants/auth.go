@@ -70,4 +70,87 @@ const (
 	// RedisIDPRefreshKeyPrefix is the prefix for IDP refresh tokens
 	// (idp_refresh:jti)
 	RedisIDPRefreshKeyPrefix = "idp_refresh:"
+
+	// RedisResetAttemptsKeyPrefix is the prefix for counters of wrong
+	// password reset codes (reset_attempts:resetID)
+	RedisResetAttemptsKeyPrefix = "reset_attempts:"
+
+	// RedisActiveResetKeyPrefix is the prefix for the one password reset a
+	// user may have open (active_reset:userID)
+	RedisActiveResetKeyPrefix = "active_reset:"
+
+	// RedisResetRequestsKeyPrefix is the prefix for counters of password
+	// reset requests (reset_requests:account:email, reset_requests:ip:address)
+	RedisResetRequestsKeyPrefix = "reset_requests:"
+
+	// RedisLinkAttemptsKeyPrefix is the prefix for counters of codes tried
+	// against a partner link request (link_attempts:linkRequestID)
+	RedisLinkAttemptsKeyPrefix = "link_attempts:"
+
+	// RedisLoginFailuresKeyPrefix is the prefix for failed login counters
+	// (login_failures:account:email, login_failures:ip:address)
+	RedisLoginFailuresKeyPrefix = "login_failures:"
+
+	// RedisLoginLockKeyPrefix is the prefix for temporary login lockouts
+	// (login_lock:account:email, login_lock:ip:address)
+	RedisLoginLockKeyPrefix = "login_lock:"
+
+	// RedisChallengeAttemptsKeyPrefix is the prefix for counters of wrong
+	// codes entered for a login challenge (challenge_attempts:challengeID)
+	RedisChallengeAttemptsKeyPrefix = "challenge_attempts:"
+
+	// RedisTOTPUsedKeyPrefix is the prefix for TOTP codes already used to
+	// sign in, which cannot be replayed (totp_used:userID:code)
+	RedisTOTPUsedKeyPrefix = "totp_used:"
+
+	// RedisRefreshFamilyKeyPrefix is the prefix for the live session of a
+	// refresh token family (refresh_family:familyID)
+	RedisRefreshFamilyKeyPrefix = "refresh_family:"
+
+	// RedisRefreshUsedKeyPrefix is the prefix for refresh tokens already
+	// exchanged, which must not be presented again (refresh_used:jti)
+	RedisRefreshUsedKeyPrefix = "refresh_used:"
+)
+
+// RefreshReuseGrace is how soon after its rotation a refresh token may be
+// presented again without being treated as stolen. It covers browser tabs
+// refreshing at the same time.
+const RefreshReuseGrace = 10 * time.Second
+
+// Login challenge constants
+const (
+	// LoginChallengeMaxAge is how long a user has to enter their second
+	// factor after the password in seconds (5 minutes = 300 seconds)
+	LoginChallengeMaxAge = 300
+
+	// LoginChallengeMaxAttempts is how many wrong codes invalidate a login
+	// challenge
+	LoginChallengeMaxAttempts = 5
+)
+
+// SessionTwoFactorSetupRequired is the session field set when the user's
+// role requires two-factor authentication they have not enabled yet. Such
+// sessions may only use the /auth routes.
+const SessionTwoFactorSetupRequired = "twoFactorSetupRequired"
+
+// Password reset constants
+const (
+	// PasswordResetMaxAge is how long a password reset code stays valid in
+	// seconds (15 minutes = 900 seconds)
+	PasswordResetMaxAge = 900
+
+	// PasswordResetMaxAttempts is how many wrong codes invalidate a reset
+	PasswordResetMaxAttempts = 5
+
+	// PasswordResetRequestWindow is the period reset requests are counted
+	// over in seconds (1 hour = 3600 seconds)
+	PasswordResetRequestWindow = 3600
+
+	// PasswordResetMaxRequestsPerAccount is how many reset codes one
+	// account is sent per window
+	PasswordResetMaxRequestsPerAccount = 3
+
+	// PasswordResetMaxRequestsPerIP is how many resets one IP address may
+	// request per window
+	PasswordResetMaxRequestsPerIP = 10
 )
@@ -1,12 +1,18 @@
 package constants
 
 const (
-	UserEntityType        = "User"
-	IIREntityType         = "IIR"
-	AppointmentEntityType = "Appointment"
-	SlipEntityType        = "Slip"
-	SystemEntityType      = "System"
-	GeneralEntityType     = "General"
-	LogEntityType         = "Log"
-	M2MClientEntityType   = "M2MClient"
+	UserEntityType              = "User"
+	IIREntityType               = "IIR"
+	AppointmentEntityType       = "Appointment"
+	SlipEntityType              = "Slip"
+	SystemEntityType            = "System"
+	GeneralEntityType           = "General"
+	LogEntityType               = "Log"
+	M2MClientEntityType         = "M2MClient"
+	CounselorEntityType         = "Counselor"
+	ClosureEntityType           = "Closure"
+	RescheduleRequestEntityType = "RescheduleRequest"
+	WaitlistEntityType          = "Waitlist"
+	SlipUrgencyPolicyEntityType = "SlipUrgencyPolicy"
+	NoteEntityType              = "Note"
 )
@@ -0,0 +1,29 @@
+package constants
+
+type Scope string
+
+// M2M client scopes for the student integration endpoints
+const (
+	ScopeStudentsRead         Scope = "students:read"
+	ScopeStudentsPersonalInfo Scope = "students:personal-info"
+	ScopeStudentsAddresses    Scope = "students:addresses"
+	ScopeStudentsLink         Scope = "students:link"
+)
+
+// M2MScopes is the catalog of scopes that can be granted to an M2M client.
+var M2MScopes = []Scope{
+	ScopeStudentsRead,
+	ScopeStudentsPersonalInfo,
+	ScopeStudentsAddresses,
+	ScopeStudentsLink,
+}
+
+// IsValidScope reports whether the given scope exists in the catalog.
+func IsValidScope(scope string) bool {
+	for _, s := range M2MScopes {
+		if string(s) == scope {
+			return true
+		}
+	}
+	return false
+}
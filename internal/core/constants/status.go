@@ -0,0 +1,16 @@
+package constants
+
+// StatusID mirrors the rows seeded into the statuses table.
+type StatusID int
+
+const (
+	StatusPending StatusID = iota + 1
+	StatusScheduled
+	StatusCompleted
+	StatusCancelled
+	StatusRejected
+	StatusRescheduled
+	StatusNoShow
+	StatusApproved
+	StatusForRevision
+)
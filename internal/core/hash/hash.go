@@ -1,6 +1,7 @@
 package hash
 
 import (
+	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 )
@@ -9,3 +10,10 @@ func GetSHA256Hash(input string, size int) string {
 	hash := sha256.Sum256([]byte(input))
 	return hex.EncodeToString(hash[:size])
 }
+
+// GetHMACSHA256 signs input with key and returns the hex-encoded MAC.
+func GetHMACSHA256(key string, input string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(input))
+	return hex.EncodeToString(mac.Sum(nil))
+}
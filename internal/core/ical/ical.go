@@ -0,0 +1,129 @@
+// Package ical renders RFC 5545 calendars for appointment feeds and email
+// invitations.
+package ical
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Calendar methods (RFC 5546). Publish is used for subscription feeds,
+// Request for new or updated invitations and Cancel to remove an event.
+const (
+	MethodPublish = "PUBLISH"
+	MethodRequest = "REQUEST"
+	MethodCancel  = "CANCEL"
+)
+
+// Event statuses.
+const (
+	StatusTentative = "TENTATIVE"
+	StatusConfirmed = "CONFIRMED"
+	StatusCancelled = "CANCELLED"
+)
+
+// ContentType is the MIME type for a calendar sent with the given method.
+func ContentType(method string) string {
+	return fmt.Sprintf("text/calendar; charset=utf-8; method=%s", method)
+}
+
+const (
+	productID   = "-//PUPT-OGOS//Appointments//EN"
+	utcLayout   = "20060102T150405Z"
+	maxLineSize = 75
+)
+
+type Event struct {
+	// UID must stay the same for every version of the event so calendar
+	// clients update it in place.
+	UID string
+	// Sequence increases with every change to the event.
+	Sequence    int
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Location    string
+	Status      string
+	Organizer   string
+	UpdatedAt   time.Time
+}
+
+// Build renders the events as a VCALENDAR document.
+func Build(name, method string, events []Event) []byte {
+	var b strings.Builder
+
+	writeLine(&b, "BEGIN:VCALENDAR")
+	writeLine(&b, "VERSION:2.0")
+	writeLine(&b, "PRODID:"+productID)
+	writeLine(&b, "CALSCALE:GREGORIAN")
+	writeLine(&b, "METHOD:"+method)
+	if name != "" {
+		writeLine(&b, "X-WR-CALNAME:"+escape(name))
+	}
+
+	for _, e := range events {
+		stamp := e.UpdatedAt
+		if stamp.IsZero() {
+			stamp = time.Now()
+		}
+
+		writeLine(&b, "BEGIN:VEVENT")
+		writeLine(&b, "UID:"+e.UID)
+		writeLine(&b, fmt.Sprintf("SEQUENCE:%d", e.Sequence))
+		writeLine(&b, "DTSTAMP:"+stamp.UTC().Format(utcLayout))
+		writeLine(&b, "DTSTART:"+e.Start.UTC().Format(utcLayout))
+		writeLine(&b, "DTEND:"+e.End.UTC().Format(utcLayout))
+		writeLine(&b, "SUMMARY:"+escape(e.Summary))
+		if e.Description != "" {
+			writeLine(&b, "DESCRIPTION:"+escape(e.Description))
+		}
+		if e.Location != "" {
+			writeLine(&b, "LOCATION:"+escape(e.Location))
+		}
+		if e.Organizer != "" {
+			writeLine(&b, "ORGANIZER:mailto:"+e.Organizer)
+		}
+		if e.Status != "" {
+			writeLine(&b, "STATUS:"+e.Status)
+		}
+		writeLine(&b, "END:VEVENT")
+	}
+
+	writeLine(&b, "END:VCALENDAR")
+
+	return []byte(b.String())
+}
+
+// writeLine folds content lines longer than 75 octets and terminates them
+// with CRLF as RFC 5545 requires.
+func writeLine(b *strings.Builder, line string) {
+	limit := maxLineSize
+	for len(line) > limit {
+		cut := limit
+		// Do not split a multi-byte UTF-8 sequence
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines spend one octet on the leading space
+		limit = maxLineSize - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+var escaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
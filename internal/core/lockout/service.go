@@ -0,0 +1,186 @@
+// Package lockout slows down password guessing. Failed logins are counted
+// per account and per client IP; past a threshold every further failure
+// locks the subject out for twice as long as the previous one.
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
+)
+
+// Lockout scopes
+const (
+	ScopeAccount = "account"
+	ScopeIP      = "ip"
+)
+
+// Policy configures when and for how long logins are locked.
+type Policy struct {
+	// AccountThreshold and IPThreshold are how many failures are allowed
+	// before lockouts start. The IP threshold is higher because many users
+	// can share one address, e.g. behind a campus NAT.
+	AccountThreshold int
+	IPThreshold      int
+	// BaseDelay is the first lockout; each further failure doubles it up
+	// to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Window is how long failures are remembered after the first one.
+	Window time.Duration
+}
+
+// Lock describes a lockout in effect.
+type Lock struct {
+	Scope      string
+	RetryAfter time.Duration
+	Failures   int64
+}
+
+type subject struct {
+	scope     string
+	value     string
+	threshold int
+}
+
+type Service struct {
+	redis  *datastore.RedisClient
+	policy Policy
+}
+
+func NewService(redis *datastore.RedisClient, policy Policy) *Service {
+	return &Service{redis: redis, policy: policy}
+}
+
+// Check returns the longest lockout currently applying to the account or
+// the IP, or nil when a login may be attempted.
+func (s *Service) Check(
+	ctx context.Context,
+	account string,
+	ip string,
+) (*Lock, error) {
+	var longest *Lock
+	for _, sub := range s.subjects(account, ip) {
+		ttl, err := s.redis.Client.PTTL(ctx, lockKey(sub)).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to check login lock: %w", err)
+		}
+		if ttl > 0 && (longest == nil || ttl > longest.RetryAfter) {
+			longest = &Lock{Scope: sub.scope, RetryAfter: ttl}
+		}
+	}
+
+	return longest, nil
+}
+
+// RecordFailure counts a failed login and locks the account or the IP
+// once it is past its threshold. It returns the longest lock it applied,
+// or nil when none was.
+func (s *Service) RecordFailure(
+	ctx context.Context,
+	account string,
+	ip string,
+) (*Lock, error) {
+	var longest *Lock
+	for _, sub := range s.subjects(account, ip) {
+		key := failuresKey(sub)
+		failures, err := s.redis.Client.Incr(ctx, key).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to count login failure: %w", err)
+		}
+		if failures == 1 {
+			s.redis.Client.Expire(ctx, key, s.policy.Window)
+		}
+		if failures <= int64(sub.threshold) {
+			continue
+		}
+
+		delay := s.backoff(failures - int64(sub.threshold) - 1)
+		err = s.redis.Set(ctx, lockKey(sub), failures, delay)
+		if err != nil {
+			return nil, fmt.Errorf("failed to lock login: %w", err)
+		}
+		if longest == nil || delay > longest.RetryAfter {
+			longest = &Lock{
+				Scope:      sub.scope,
+				RetryAfter: delay,
+				Failures:   failures,
+			}
+		}
+	}
+
+	return longest, nil
+}
+
+// RecordSuccess clears the account's failures after a successful login.
+// The IP's failures are kept, so one valid credential does not reset an
+// attacker trying many accounts.
+func (s *Service) RecordSuccess(ctx context.Context, account string) error {
+	return s.Unlock(ctx, account)
+}
+
+// Unlock lifts the account's lockout and forgets its failures.
+func (s *Service) Unlock(ctx context.Context, account string) error {
+	sub := subject{scope: ScopeAccount, value: normalize(account)}
+	err := s.redis.Client.Del(ctx, failuresKey(sub), lockKey(sub)).Err()
+	if err != nil {
+		return fmt.Errorf("failed to unlock account: %w", err)
+	}
+
+	return nil
+}
+
+// backoff returns BaseDelay doubled n times, capped at MaxDelay.
+func (s *Service) backoff(n int64) time.Duration {
+	delay := s.policy.BaseDelay
+	for i := int64(0); i < n && delay < s.policy.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, s.policy.MaxDelay)
+}
+
+func (s *Service) subjects(account, ip string) []subject {
+	subjects := []subject{{
+		scope:     ScopeAccount,
+		value:     normalize(account),
+		threshold: s.policy.AccountThreshold,
+	}}
+	if ip != "" {
+		subjects = append(subjects, subject{
+			scope:     ScopeIP,
+			value:     ip,
+			threshold: s.policy.IPThreshold,
+		})
+	}
+
+	return subjects
+}
+
+// normalize makes differently-cased spellings of an email count as one
+// account.
+func normalize(account string) string {
+	return strings.ToLower(strings.TrimSpace(account))
+}
+
+func failuresKey(sub subject) string {
+	return fmt.Sprintf(
+		"%s%s:%s",
+		constants.RedisLoginFailuresKeyPrefix,
+		sub.scope,
+		sub.value,
+	)
+}
+
+func lockKey(sub subject) string {
+	return fmt.Sprintf(
+		"%s%s:%s",
+		constants.RedisLoginLockKeyPrefix,
+		sub.scope,
+		sub.value,
+	)
+}
@@ -7,12 +7,16 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/sessions"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/tokens"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
 )
 
-func AuthMiddleware(redis *datastore.RedisClient) gin.HandlerFunc {
+func AuthMiddleware(
+	redis *datastore.RedisClient,
+	tokenService *tokens.Service,
+) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tokenString := getTokenString(c)
 		if tokenString == "" {
@@ -23,7 +27,7 @@ func AuthMiddleware(redis *datastore.RedisClient) gin.HandlerFunc {
 			return
 		}
 
-		claims, err := tokens.NewService().ValidateToken(tokenString)
+		claims, err := tokenService.ValidateToken(tokenString)
 		if err != nil {
 			log.Printf("[AuthMiddleware] {Token}: Invalid or expired: %v", err)
 			c.AbortWithStatusJSON(
@@ -85,6 +89,14 @@ func validateSession(
 		if clientName, ok := sessionData["clientName"]; ok {
 			c.Set("clientName", clientName)
 		}
+		if sessionData[constants.SessionTwoFactorSetupRequired] == "true" &&
+			!strings.HasPrefix(c.Request.URL.Path, "/api/v1/auth/") {
+			c.AbortWithStatusJSON(
+				http.StatusForbidden,
+				gin.H{"error": "Two-factor authentication must be set up first"},
+			)
+			return false
+		}
 	}
 	return true
 }
@@ -109,6 +121,7 @@ func validateM2MPath(c *gin.Context, clientID string) bool {
 func setContextInfo(c *gin.Context, claims *tokens.Claims) {
 	if claims.M2MClientID != "" {
 		c.Set("m2mClientID", claims.M2MClientID)
+		c.Set("m2mScopes", claims.Scopes)
 		c.Set("isM2M", true)
 	} else {
 		c.Set("userID", claims.UserID)
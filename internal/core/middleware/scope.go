@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+)
+
+// ScopeMiddleware rejects M2M requests whose token was not granted the
+// required scope. User sessions are left to RoleMiddleware and pass through.
+func ScopeMiddleware(required constants.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool("isM2M") {
+			c.Next()
+			return
+		}
+
+		for _, scope := range c.GetStringSlice("m2mScopes") {
+			if scope == string(required) {
+				c.Next()
+				return
+			}
+		}
+
+		if logSvc, ok := c.Get(SecurityLoggerContextKey); ok {
+			if svc, ok := logSvc.(SecurityLogger); ok {
+				clientName := c.GetString("clientName")
+				svc.RecordSecurity(
+					c.Request.Context(),
+					"ACCESS_DENIED",
+					fmt.Sprintf(
+						"M2M client %s (%s) lacks scope %s on %s %s",
+						clientName,
+						c.GetString("m2mClientID"),
+						required,
+						c.Request.Method,
+						c.Request.URL.Path,
+					),
+					clientName,
+					c.ClientIP(),
+					c.Request.UserAgent(),
+				)
+			}
+		}
+
+		c.AbortWithStatusJSON(
+			http.StatusForbidden,
+			gin.H{"error": fmt.Sprintf("Missing required scope: %s", required)},
+		)
+	}
+}
@@ -28,6 +28,40 @@ func (j JTIDTO) ToIDPRefreshKey() string {
 	return fmt.Sprintf("%s%s", constants.RedisIDPRefreshKeyPrefix, j.Value)
 }
 
+// ToResetAttemptsKey returns the Redis key counting wrong codes entered for
+// a password reset.
+func (j JTIDTO) ToResetAttemptsKey() string {
+	return fmt.Sprintf("%s%s", constants.RedisResetAttemptsKeyPrefix, j.Value)
+}
+
+// ToLinkAttemptsKey returns the Redis key counting codes tried against a
+// partner link request.
+func (j JTIDTO) ToLinkAttemptsKey() string {
+	return fmt.Sprintf("%s%s", constants.RedisLinkAttemptsKeyPrefix, j.Value)
+}
+
+// ToChallengeAttemptsKey returns the Redis key counting wrong codes
+// entered for a login challenge.
+func (j JTIDTO) ToChallengeAttemptsKey() string {
+	return fmt.Sprintf(
+		"%s%s",
+		constants.RedisChallengeAttemptsKeyPrefix,
+		j.Value,
+	)
+}
+
+// ToRefreshUsedKey returns the Redis key marking a refresh token as
+// already exchanged.
+func (j JTIDTO) ToRefreshUsedKey() string {
+	return fmt.Sprintf("%s%s", constants.RedisRefreshUsedKeyPrefix, j.Value)
+}
+
+// ToRefreshFamilyKey returns the Redis key for the live session of a
+// refresh token family.
+func ToRefreshFamilyKey(familyID string) string {
+	return fmt.Sprintf("%s%s", constants.RedisRefreshFamilyKeyPrefix, familyID)
+}
+
 // ToUserSessionsKey returns the Redis key for the set of sessions belonging
 // to a specific user.
 func ToUserSessionsKey(userId string) string {
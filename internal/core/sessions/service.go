@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/go-redis/redis/v8"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
 )
 
@@ -40,6 +41,29 @@ func (s *Service) StoreToken(
 	return nil
 }
 
+// CountAttempt increments the counter at key and returns its new value.
+// The counter expires expireSeconds after the first attempt; later
+// attempts do not extend it.
+func (s *Service) CountAttempt(
+	ctx context.Context,
+	key string,
+	expireSeconds int,
+) (int64, error) {
+	count, err := s.redis.Client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count attempt in redis: %w", err)
+	}
+	if count == 1 {
+		s.redis.Client.Expire(
+			ctx,
+			key,
+			time.Duration(expireSeconds)*time.Second,
+		)
+	}
+
+	return count, nil
+}
+
 // GetToken retrieves session data from Redis.
 func (s *Service) GetToken(
 	ctx context.Context,
@@ -133,3 +157,161 @@ func (s *Service) ListUserSessions(
 
 	return sessions, nil
 }
+
+// RevokeUserSessions deletes every session of the user except exceptJTI,
+// which may be empty to revoke all of them. It returns how many sessions
+// were revoked.
+func (s *Service) RevokeUserSessions(
+	ctx context.Context,
+	userID string,
+	exceptJTI string,
+) (int, error) {
+	userKey := ToUserSessionsKey(userID)
+	jtis, err := s.redis.Client.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list user sessions: %w", err)
+	}
+
+	revoked := 0
+	for _, jtiVal := range jtis {
+		if jtiVal == exceptJTI {
+			continue
+		}
+		if err := s.DeleteUserToken(ctx, userID, NewJTI(jtiVal)); err != nil {
+			return revoked, fmt.Errorf("failed to revoke session: %w", err)
+		}
+		revoked++
+	}
+
+	return revoked, nil
+}
+
+// UnsetUserSessionField removes field from every session of the user.
+// Each session keeps its remaining lifetime.
+func (s *Service) UnsetUserSessionField(
+	ctx context.Context,
+	userID string,
+	field string,
+) error {
+	userKey := ToUserSessionsKey(userID)
+	jtis, err := s.redis.Client.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to list user sessions: %w", err)
+	}
+
+	for _, jtiVal := range jtis {
+		jti := NewJTI(jtiVal)
+		data, err := s.GetToken(ctx, jti)
+		if err != nil {
+			continue
+		}
+		if _, ok := data[field]; !ok {
+			continue
+		}
+		delete(data, field)
+
+		valJSON, _ := json.Marshal(data)
+		// XX skips sessions that expired or were revoked meanwhile
+		err = s.redis.Client.SetXX(
+			ctx,
+			jti.ToSessionKey(),
+			string(valJSON),
+			redis.KeepTTL,
+		).Err()
+		if err != nil {
+			return fmt.Errorf("failed to update session: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// StoreRefreshFamily records the session that currently holds a refresh
+// token family. A family without a record was revoked or has expired.
+func (s *Service) StoreRefreshFamily(
+	ctx context.Context,
+	familyID string,
+	data map[string]string,
+	expireSeconds int,
+) error {
+	valJSON, _ := json.Marshal(data)
+
+	err := s.redis.Set(
+		ctx,
+		ToRefreshFamilyKey(familyID),
+		string(valJSON),
+		time.Duration(expireSeconds)*time.Second,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store refresh family: %w", err)
+	}
+
+	return nil
+}
+
+// GetRefreshFamily retrieves the record of a refresh token family.
+func (s *Service) GetRefreshFamily(
+	ctx context.Context,
+	familyID string,
+) (map[string]string, error) {
+	val, err := s.redis.Get(ctx, ToRefreshFamilyKey(familyID))
+	if err != nil {
+		return nil, fmt.Errorf("refresh family not found or expired: %w", err)
+	}
+
+	var data map[string]string
+	if err := json.Unmarshal([]byte(val), &data); err != nil {
+		return nil, fmt.Errorf("failed to parse refresh family: %w", err)
+	}
+
+	return data, nil
+}
+
+// DeleteRefreshFamily revokes every refresh token of a family.
+func (s *Service) DeleteRefreshFamily(
+	ctx context.Context,
+	familyID string,
+) error {
+	return s.redis.Del(ctx, ToRefreshFamilyKey(familyID))
+}
+
+// ClaimRefreshToken marks a refresh token as exchanged so it works once.
+// When it already was, it reports false and when that happened.
+func (s *Service) ClaimRefreshToken(
+	ctx context.Context,
+	jti JTIDTO,
+	expireSeconds int,
+) (bool, time.Time, error) {
+	key := jti.ToRefreshUsedKey()
+	now := time.Now()
+
+	fresh, err := s.redis.Client.SetNX(
+		ctx,
+		key,
+		now.UnixMilli(),
+		time.Duration(expireSeconds)*time.Second,
+	).Result()
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf(
+			"failed to claim refresh token: %w",
+			err,
+		)
+	}
+	if fresh {
+		return true, now, nil
+	}
+
+	usedAt, err := s.redis.Client.Get(ctx, key).Int64()
+	if err != nil {
+		// Treat an unreadable mark as an old one
+		return false, time.Time{}, nil
+	}
+
+	return false, time.UnixMilli(usedAt), nil
+}
+
+// ReleaseRefreshToken undoes ClaimRefreshToken for a refresh token whose
+// exchange failed, so it can be presented again.
+func (s *Service) ReleaseRefreshToken(ctx context.Context, jti JTIDTO) error {
+	return s.redis.Del(ctx, jti.ToRefreshUsedKey())
+}
@@ -5,11 +5,14 @@ import (
 )
 
 type Claims struct {
-	UserID      string `json:"userId"`
-	IDPUserID   string `json:"idpUserId"` // Only for IDP sessions
-	UserEmail   string `json:"userEmail"`
-	RoleID      int    `json:"roleId"`
-	TokenType   string `json:"tokenType"`   // "native", "idp", or "m2m"
-	M2MClientID string `json:"m2mClientId"` // Only for M2M sessions
+	UserID      string   `json:"userId"`
+	IDPUserID   string   `json:"idpUserId"` // Only for IDP sessions
+	UserEmail   string   `json:"userEmail"`
+	RoleID      int      `json:"roleId"`
+	TokenType   string   `json:"tokenType"`        // "native", "idp", or "m2m"
+	M2MClientID string   `json:"m2mClientId"`      // Only for M2M sessions
+	Scopes      []string `json:"scopes,omitempty"` // Only for M2M sessions
+	// FamilyID links a refresh token to the ones rotated before it
+	FamilyID string `json:"familyId,omitempty"` // Only for refresh tokens
 	jwt.RegisteredClaims
 }
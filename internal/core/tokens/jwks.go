@@ -0,0 +1,27 @@
+package tokens
+
+import "time"
+
+// JWK is a public signing key as published at /.well-known/jwks.json
+// (RFC 7517, with Ed25519 keys as in RFC 8037).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// KeyInfo describes a signing key without its secret.
+type KeyInfo struct {
+	ID        string
+	Active    bool
+	Next      bool
+	CreatedAt time.Time
+	RetiredAt *time.Time
+}
@@ -0,0 +1,415 @@
+package tokens
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultKeysFile is where the signing keys are kept when JWT_KEYS_FILE is
+// not set.
+const DefaultKeysFile = "keys/jwt-signing.json"
+
+// reloadInterval bounds how long a running server keeps signing with a key
+// after the file names a new active key.
+const reloadInterval = 30 * time.Second
+
+// JWKSMaxAge is how long verifiers may cache /.well-known/jwks.json.
+const JWKSMaxAge = 5 * time.Minute
+
+// MinPublishAge is how long a key is published before it may sign: every
+// server has reloaded the file and every cached JWKS has expired by then.
+const MinPublishAge = reloadInterval + JWKSMaxAge
+
+var (
+	ErrUnknownKey      = errors.New("unknown signing key")
+	ErrKeyNotPublished = errors.New("next key has not been published long enough")
+)
+
+// keyFile is the JSON layout of the signing key file:
+//
+//	{"active": "k20260101000000-1a2b3c4d", "next": "k20260401000000-5e6f7a8b",
+//	 "keys": {...}}
+//
+// Every key in the file is published in the JWKS. The next key does not
+// sign yet, so verifiers learn it before the rotation that activates it.
+// Retired keys no longer sign but still verify until they are pruned.
+type keyFile struct {
+	Active string              `json:"active"`
+	Next   string              `json:"next,omitempty"`
+	Keys   map[string]keyEntry `json:"keys"`
+}
+
+type keyEntry struct {
+	// Seed is the base64-encoded Ed25519 private key seed
+	Seed      string     `json:"seed"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RetiredAt *time.Time `json:"retiredAt,omitempty"`
+}
+
+// KeySet holds the Ed25519 keys tokens are signed and verified with. The
+// file is reloaded when it changes, so a key added by `rotate` is picked up
+// by running servers without a restart.
+type KeySet struct {
+	path string
+
+	mu        sync.RWMutex
+	active    string
+	private   ed25519.PrivateKey
+	public    map[string]ed25519.PublicKey
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func NewKeySet(path string) (*KeySet, error) {
+	k := &KeySet{path: path}
+	if err := k.load(); err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// signingKey returns the active key and its ID.
+func (k *KeySet) signingKey() (string, ed25519.PrivateKey) {
+	k.refresh(false)
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.active, k.private
+}
+
+// publicKey returns the verification key with the given ID.
+func (k *KeySet) publicKey(kid string) (ed25519.PublicKey, error) {
+	k.refresh(false)
+
+	k.mu.RLock()
+	key, ok := k.public[kid]
+	k.mu.RUnlock()
+
+	// Another server may already sign with a key this one has not loaded
+	if !ok {
+		k.refresh(true)
+
+		k.mu.RLock()
+		key, ok = k.public[kid]
+		k.mu.RUnlock()
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, kid)
+	}
+
+	return key, nil
+}
+
+// JWKS returns the public keys in JSON Web Key Set form, sorted by ID.
+func (k *KeySet) JWKS() JWKSet {
+	k.refresh(false)
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	set := JWKSet{Keys: make([]JWK, 0, len(k.public))}
+	for kid, key := range k.public {
+		set.Keys = append(set.Keys, JWK{
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(key),
+			KeyID:     kid,
+			Use:       "sig",
+			Algorithm: "EdDSA",
+		})
+	}
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].KeyID < set.Keys[j].KeyID
+	})
+
+	return set
+}
+
+// refresh reloads the key file when it changed. Unless forced, the file is
+// checked at most once per reloadInterval. A file that fails to load keeps
+// the previous keys in use.
+func (k *KeySet) refresh(force bool) {
+	k.mu.RLock()
+	due := force || time.Since(k.checkedAt) >= reloadInterval
+	k.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	info, err := os.Stat(k.path)
+
+	k.mu.Lock()
+	k.checkedAt = time.Now()
+	changed := err == nil && !info.ModTime().Equal(k.modTime)
+	k.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	if err := k.load(); err != nil {
+		log.Printf("[KeySet] {Reload Keys}: %v", err)
+	}
+}
+
+func (k *KeySet) load() error {
+	info, err := os.Stat(k.path)
+	if err != nil {
+		return fmt.Errorf("failed to read signing key file: %w", err)
+	}
+
+	file, err := readKeyFile(k.path)
+	if err != nil {
+		return err
+	}
+
+	var private ed25519.PrivateKey
+	public := make(map[string]ed25519.PublicKey, len(file.Keys))
+	for kid, entry := range file.Keys {
+		key, err := decodeSeed(kid, entry.Seed)
+		if err != nil {
+			return err
+		}
+		public[kid] = key.Public().(ed25519.PublicKey)
+		if kid == file.Active {
+			private = key
+		}
+	}
+	if private == nil {
+		return fmt.Errorf("active key %q is not in the key file", file.Active)
+	}
+	if file.Keys[file.Active].RetiredAt != nil {
+		return fmt.Errorf("active key %q is retired", file.Active)
+	}
+	if _, ok := file.Keys[file.Next]; file.Next != "" && !ok {
+		return fmt.Errorf("next key %q is not in the key file", file.Next)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.active = file.Active
+	k.private = private
+	k.public = public
+	k.modTime = info.ModTime()
+	k.checkedAt = time.Now()
+
+	return nil
+}
+
+func decodeSeed(kid string, encoded string) (ed25519.PrivateKey, error) {
+	if kid == "" {
+		return nil, errors.New("empty key id")
+	}
+
+	seed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf(
+			"key %q must be a %d-byte base64-encoded seed",
+			kid,
+			ed25519.SeedSize,
+		)
+	}
+
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+func readKeyFile(path string) (*keyFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key file: %w", err)
+	}
+
+	var file keyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse signing key file: %w", err)
+	}
+
+	return &file, nil
+}
+
+// RotateKeys advances the rotation by one step. The next key becomes the
+// active one once it has been published for MinPublishAge, and the
+// previous active key is retired: it stops signing but keeps verifying
+// tokens already issued with it until it is pruned. A new key is then
+// published as the next one. It returns the IDs of the key that became
+// active, empty when there was no next key yet, and of the new next key.
+func RotateKeys(path string) (string, string, error) {
+	file, err := readKeyFile(path)
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now().UTC()
+	activated := ""
+	if file.Next != "" {
+		next, ok := file.Keys[file.Next]
+		if !ok {
+			return "", "", fmt.Errorf(
+				"next key %q is not in the key file",
+				file.Next,
+			)
+		}
+		if ready := next.CreatedAt.Add(MinPublishAge); now.Before(ready) {
+			return "", "", fmt.Errorf(
+				"%w: key %q may sign from %s",
+				ErrKeyNotPublished,
+				file.Next,
+				ready.Format(time.RFC3339),
+			)
+		}
+
+		if previous, ok := file.Keys[file.Active]; ok {
+			previous.RetiredAt = &now
+			file.Keys[file.Active] = previous
+		}
+		file.Active, file.Next = file.Next, ""
+		activated = file.Active
+	}
+
+	kid, err := addKey(file, now)
+	if err != nil {
+		return "", "", err
+	}
+	file.Next = kid
+
+	if err := writeKeyFile(path, file); err != nil {
+		return "", "", err
+	}
+
+	return activated, kid, nil
+}
+
+// addKey generates a key and stores it in file without making it active.
+func addKey(file *keyFile, now time.Time) (string, error) {
+	if file.Keys == nil {
+		file.Keys = map[string]keyEntry{}
+	}
+
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	// The seed suffix keeps keys added within the same second apart
+	kid := fmt.Sprintf("k%s-%x", now.Format("20060102150405"), seed[:4])
+	if _, exists := file.Keys[kid]; exists {
+		return "", fmt.Errorf("key %q already exists", kid)
+	}
+
+	file.Keys[kid] = keyEntry{
+		Seed:      base64.StdEncoding.EncodeToString(seed),
+		CreatedAt: now,
+	}
+
+	return kid, nil
+}
+
+// PruneKeys removes keys retired more than gracePeriod ago, once no token
+// signed with them can still be valid. It returns the removed key IDs.
+func PruneKeys(path string, gracePeriod time.Duration) ([]string, error) {
+	file, err := readKeyFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-gracePeriod)
+	var removed []string
+	for kid, entry := range file.Keys {
+		if kid == file.Active || entry.RetiredAt == nil ||
+			entry.RetiredAt.After(cutoff) {
+			continue
+		}
+		delete(file.Keys, kid)
+		removed = append(removed, kid)
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+	sort.Strings(removed)
+
+	return removed, writeKeyFile(path, file)
+}
+
+// EnsureKeyFile creates a key file with a fresh key when none exists yet.
+// It is meant for local development; production keys are provisioned.
+func EnsureKeyFile(path string) error {
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	file := &keyFile{}
+	kid, err := addKey(file, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	file.Active = kid
+
+	return writeKeyFile(path, file)
+}
+
+// writeKeyFile replaces the key file in one rename so servers reloading it
+// never read a partial file.
+func writeKeyFile(path string, file *keyFile) error {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create key directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".jwt-keys-*")
+	if err != nil {
+		return fmt.Errorf("failed to write signing key file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write signing key file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write signing key file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o600); err != nil {
+		return fmt.Errorf("failed to write signing key file: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// ListKeys describes the keys in the key file, oldest first.
+func ListKeys(path string) ([]KeyInfo, error) {
+	file, err := readKeyFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]KeyInfo, 0, len(file.Keys))
+	for kid, entry := range file.Keys {
+		infos = append(infos, KeyInfo{
+			ID:        kid,
+			Active:    kid == file.Active,
+			Next:      kid == file.Next,
+			CreatedAt: entry.CreatedAt,
+			RetiredAt: entry.RetiredAt,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ID < infos[j].ID
+	})
+
+	return infos, nil
+}
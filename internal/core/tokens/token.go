@@ -1,8 +1,7 @@
 package tokens
 
 import (
-	"log"
-	"os"
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -10,18 +9,33 @@ import (
 	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
 )
 
+// MaxTokenLifetime is the longest any token is valid for.
+const MaxTokenLifetime = time.Duration(
+	constants.M2MRefreshTokenMaxAge,
+) * time.Second
+
+// MinPruneAge is how long a key must have been retired before it may be
+// removed. Servers that had not yet reloaded the key file may have signed
+// with it shortly after it was retired.
+const MinPruneAge = MaxTokenLifetime + time.Hour
+
 type Service struct {
-	secret []byte
+	keys *KeySet
+	// legacySecret verifies HS256 tokens issued before signing moved to
+	// EdDSA; nil once they are no longer accepted
+	legacySecret []byte
 }
 
-func NewService() *Service {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		log.Printf(
-			"[WARNING] {NewService}: JWT_SECRET is empty. Signing will fail.",
-		)
+// NewService creates a token service that signs with the active key of
+// keys. Tokens signed with legacySecret using HS256 are still accepted when
+// it is not empty.
+func NewService(keys *KeySet, legacySecret string) *Service {
+	s := &Service{keys: keys}
+	if legacySecret != "" {
+		s.legacySecret = []byte(legacySecret)
 	}
-	return &Service{secret: []byte(secret)}
+
+	return s
 }
 
 func (s *Service) GenerateToken(
@@ -47,21 +61,91 @@ func (s *Service) GenerateToken(
 		},
 	}
 
-	// Create the token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signed, err := token.SignedString(s.secret)
+	signed, err := s.sign(claims)
+	return signed, claims, err
+}
+
+// GenerateRefreshToken signs a user refresh token belonging to the given
+// token family. Every rotation issues a new token in the same family.
+func (s *Service) GenerateRefreshToken(
+	userEmail string,
+	userID string,
+	roleID int,
+	tokenType string,
+	familyID string,
+	expireSeconds int,
+) (string, *Claims, error) {
+	claims := &Claims{
+		UserEmail: userEmail,
+		UserID:    userID,
+		RoleID:    roleID,
+		TokenType: tokenType,
+		FamilyID:  familyID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(
+				time.Duration(expireSeconds) * time.Second),
+			),
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+			Issuer:   constants.ClaimsIssuer,
+			ID:       uuid.New().String(),
+		},
+	}
+
+	signed, err := s.sign(claims)
+	return signed, claims, err
+}
+
+// GenerateM2MToken signs a token for a machine client. The client ID and
+// scopes are part of the signed claims so they can be trusted by the
+// middleware without a database lookup.
+func (s *Service) GenerateM2MToken(
+	clientID string,
+	clientName string,
+	scopes []string,
+	expireSeconds int,
+) (string, *Claims, error) {
+	claims := &Claims{
+		UserEmail:   clientName,
+		TokenType:   string(constants.AuthTypeM2M),
+		M2MClientID: clientID,
+		Scopes:      scopes,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(
+				time.Duration(expireSeconds) * time.Second),
+			),
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+			Issuer:   constants.ClaimsIssuer,
+			ID:       uuid.New().String(),
+		},
+	}
+
+	signed, err := s.sign(claims)
 	return signed, claims, err
 }
 
+// sign signs claims with the active key, named in the kid header so
+// verifiers can pick the matching public key.
+func (s *Service) sign(claims *Claims) (string, error) {
+	kid, key := s.keys.signingKey()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = kid
+
+	return token.SignedString(key)
+}
+
 func (s *Service) ValidateToken(tokenString string) (
 	*Claims, error,
 ) {
 	token, err := jwt.ParseWithClaims(
 		tokenString,
 		&Claims{},
-		func(token *jwt.Token) (interface{}, error) {
-			return s.secret, nil
-		},
+		s.verificationKey,
+		jwt.WithValidMethods([]string{
+			jwt.SigningMethodEdDSA.Alg(),
+			jwt.SigningMethodHS256.Alg(),
+		}),
+		jwt.WithIssuer(constants.ClaimsIssuer),
 	)
 	if err != nil {
 		return nil, err
@@ -75,6 +159,27 @@ func (s *Service) ValidateToken(tokenString string) (
 	return claims, nil
 }
 
+func (s *Service) verificationKey(token *jwt.Token) (interface{}, error) {
+	if token.Method.Alg() == jwt.SigningMethodHS256.Alg() {
+		if s.legacySecret == nil {
+			return nil, errors.New("HS256 tokens are no longer accepted")
+		}
+		return s.legacySecret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("token has no key id")
+	}
+
+	return s.keys.publicKey(kid)
+}
+
+// JWKS returns the public keys tokens can be verified with.
+func (s *Service) JWKS() JWKSet {
+	return s.keys.JWKS()
+}
+
 // ParseTokenUnverified extracts claims from a token string without
 // verifying its signature or expiration. Use this ONLY to identify
 // a session for refresh logic, never for authorization.
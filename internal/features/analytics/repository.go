@@ -29,7 +29,7 @@ func (r *Repository) GetTotalReports(ctx context.Context) (int, error) {
 	err := r.db.GetContext(
 		ctx,
 		&total,
-		"SELECT COUNT(*) FROM significant_notes",
+		"SELECT COUNT(*) FROM significant_notes WHERE deleted_at IS NULL",
 	)
 	return total, err
 }
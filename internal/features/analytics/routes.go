@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/middleware"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/tokens"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
 )
 
@@ -11,10 +12,11 @@ func RegisterRoutes(
 	rg *gin.RouterGroup,
 	h *Handler,
 	redis *datastore.RedisClient,
+	tokenService *tokens.Service,
 ) {
 	analyticsRoutes := rg.Group("/analytics")
 	analyticsRoutes.Use(middleware.AuditContextMiddleware())
-	analyticsRoutes.Use(middleware.AuthMiddleware(redis))
+	analyticsRoutes.Use(middleware.AuthMiddleware(redis, tokenService))
 
 	analyticsRoutes.GET("/dashboard",
 		middleware.RoleMiddleware(int(constants.AdminRoleID)),
@@ -30,7 +30,91 @@ type AppointmentDTO struct {
 	AppointmentCategory AppointmentCategory    `db:"appointment_category" json:"appointmentCategory,omitempty"`
 	AdminNotes          structs.NullableString `db:"admin_notes"          json:"adminNotes,omitempty"`
 	Status              AppointmentStatus      `db:"status"               json:"status,omitempty"`
+	Counselor           *AppointmentCounselor  `db:"counselor"            json:"counselor,omitempty"`
+	Flag                *AppointmentFlag       `                          json:"flag,omitempty"`
 	HasSignificantNote  bool                   `                          json:"hasSignificantNote"`
 	CreatedAt           time.Time              `db:"created_at"           json:"createdAt,omitempty"`
 	UpdatedAt           time.Time              `db:"updated_at"           json:"updatedAt,omitempty"`
 }
+
+// AppointmentFlag marks an appointment that landed on a day closed after it
+// was booked. It is cleared once the appointment is rescheduled.
+type AppointmentFlag struct {
+	Reason    string    `json:"reason"`
+	FlaggedAt time.Time `json:"flaggedAt"`
+}
+
+// AppointmentCounselor identifies the counselor assigned to an appointment.
+// Only ID is read when it is part of a request.
+type AppointmentCounselor struct {
+	ID        string `json:"id"`
+	FirstName string `json:"firstName,omitempty"`
+	LastName  string `json:"lastName,omitempty"`
+}
+
+type CreateRescheduleRequest struct {
+	Reason  string                    `json:"reason"  binding:"required"`
+	Options []RescheduleOptionRequest `json:"options" binding:"required,min=1,max=3,dive"`
+}
+
+type RescheduleOptionRequest struct {
+	WhenDate   string `json:"whenDate"   binding:"required"`
+	TimeSlotID int    `json:"timeSlotId" binding:"required"`
+}
+
+type ApproveRescheduleRequest struct {
+	OptionID string `json:"optionId" binding:"required"`
+	Note     string `json:"note"`
+}
+
+type DeclineRescheduleRequest struct {
+	Note string `json:"note" binding:"required"`
+}
+
+type RescheduleRequestDTO struct {
+	ID             string                 `json:"id"`
+	AppointmentID  string                 `json:"appointmentId"`
+	RequestedBy    string                 `json:"requestedBy"`
+	Reason         string                 `json:"reason"`
+	Status         string                 `json:"status"`
+	Options        []RescheduleOptionDTO  `json:"options"`
+	ChosenOptionID structs.NullableString `json:"chosenOptionId,omitempty"`
+	DecisionNote   structs.NullableString `json:"decisionNote,omitempty"`
+	DecidedBy      structs.NullableString `json:"decidedBy,omitempty"`
+	DecidedAt      *time.Time             `json:"decidedAt,omitempty"`
+	CreatedAt      time.Time              `json:"createdAt"`
+}
+
+type RescheduleOptionDTO struct {
+	ID       string   `json:"id"`
+	WhenDate string   `json:"whenDate"`
+	TimeSlot TimeSlot `json:"timeSlot"`
+}
+
+type JoinWaitlistRequest struct {
+	StartDate             string                 `json:"startDate"             binding:"required"`
+	EndDate               string                 `json:"endDate"               binding:"required"`
+	AppointmentCategoryID int                    `json:"appointmentCategoryId" binding:"required"`
+	Reason                structs.NullableString `json:"reason"`
+}
+
+type WaitlistEntryDTO struct {
+	ID                  string                 `json:"id"`
+	StartDate           string                 `json:"startDate"`
+	EndDate             string                 `json:"endDate"`
+	AppointmentCategory AppointmentCategory    `json:"appointmentCategory"`
+	Reason              structs.NullableString `json:"reason,omitempty"`
+	Status              string                 `json:"status"`
+	AppointmentID       structs.NullableString `json:"appointmentId,omitempty"`
+	Offer               *WaitlistOfferDTO      `json:"offer,omitempty"`
+	CreatedAt           time.Time              `json:"createdAt"`
+}
+
+// WaitlistOfferDTO is the slot currently held for a waitlist entry. It must
+// be accepted before ExpiresAt.
+type WaitlistOfferDTO struct {
+	ID        string    `json:"id"`
+	WhenDate  string    `json:"whenDate"`
+	TimeSlot  TimeSlot  `json:"timeSlot"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
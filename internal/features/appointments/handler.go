@@ -2,6 +2,7 @@ package appointments
 
 import (
 	"database/sql"
+	"errors"
 	"log"
 	"net/http"
 	"strings"
@@ -9,8 +10,10 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/audit"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/ical"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/response"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/structs"
+	"github.com/olazo-johnalbert/duckload-api/internal/features/calendar"
 )
 
 type Handler struct {
@@ -106,6 +109,7 @@ func (h *Handler) GetDailyStatusCountList(c *gin.Context) {
 	dsc, err := h.service.GetDailyStatusCount(
 		c,
 		req.StartDate,
+		getCounselorScope(c),
 	)
 	if err != nil {
 		log.Printf(
@@ -134,6 +138,7 @@ func (h *Handler) GetDailyStatusCountList(c *gin.Context) {
 // @Success      201     {object}  map[string]interface{}
 // @Failure      400     {object}  map[string]string
 // @Failure      403     {object}  map[string]string
+// @Failure      409     {object}  map[string]string
 // @Failure      500     {object}  map[string]string
 // @Router       /appointments [post]
 func (h *Handler) PostAppointment(c *gin.Context) {
@@ -154,6 +159,19 @@ func (h *Handler) PostAppointment(c *gin.Context) {
 		req,
 	)
 	if err != nil {
+		if errors.Is(err, calendar.ErrDateUnavailable) {
+			response.SendFail(c, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, ErrCounselorUnavailable) ||
+			errors.Is(err, ErrSlotFull) {
+			response.SendFail(
+				c,
+				gin.H{"error": err.Error()},
+				http.StatusConflict,
+			)
+			return
+		}
 		log.Printf(
 			"[PostAppointment] {Create Appointment}: %v",
 			err,
@@ -245,6 +263,7 @@ func (h *Handler) GetAppointmentList(c *gin.Context) {
 	appts, err := h.service.ListAppointments(
 		c.Request.Context(),
 		req,
+		getCounselorScope(c),
 	)
 	if err != nil {
 		log.Printf(
@@ -265,10 +284,11 @@ func (h *Handler) GetAppointmentList(c *gin.Context) {
 
 // GetAvailableTimeSlotList godoc
 // @Summary      Get available time slots
-// @Description  Retrieves available time slots for a date.
+// @Description  Retrieves per-slot capacity and remaining seats for a date.
 // @Tags         Appointments
 // @Produce      json
-// @Param        date query string true "Date (YYYY-MM-DD)"
+// @Param        date         query string true  "Date (YYYY-MM-DD)"
+// @Param        counselor_id query string false "Only consider this counselor"
 // @Success      200  {object} []AvailableTimeSlotView
 // @Failure      400  {object} map[string]string
 // @Failure      500  {object} map[string]string
@@ -280,9 +300,15 @@ func (h *Handler) GetAvailableTimeSlotList(c *gin.Context) {
 		return
 	}
 
+	var counselorID *string
+	if id := c.Query("counselor_id"); id != "" {
+		counselorID = &id
+	}
+
 	slots, err := h.service.GetAvailableTimeSlots(
 		c.Request.Context(),
 		date,
+		counselorID,
 	)
 	if err != nil {
 		log.Printf(
@@ -412,10 +438,16 @@ func (h *Handler) GetAppointmentStatsList(c *gin.Context) {
 		return
 	}
 
+	var counselorIDPtr *string
+	if iirIDPtr == nil {
+		counselorIDPtr = getCounselorScope(c)
+	}
+
 	stats, err := h.service.GetAppointmentStats(
 		c.Request.Context(),
 		req,
 		iirIDPtr,
+		counselorIDPtr,
 	)
 	if err != nil {
 		log.Printf(
@@ -436,7 +468,7 @@ func (h *Handler) GetAppointmentStatsList(c *gin.Context) {
 
 // PatchAppointment godoc
 // @Summary      Update appointment
-// @Description  Updates appointment details (reschedule).
+// @Description  Updates appointment details (reschedule). Admin only.
 // @Tags         Appointments
 // @Accept       json
 // @Produce      json
@@ -444,7 +476,9 @@ func (h *Handler) GetAppointmentStatsList(c *gin.Context) {
 // @Param        body body      AppointmentDTO true  "Updated appointment"
 // @Success      200  {object} map[string]string
 // @Failure      400  {object} map[string]string
+// @Failure      403  {object} map[string]string
 // @Failure      404  {object} map[string]string
+// @Failure      409  {object} map[string]string
 // @Failure      500  {object} map[string]string
 // @Router       /appointments/id/{id} [patch]
 type CancelAppointmentRequest struct {
@@ -473,33 +507,6 @@ func (h *Handler) PostCancelAppointment(c *gin.Context) {
 		return
 	}
 
-	// Only allow cancellation of Pending or Scheduled appointments
-	statusName := strings.ToLower(appt.Status.Name)
-	if statusName != "pending" && statusName != "scheduled" {
-		response.SendFail(c, gin.H{"error": "Only pending or scheduled appointments can be cancelled"})
-		return
-	}
-
-	// Fetch all statuses to find 'Cancelled'
-	statuses, err := h.service.GetAppointmentStatuses(c.Request.Context())
-	if err != nil {
-		response.SendError(c, "Failed to fetch statuses", http.StatusInternalServerError, nil)
-		return
-	}
-
-	var cancelStatusID int
-	for _, s := range statuses {
-		if strings.ToLower(s.Name) == "cancelled" {
-			cancelStatusID = s.ID
-			break
-		}
-	}
-
-	if cancelStatusID == 0 {
-		response.SendError(c, "Cancelled status not found", http.StatusInternalServerError, nil)
-		return
-	}
-
 	var req CancelAppointmentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		// We allow empty body for backward compatibility or simple cancellations
@@ -511,7 +518,7 @@ func (h *Handler) PostCancelAppointment(c *gin.Context) {
 
 	// Update appointment status
 	updateReq := *appt
-	updateReq.Status.ID = cancelStatusID
+	updateReq.Status.ID = int(constants.StatusCancelled)
 
 	if req.Reason != "" {
 		if updateReq.AdminNotes.Valid && updateReq.AdminNotes.String != "" {
@@ -522,6 +529,9 @@ func (h *Handler) PostCancelAppointment(c *gin.Context) {
 	}
 
 	if err := h.service.UpdateAppointment(c.Request.Context(), id, updateReq); err != nil {
+		if sendTransitionFail(c, err) {
+			return
+		}
 		log.Printf("[PostCancelAppointment] {Update}: %v", err)
 		response.SendError(c, "Failed to cancel appointment", http.StatusInternalServerError, nil)
 		return
@@ -557,6 +567,22 @@ func (h *Handler) PatchAppointment(c *gin.Context) {
 			)
 			return
 		}
+		if errors.Is(err, calendar.ErrDateUnavailable) {
+			response.SendFail(c, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, ErrCounselorUnavailable) ||
+			errors.Is(err, ErrSlotFull) {
+			response.SendFail(
+				c,
+				gin.H{"error": err.Error()},
+				http.StatusConflict,
+			)
+			return
+		}
+		if sendTransitionFail(c, err) {
+			return
+		}
 		log.Printf(
 			"[PatchAppointment] {Update Appointment}: %v",
 			err,
@@ -574,3 +600,598 @@ func (h *Handler) PatchAppointment(c *gin.Context) {
 		"message": "Appointment updated successfully",
 	})
 }
+
+// getCounselorScope limits counselors (admins) to their own and unassigned
+// appointments. Superadmins see everything.
+func getCounselorScope(c *gin.Context) *string {
+	roleID, ok := c.Get("roleID")
+	if !ok || roleID != int(constants.AdminRoleID) {
+		return nil
+	}
+
+	userID := c.GetString("userID")
+	if userID == "" {
+		return nil
+	}
+
+	return &userID
+}
+
+// PostCalendarFeedToken godoc
+// @Summary      Create calendar feed URL
+// @Description  Issues a private iCalendar subscription URL for the caller's appointments. Any previous URL stops working.
+// @Tags         Appointments
+// @Produce      json
+// @Success      201  {object} map[string]string
+// @Failure      500  {object} map[string]string
+// @Router       /appointments/calendar/feed [post]
+func (h *Handler) PostCalendarFeedToken(c *gin.Context) {
+	token, err := h.service.CreateCalendarFeedToken(
+		c.Request.Context(),
+		c.GetString("userID"),
+	)
+	if err != nil {
+		log.Printf("[PostCalendarFeedToken] {Create Token}: %v", err)
+		response.SendError(
+			c,
+			"Failed to create calendar feed",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	scheme := "https"
+	if c.Request.TLS == nil && c.GetHeader("X-Forwarded-Proto") != "https" {
+		scheme = "http"
+	}
+	path := strings.TrimSuffix(c.Request.URL.Path, "/") + "/" + token + ".ics"
+
+	response.SendSuccess(c, gin.H{
+		"token": token,
+		"url":   scheme + "://" + c.Request.Host + path,
+	}, http.StatusCreated)
+}
+
+// GetCalendarFeed godoc
+// @Summary      Calendar feed
+// @Description  Public iCalendar feed of a user's appointments, authorized by the token in the URL.
+// @Tags         Appointments
+// @Produce      text/calendar
+// @Param        token path string true "Feed token, optionally suffixed with .ics"
+// @Success      200  {string} string
+// @Failure      404  {object} map[string]string
+// @Failure      500  {object} map[string]string
+// @Router       /appointments/calendar/feed/{token} [get]
+func (h *Handler) GetCalendarFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	feed, err := h.service.GetCalendarFeed(c.Request.Context(), token)
+	if err != nil {
+		if errors.Is(err, ErrFeedNotFound) {
+			response.SendFail(
+				c,
+				gin.H{"error": "Calendar feed not found"},
+				http.StatusNotFound,
+			)
+			return
+		}
+		log.Printf("[GetCalendarFeed] {Build Feed}: %v", err)
+		response.SendError(
+			c,
+			"Failed to build calendar feed",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	c.Header("Content-Disposition", `inline; filename="appointments.ics"`)
+	c.Data(http.StatusOK, ical.ContentType(ical.MethodPublish), feed)
+}
+
+// sendTransitionFail answers status machine errors with a JSend fail and
+// reports whether it did.
+func sendTransitionFail(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, ErrInvalidTransition):
+		response.SendFail(
+			c,
+			gin.H{"error": err.Error()},
+			http.StatusConflict,
+		)
+	case errors.Is(err, ErrTransitionForbidden):
+		response.SendFail(
+			c,
+			gin.H{"error": err.Error()},
+			http.StatusForbidden,
+		)
+	default:
+		return false
+	}
+
+	return true
+}
+
+// PostRescheduleRequest godoc
+// @Summary      Request a reschedule
+// @Description  Lets the student who owns the appointment propose up to three new schedules. The appointment keeps its current schedule until a counselor approves one.
+// @Tags         Appointments
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string                  true  "Appointment ID"
+// @Param        body body      CreateRescheduleRequest true  "Proposed schedules"
+// @Success      201  {object} RescheduleRequestDTO
+// @Failure      400  {object} map[string]string
+// @Failure      403  {object} map[string]string
+// @Failure      404  {object} map[string]string
+// @Failure      409  {object} map[string]string
+// @Failure      500  {object} map[string]string
+// @Router       /appointments/id/{id}/reschedule-requests [post]
+func (h *Handler) PostRescheduleRequest(c *gin.Context) {
+	id := c.Param("id")
+	if !h.checkAppointmentOwner(c, id) {
+		return
+	}
+
+	var req CreateRescheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendFail(c, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	request, err := h.service.CreateRescheduleRequest(
+		c.Request.Context(),
+		id,
+		req,
+	)
+	if err != nil {
+		if sendRescheduleFail(c, err) {
+			return
+		}
+		log.Printf("[PostRescheduleRequest] {Create Request}: %v", err)
+		response.SendError(
+			c,
+			"Failed to request reschedule",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	response.SendSuccess(c, request, http.StatusCreated)
+}
+
+// GetRescheduleRequestList godoc
+// @Summary      List reschedule requests
+// @Description  Returns the reschedule history of an appointment, newest first. Students only see their own appointments.
+// @Tags         Appointments
+// @Produce      json
+// @Param        id   path      string  true  "Appointment ID"
+// @Success      200  {array}  RescheduleRequestDTO
+// @Failure      403  {object} map[string]string
+// @Failure      404  {object} map[string]string
+// @Failure      500  {object} map[string]string
+// @Router       /appointments/id/{id}/reschedule-requests [get]
+func (h *Handler) GetRescheduleRequestList(c *gin.Context) {
+	id := c.Param("id")
+	roleID, _ := c.Get("roleID")
+	if roleID == int(constants.StudentRoleID) &&
+		!h.checkAppointmentOwner(c, id) {
+		return
+	}
+
+	requests, err := h.service.ListRescheduleRequests(
+		c.Request.Context(),
+		id,
+	)
+	if err != nil {
+		log.Printf("[GetRescheduleRequestList] {List Requests}: %v", err)
+		response.SendError(
+			c,
+			"Failed to retrieve reschedule requests",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	response.SendSuccess(c, requests)
+}
+
+// PostApproveRescheduleRequest godoc
+// @Summary      Approve a reschedule request
+// @Description  Moves the appointment to the chosen option if its slot still has room. Counselors may only decide requests on their own or unassigned appointments.
+// @Tags         Appointments
+// @Accept       json
+// @Produce      json
+// @Param        requestId path      string                   true  "Reschedule request ID"
+// @Param        body      body      ApproveRescheduleRequest true  "Chosen option"
+// @Success      200  {object} map[string]string
+// @Failure      400  {object} map[string]string
+// @Failure      403  {object} map[string]string
+// @Failure      404  {object} map[string]string
+// @Failure      409  {object} map[string]string
+// @Failure      500  {object} map[string]string
+// @Router       /appointments/reschedule-requests/{requestId}/approve [post]
+func (h *Handler) PostApproveRescheduleRequest(c *gin.Context) {
+	var req ApproveRescheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendFail(c, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	err := h.service.ApproveRescheduleRequest(
+		c.Request.Context(),
+		c.Param("requestId"),
+		req,
+		getCounselorScope(c),
+	)
+	if err != nil {
+		if sendRescheduleFail(c, err) {
+			return
+		}
+		log.Printf("[PostApproveRescheduleRequest] {Approve}: %v", err)
+		response.SendError(
+			c,
+			"Failed to approve reschedule request",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	response.SendSuccess(c, gin.H{
+		"message": "Appointment rescheduled successfully",
+	})
+}
+
+// PostDeclineRescheduleRequest godoc
+// @Summary      Decline a reschedule request
+// @Description  Closes the request and keeps the appointment on its current schedule.
+// @Tags         Appointments
+// @Accept       json
+// @Produce      json
+// @Param        requestId path      string                   true  "Reschedule request ID"
+// @Param        body      body      DeclineRescheduleRequest true  "Reason for declining"
+// @Success      200  {object} map[string]string
+// @Failure      400  {object} map[string]string
+// @Failure      403  {object} map[string]string
+// @Failure      404  {object} map[string]string
+// @Failure      409  {object} map[string]string
+// @Failure      500  {object} map[string]string
+// @Router       /appointments/reschedule-requests/{requestId}/decline [post]
+func (h *Handler) PostDeclineRescheduleRequest(c *gin.Context) {
+	var req DeclineRescheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendFail(c, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	err := h.service.DeclineRescheduleRequest(
+		c.Request.Context(),
+		c.Param("requestId"),
+		req,
+		getCounselorScope(c),
+	)
+	if err != nil {
+		if sendRescheduleFail(c, err) {
+			return
+		}
+		log.Printf("[PostDeclineRescheduleRequest] {Decline}: %v", err)
+		response.SendError(
+			c,
+			"Failed to decline reschedule request",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	response.SendSuccess(c, gin.H{
+		"message": "Reschedule request declined",
+	})
+}
+
+// checkAppointmentOwner responds with 404 or 403 and returns false unless
+// the caller is the student who owns the appointment.
+func (h *Handler) checkAppointmentOwner(c *gin.Context, id string) bool {
+	ownerID, err := h.service.GetUserIDByAppointmentID(
+		c.Request.Context(),
+		id,
+	)
+	if err == sql.ErrNoRows {
+		response.SendFail(
+			c,
+			gin.H{"error": "Appointment not found"},
+			http.StatusNotFound,
+		)
+		return false
+	}
+	if err != nil {
+		log.Printf("[checkAppointmentOwner] {Fetch Owner}: %v", err)
+		response.SendError(
+			c,
+			"Failed to verify ownership",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return false
+	}
+	if ownerID != c.GetString("userID") {
+		response.SendFail(
+			c,
+			gin.H{"error": "Access denied"},
+			http.StatusForbidden,
+		)
+		return false
+	}
+
+	return true
+}
+
+// sendRescheduleFail maps reschedule errors to a response and reports
+// whether it sent one.
+func sendRescheduleFail(c *gin.Context, err error) bool {
+	switch {
+	case err == sql.ErrNoRows:
+		response.SendFail(
+			c,
+			gin.H{"error": "Appointment not found"},
+			http.StatusNotFound,
+		)
+	case errors.Is(err, ErrRescheduleNotFound):
+		response.SendFail(
+			c,
+			gin.H{"error": err.Error()},
+			http.StatusNotFound,
+		)
+	case errors.Is(err, ErrRescheduleNotAssigned):
+		response.SendFail(
+			c,
+			gin.H{"error": err.Error()},
+			http.StatusForbidden,
+		)
+	case errors.Is(err, ErrRescheduleOption),
+		errors.Is(err, calendar.ErrDateUnavailable):
+		response.SendFail(c, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrReschedulePending),
+		errors.Is(err, ErrRescheduleDecided),
+		errors.Is(err, ErrSlotFull),
+		errors.Is(err, ErrCounselorUnavailable):
+		response.SendFail(
+			c,
+			gin.H{"error": err.Error()},
+			http.StatusConflict,
+		)
+	default:
+		return sendTransitionFail(c, err)
+	}
+
+	return true
+}
+
+// PostWaitlistEntry godoc
+// @Summary      Join the waitlist
+// @Description  Puts the student in line for any slot of a category between two dates. When a slot frees up it is held for the next student in line.
+// @Tags         Appointments
+// @Accept       json
+// @Produce      json
+// @Param        body body      JoinWaitlistRequest true  "Waitlist range"
+// @Success      201  {object} WaitlistEntryDTO
+// @Failure      400  {object} map[string]string
+// @Failure      403  {object} map[string]string
+// @Failure      409  {object} map[string]string
+// @Failure      500  {object} map[string]string
+// @Router       /appointments/waitlist [post]
+func (h *Handler) PostWaitlistEntry(c *gin.Context) {
+	iirID, ok := getIIRIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req JoinWaitlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendFail(c, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	entry, err := h.service.JoinWaitlist(c.Request.Context(), iirID, req)
+	if err != nil {
+		if sendWaitlistFail(c, err) {
+			return
+		}
+		log.Printf("[PostWaitlistEntry] {Join Waitlist}: %v", err)
+		response.SendError(
+			c,
+			"Failed to join the waitlist",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	response.SendSuccess(c, entry, http.StatusCreated)
+}
+
+// GetWaitlistEntryList godoc
+// @Summary      List my waitlist entries
+// @Description  Returns the student's waitlist entries, newest first, including any slot currently held for them.
+// @Tags         Appointments
+// @Produce      json
+// @Success      200  {array}  WaitlistEntryDTO
+// @Failure      403  {object} map[string]string
+// @Failure      500  {object} map[string]string
+// @Router       /appointments/waitlist [get]
+func (h *Handler) GetWaitlistEntryList(c *gin.Context) {
+	iirID, ok := getIIRIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	entries, err := h.service.ListWaitlist(c.Request.Context(), iirID)
+	if err != nil {
+		log.Printf("[GetWaitlistEntryList] {List Waitlist}: %v", err)
+		response.SendError(
+			c,
+			"Failed to retrieve waitlist",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	response.SendSuccess(c, entries)
+}
+
+// DeleteWaitlistEntry godoc
+// @Summary      Leave the waitlist
+// @Description  Cancels a waitlist entry. A slot held for it is offered to the next student.
+// @Tags         Appointments
+// @Produce      json
+// @Param        id   path      string  true  "Waitlist entry ID"
+// @Success      200  {object} map[string]string
+// @Failure      403  {object} map[string]string
+// @Failure      404  {object} map[string]string
+// @Failure      409  {object} map[string]string
+// @Failure      500  {object} map[string]string
+// @Router       /appointments/waitlist/id/{id} [delete]
+func (h *Handler) DeleteWaitlistEntry(c *gin.Context) {
+	iirID, ok := getIIRIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	err := h.service.LeaveWaitlist(c.Request.Context(), iirID, c.Param("id"))
+	if err != nil {
+		if sendWaitlistFail(c, err) {
+			return
+		}
+		log.Printf("[DeleteWaitlistEntry] {Leave Waitlist}: %v", err)
+		response.SendError(
+			c,
+			"Failed to leave the waitlist",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	response.SendSuccess(c, gin.H{"message": "Removed from the waitlist"})
+}
+
+// PostAcceptWaitlistOffer godoc
+// @Summary      Accept a waitlist offer
+// @Description  Books the slot held for the student as a pending appointment.
+// @Tags         Appointments
+// @Produce      json
+// @Param        offerId path      string  true  "Waitlist offer ID"
+// @Success      201  {object} map[string]interface{}
+// @Failure      400  {object} map[string]string
+// @Failure      403  {object} map[string]string
+// @Failure      404  {object} map[string]string
+// @Failure      409  {object} map[string]string
+// @Failure      500  {object} map[string]string
+// @Router       /appointments/waitlist/offers/{offerId}/accept [post]
+func (h *Handler) PostAcceptWaitlistOffer(c *gin.Context) {
+	iirID, ok := getIIRIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	appt, err := h.service.AcceptWaitlistOffer(
+		c.Request.Context(),
+		iirID,
+		c.Param("offerId"),
+	)
+	if err != nil {
+		if sendWaitlistFail(c, err) {
+			return
+		}
+		log.Printf("[PostAcceptWaitlistOffer] {Accept Offer}: %v", err)
+		response.SendError(
+			c,
+			"Failed to accept waitlist offer",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	response.SendSuccess(c, gin.H{
+		"message": "Appointment created successfully",
+		"id":      appt.ID,
+	}, http.StatusCreated)
+}
+
+// PostDeclineWaitlistOffer godoc
+// @Summary      Decline a waitlist offer
+// @Description  Gives up the held slot. The student stays on the waitlist for other slots.
+// @Tags         Appointments
+// @Produce      json
+// @Param        offerId path      string  true  "Waitlist offer ID"
+// @Success      200  {object} map[string]string
+// @Failure      403  {object} map[string]string
+// @Failure      404  {object} map[string]string
+// @Failure      409  {object} map[string]string
+// @Failure      500  {object} map[string]string
+// @Router       /appointments/waitlist/offers/{offerId}/decline [post]
+func (h *Handler) PostDeclineWaitlistOffer(c *gin.Context) {
+	iirID, ok := getIIRIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	err := h.service.DeclineWaitlistOffer(
+		c.Request.Context(),
+		iirID,
+		c.Param("offerId"),
+	)
+	if err != nil {
+		if sendWaitlistFail(c, err) {
+			return
+		}
+		log.Printf("[PostDeclineWaitlistOffer] {Decline Offer}: %v", err)
+		response.SendError(
+			c,
+			"Failed to decline waitlist offer",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	response.SendSuccess(c, gin.H{"message": "Waitlist offer declined"})
+}
+
+// sendWaitlistFail maps waitlist errors to a response and reports whether
+// it sent one.
+func sendWaitlistFail(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, ErrWaitlistRange),
+		errors.Is(err, ErrWaitlistCategory),
+		errors.Is(err, calendar.ErrDateUnavailable):
+		response.SendFail(c, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrWaitlistNotFound),
+		errors.Is(err, ErrOfferNotFound):
+		response.SendFail(
+			c,
+			gin.H{"error": err.Error()},
+			http.StatusNotFound,
+		)
+	case errors.Is(err, ErrWaitlistDuplicate),
+		errors.Is(err, ErrWaitlistClosed),
+		errors.Is(err, ErrOfferExpired),
+		errors.Is(err, ErrSlotFull),
+		errors.Is(err, ErrCounselorUnavailable):
+		response.SendFail(
+			c,
+			gin.H{"error": err.Error()},
+			http.StatusConflict,
+		)
+	default:
+		return false
+	}
+
+	return true
+}
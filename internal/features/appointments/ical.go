@@ -0,0 +1,216 @@
+package appointments
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"time"
+
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/ical"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/email"
+)
+
+const (
+	// appointmentDuration is how long each calendar event lasts; time slots
+	// are one hour apart.
+	appointmentDuration = time.Hour
+	icalUIDDomain       = "appointments.pupt-ogos"
+	icalLocation        = "PUPT Guidance Office"
+)
+
+var ErrFeedNotFound = errors.New("calendar feed not found")
+
+// CreateCalendarFeedToken issues a new subscription token for the user. Any
+// previous token stops working.
+func (s *Service) CreateCalendarFeedToken(
+	ctx context.Context,
+	userID string,
+) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate feed token: %w", err)
+	}
+	token := hex.EncodeToString(raw)
+
+	if err := s.repo.UpsertFeedToken(ctx, userID, hashFeedToken(token)); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// GetCalendarFeed renders the appointments of the token's owner as an
+// iCalendar subscription feed.
+func (s *Service) GetCalendarFeed(
+	ctx context.Context,
+	token string,
+) ([]byte, error) {
+	userID, err := s.repo.GetUserIDByFeedToken(ctx, hashFeedToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if userID == "" {
+		return nil, ErrFeedNotFound
+	}
+
+	appts, err := s.repo.ListForCalendarFeed(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]ical.Event, 0, len(appts))
+	for i := range appts {
+		event, err := toICalEvent(&appts[i])
+		if err != nil {
+			log.Printf("[GetCalendarFeed] {Build Event}: %v", err)
+			continue
+		}
+		events = append(events, event)
+	}
+
+	return ical.Build("Guidance Appointments", ical.MethodPublish, events), nil
+}
+
+// sendCalendarInvite emails the student and the assigned counselor an .ics
+// attachment for the appointment's current state. Cancelled and rejected
+// appointments are sent as CANCEL so clients remove the event. A counselor
+// who was unassigned by the update gets a CANCEL for their copy.
+func (s *Service) sendCalendarInvite(
+	ctx context.Context,
+	old, appt *AppointmentWithDetailsView,
+) {
+	event, err := toICalEvent(appt)
+	if err != nil {
+		log.Printf("[sendCalendarInvite] {Build Event}: %v", err)
+		return
+	}
+
+	method, subject := ical.MethodRequest, "Appointment Update"
+	if event.Status == ical.StatusCancelled {
+		method, subject = ical.MethodCancel, "Appointment Cancelled"
+	}
+
+	recipients := []string{appt.UserEmail}
+	if appt.CounselorEmail.Valid {
+		recipients = append(recipients, appt.CounselorEmail.String)
+	}
+	s.sendICS(ctx, event, method, subject, appt.StatusName, recipients)
+
+	if old != nil && old.CounselorEmail.Valid &&
+		old.CounselorID != appt.CounselorID {
+		cancelled := event
+		cancelled.Status = ical.StatusCancelled
+		s.sendICS(
+			ctx,
+			cancelled,
+			ical.MethodCancel,
+			"Appointment Reassigned",
+			"Reassigned",
+			[]string{old.CounselorEmail.String},
+		)
+	}
+}
+
+func (s *Service) sendICS(
+	ctx context.Context,
+	event ical.Event,
+	method, subject, statusName string,
+	recipients []string,
+) {
+	attachment := email.Attachment{
+		Filename:    "appointment.ics",
+		ContentType: ical.ContentType(method),
+		Content:     ical.Build("", method, []ical.Event{event}),
+	}
+	body := fmt.Sprintf(
+		"<p>%s on %s: <strong>%s</strong>.</p>"+
+			"<p>Open the attached invitation to update your calendar.</p>",
+		html.EscapeString(event.Summary),
+		event.Start.Format("January 2, 2006 3:04 PM"),
+		html.EscapeString(statusName),
+	)
+
+	for _, to := range recipients {
+		if to == "" {
+			continue
+		}
+		_, err := s.emailer.SendEmailWithAttachments(
+			ctx,
+			to,
+			subject,
+			body,
+			[]email.Attachment{attachment},
+		)
+		if err != nil {
+			log.Printf("[sendCalendarInvite] {Send Email}: %v", err)
+		}
+	}
+}
+
+// isCalendarChange reports whether an update changed anything shown on the
+// calendar event, so notes-only edits do not send a new invitation.
+func isCalendarChange(old, updated *AppointmentWithDetailsView) bool {
+	return old == nil ||
+		old.WhenDate != updated.WhenDate ||
+		old.TimeSlotID != updated.TimeSlotID ||
+		old.StatusID != updated.StatusID ||
+		old.CounselorID != updated.CounselorID
+}
+
+func toICalEvent(appt *AppointmentWithDetailsView) (ical.Event, error) {
+	start, err := time.ParseInLocation(
+		"2006-01-02 15:04:05",
+		appt.WhenDate+" "+appt.TimeSlotTime,
+		time.Local,
+	)
+	if err != nil {
+		return ical.Event{}, fmt.Errorf(
+			"invalid schedule for appointment %s: %w",
+			appt.ID,
+			err,
+		)
+	}
+
+	description := fmt.Sprintf("Status: %s", appt.StatusName)
+	if appt.CounselorID.Valid {
+		description += fmt.Sprintf(
+			"\nCounselor: %s %s",
+			appt.CounselorFirstName.String,
+			appt.CounselorLastName.String,
+		)
+	}
+
+	return ical.Event{
+		UID:         fmt.Sprintf("%s@%s", appt.ID, icalUIDDomain),
+		Sequence:    appt.ICalSequence,
+		Start:       start,
+		End:         start.Add(appointmentDuration),
+		Summary:     fmt.Sprintf("Guidance Appointment: %s", appt.CategoryName),
+		Description: description,
+		Location:    icalLocation,
+		Status:      icalStatus(constants.StatusID(appt.StatusID)),
+		UpdatedAt:   appt.UpdatedAt,
+	}, nil
+}
+
+func icalStatus(statusID constants.StatusID) string {
+	switch statusID {
+	case constants.StatusPending:
+		return ical.StatusTentative
+	case constants.StatusCancelled, constants.StatusRejected:
+		return ical.StatusCancelled
+	default:
+		return ical.StatusConfirmed
+	}
+}
+
+func hashFeedToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
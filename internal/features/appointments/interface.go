@@ -2,6 +2,8 @@ package appointments
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
@@ -18,10 +20,12 @@ type ServiceInterface interface {
 	GetDailyStatusCount(
 		ctx context.Context,
 		startDate string,
+		counselorID *string,
 	) ([]DailyStatusCount, error)
 	ListAppointments(
 		ctx context.Context,
 		req ListAppointmentsRequest,
+		counselorID *string,
 	) (*ListAppointmentsDTO, error)
 	GetAppointmentsByUserID(
 		ctx context.Context,
@@ -36,15 +40,52 @@ type ServiceInterface interface {
 	GetAppointmentStats(
 		ctx context.Context,
 		req ListAppointmentsRequest,
-		iirID *string,
+		iirID, counselorID *string,
 	) ([]StatusCount, error)
 	GetAvailableTimeSlots(
 		ctx context.Context,
 		date string,
+		counselorID *string,
 	) ([]AvailableTimeSlotView, error)
 	GetAppointmentStatuses(ctx context.Context) ([]AppointmentStatus, error)
+	CreateCalendarFeedToken(ctx context.Context, userID string) (string, error)
+	GetCalendarFeed(ctx context.Context, token string) ([]byte, error)
 	UpdateAppointment(ctx context.Context, id string, req AppointmentDTO) error
 	GetUserIDByAppointmentID(ctx context.Context, id string) (string, error)
+	CreateRescheduleRequest(
+		ctx context.Context,
+		appointmentID string,
+		req CreateRescheduleRequest,
+	) (*RescheduleRequestDTO, error)
+	ListRescheduleRequests(
+		ctx context.Context,
+		appointmentID string,
+	) ([]RescheduleRequestDTO, error)
+	ApproveRescheduleRequest(
+		ctx context.Context,
+		requestID string,
+		req ApproveRescheduleRequest,
+		counselorID *string,
+	) error
+	DeclineRescheduleRequest(
+		ctx context.Context,
+		requestID string,
+		req DeclineRescheduleRequest,
+		counselorID *string,
+	) error
+	JoinWaitlist(
+		ctx context.Context,
+		iirID string,
+		req JoinWaitlistRequest,
+	) (*WaitlistEntryDTO, error)
+	ListWaitlist(ctx context.Context, iirID string) ([]WaitlistEntryDTO, error)
+	LeaveWaitlist(ctx context.Context, iirID, id string) error
+	AcceptWaitlistOffer(
+		ctx context.Context,
+		iirID, offerID string,
+	) (*Appointment, error)
+	DeclineWaitlistOffer(ctx context.Context, iirID, offerID string) error
+	ExpireWaitlistOffers(ctx context.Context)
 }
 
 type RepositoryInterface interface {
@@ -58,16 +99,18 @@ type RepositoryInterface interface {
 	GetDailyStatusCount(
 		ctx context.Context,
 		startDate, endDate string,
+		counselorID *string,
 	) ([]DailyStatusCount, error)
 	GetTotalAppointmentsCount(
 		ctx context.Context,
 		statusID, startDate, endDate string,
-		iirID *string,
+		iirID, counselorID *string,
 	) (int, error)
 	List(
 		ctx context.Context,
 		offset, limit int,
 		search, orderBy, statusIDs, startDate, endDate string,
+		counselorID *string,
 	) ([]AppointmentWithDetailsView, error)
 	GetTimeSlotByID(ctx context.Context, id int) (*TimeSlot, error)
 	GetAppointmentCategoryByID(
@@ -78,7 +121,50 @@ type RepositoryInterface interface {
 	GetAvailableTimeSlots(
 		ctx context.Context,
 		date string,
+		counselorID *string,
 	) ([]AvailableTimeSlotView, error)
+	LockTimeSlot(ctx context.Context, tx datastore.DB, timeSlotID int) error
+	ClearFlag(ctx context.Context, tx datastore.DB, id string) error
+	ClearReminders(
+		ctx context.Context,
+		tx datastore.DB,
+		appointmentID string,
+	) error
+	LockAppointmentStatus(
+		ctx context.Context,
+		tx datastore.DB,
+		id string,
+	) (int, error)
+	ListReminderCandidates(
+		ctx context.Context,
+		from, to string,
+	) ([]ReminderCandidate, error)
+	ClaimReminder(
+		ctx context.Context,
+		appointmentID string,
+		offsetMinutes int,
+	) (bool, error)
+	ReleaseReminder(
+		ctx context.Context,
+		appointmentID string,
+		offsetMinutes int,
+	) error
+	ListForCalendarFeed(
+		ctx context.Context,
+		userID string,
+	) ([]AppointmentWithDetailsView, error)
+	UpsertFeedToken(ctx context.Context, userID, tokenHash string) error
+	GetUserIDByFeedToken(
+		ctx context.Context,
+		tokenHash string,
+	) (string, error)
+	CountActiveBySlot(
+		ctx context.Context,
+		tx datastore.DB,
+		date string,
+		timeSlotID int,
+		excludeID string,
+	) (int, error)
 	GetStatuses(ctx context.Context) ([]AppointmentStatus, error)
 	ListByUserID(
 		ctx context.Context,
@@ -97,7 +183,7 @@ type RepositoryInterface interface {
 	GetAppointmentStats(
 		ctx context.Context,
 		statusID, startDate, endDate string,
-		iirID *string,
+		iirID, counselorID *string,
 	) ([]StatusCount, error)
 	CreateAppointment(
 		ctx context.Context,
@@ -110,4 +196,86 @@ type RepositoryInterface interface {
 		appt Appointment,
 	) error
 	GetUserIDByAppointmentID(ctx context.Context, id string) (string, error)
+	CreateRescheduleRequest(
+		ctx context.Context,
+		tx datastore.DB,
+		req *RescheduleRequest,
+		options []RescheduleOption,
+	) error
+	HasPendingRescheduleRequest(
+		ctx context.Context,
+		tx datastore.DB,
+		appointmentID string,
+	) (bool, error)
+	LockRescheduleRequest(
+		ctx context.Context,
+		tx datastore.DB,
+		id string,
+	) (*RescheduleRequest, error)
+	ListRescheduleRequests(
+		ctx context.Context,
+		appointmentID string,
+	) ([]RescheduleRequest, error)
+	ListRescheduleOptions(
+		ctx context.Context,
+		tx datastore.DB,
+		requestIDs []string,
+	) ([]RescheduleOption, error)
+	DecideRescheduleRequest(
+		ctx context.Context,
+		tx datastore.DB,
+		req *RescheduleRequest,
+	) error
+	CreateWaitlistEntry(
+		ctx context.Context,
+		tx datastore.DB,
+		entry *WaitlistEntry,
+	) error
+	HasActiveWaitlistEntry(
+		ctx context.Context,
+		tx datastore.DB,
+		iirID string,
+		categoryID int,
+		startDate, endDate string,
+	) (bool, error)
+	ListWaitlistByIIRID(
+		ctx context.Context,
+		iirID string,
+	) ([]WaitlistEntry, error)
+	LockWaitlistEntry(
+		ctx context.Context,
+		tx datastore.DB,
+		id string,
+	) (*WaitlistEntry, error)
+	NextWaitlistCandidate(
+		ctx context.Context,
+		tx datastore.DB,
+		date string,
+		timeSlotID int,
+	) (*WaitlistEntry, error)
+	UpdateWaitlistStatus(
+		ctx context.Context,
+		tx datastore.DB,
+		id, status string,
+		appointmentID sql.NullString,
+	) error
+	ExpireWaitlistEntries(ctx context.Context) error
+	CreateWaitlistOffer(
+		ctx context.Context,
+		tx datastore.DB,
+		offer *WaitlistOffer,
+		hold time.Duration,
+	) error
+	GetWaitlistOffer(ctx context.Context, id string) (*WaitlistOffer, error)
+	LockWaitlistOffer(
+		ctx context.Context,
+		tx datastore.DB,
+		id string,
+	) (*WaitlistOffer, error)
+	UpdateWaitlistOfferStatus(
+		ctx context.Context,
+		tx datastore.DB,
+		id, status string,
+	) error
+	ListExpiredWaitlistOffers(ctx context.Context) ([]string, error)
 }
@@ -29,14 +29,20 @@ type AppointmentCategory struct {
 }
 
 type AvailableTimeSlotView struct {
-	ID          int    `db:"time_slot_id" json:"id"`
-	Time        string `db:"time"         json:"time,omitempty"`
-	IsAvailable bool   `db:"is_available" json:"isAvailable"`
+	ID                  int    `db:"time_slot_id"         json:"id"`
+	Time                string `db:"time"                 json:"time,omitempty"`
+	BookedCount         int    `db:"booked_count"         json:"bookedCount"`
+	AvailableCounselors int    `db:"available_counselors" json:"availableCounselors"`
+	Capacity            int    `db:"-"                    json:"capacity"`
+	Remaining           int    `db:"-"                    json:"remaining"`
+	IsAvailable         bool   `db:"-"                    json:"isAvailable"`
+	ClosedReason        string `db:"-"                    json:"closedReason,omitempty"`
 }
 
 type Appointment struct {
 	ID                    string         `db:"id"                      json:"id,omitempty"`
 	IIRID                 string         `db:"iir_id"                  json:"iirId,omitempty"`
+	CounselorID           sql.NullString `db:"counselor_id"            json:"counselorId,omitempty"`
 	Reason                sql.NullString `db:"reason"                  json:"reason,omitempty"`
 	AdminNotes            sql.NullString `db:"admin_notes"             json:"adminNotes,omitempty"`
 	WhenDate              string         `db:"when_date"               json:"whenDate"`
@@ -69,6 +75,16 @@ type AppointmentWithDetailsView struct {
 	StatusID       int            `db:"status_id"`
 	StatusName     string         `db:"status_name"`
 	StatusColorKey string         `db:"status_color_key"`
+
+	CounselorID        sql.NullString `db:"counselor_id"`
+	CounselorFirstName sql.NullString `db:"counselor_first_name"`
+	CounselorLastName  sql.NullString `db:"counselor_last_name"`
+	CounselorEmail     sql.NullString `db:"counselor_email"`
+
+	FlaggedAt  sql.NullTime   `db:"flagged_at"`
+	FlagReason sql.NullString `db:"flag_reason"`
+
+	ICalSequence int `db:"ical_sequence"`
 }
 
 type DailyStatusCount struct {
@@ -77,3 +93,83 @@ type DailyStatusCount struct {
 	ScheduledCount   int    `db:"scheduled_count"`
 	RescheduledCount int    `db:"rescheduled_count"`
 }
+
+// ReminderCandidate is an upcoming appointment the reminder scheduler may
+// need to notify the student about.
+type ReminderCandidate struct {
+	ID           string `db:"id"`
+	UserID       string `db:"user_id"`
+	WhenDate     string `db:"when_date"`
+	TimeSlotTime string `db:"time_slot_time"`
+	CategoryName string `db:"category_name"`
+	StartsAt     string `db:"starts_at"`
+}
+
+// RescheduleRequest is a student's proposal to move an appointment, pending
+// until a counselor approves one of its options or declines it.
+type RescheduleRequest struct {
+	ID             string         `db:"id"`
+	AppointmentID  string         `db:"appointment_id"`
+	RequestedBy    string         `db:"requested_by"`
+	Reason         string         `db:"reason"`
+	Status         string         `db:"status"`
+	ChosenOptionID sql.NullString `db:"chosen_option_id"`
+	DecisionNote   sql.NullString `db:"decision_note"`
+	DecidedBy      sql.NullString `db:"decided_by"`
+	DecidedAt      sql.NullTime   `db:"decided_at"`
+	CreatedAt      time.Time      `db:"created_at"`
+	UpdatedAt      time.Time      `db:"updated_at"`
+}
+
+// RescheduleOption is one schedule proposed in a reschedule request
+type RescheduleOption struct {
+	ID           string `db:"id"`
+	RequestID    string `db:"request_id"`
+	Position     int    `db:"position"`
+	WhenDate     string `db:"when_date"`
+	TimeSlotID   int    `db:"time_slot_id"`
+	TimeSlotTime string `db:"time_slot_time"`
+}
+
+// WaitlistEntry is a student waiting for any slot between StartDate and
+// EndDate. Offer fields are set while the entry holds a pending offer.
+type WaitlistEntry struct {
+	ID                    string         `db:"id"`
+	IIRID                 string         `db:"iir_id"`
+	AppointmentCategoryID int            `db:"appointment_category_id"`
+	CategoryName          string         `db:"category_name"`
+	StartDate             string         `db:"start_date"`
+	EndDate               string         `db:"end_date"`
+	Reason                sql.NullString `db:"reason"`
+	Status                string         `db:"status"`
+	AppointmentID         sql.NullString `db:"appointment_id"`
+	CreatedAt             time.Time      `db:"created_at"`
+	UpdatedAt             time.Time      `db:"updated_at"`
+
+	OfferID           sql.NullString `db:"offer_id"`
+	OfferWhenDate     sql.NullString `db:"offer_when_date"`
+	OfferTimeSlotID   sql.NullInt64  `db:"offer_time_slot_id"`
+	OfferTimeSlotTime sql.NullString `db:"offer_time_slot_time"`
+	OfferExpiresAt    sql.NullTime   `db:"offer_expires_at"`
+}
+
+// WaitlistOffer is a freed slot held for a waitlist entry until ExpiresAt.
+// It carries the entry and student details needed to book and notify.
+type WaitlistOffer struct {
+	ID           string    `db:"id"`
+	WaitlistID   string    `db:"waitlist_id"`
+	WhenDate     string    `db:"when_date"`
+	TimeSlotID   int       `db:"time_slot_id"`
+	TimeSlotTime string    `db:"time_slot_time"`
+	Status       string    `db:"status"`
+	ExpiresAt    time.Time `db:"expires_at"`
+	Expired      bool      `db:"expired"`
+
+	IIRID                 string         `db:"iir_id"`
+	AppointmentCategoryID int            `db:"appointment_category_id"`
+	CategoryName          string         `db:"category_name"`
+	Reason                sql.NullString `db:"reason"`
+	UserID                string         `db:"user_id"`
+	UserEmail             string         `db:"user_email"`
+	UserFirstName         string         `db:"user_first_name"`
+}
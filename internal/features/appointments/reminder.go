@@ -0,0 +1,184 @@
+package appointments
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/olazo-johnalbert/duckload-api/internal/core/audit"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/datetime"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/structs"
+)
+
+const reminderTimeLayout = "2006-01-02 15:04:05"
+
+// ReminderScheduler periodically reminds students of upcoming appointments
+// with a notification, delivered in-app and by email as each student's
+// notification preferences say. Each (appointment, offset)
+// pair is claimed in appointment_reminders before sending, so restarts and
+// multiple replicas never deliver the same reminder twice. A claim is
+// released when sending fails, and rescheduling an appointment clears its
+// claims.
+type ReminderScheduler struct {
+	repo         RepositoryInterface
+	notifService audit.Notifier
+	logService   audit.Logger
+	offsets      []time.Duration
+	interval     time.Duration
+}
+
+// NewReminderScheduler creates a scheduler for the given offsets, which must
+// be sorted from largest to smallest.
+func NewReminderScheduler(
+	repo RepositoryInterface,
+	notifService audit.Notifier,
+	logService audit.Logger,
+	offsets []time.Duration,
+	interval time.Duration,
+) *ReminderScheduler {
+	return &ReminderScheduler{
+		repo:         repo,
+		notifService: notifService,
+		logService:   logService,
+		offsets:      offsets,
+		interval:     interval,
+	}
+}
+
+// Run checks for due reminders every interval until ctx is cancelled.
+func (s *ReminderScheduler) Run(ctx context.Context) {
+	if len(s.offsets) == 0 {
+		log.Printf("[ReminderScheduler] {Run}: no offsets configured")
+		return
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.sendDueReminders(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ReminderScheduler) sendDueReminders(
+	ctx context.Context,
+	now time.Time,
+) {
+	// Offsets are sorted largest first, so the first one bounds the window
+	candidates, err := s.repo.ListReminderCandidates(
+		ctx,
+		now.Format(reminderTimeLayout),
+		now.Add(s.offsets[0]).Format(reminderTimeLayout),
+	)
+	if err != nil {
+		log.Printf("[ReminderScheduler] {List Candidates}: %v", err)
+		return
+	}
+
+	for _, appt := range candidates {
+		startsAt, err := time.ParseInLocation(
+			reminderTimeLayout,
+			appt.StartsAt,
+			time.Local,
+		)
+		if err != nil {
+			log.Printf("[ReminderScheduler] {Parse Start}: %v", err)
+			continue
+		}
+
+		offset, ok := s.dueOffset(now, startsAt)
+		if !ok {
+			continue
+		}
+
+		offsetMinutes := int(offset / time.Minute)
+		claimed, err := s.repo.ClaimReminder(ctx, appt.ID, offsetMinutes)
+		if err != nil {
+			log.Printf("[ReminderScheduler] {Claim Reminder}: %v", err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := s.deliver(ctx, appt, offset); err != nil {
+			log.Printf("[ReminderScheduler] {Deliver}: %v", err)
+			err = s.repo.ReleaseReminder(ctx, appt.ID, offsetMinutes)
+			if err != nil {
+				log.Printf("[ReminderScheduler] {Release Reminder}: %v", err)
+			}
+		}
+	}
+}
+
+// dueOffset returns the smallest offset whose reminder time has passed.
+// Only that one is sent, so a booking made an hour before the appointment
+// does not also receive the day-before reminder.
+func (s *ReminderScheduler) dueOffset(
+	now, startsAt time.Time,
+) (time.Duration, bool) {
+	for i := len(s.offsets) - 1; i >= 0; i-- {
+		if !now.Before(startsAt.Add(-s.offsets[i])) {
+			return s.offsets[i], true
+		}
+	}
+
+	return 0, false
+}
+
+// deliver sends the reminder notification and, once it is out, logs it.
+func (s *ReminderScheduler) deliver(
+	ctx context.Context,
+	appt ReminderCandidate,
+	offset time.Duration,
+) error {
+	err := s.notifService.Send(ctx, audit.NotificationEntry{
+		ReceiverID: structs.StringToNullableString(appt.UserID),
+		TargetID:   structs.StringToNullableString(appt.ID),
+		TargetType: structs.StringToNullableString(
+			constants.AppointmentEntityType,
+		),
+		Title: "Upcoming Appointment",
+		Message: fmt.Sprintf(
+			"Reminder: your %s appointment is on %s at %s.",
+			appt.CategoryName,
+			datetime.FormatDate(appt.WhenDate),
+			datetime.FormatTime(appt.TimeSlotTime),
+		),
+		Type: constants.AppointmentEntityType,
+	})
+	if err != nil {
+		return fmt.Errorf(
+			"failed to send reminder for appointment #%s: %w",
+			appt.ID,
+			err,
+		)
+	}
+
+	audit.Dispatch(ctx, s.logService, nil, audit.DispatchParams{
+		Log: &audit.LogParams{
+			Level:    audit.LevelInfo,
+			Category: audit.CategorySystem,
+			Action:   audit.ActionAppointmentReminderSent,
+			Message: fmt.Sprintf(
+				"Sent %s reminder for appointment #%s",
+				offset,
+				appt.ID,
+			),
+			TargetID: structs.StringToNullableString(appt.ID),
+			TargetType: structs.StringToNullableString(
+				constants.AppointmentEntityType,
+			),
+		},
+	})
+
+	return nil
+}
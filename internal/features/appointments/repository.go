@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
@@ -19,6 +20,16 @@ const (
 	filterWhenDateLe = " AND a.when_date <= ?"
 )
 
+// inactiveStatuses lists the statuses that no longer occupy a time slot.
+const inactiveStatuses = `('Cancelled', 'Rejected')`
+
+// heldOfferFilter matches waitlist offers that still hold their slot. Held
+// slots count as booked until the offer is accepted or runs out.
+const heldOfferFilter = `wo.status = 'pending' AND wo.expires_at > NOW()`
+
+// unremindedStatuses lists the statuses that never get a reminder.
+const unremindedStatuses = `('Cancelled', 'Rejected', 'Completed', 'No-show')`
+
 const appointmentsBaseQuery = `
 	SELECT
 		a.id,
@@ -39,7 +50,14 @@ const appointmentsBaseQuery = `
 		ac.name AS category_name,
 		as2.id AS status_id,
 		as2.name AS status_name,
-		as2.color_key AS status_color_key
+		as2.color_key AS status_color_key,
+		a.counselor_id AS counselor_id,
+		cu.first_name AS counselor_first_name,
+		cu.last_name AS counselor_last_name,
+		cu.email AS counselor_email,
+		a.flagged_at AS flagged_at,
+		a.flag_reason AS flag_reason,
+		a.ical_sequence AS ical_sequence
 	FROM appointments a
 	LEFT JOIN iir_records ir ON a.iir_id = ir.id
 	LEFT JOIN users u ON ir.user_id = u.id
@@ -48,6 +66,7 @@ const appointmentsBaseQuery = `
 	JOIN appointment_categories ac ON
 		a.appointment_category_id = ac.id
 	JOIN statuses as2 ON a.status_id = as2.id
+	LEFT JOIN users cu ON a.counselor_id = cu.id
 `
 
 func NewRepository(db *sqlx.DB) *Repository {
@@ -135,8 +154,10 @@ func (r *Repository) GetUserIDByAppointmentID(
 func (r *Repository) GetDailyStatusCount(
 	ctx context.Context,
 	startDate, endDate string,
+	counselorID *string,
 ) ([]DailyStatusCount, error) {
-	query := `
+	query, args := r.applyFilters(
+		`
 		SELECT
 			DATE(a.when_date) as date,
 			COUNT(CASE WHEN s.name = 'Pending' THEN 1 END) as pending_count,
@@ -144,12 +165,19 @@ func (r *Repository) GetDailyStatusCount(
 			COUNT(CASE WHEN s.name = 'Rescheduled' THEN 1 END) as rescheduled_count
 		FROM appointments a
 		JOIN statuses s ON a.status_id = s.id
-		WHERE when_date BETWEEN ? AND ?
-		GROUP BY DATE(a.when_date);
-	`
+		WHERE a.when_date BETWEEN ? AND ?
+		`,
+		[]interface{}{startDate, endDate},
+		"",
+		"",
+		"",
+		nil,
+		counselorID,
+	)
+	query += " GROUP BY DATE(a.when_date)"
 
 	var dsc []DailyStatusCount
-	err := r.db.SelectContext(ctx, &dsc, query, startDate, endDate)
+	err := r.db.SelectContext(ctx, &dsc, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -160,7 +188,7 @@ func (r *Repository) GetDailyStatusCount(
 func (r *Repository) GetTotalAppointmentsCount(
 	ctx context.Context,
 	statusID, startDate, endDate string,
-	iirID *string,
+	iirID, counselorID *string,
 ) (int, error) {
 	query, args := r.applyFilters(
 		"SELECT COUNT(*) FROM appointments a WHERE 1=1",
@@ -169,6 +197,7 @@ func (r *Repository) GetTotalAppointmentsCount(
 		startDate,
 		endDate,
 		iirID,
+		counselorID,
 	)
 
 	var count int
@@ -186,7 +215,7 @@ func (r *Repository) applyFilters(
 	query string,
 	args []interface{},
 	statusID, startDate, endDate string,
-	iirID *string,
+	iirID, counselorID *string,
 ) (string, []interface{}) {
 	if args == nil {
 		args = []interface{}{}
@@ -208,6 +237,11 @@ func (r *Repository) applyFilters(
 		query += " AND a.iir_id = ?"
 		args = append(args, *iirID)
 	}
+	// Unassigned appointments stay visible to every counselor
+	if counselorID != nil {
+		query += " AND (a.counselor_id = ? OR a.counselor_id IS NULL)"
+		args = append(args, *counselorID)
+	}
 
 	return query, args
 }
@@ -216,6 +250,7 @@ func (r *Repository) List(
 	ctx context.Context,
 	offset, limit int,
 	search, orderBy, statusIDs, startDate, endDate string,
+	counselorID *string,
 ) ([]AppointmentWithDetailsView, error) {
 	query := appointmentsBaseQuery + " WHERE 1=1"
 	var args []interface{}
@@ -232,6 +267,7 @@ func (r *Repository) List(
 		startDate,
 		endDate,
 		nil,
+		counselorID,
 	)
 
 	if search != "" {
@@ -338,23 +374,74 @@ func (r *Repository) GetStatusByID(
 	return &status, nil
 }
 
+// GetAvailableTimeSlots returns, per time slot, how many bookings the date
+// already holds and how many available counselors are still unassigned. When
+// counselorID is set, only that counselor is considered.
 func (r *Repository) GetAvailableTimeSlots(
 	ctx context.Context,
 	date string,
+	counselorID *string,
 ) ([]AvailableTimeSlotView, error) {
-	query := `
+	query := fmt.Sprintf(`
 		SELECT
 			ts.id as time_slot_id,
-            ts.time,
-            (a.id IS NULL) as is_available
-        FROM time_slots ts
-        LEFT JOIN appointments a ON ts.id = a.time_slot_id
-            AND a.when_date = ?
-            AND a.status_id != (SELECT id FROM statuses WHERE name = 'Cancelled')
-	`
+			ts.time,
+			(
+				SELECT COUNT(*)
+				FROM appointments a
+				WHERE a.time_slot_id = ts.id
+					AND a.when_date = ?
+					AND a.status_id NOT IN (
+						SELECT id FROM statuses WHERE name IN %[1]s
+					)
+			) + (
+				SELECT COUNT(*)
+				FROM appointment_waitlist_offers wo
+				WHERE wo.time_slot_id = ts.id
+					AND wo.when_date = ?
+					AND %[2]s
+			) as booked_count,
+			(
+				SELECT COUNT(*)
+				FROM counselor_profiles cp
+				JOIN users u ON cp.user_id = u.id
+				WHERE cp.is_available = 1
+					AND u.is_active = 1
+					AND (? IS NULL OR cp.user_id = ?)
+					AND NOT EXISTS (
+						SELECT 1
+						FROM calendar_closures cc
+						WHERE cc.counselor_id = cp.user_id
+							AND cc.closure_type = 'leave'
+							AND ? BETWEEN cc.start_date AND cc.end_date
+					)
+					AND NOT EXISTS (
+						SELECT 1
+						FROM appointments ca
+						WHERE ca.counselor_id = cp.user_id
+							AND ca.time_slot_id = ts.id
+							AND ca.when_date = ?
+							AND ca.status_id NOT IN (
+								SELECT id FROM statuses WHERE name IN %[1]s
+							)
+					)
+			) as available_counselors
+		FROM time_slots ts
+		ORDER BY ts.time ASC
+	`, inactiveStatuses, heldOfferFilter)
 
 	var slots []AvailableTimeSlotView
-	err := r.db.SelectContext(ctx, &slots, query, date)
+	err := r.db.SelectContext(
+		ctx,
+		&slots,
+		query,
+		date,
+		date,
+		counselorID,
+		counselorID,
+		date,
+		date,
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get available time slots: %w", err)
 	}
@@ -362,6 +449,71 @@ func (r *Repository) GetAvailableTimeSlots(
 	return slots, nil
 }
 
+// LockTimeSlot takes a row lock on the time slot so concurrent bookings for
+// it are serialized until the surrounding transaction ends.
+func (r *Repository) LockTimeSlot(
+	ctx context.Context,
+	tx datastore.DB,
+	timeSlotID int,
+) error {
+	var id int
+	err := tx.GetContext(
+		ctx,
+		&id,
+		"SELECT id FROM time_slots WHERE id = ? FOR UPDATE",
+		timeSlotID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to lock time slot: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) CountActiveBySlot(
+	ctx context.Context,
+	tx datastore.DB,
+	date string,
+	timeSlotID int,
+	excludeID string,
+) (int, error) {
+	query := fmt.Sprintf(`
+		SELECT (
+			SELECT COUNT(*)
+			FROM appointments
+			WHERE when_date = ?
+				AND time_slot_id = ?
+				AND id != ?
+				AND status_id NOT IN (
+					SELECT id FROM statuses WHERE name IN %s
+				)
+		) + (
+			SELECT COUNT(*)
+			FROM appointment_waitlist_offers wo
+			WHERE wo.when_date = ?
+				AND wo.time_slot_id = ?
+				AND %s
+		)
+	`, inactiveStatuses, heldOfferFilter)
+
+	var count int
+	err := tx.GetContext(
+		ctx,
+		&count,
+		query,
+		date,
+		timeSlotID,
+		excludeID,
+		date,
+		timeSlotID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count slot appointments: %w", err)
+	}
+
+	return count, nil
+}
+
 func (r *Repository) GetStatuses(
 	ctx context.Context,
 ) ([]AppointmentStatus, error) {
@@ -393,6 +545,7 @@ func (r *Repository) ListByUserID(
 		startDate,
 		endDate,
 		nil,
+		nil,
 	)
 
 	query += fmt.Sprintf(
@@ -428,6 +581,7 @@ func (r *Repository) ListByIIRID(
 		startDate,
 		endDate,
 		nil,
+		nil,
 	)
 
 	query += fmt.Sprintf(
@@ -452,7 +606,7 @@ func (r *Repository) ListByIIRID(
 func (r *Repository) GetAppointmentStats(
 	ctx context.Context,
 	statusID, startDate, endDate string,
-	iirID *string,
+	iirID, counselorID *string,
 ) ([]StatusCount, error) {
 	joinCondition := "a.status_id = as2.id"
 	var args []interface{}
@@ -477,6 +631,11 @@ func (r *Repository) GetAppointmentStats(
 		args = append(args, *iirID)
 	}
 
+	if counselorID != nil {
+		joinCondition += " AND (a.counselor_id = ? OR a.counselor_id IS NULL)"
+		args = append(args, *counselorID)
+	}
+
 	query := fmt.Sprintf(`
 		SELECT
 			as2.id AS id,
@@ -557,12 +716,19 @@ func (r *Repository) UpdateAppointment(
 		setQuery = append(setQuery, "status_id = ?")
 		args = append(args, appt.StatusID)
 	}
+	if appt.CounselorID.Valid {
+		setQuery = append(setQuery, "counselor_id = ?")
+		args = append(args, appt.CounselorID.String)
+	}
 
 	// Validate that there is actually something to update
 	if len(setQuery) == 0 {
 		return nil
 	}
 
+	// Every change is a new version of the calendar event
+	setQuery = append(setQuery, "ical_sequence = ical_sequence + 1")
+
 	query := "UPDATE appointments SET " +
 		strings.Join(setQuery, ", ") +
 		" WHERE id = ?"
@@ -575,3 +741,722 @@ func (r *Repository) UpdateAppointment(
 
 	return nil
 }
+
+// ClearFlag removes the closure flag once an appointment has been moved.
+func (r *Repository) ClearFlag(
+	ctx context.Context,
+	tx datastore.DB,
+	id string,
+) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`UPDATE appointments
+		SET flagged_at = NULL, flag_reason = NULL
+		WHERE id = ?`,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to clear appointment flag: %w", err)
+	}
+
+	return nil
+}
+
+// ClearReminders forgets which reminders were sent for an appointment, so
+// a rescheduled appointment is reminded again for its new time.
+func (r *Repository) ClearReminders(
+	ctx context.Context,
+	tx datastore.DB,
+	appointmentID string,
+) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM appointment_reminders WHERE appointment_id = ?`,
+		appointmentID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to clear appointment reminders: %w", err)
+	}
+
+	return nil
+}
+
+// ListReminderCandidates returns active appointments starting after from and
+// no later than to. Both bounds are local "YYYY-MM-DD HH:MM:SS" strings.
+func (r *Repository) ListReminderCandidates(
+	ctx context.Context,
+	from, to string,
+) ([]ReminderCandidate, error) {
+	query := fmt.Sprintf(`
+		SELECT
+			a.id,
+			u.id AS user_id,
+			DATE_FORMAT(a.when_date, '%%Y-%%m-%%d') AS when_date,
+			ts.time AS time_slot_time,
+			ac.name AS category_name,
+			DATE_FORMAT(
+				TIMESTAMP(a.when_date, ts.time),
+				'%%Y-%%m-%%d %%H:%%i:%%s'
+			) AS starts_at
+		FROM appointments a
+		JOIN iir_records ir ON a.iir_id = ir.id
+		JOIN users u ON ir.user_id = u.id
+		JOIN time_slots ts ON a.time_slot_id = ts.id
+		JOIN appointment_categories ac ON a.appointment_category_id = ac.id
+		WHERE TIMESTAMP(a.when_date, ts.time) > ?
+			AND TIMESTAMP(a.when_date, ts.time) <= ?
+			AND a.status_id NOT IN (
+				SELECT id FROM statuses WHERE name IN %s
+			)
+	`, unremindedStatuses)
+
+	var candidates []ReminderCandidate
+	err := r.db.SelectContext(ctx, &candidates, query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reminder candidates: %w", err)
+	}
+
+	return candidates, nil
+}
+
+// ClaimReminder records that the reminder for the offset is being sent. It
+// returns false when another run or replica already claimed it.
+func (r *Repository) ClaimReminder(
+	ctx context.Context,
+	appointmentID string,
+	offsetMinutes int,
+) (bool, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		`INSERT IGNORE INTO appointment_reminders
+			(appointment_id, offset_minutes)
+		VALUES (?, ?)`,
+		appointmentID,
+		offsetMinutes,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim reminder: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim reminder: %w", err)
+	}
+
+	return rows == 1, nil
+}
+
+// ReleaseReminder drops the claim on a reminder that could not be sent, so
+// the next run tries again.
+func (r *Repository) ReleaseReminder(
+	ctx context.Context,
+	appointmentID string,
+	offsetMinutes int,
+) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`DELETE FROM appointment_reminders
+		WHERE appointment_id = ? AND offset_minutes = ?`,
+		appointmentID,
+		offsetMinutes,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to release reminder: %w", err)
+	}
+
+	return nil
+}
+
+// ListForCalendarFeed returns the appointments a user attends, either as the
+// student or as the assigned counselor, from 90 days ago onwards. Cancelled
+// appointments are included so subscribed calendars drop them.
+func (r *Repository) ListForCalendarFeed(
+	ctx context.Context,
+	userID string,
+) ([]AppointmentWithDetailsView, error) {
+	query := appointmentsBaseQuery + `
+		WHERE (ir.user_id = ? OR a.counselor_id = ?)
+			AND a.when_date >= CURDATE() - INTERVAL 90 DAY
+		ORDER BY a.when_date ASC, ts.time ASC
+	`
+
+	var appts []AppointmentWithDetailsView
+	err := r.db.SelectContext(ctx, &appts, query, userID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list calendar feed: %w", err)
+	}
+
+	return appts, nil
+}
+
+func (r *Repository) UpsertFeedToken(
+	ctx context.Context,
+	userID, tokenHash string,
+) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO calendar_feed_tokens (user_id, token_hash)
+		VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE token_hash = VALUES(token_hash)`,
+		userID,
+		tokenHash,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save calendar feed token: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetUserIDByFeedToken(
+	ctx context.Context,
+	tokenHash string,
+) (string, error) {
+	var userID string
+	err := r.db.GetContext(
+		ctx,
+		&userID,
+		"SELECT user_id FROM calendar_feed_tokens WHERE token_hash = ?",
+		tokenHash,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get calendar feed token: %w", err)
+	}
+
+	return userID, nil
+}
+
+// LockAppointmentStatus reads the current status and locks the appointment
+// row until the transaction ends, so concurrent updates cannot both pass the
+// transition check.
+func (r *Repository) LockAppointmentStatus(
+	ctx context.Context,
+	tx datastore.DB,
+	id string,
+) (int, error) {
+	var statusID int
+	err := tx.GetContext(
+		ctx,
+		&statusID,
+		"SELECT status_id FROM appointments WHERE id = ? FOR UPDATE",
+		id,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, err
+		}
+		return 0, fmt.Errorf("failed to lock appointment: %w", err)
+	}
+
+	return statusID, nil
+}
+
+const rescheduleRequestColumns = `
+	id, appointment_id, requested_by, reason, status, chosen_option_id,
+	decision_note, decided_by, decided_at, created_at, updated_at
+`
+
+func (r *Repository) CreateRescheduleRequest(
+	ctx context.Context,
+	tx datastore.DB,
+	req *RescheduleRequest,
+	options []RescheduleOption,
+) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO appointment_reschedule_requests
+			(id, appointment_id, requested_by, reason)
+		VALUES (?, ?, ?, ?)`,
+		req.ID,
+		req.AppointmentID,
+		req.RequestedBy,
+		req.Reason,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert reschedule request: %w", err)
+	}
+
+	for _, opt := range options {
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO appointment_reschedule_options
+				(id, request_id, position, when_date, time_slot_id)
+			VALUES (?, ?, ?, ?, ?)`,
+			opt.ID,
+			req.ID,
+			opt.Position,
+			opt.WhenDate,
+			opt.TimeSlotID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert reschedule option: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Repository) HasPendingRescheduleRequest(
+	ctx context.Context,
+	tx datastore.DB,
+	appointmentID string,
+) (bool, error) {
+	var count int
+	err := tx.GetContext(
+		ctx,
+		&count,
+		`SELECT COUNT(*) FROM appointment_reschedule_requests
+		WHERE appointment_id = ? AND status = 'pending'`,
+		appointmentID,
+	)
+	if err != nil {
+		return false, fmt.Errorf(
+			"failed to check pending reschedule requests: %w",
+			err,
+		)
+	}
+
+	return count > 0, nil
+}
+
+// LockRescheduleRequest reads a reschedule request and holds its row until
+// tx ends, so only one decision can be made on it.
+func (r *Repository) LockRescheduleRequest(
+	ctx context.Context,
+	tx datastore.DB,
+	id string,
+) (*RescheduleRequest, error) {
+	var req RescheduleRequest
+	err := tx.GetContext(
+		ctx,
+		&req,
+		"SELECT "+rescheduleRequestColumns+
+			" FROM appointment_reschedule_requests WHERE id = ? FOR UPDATE",
+		id,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to lock reschedule request: %w", err)
+	}
+
+	return &req, nil
+}
+
+func (r *Repository) ListRescheduleRequests(
+	ctx context.Context,
+	appointmentID string,
+) ([]RescheduleRequest, error) {
+	var reqs []RescheduleRequest
+	err := r.db.SelectContext(
+		ctx,
+		&reqs,
+		"SELECT "+rescheduleRequestColumns+
+			` FROM appointment_reschedule_requests
+			WHERE appointment_id = ?
+			ORDER BY created_at DESC`,
+		appointmentID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reschedule requests: %w", err)
+	}
+
+	return reqs, nil
+}
+
+func (r *Repository) ListRescheduleOptions(
+	ctx context.Context,
+	tx datastore.DB,
+	requestIDs []string,
+) ([]RescheduleOption, error) {
+	if len(requestIDs) == 0 {
+		return nil, nil
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT
+			o.id,
+			o.request_id,
+			o.position,
+			DATE_FORMAT(o.when_date, '%Y-%m-%d') AS when_date,
+			o.time_slot_id,
+			ts.time AS time_slot_time
+		FROM appointment_reschedule_options o
+		JOIN time_slots ts ON o.time_slot_id = ts.id
+		WHERE o.request_id IN (?)
+		ORDER BY o.request_id, o.position`,
+		requestIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []RescheduleOption
+	err = tx.SelectContext(ctx, &opts, tx.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reschedule options: %w", err)
+	}
+
+	return opts, nil
+}
+
+// DecideRescheduleRequest records the counselor's decision on a pending
+// request.
+func (r *Repository) DecideRescheduleRequest(
+	ctx context.Context,
+	tx datastore.DB,
+	req *RescheduleRequest,
+) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`UPDATE appointment_reschedule_requests
+		SET status = ?,
+			chosen_option_id = ?,
+			decision_note = ?,
+			decided_by = ?,
+			decided_at = NOW()
+		WHERE id = ?`,
+		req.Status,
+		req.ChosenOptionID,
+		req.DecisionNote,
+		req.DecidedBy,
+		req.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update reschedule request: %w", err)
+	}
+
+	return nil
+}
+
+const waitlistBaseQuery = `
+	SELECT
+		w.id,
+		w.iir_id,
+		w.appointment_category_id,
+		ac.name AS category_name,
+		DATE_FORMAT(w.start_date, '%Y-%m-%d') AS start_date,
+		DATE_FORMAT(w.end_date, '%Y-%m-%d') AS end_date,
+		w.reason,
+		w.status,
+		w.appointment_id,
+		w.created_at,
+		w.updated_at,
+		wo.id AS offer_id,
+		DATE_FORMAT(wo.when_date, '%Y-%m-%d') AS offer_when_date,
+		wo.time_slot_id AS offer_time_slot_id,
+		ts.time AS offer_time_slot_time,
+		wo.expires_at AS offer_expires_at
+	FROM appointment_waitlist w
+	JOIN appointment_categories ac ON w.appointment_category_id = ac.id
+	LEFT JOIN appointment_waitlist_offers wo ON
+		wo.waitlist_id = w.id AND wo.status = 'pending'
+	LEFT JOIN time_slots ts ON wo.time_slot_id = ts.id
+`
+
+const waitlistOfferBaseQuery = `
+	SELECT
+		wo.id,
+		wo.waitlist_id,
+		DATE_FORMAT(wo.when_date, '%Y-%m-%d') AS when_date,
+		wo.time_slot_id,
+		ts.time AS time_slot_time,
+		wo.status,
+		wo.expires_at,
+		wo.expires_at <= NOW() AS expired,
+		w.iir_id,
+		w.appointment_category_id,
+		ac.name AS category_name,
+		w.reason,
+		u.id AS user_id,
+		u.email AS user_email,
+		u.first_name AS user_first_name
+	FROM appointment_waitlist_offers wo
+	JOIN appointment_waitlist w ON wo.waitlist_id = w.id
+	JOIN appointment_categories ac ON w.appointment_category_id = ac.id
+	JOIN iir_records ir ON w.iir_id = ir.id
+	JOIN users u ON ir.user_id = u.id
+	JOIN time_slots ts ON wo.time_slot_id = ts.id
+`
+
+func (r *Repository) CreateWaitlistEntry(
+	ctx context.Context,
+	tx datastore.DB,
+	entry *WaitlistEntry,
+) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO appointment_waitlist
+			(id, iir_id, appointment_category_id, start_date, end_date, reason)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		entry.ID,
+		entry.IIRID,
+		entry.AppointmentCategoryID,
+		entry.StartDate,
+		entry.EndDate,
+		entry.Reason,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert waitlist entry: %w", err)
+	}
+
+	return nil
+}
+
+// HasActiveWaitlistEntry reports whether the student is already waiting
+// for the category on any day of the range.
+func (r *Repository) HasActiveWaitlistEntry(
+	ctx context.Context,
+	tx datastore.DB,
+	iirID string,
+	categoryID int,
+	startDate, endDate string,
+) (bool, error) {
+	var count int
+	err := tx.GetContext(
+		ctx,
+		&count,
+		`SELECT COUNT(*) FROM appointment_waitlist
+		WHERE iir_id = ?
+			AND appointment_category_id = ?
+			AND status IN ('waiting', 'offered')
+			AND start_date <= ?
+			AND end_date >= ?`,
+		iirID,
+		categoryID,
+		endDate,
+		startDate,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to check waitlist entries: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+func (r *Repository) ListWaitlistByIIRID(
+	ctx context.Context,
+	iirID string,
+) ([]WaitlistEntry, error) {
+	var entries []WaitlistEntry
+	err := r.db.SelectContext(
+		ctx,
+		&entries,
+		waitlistBaseQuery+" WHERE w.iir_id = ? ORDER BY w.created_at DESC",
+		iirID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list waitlist entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+func (r *Repository) LockWaitlistEntry(
+	ctx context.Context,
+	tx datastore.DB,
+	id string,
+) (*WaitlistEntry, error) {
+	var entry WaitlistEntry
+	err := tx.GetContext(
+		ctx,
+		&entry,
+		waitlistBaseQuery+" WHERE w.id = ? FOR UPDATE OF w",
+		id,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to lock waitlist entry: %w", err)
+	}
+
+	return &entry, nil
+}
+
+// NextWaitlistCandidate locks the oldest waiting entry that covers date and
+// has not been offered this slot before.
+func (r *Repository) NextWaitlistCandidate(
+	ctx context.Context,
+	tx datastore.DB,
+	date string,
+	timeSlotID int,
+) (*WaitlistEntry, error) {
+	var entry WaitlistEntry
+	err := tx.GetContext(
+		ctx,
+		&entry,
+		waitlistBaseQuery+`
+		WHERE w.status = 'waiting'
+			AND ? BETWEEN w.start_date AND w.end_date
+			AND NOT EXISTS (
+				SELECT 1
+				FROM appointment_waitlist_offers po
+				WHERE po.waitlist_id = w.id
+					AND po.when_date = ?
+					AND po.time_slot_id = ?
+			)
+		ORDER BY w.created_at ASC
+		LIMIT 1
+		FOR UPDATE OF w`,
+		date,
+		date,
+		timeSlotID,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get waitlist candidate: %w", err)
+	}
+
+	return &entry, nil
+}
+
+func (r *Repository) UpdateWaitlistStatus(
+	ctx context.Context,
+	tx datastore.DB,
+	id, status string,
+	appointmentID sql.NullString,
+) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`UPDATE appointment_waitlist
+		SET status = ?, appointment_id = COALESCE(?, appointment_id)
+		WHERE id = ?`,
+		status,
+		appointmentID,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update waitlist entry: %w", err)
+	}
+
+	return nil
+}
+
+// ExpireWaitlistEntries closes waiting entries whose range has passed.
+func (r *Repository) ExpireWaitlistEntries(ctx context.Context) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE appointment_waitlist
+		SET status = 'expired'
+		WHERE status = 'waiting' AND end_date < CURDATE()`,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to expire waitlist entries: %w", err)
+	}
+
+	return nil
+}
+
+// CreateWaitlistOffer holds a slot for the entry for the given duration,
+// measured on the database clock like every other hold check.
+func (r *Repository) CreateWaitlistOffer(
+	ctx context.Context,
+	tx datastore.DB,
+	offer *WaitlistOffer,
+	hold time.Duration,
+) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO appointment_waitlist_offers
+			(id, waitlist_id, when_date, time_slot_id, expires_at)
+		VALUES (?, ?, ?, ?, DATE_ADD(NOW(), INTERVAL ? SECOND))`,
+		offer.ID,
+		offer.WaitlistID,
+		offer.WhenDate,
+		offer.TimeSlotID,
+		int(hold/time.Second),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert waitlist offer: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetWaitlistOffer(
+	ctx context.Context,
+	id string,
+) (*WaitlistOffer, error) {
+	var offer WaitlistOffer
+	err := r.db.GetContext(
+		ctx,
+		&offer,
+		waitlistOfferBaseQuery+" WHERE wo.id = ?",
+		id,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get waitlist offer: %w", err)
+	}
+
+	return &offer, nil
+}
+
+func (r *Repository) LockWaitlistOffer(
+	ctx context.Context,
+	tx datastore.DB,
+	id string,
+) (*WaitlistOffer, error) {
+	var offer WaitlistOffer
+	err := tx.GetContext(
+		ctx,
+		&offer,
+		waitlistOfferBaseQuery+" WHERE wo.id = ? FOR UPDATE OF wo, w",
+		id,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to lock waitlist offer: %w", err)
+	}
+
+	return &offer, nil
+}
+
+func (r *Repository) UpdateWaitlistOfferStatus(
+	ctx context.Context,
+	tx datastore.DB,
+	id, status string,
+) error {
+	_, err := tx.ExecContext(
+		ctx,
+		"UPDATE appointment_waitlist_offers SET status = ? WHERE id = ?",
+		status,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update waitlist offer: %w", err)
+	}
+
+	return nil
+}
+
+// ListExpiredWaitlistOffers returns the IDs of pending offers whose hold
+// has run out.
+func (r *Repository) ListExpiredWaitlistOffers(
+	ctx context.Context,
+) ([]string, error) {
+	var ids []string
+	err := r.db.SelectContext(
+		ctx,
+		&ids,
+		`SELECT id FROM appointment_waitlist_offers
+		WHERE status = 'pending' AND expires_at <= NOW()
+		ORDER BY expires_at ASC`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired waitlist offers: %w", err)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,673 @@
+package appointments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/olazo-johnalbert/duckload-api/internal/core/audit"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/datetime"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/structs"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RescheduleStatusPending  = "pending"
+	RescheduleStatusApproved = "approved"
+	RescheduleStatusDeclined = "declined"
+)
+
+var (
+	ErrRescheduleNotFound = errors.New("reschedule request not found")
+	ErrReschedulePending  = errors.New(
+		"appointment already has a pending reschedule request",
+	)
+	ErrRescheduleDecided = errors.New(
+		"reschedule request has already been decided",
+	)
+	ErrRescheduleOption = errors.New(
+		"reschedule option is not valid for this appointment",
+	)
+	ErrRescheduleNotAssigned = errors.New(
+		"only the assigned counselor can decide this reschedule request",
+	)
+)
+
+// CreateRescheduleRequest records a student's proposal to move an
+// appointment to one of the given options. The appointment is untouched
+// until a counselor approves.
+func (s *Service) CreateRescheduleRequest(
+	ctx context.Context,
+	appointmentID string,
+	req CreateRescheduleRequest,
+) (*RescheduleRequestDTO, error) {
+	userID := audit.ExtractUserID(ctx)
+	request := &RescheduleRequest{
+		ID:            uuid.New().String(),
+		AppointmentID: appointmentID,
+		RequestedBy:   userID,
+		Reason:        req.Reason,
+		Status:        RescheduleStatusPending,
+		CreatedAt:     time.Now(),
+	}
+
+	var appt *AppointmentWithDetailsView
+	var options []RescheduleOption
+
+	err := datastore.RunInTransaction(
+		ctx,
+		s.repo.GetDB(),
+		func(tx datastore.DB) error {
+			statusID, err := s.repo.LockAppointmentStatus(
+				ctx,
+				tx,
+				appointmentID,
+			)
+			if err != nil {
+				return err
+			}
+
+			// The request is only worth making if a counselor could
+			// actually reschedule the appointment.
+			err = checkTransition(
+				constants.StatusID(statusID),
+				constants.StatusRescheduled,
+				constants.AdminRoleID,
+			)
+			if err != nil {
+				return err
+			}
+
+			pending, err := s.repo.HasPendingRescheduleRequest(
+				ctx,
+				tx,
+				appointmentID,
+			)
+			if err != nil {
+				return err
+			}
+			if pending {
+				return ErrReschedulePending
+			}
+
+			appt, err = s.repo.GetAppointment(ctx, appointmentID)
+			if err != nil {
+				return err
+			}
+			options, err = s.buildRescheduleOptions(ctx, appt, req.Options)
+			if err != nil {
+				return err
+			}
+
+			return s.repo.CreateRescheduleRequest(ctx, tx, request, options)
+		},
+	)
+	if err != nil {
+		audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+			Log: &audit.LogParams{
+				Level:    audit.LevelError,
+				Category: audit.CategoryAudit,
+				Action:   audit.ActionRescheduleRequestFailed,
+				Message: fmt.Sprintf(
+					"Failed to request reschedule of appointment #%s",
+					appointmentID,
+				),
+				Metadata: &audit.LogMetadata{
+					EntityType: constants.RescheduleRequestEntityType,
+					EntityID:   appointmentID,
+					OldValues:  appt,
+					NewValues:  req,
+					Error:      err.Error(),
+				},
+			},
+		})
+		return nil, err
+	}
+
+	// The assigned counselor decides; unassigned appointments go to every
+	// admin.
+	counselorIDs := []string{appt.CounselorID.String}
+	if !appt.CounselorID.Valid {
+		counselorIDs, _ = s.userService.GetUserIDsByRole(
+			ctx,
+			int(constants.AdminRoleID),
+		)
+	}
+
+	notifications := []audit.NotificationParams{
+		{
+			ReceiverID: structs.StringToNullableString(userID),
+			TargetID:   structs.StringToNullableString(appointmentID),
+			TargetType: structs.StringToNullableString(
+				constants.AppointmentEntityType,
+			),
+			Title: "Reschedule Request Sent",
+			Message: fmt.Sprintf(
+				"Your request to reschedule the appointment on %s at %s is awaiting the counselor's decision.",
+				datetime.FormatDate(appt.WhenDate),
+				datetime.FormatTime(appt.TimeSlotTime),
+			),
+			Type: constants.AppointmentEntityType,
+		},
+	}
+	for _, cid := range counselorIDs {
+		notifications = append(notifications, audit.NotificationParams{
+			ReceiverID: structs.StringToNullableString(cid),
+			TargetID:   structs.StringToNullableString(appointmentID),
+			TargetType: structs.StringToNullableString(
+				constants.AppointmentEntityType,
+			),
+			Title: "New Reschedule Request",
+			Message: fmt.Sprintf(
+				"%s %s asked to move the appointment on %s at %s.",
+				appt.UserFirstName,
+				appt.UserLastName,
+				datetime.FormatDate(appt.WhenDate),
+				datetime.FormatTime(appt.TimeSlotTime),
+			),
+			Type: constants.AppointmentEntityType,
+		})
+	}
+
+	dto := mapRescheduleRequest(request, options)
+
+	audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+		Log: &audit.LogParams{
+			Level:    audit.LevelInfo,
+			Category: audit.CategoryAudit,
+			Action:   audit.ActionRescheduleRequested,
+			Message: fmt.Sprintf(
+				"Reschedule of appointment #%s requested",
+				appointmentID,
+			),
+			Metadata: &audit.LogMetadata{
+				EntityType: constants.RescheduleRequestEntityType,
+				EntityID:   request.ID,
+				OldValues:  appt,
+				NewValues:  dto,
+			},
+		},
+		Notifications: notifications,
+	})
+
+	return &dto, nil
+}
+
+// ListRescheduleRequests returns the reschedule history of an appointment,
+// newest first.
+func (s *Service) ListRescheduleRequests(
+	ctx context.Context,
+	appointmentID string,
+) ([]RescheduleRequestDTO, error) {
+	requests, err := s.repo.ListRescheduleRequests(ctx, appointmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(requests))
+	for i := range requests {
+		ids[i] = requests[i].ID
+	}
+	options, err := s.repo.ListRescheduleOptions(ctx, s.repo.GetDB(), ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byRequest := make(map[string][]RescheduleOption, len(requests))
+	for _, opt := range options {
+		byRequest[opt.RequestID] = append(byRequest[opt.RequestID], opt)
+	}
+
+	dtos := make([]RescheduleRequestDTO, len(requests))
+	for i := range requests {
+		dtos[i] = mapRescheduleRequest(
+			&requests[i],
+			byRequest[requests[i].ID],
+		)
+	}
+
+	return dtos, nil
+}
+
+// ApproveRescheduleRequest moves the appointment to the chosen option. The
+// slot is reserved, the counselor re-checked and the request closed in one
+// transaction, so a full slot leaves both untouched.
+func (s *Service) ApproveRescheduleRequest(
+	ctx context.Context,
+	requestID string,
+	req ApproveRescheduleRequest,
+	counselorID *string,
+) error {
+	var request *RescheduleRequest
+	var oldAppt *AppointmentWithDetailsView
+
+	err := datastore.RunInTransaction(
+		ctx,
+		s.repo.GetDB(),
+		func(tx datastore.DB) error {
+			var err error
+			request, oldAppt, err = s.lockPendingReschedule(
+				ctx,
+				tx,
+				requestID,
+				counselorID,
+			)
+			if err != nil {
+				return err
+			}
+
+			options, err := s.repo.ListRescheduleOptions(
+				ctx,
+				tx,
+				[]string{requestID},
+			)
+			if err != nil {
+				return err
+			}
+
+			var chosen *RescheduleOption
+			for i := range options {
+				if options[i].ID == req.OptionID {
+					chosen = &options[i]
+				}
+			}
+			if chosen == nil {
+				return ErrRescheduleOption
+			}
+
+			err = checkTransition(
+				constants.StatusID(oldAppt.StatusID),
+				constants.StatusRescheduled,
+				constants.RoleID(audit.ExtractRoleID(ctx)),
+			)
+			if err != nil {
+				return err
+			}
+
+			assignee, err := s.applySchedule(
+				ctx,
+				tx,
+				oldAppt,
+				nil,
+				chosen.WhenDate,
+				chosen.TimeSlotID,
+			)
+			if err != nil {
+				return err
+			}
+
+			err = s.repo.UpdateAppointment(ctx, tx, Appointment{
+				ID:          oldAppt.ID,
+				WhenDate:    chosen.WhenDate,
+				TimeSlotID:  chosen.TimeSlotID,
+				StatusID:    int(constants.StatusRescheduled),
+				CounselorID: assignee,
+			})
+			if err != nil {
+				return err
+			}
+
+			request.Status = RescheduleStatusApproved
+			request.ChosenOptionID = toNullString(chosen.ID)
+			request.DecisionNote = toNullString(req.Note)
+			request.DecidedBy = toNullString(audit.ExtractUserID(ctx))
+
+			return s.repo.DecideRescheduleRequest(ctx, tx, request)
+		},
+	)
+	if err != nil {
+		audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+			Log: &audit.LogParams{
+				Level:    audit.LevelError,
+				Category: audit.CategoryAudit,
+				Action:   audit.ActionRescheduleApproveFailed,
+				Message: fmt.Sprintf(
+					"Failed to approve reschedule request #%s",
+					requestID,
+				),
+				Metadata: &audit.LogMetadata{
+					EntityType: constants.RescheduleRequestEntityType,
+					EntityID:   requestID,
+					OldValues:  oldAppt,
+					NewValues:  req,
+					Error:      err.Error(),
+				},
+			},
+		})
+		return err
+	}
+
+	newAppt, _ := s.repo.GetAppointment(ctx, oldAppt.ID)
+	if newAppt == nil {
+		newAppt = oldAppt
+	}
+	go s.sendCalendarInvite(context.WithoutCancel(ctx), oldAppt, newAppt)
+	if releasesSlot(oldAppt, newAppt) {
+		go s.offerFreedSlot(
+			context.WithoutCancel(ctx),
+			oldAppt.WhenDate,
+			oldAppt.TimeSlotID,
+		)
+	}
+
+	message := fmt.Sprintf(
+		"Your appointment on %s at %s has been moved to %s at %s.",
+		datetime.FormatDate(oldAppt.WhenDate),
+		datetime.FormatTime(oldAppt.TimeSlotTime),
+		datetime.FormatDate(newAppt.WhenDate),
+		datetime.FormatTime(newAppt.TimeSlotTime),
+	)
+	notifications := []audit.NotificationParams{
+		{
+			ReceiverID: structs.StringToNullableString(request.RequestedBy),
+			TargetID:   structs.StringToNullableString(oldAppt.ID),
+			TargetType: structs.StringToNullableString(
+				constants.AppointmentEntityType,
+			),
+			Title:   "Reschedule Request Approved",
+			Message: message,
+			Type:    constants.AppointmentEntityType,
+		},
+	}
+	notifications = append(
+		notifications,
+		s.rescheduleCounselorNotifications(ctx, oldAppt, newAppt)...,
+	)
+
+	audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+		Log: &audit.LogParams{
+			Level:    audit.LevelInfo,
+			Category: audit.CategoryAudit,
+			Action:   audit.ActionRescheduleApproved,
+			Message: fmt.Sprintf(
+				"Reschedule request #%s approved for appointment #%s",
+				requestID,
+				oldAppt.ID,
+			),
+			Metadata: &audit.LogMetadata{
+				EntityType: constants.RescheduleRequestEntityType,
+				EntityID:   requestID,
+				OldValues:  oldAppt,
+				NewValues:  newAppt,
+			},
+		},
+		Notifications: notifications,
+	})
+
+	return nil
+}
+
+// DeclineRescheduleRequest closes a pending request and leaves the
+// appointment as it is.
+func (s *Service) DeclineRescheduleRequest(
+	ctx context.Context,
+	requestID string,
+	req DeclineRescheduleRequest,
+	counselorID *string,
+) error {
+	var request *RescheduleRequest
+	var appt *AppointmentWithDetailsView
+	var oldRequest RescheduleRequest
+
+	err := datastore.RunInTransaction(
+		ctx,
+		s.repo.GetDB(),
+		func(tx datastore.DB) error {
+			var err error
+			request, appt, err = s.lockPendingReschedule(
+				ctx,
+				tx,
+				requestID,
+				counselorID,
+			)
+			if err != nil {
+				return err
+			}
+			oldRequest = *request
+
+			request.Status = RescheduleStatusDeclined
+			request.DecisionNote = toNullString(req.Note)
+			request.DecidedBy = toNullString(audit.ExtractUserID(ctx))
+
+			return s.repo.DecideRescheduleRequest(ctx, tx, request)
+		},
+	)
+	if err != nil {
+		audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+			Log: &audit.LogParams{
+				Level:    audit.LevelError,
+				Category: audit.CategoryAudit,
+				Action:   audit.ActionRescheduleDeclineFailed,
+				Message: fmt.Sprintf(
+					"Failed to decline reschedule request #%s",
+					requestID,
+				),
+				Metadata: &audit.LogMetadata{
+					EntityType: constants.RescheduleRequestEntityType,
+					EntityID:   requestID,
+					NewValues:  req,
+					Error:      err.Error(),
+				},
+			},
+		})
+		return err
+	}
+
+	audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+		Log: &audit.LogParams{
+			Level:    audit.LevelInfo,
+			Category: audit.CategoryAudit,
+			Action:   audit.ActionRescheduleDeclined,
+			Message: fmt.Sprintf(
+				"Reschedule request #%s declined for appointment #%s",
+				requestID,
+				appt.ID,
+			),
+			Metadata: &audit.LogMetadata{
+				EntityType: constants.RescheduleRequestEntityType,
+				EntityID:   requestID,
+				OldValues:  oldRequest,
+				NewValues:  request,
+			},
+		},
+		Notifications: []audit.NotificationParams{
+			{
+				ReceiverID: structs.StringToNullableString(
+					request.RequestedBy,
+				),
+				TargetID: structs.StringToNullableString(appt.ID),
+				TargetType: structs.StringToNullableString(
+					constants.AppointmentEntityType,
+				),
+				Title: "Reschedule Request Declined",
+				Message: fmt.Sprintf(
+					"Your appointment stays on %s at %s. Counselor's note: %s",
+					datetime.FormatDate(appt.WhenDate),
+					datetime.FormatTime(appt.TimeSlotTime),
+					req.Note,
+				),
+				Type: constants.AppointmentEntityType,
+			},
+		},
+	})
+
+	return nil
+}
+
+// lockPendingReschedule locks a request and its appointment for a decision.
+// Counselors may only decide requests on their own or unassigned
+// appointments.
+func (s *Service) lockPendingReschedule(
+	ctx context.Context,
+	tx datastore.DB,
+	requestID string,
+	counselorID *string,
+) (*RescheduleRequest, *AppointmentWithDetailsView, error) {
+	request, err := s.repo.LockRescheduleRequest(ctx, tx, requestID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if request == nil {
+		return nil, nil, ErrRescheduleNotFound
+	}
+	if request.Status != RescheduleStatusPending {
+		return nil, nil, ErrRescheduleDecided
+	}
+
+	_, err = s.repo.LockAppointmentStatus(ctx, tx, request.AppointmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	appt, err := s.repo.GetAppointment(ctx, request.AppointmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if appt == nil {
+		return nil, nil, ErrRescheduleNotFound
+	}
+
+	if counselorID != nil && appt.CounselorID.Valid &&
+		appt.CounselorID.String != *counselorID {
+		return nil, nil, ErrRescheduleNotAssigned
+	}
+
+	return request, appt, nil
+}
+
+// buildRescheduleOptions validates the proposed schedules. Each must be a
+// bookable date and an existing slot, differ from the current schedule and
+// appear only once.
+func (s *Service) buildRescheduleOptions(
+	ctx context.Context,
+	appt *AppointmentWithDetailsView,
+	reqs []RescheduleOptionRequest,
+) ([]RescheduleOption, error) {
+	seen := make(map[string]bool, len(reqs))
+	options := make([]RescheduleOption, 0, len(reqs))
+
+	for i, req := range reqs {
+		date := strings.Split(req.WhenDate, "T")[0]
+		key := fmt.Sprintf("%s/%d", date, req.TimeSlotID)
+		if seen[key] ||
+			(date == appt.WhenDate && req.TimeSlotID == appt.TimeSlotID) {
+			return nil, ErrRescheduleOption
+		}
+		seen[key] = true
+
+		if err := s.calendarService.ValidateBookingDate(ctx, date); err != nil {
+			return nil, err
+		}
+
+		slot, err := s.repo.GetTimeSlotByID(ctx, req.TimeSlotID)
+		if err != nil {
+			return nil, err
+		}
+		if slot == nil {
+			return nil, ErrRescheduleOption
+		}
+
+		options = append(options, RescheduleOption{
+			ID:           uuid.New().String(),
+			Position:     i + 1,
+			WhenDate:     date,
+			TimeSlotID:   slot.ID,
+			TimeSlotTime: slot.Time,
+		})
+	}
+
+	return options, nil
+}
+
+// rescheduleCounselorNotifications tells the counselor now holding the
+// appointment about the move, and a counselor who lost it to reassignment.
+func (s *Service) rescheduleCounselorNotifications(
+	ctx context.Context,
+	oldAppt, newAppt *AppointmentWithDetailsView,
+) []audit.NotificationParams {
+	actorID := audit.ExtractUserID(ctx)
+	var notifications []audit.NotificationParams
+
+	if newAppt.CounselorID.Valid && newAppt.CounselorID.String != actorID {
+		notifications = append(notifications, audit.NotificationParams{
+			ReceiverID: structs.FromSqlNull(newAppt.CounselorID),
+			TargetID:   structs.StringToNullableString(newAppt.ID),
+			TargetType: structs.StringToNullableString(
+				constants.AppointmentEntityType,
+			),
+			Title: "Assigned Appointment Rescheduled",
+			Message: fmt.Sprintf(
+				"Appointment with %s %s is now on %s at %s.",
+				newAppt.UserFirstName,
+				newAppt.UserLastName,
+				datetime.FormatDate(newAppt.WhenDate),
+				datetime.FormatTime(newAppt.TimeSlotTime),
+			),
+			Type: constants.AppointmentEntityType,
+		})
+	}
+
+	if oldAppt.CounselorID.Valid &&
+		oldAppt.CounselorID != newAppt.CounselorID &&
+		oldAppt.CounselorID.String != actorID {
+		notifications = append(notifications, audit.NotificationParams{
+			ReceiverID: structs.FromSqlNull(oldAppt.CounselorID),
+			TargetID:   structs.StringToNullableString(oldAppt.ID),
+			TargetType: structs.StringToNullableString(
+				constants.AppointmentEntityType,
+			),
+			Title: "Appointment Reassigned",
+			Message: fmt.Sprintf(
+				"Appointment with %s %s on %s at %s was rescheduled and reassigned to another counselor.",
+				oldAppt.UserFirstName,
+				oldAppt.UserLastName,
+				datetime.FormatDate(oldAppt.WhenDate),
+				datetime.FormatTime(oldAppt.TimeSlotTime),
+			),
+			Type: constants.AppointmentEntityType,
+		})
+	}
+
+	return notifications
+}
+
+func mapRescheduleRequest(
+	req *RescheduleRequest,
+	options []RescheduleOption,
+) RescheduleRequestDTO {
+	dto := RescheduleRequestDTO{
+		ID:             req.ID,
+		AppointmentID:  req.AppointmentID,
+		RequestedBy:    req.RequestedBy,
+		Reason:         req.Reason,
+		Status:         req.Status,
+		Options:        make([]RescheduleOptionDTO, len(options)),
+		ChosenOptionID: structs.FromSqlNull(req.ChosenOptionID),
+		DecisionNote:   structs.FromSqlNull(req.DecisionNote),
+		DecidedBy:      structs.FromSqlNull(req.DecidedBy),
+		CreatedAt:      req.CreatedAt,
+	}
+	if req.DecidedAt.Valid {
+		dto.DecidedAt = &req.DecidedAt.Time
+	}
+
+	for i, opt := range options {
+		dto.Options[i] = RescheduleOptionDTO{
+			ID:       opt.ID,
+			WhenDate: opt.WhenDate,
+			TimeSlot: TimeSlot{ID: opt.TimeSlotID, Time: opt.TimeSlotTime},
+		}
+	}
+
+	return dto
+}
+
+func toNullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/middleware"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/tokens"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
 )
 
@@ -13,9 +14,14 @@ func RegisterRoutes(
 	rg *gin.RouterGroup,
 	h *Handler,
 	redis *datastore.RedisClient,
+	tokenService *tokens.Service,
 ) {
+	// Calendar apps cannot send auth headers; the token in the URL is the
+	// credential for the feed.
+	rg.GET("/appointments/calendar/feed/:token", h.GetCalendarFeed)
+
 	routes := rg.Group("/appointments")
-	routes.Use(middleware.AuthMiddleware(redis))
+	routes.Use(middleware.AuthMiddleware(redis, tokenService))
 	routes.Use(middleware.HydrateStudentContext(db))
 	routes.Use(middleware.AuditContextMiddleware())
 
@@ -29,6 +35,17 @@ func RegisterRoutes(
 			"/calendar/stats",
 			h.GetDailyStatusCountList,
 		)
+		// Students cancel or ask for a new schedule through their own
+		// endpoints; direct edits bypass the approval flow
+		adminOnly.PATCH("/id/:id", h.PatchAppointment)
+		adminOnly.POST(
+			"/reschedule-requests/:requestId/approve",
+			h.PostApproveRescheduleRequest,
+		)
+		adminOnly.POST(
+			"/reschedule-requests/:requestId/decline",
+			h.PostDeclineRescheduleRequest,
+		)
 	}
 
 	studentOnly := routes.Group("")
@@ -39,6 +56,21 @@ func RegisterRoutes(
 		studentOnly.GET("/me", h.GetAppointmentListByIIR)
 		studentOnly.POST("", h.PostAppointment)
 		studentOnly.POST("/id/:id/cancel", h.PostCancelAppointment)
+		studentOnly.POST(
+			"/id/:id/reschedule-requests",
+			h.PostRescheduleRequest,
+		)
+		studentOnly.GET("/waitlist", h.GetWaitlistEntryList)
+		studentOnly.POST("/waitlist", h.PostWaitlistEntry)
+		studentOnly.DELETE("/waitlist/id/:id", h.DeleteWaitlistEntry)
+		studentOnly.POST(
+			"/waitlist/offers/:offerId/accept",
+			h.PostAcceptWaitlistOffer,
+		)
+		studentOnly.POST(
+			"/waitlist/offers/:offerId/decline",
+			h.PostDeclineWaitlistOffer,
+		)
 	}
 
 	sharedRoutes := routes.Group("")
@@ -48,7 +80,12 @@ func RegisterRoutes(
 	))
 	{
 		sharedRoutes.GET("/id/:id", h.GetAppointmentByID)
+		sharedRoutes.GET(
+			"/id/:id/reschedule-requests",
+			h.GetRescheduleRequestList,
+		)
 		sharedRoutes.GET("/stats", h.GetAppointmentStatsList)
+		sharedRoutes.POST("/calendar/feed", h.PostCalendarFeedToken)
 		sharedRoutes.GET(
 			"/lookups/categories",
 			h.GetAppointmentCategoryList,
@@ -61,9 +98,5 @@ func RegisterRoutes(
 			"/lookups/statuses",
 			h.GetAppointmentStatusList,
 		)
-		sharedRoutes.PATCH(
-			"/id/:id",
-			h.PatchAppointment,
-		)
 	}
 }
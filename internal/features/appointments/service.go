@@ -2,27 +2,48 @@ package appointments
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/olazo-johnalbert/duckload-api/internal/core/audit"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/config"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/datetime"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/structs"
+	"github.com/olazo-johnalbert/duckload-api/internal/features/calendar"
+	"github.com/olazo-johnalbert/duckload-api/internal/features/counselors"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/notes"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/users"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/email"
 
 	"github.com/google/uuid"
 )
 
+// ErrCounselorUnavailable is returned when the requested counselor is not
+// accepting appointments or is already booked for the slot.
+var ErrCounselorUnavailable = errors.New(
+	"selected counselor is not available for this schedule",
+)
+
+// ErrSlotFull is returned when a time slot has no capacity left on the
+// requested date.
+var ErrSlotFull = errors.New("selected time slot is fully booked")
+
 type Service struct {
-	repo         RepositoryInterface
-	notifService audit.Notifier
-	logService   audit.Logger
-	userService  users.ServiceInterface
-	noteService  notes.ServiceInterface
+	repo             RepositoryInterface
+	notifService     audit.Notifier
+	logService       audit.Logger
+	userService      users.ServiceInterface
+	noteService      notes.ServiceInterface
+	counselorService counselors.ServiceInterface
+	calendarService  calendar.ServiceInterface
+	emailer          email.Emailer
+	slotCapacity     int
+	waitlistHold     time.Duration
 }
 
 func NewService(
@@ -31,13 +52,22 @@ func NewService(
 	logService audit.Logger,
 	userService users.ServiceInterface,
 	noteService notes.ServiceInterface,
+	counselorService counselors.ServiceInterface,
+	calendarService calendar.ServiceInterface,
+	emailer email.Emailer,
+	cfg *config.Config,
 ) *Service {
 	return &Service{
-		repo:         repo,
-		notifService: notifService,
-		logService:   logService,
-		userService:  userService,
-		noteService:  noteService,
+		repo:             repo,
+		notifService:     notifService,
+		logService:       logService,
+		userService:      userService,
+		noteService:      noteService,
+		counselorService: counselorService,
+		calendarService:  calendarService,
+		emailer:          emailer,
+		slotCapacity:     cfg.AppointmentSlotCapacity,
+		waitlistHold:     cfg.AppointmentWaitlistHold,
 	}
 }
 
@@ -59,14 +89,19 @@ func (s *Service) CreateAppointment(
 		WhenDate:              strings.Split(req.WhenDate, "T")[0],
 		TimeSlotID:            req.TimeSlot.ID,
 		AppointmentCategoryID: req.AppointmentCategory.ID,
-		StatusID:              1,
+		StatusID:              int(constants.StatusPending),
 	}
 
-	err := datastore.RunInTransaction(
+	err := s.calendarService.ValidateBookingDate(ctx, appt.WhenDate)
+	if err != nil {
+		return nil, err
+	}
+
+	err = datastore.RunInTransaction(
 		ctx,
 		s.repo.GetDB(),
 		func(tx datastore.DB) error {
-			return s.repo.CreateAppointment(ctx, tx, appt)
+			return s.bookAppointment(ctx, tx, appt, req.Counselor)
 		},
 	)
 	if err != nil {
@@ -88,62 +123,7 @@ func (s *Service) CreateAppointment(
 		return nil, err
 	}
 
-	// Fetch personalized notification targets
-	userID := audit.ExtractUserID(ctx)
-	student, _ := s.userService.GetUserByID(ctx, userID)
-	studentName := "A student"
-	if student != nil {
-		studentName = fmt.Sprintf("%s %s", student.FirstName, student.LastName)
-	}
-
-	counselorIDs, _ := s.userService.GetUserIDsByRole(
-		ctx,
-		int(constants.AdminRoleID),
-	)
-
-	notifications := []audit.NotificationParams{
-		{
-			ReceiverID: structs.StringToNullableString(userID),
-			TargetID:   structs.StringToNullableString(appt.ID),
-			TargetType: structs.StringToNullableString(
-				constants.AppointmentEntityType,
-			),
-			Title:   "Appointment Created Successfully",
-			Message: "Your appointment has been created and is pending approval.",
-			Type:    constants.AppointmentEntityType,
-		},
-	}
-
-	for _, cid := range counselorIDs {
-		notifications = append(notifications, audit.NotificationParams{
-			ReceiverID: structs.StringToNullableString(cid),
-			TargetID:   structs.StringToNullableString(appt.ID),
-			TargetType: structs.StringToNullableString(
-				constants.AppointmentEntityType,
-			),
-			Title: "New Appointment Request",
-			Message: fmt.Sprintf(
-				"New appointment request received from %s.",
-				studentName,
-			),
-			Type: constants.AppointmentEntityType,
-		})
-	}
-
-	audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
-		Log: &audit.LogParams{
-			Level:    audit.LevelInfo,
-			Category: audit.CategoryAudit,
-			Action:   audit.ActionAppointmentCreated,
-			Message:  fmt.Sprintf("Appointment #%s created", appt.ID),
-			Metadata: &audit.LogMetadata{
-				EntityType: constants.AppointmentEntityType,
-				EntityID:   appt.ID,
-				NewValues:  req,
-			},
-		},
-		Notifications: notifications,
-	})
+	s.announceAppointment(ctx, appt, req)
 
 	return appt, nil
 }
@@ -188,6 +168,8 @@ func (s *Service) GetAppointmentByID(
 			Name:     appt.StatusName,
 			ColorKey: appt.StatusColorKey,
 		},
+		Counselor: mapCounselor(appt),
+		Flag:      mapFlag(appt),
 		CreatedAt: appt.CreatedAt,
 		UpdatedAt: appt.UpdatedAt,
 	}
@@ -201,6 +183,7 @@ func (s *Service) GetAppointmentByID(
 func (s *Service) GetDailyStatusCount(
 	ctx context.Context,
 	startDate string,
+	counselorID *string,
 ) ([]DailyStatusCount, error) {
 	layout := "2006-01-02"
 	t, err := time.Parse(layout, startDate)
@@ -224,12 +207,13 @@ func (s *Service) GetDailyStatusCount(
 	startStr := startOfMonth.Format(layout)
 	endStr := endOfMonth.Format(layout)
 
-	return s.repo.GetDailyStatusCount(ctx, startStr, endStr)
+	return s.repo.GetDailyStatusCount(ctx, startStr, endStr, counselorID)
 }
 
 func (s *Service) ListAppointments(
 	ctx context.Context,
 	req ListAppointmentsRequest,
+	counselorID *string,
 ) (*ListAppointmentsDTO, error) {
 	req.SetDefaults("created_at")
 
@@ -247,6 +231,7 @@ func (s *Service) ListAppointments(
 		strings.Join(statusIDs, ","),
 		req.StartDate,
 		req.EndDate,
+		counselorID,
 	)
 	if err != nil {
 		return nil, err
@@ -284,6 +269,8 @@ func (s *Service) ListAppointments(
 				Name:     appt.StatusName,
 				ColorKey: appt.StatusColorKey,
 			},
+			Counselor: mapCounselor(&appt),
+			Flag:      mapFlag(&appt),
 			CreatedAt: appt.CreatedAt,
 			UpdatedAt: appt.UpdatedAt,
 		}
@@ -300,6 +287,7 @@ func (s *Service) ListAppointments(
 		req.StartDate,
 		req.EndDate,
 		nil,
+		counselorID,
 	)
 	if err != nil {
 		return nil, err
@@ -364,6 +352,8 @@ func (s *Service) GetAppointmentsByUserID(
 				Name:     appt.StatusName,
 				ColorKey: appt.StatusColorKey,
 			},
+			Counselor: mapCounselor(&appt),
+			Flag:      mapFlag(&appt),
 			CreatedAt: appt.CreatedAt,
 			UpdatedAt: appt.UpdatedAt,
 		}
@@ -380,6 +370,7 @@ func (s *Service) GetAppointmentsByUserID(
 		req.StartDate,
 		req.EndDate,
 		&userID,
+		nil,
 	)
 	if err != nil {
 		return nil, err
@@ -444,6 +435,8 @@ func (s *Service) GetAppointmentsByIIRID(
 				Name:     appt.StatusName,
 				ColorKey: appt.StatusColorKey,
 			},
+			Counselor: mapCounselor(&appt),
+			Flag:      mapFlag(&appt),
 			CreatedAt: appt.CreatedAt,
 			UpdatedAt: appt.UpdatedAt,
 		}
@@ -460,6 +453,7 @@ func (s *Service) GetAppointmentsByIIRID(
 		req.StartDate,
 		req.EndDate,
 		&iirID,
+		nil,
 	)
 	if err != nil {
 		return nil, err
@@ -474,7 +468,7 @@ func (s *Service) GetAppointmentsByIIRID(
 func (s *Service) GetAppointmentStats(
 	ctx context.Context,
 	req ListAppointmentsRequest,
-	iirID *string,
+	iirID, counselorID *string,
 ) ([]StatusCount, error) {
 	return s.repo.GetAppointmentStats(
 		ctx,
@@ -482,18 +476,55 @@ func (s *Service) GetAppointmentStats(
 		req.StartDate,
 		req.EndDate,
 		iirID,
+		counselorID,
 	)
 }
 
 func (s *Service) GetAvailableTimeSlots(
 	ctx context.Context,
 	date string,
+	counselorID *string,
 ) ([]AvailableTimeSlotView, error) {
-	availableSlots, err := s.repo.GetAvailableTimeSlots(ctx, date)
+	availableSlots, err := s.repo.GetAvailableTimeSlots(
+		ctx,
+		date,
+		counselorID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Closed days still list their slots so the UI can explain why
+	err = s.calendarService.ValidateBookingDate(ctx, date)
+	if errors.Is(err, calendar.ErrDateUnavailable) {
+		for i := range availableSlots {
+			availableSlots[i].ClosedReason = err.Error()
+		}
+		return availableSlots, nil
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	capacity, perCounselor, err := s.getSlotCapacity(
+		ctx,
+		s.repo.GetDB(),
+		date,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range availableSlots {
+		slot := &availableSlots[i]
+		slot.Capacity = capacity
+		slot.Remaining = max(capacity-slot.BookedCount, 0)
+		if perCounselor || counselorID != nil {
+			slot.Remaining = min(slot.Remaining, slot.AvailableCounselors)
+		}
+		slot.IsAvailable = slot.Remaining > 0
+	}
+
 	return availableSlots, nil
 }
 
@@ -516,7 +547,7 @@ func (s *Service) UpdateAppointment(
 		ID:                    id,
 		StatusID:              req.Status.ID,
 		Reason:                structs.ToSqlNull(req.Reason),
-		AdminNotes:           structs.ToSqlNull(req.AdminNotes),
+		AdminNotes:            structs.ToSqlNull(req.AdminNotes),
 		WhenDate:              strings.Split(req.WhenDate, "T")[0],
 		TimeSlotID:            req.TimeSlot.ID,
 		AppointmentCategoryID: req.AppointmentCategory.ID,
@@ -526,6 +557,45 @@ func (s *Service) UpdateAppointment(
 		ctx,
 		s.repo.GetDB(),
 		func(tx datastore.DB) error {
+			currentStatusID, err := s.repo.LockAppointmentStatus(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+			if appt.StatusID != 0 {
+				err := checkTransition(
+					constants.StatusID(currentStatusID),
+					constants.StatusID(appt.StatusID),
+					constants.RoleID(audit.ExtractRoleID(ctx)),
+				)
+				if err != nil {
+					return err
+				}
+			}
+
+			if oldAppt == nil {
+				return s.repo.UpdateAppointment(ctx, tx, appt)
+			}
+
+			date, timeSlotID := oldAppt.WhenDate, oldAppt.TimeSlotID
+			if appt.WhenDate != "" {
+				date = appt.WhenDate
+			}
+			if appt.TimeSlotID != 0 {
+				timeSlotID = appt.TimeSlotID
+			}
+
+			appt.CounselorID, err = s.applySchedule(
+				ctx,
+				tx,
+				oldAppt,
+				req.Counselor,
+				date,
+				timeSlotID,
+			)
+			if err != nil {
+				return err
+			}
+
 			return s.repo.UpdateAppointment(ctx, tx, appt)
 		},
 	)
@@ -550,6 +620,16 @@ func (s *Service) UpdateAppointment(
 	}
 
 	newAppt, _ := s.repo.GetAppointment(ctx, id)
+	if newAppt != nil && isCalendarChange(oldAppt, newAppt) {
+		go s.sendCalendarInvite(context.WithoutCancel(ctx), oldAppt, newAppt)
+	}
+	if releasesSlot(oldAppt, newAppt) {
+		go s.offerFreedSlot(
+			context.WithoutCancel(ctx),
+			oldAppt.WhenDate,
+			oldAppt.TimeSlotID,
+		)
+	}
 
 	// Fetch student UserID for notification
 	studentUserID, _ := s.repo.GetUserIDByAppointmentID(ctx, id)
@@ -587,6 +667,28 @@ func (s *Service) UpdateAppointment(
 		},
 	}
 
+	// Keep the assigned counselor informed of changes made by others
+	actorID := audit.ExtractUserID(ctx)
+	if newAppt.CounselorID.Valid && newAppt.CounselorID.String != actorID {
+		notifications = append(notifications, audit.NotificationParams{
+			ReceiverID: structs.FromSqlNull(newAppt.CounselorID),
+			TargetID:   structs.StringToNullableString(newAppt.ID),
+			TargetType: structs.StringToNullableString(
+				constants.AppointmentEntityType,
+			),
+			Title: "Assigned Appointment Updated",
+			Message: fmt.Sprintf(
+				"Appointment with %s %s on %s at %s is now '%s'.",
+				newAppt.UserFirstName,
+				newAppt.UserLastName,
+				datetime.FormatDate(newAppt.WhenDate),
+				datetime.FormatTime(newAppt.TimeSlotTime),
+				newAppt.StatusName,
+			),
+			Type: constants.AppointmentEntityType,
+		})
+	}
+
 	audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
 		Log: &audit.LogParams{
 			Level:    audit.LevelInfo,
@@ -604,8 +706,7 @@ func (s *Service) UpdateAppointment(
 	})
 
 	// Add special prompt for counselors if appointment is completed
-	// status_id 3 = Completed
-	if req.Status.ID == 3 {
+	if req.Status.ID == int(constants.StatusCompleted) {
 		hasNote, _ := s.noteService.HasNoteForAppointment(ctx, id)
 		if !hasNote {
 			audit.Dispatch(
@@ -641,3 +742,315 @@ func (s *Service) GetUserIDByAppointmentID(
 ) (string, error) {
 	return s.repo.GetUserIDByAppointmentID(ctx, id)
 }
+
+// resolveCounselor returns the counselor to assign for a slot. A requested
+// counselor must be free; otherwise the least busy available counselor is
+// picked. An empty ID means no counselor could be assigned.
+func (s *Service) resolveCounselor(
+	ctx context.Context,
+	tx datastore.DB,
+	requested *AppointmentCounselor,
+	date string,
+	timeSlotID int,
+) (string, error) {
+	if requested != nil && requested.ID != "" {
+		free, err := s.counselorService.IsCounselorFree(
+			ctx,
+			tx,
+			requested.ID,
+			date,
+			timeSlotID,
+		)
+		if err != nil {
+			return "", err
+		}
+		if !free {
+			return "", ErrCounselorUnavailable
+		}
+
+		return requested.ID, nil
+	}
+
+	return s.counselorService.FindAvailableCounselor(
+		ctx,
+		tx,
+		date,
+		timeSlotID,
+	)
+}
+
+// isCounselorChange reports whether an update asks for a different
+// counselor than the one currently assigned.
+func isCounselorChange(
+	current *AppointmentWithDetailsView,
+	requested *AppointmentCounselor,
+) bool {
+	if current == nil || requested == nil || requested.ID == "" {
+		return false
+	}
+
+	return !current.CounselorID.Valid ||
+		current.CounselorID.String != requested.ID
+}
+
+func mapCounselor(appt *AppointmentWithDetailsView) *AppointmentCounselor {
+	if !appt.CounselorID.Valid {
+		return nil
+	}
+
+	return &AppointmentCounselor{
+		ID:        appt.CounselorID.String,
+		FirstName: appt.CounselorFirstName.String,
+		LastName:  appt.CounselorLastName.String,
+	}
+}
+
+// getSlotCapacity returns how many bookings a single time slot accepts. The
+// configured capacity wins; otherwise each available counselor adds one
+// seat, and perCounselor reports that a seat also needs a free counselor.
+// Offices without counselor profiles keep the original single booking.
+func (s *Service) getSlotCapacity(
+	ctx context.Context,
+	db datastore.DB,
+	date string,
+) (capacity int, perCounselor bool, err error) {
+	if s.slotCapacity > 0 {
+		return s.slotCapacity, false, nil
+	}
+
+	count, err := s.counselorService.CountAvailableCounselors(ctx, db, date)
+	if err != nil {
+		return 0, false, err
+	}
+	if count == 0 {
+		return 1, false, nil
+	}
+
+	return count, true, nil
+}
+
+// reserveSlot locks the time slot and verifies it still has capacity on the
+// given date. It must run inside the booking transaction so the lock is held
+// until the appointment row is written.
+func (s *Service) reserveSlot(
+	ctx context.Context,
+	tx datastore.DB,
+	date string,
+	timeSlotID int,
+	excludeID string,
+) error {
+	if err := s.repo.LockTimeSlot(ctx, tx, timeSlotID); err != nil {
+		return err
+	}
+
+	booked, err := s.repo.CountActiveBySlot(
+		ctx,
+		tx,
+		date,
+		timeSlotID,
+		excludeID,
+	)
+	if err != nil {
+		return err
+	}
+
+	capacity, _, err := s.getSlotCapacity(ctx, tx, date)
+	if err != nil {
+		return err
+	}
+	if booked >= capacity {
+		return ErrSlotFull
+	}
+
+	return nil
+}
+
+func mapFlag(appt *AppointmentWithDetailsView) *AppointmentFlag {
+	if !appt.FlaggedAt.Valid {
+		return nil
+	}
+
+	return &AppointmentFlag{
+		Reason:    appt.FlagReason.String,
+		FlaggedAt: appt.FlaggedAt.Time,
+	}
+}
+
+// applySchedule prepares moving an appointment to date and timeSlotID inside
+// tx. A move is checked against the calendar and slot capacity and clears any
+// closure flag. It returns the counselor to assign, which is invalid when the
+// current assignment stays: the current counselor is kept when still free,
+// otherwise the requested or next available counselor takes over.
+func (s *Service) applySchedule(
+	ctx context.Context,
+	tx datastore.DB,
+	current *AppointmentWithDetailsView,
+	requested *AppointmentCounselor,
+	date string,
+	timeSlotID int,
+) (sql.NullString, error) {
+	moved := date != current.WhenDate || timeSlotID != current.TimeSlotID
+	if moved {
+		err := s.calendarService.ValidateBookingDate(ctx, date)
+		if err != nil {
+			return sql.NullString{}, err
+		}
+		err = s.reserveSlot(ctx, tx, date, timeSlotID, current.ID)
+		if err != nil {
+			return sql.NullString{}, err
+		}
+		if err := s.repo.ClearFlag(ctx, tx, current.ID); err != nil {
+			return sql.NullString{}, err
+		}
+		err = s.repo.ClearReminders(ctx, tx, current.ID)
+		if err != nil {
+			return sql.NullString{}, err
+		}
+	}
+
+	if !isCounselorChange(current, requested) {
+		if !moved || !current.CounselorID.Valid {
+			return sql.NullString{}, nil
+		}
+
+		// Keep the current counselor when they are free at the new
+		// schedule, otherwise hand it to someone who is.
+		free, err := s.counselorService.IsCounselorFree(
+			ctx,
+			tx,
+			current.CounselorID.String,
+			date,
+			timeSlotID,
+		)
+		if err != nil {
+			return sql.NullString{}, err
+		}
+		if free {
+			return sql.NullString{}, nil
+		}
+		requested = nil
+	}
+
+	counselorID, err := s.resolveCounselor(
+		ctx,
+		tx,
+		requested,
+		date,
+		timeSlotID,
+	)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	if counselorID == "" {
+		return sql.NullString{}, ErrCounselorUnavailable
+	}
+
+	return sql.NullString{String: counselorID, Valid: true}, nil
+}
+
+// bookAppointment reserves the slot, assigns a counselor and inserts appt
+// inside tx.
+func (s *Service) bookAppointment(
+	ctx context.Context,
+	tx datastore.DB,
+	appt *Appointment,
+	requested *AppointmentCounselor,
+) error {
+	err := s.reserveSlot(ctx, tx, appt.WhenDate, appt.TimeSlotID, "")
+	if err != nil {
+		return err
+	}
+
+	counselorID, err := s.resolveCounselor(
+		ctx,
+		tx,
+		requested,
+		appt.WhenDate,
+		appt.TimeSlotID,
+	)
+	if err != nil {
+		return err
+	}
+	appt.CounselorID = sql.NullString{
+		String: counselorID,
+		Valid:  counselorID != "",
+	}
+
+	return s.repo.CreateAppointment(ctx, tx, appt)
+}
+
+// announceAppointment audits a new booking and tells the student and the
+// counselors about it.
+func (s *Service) announceAppointment(
+	ctx context.Context,
+	appt *Appointment,
+	newValues interface{},
+) {
+	// Fetch personalized notification targets
+	userID := audit.ExtractUserID(ctx)
+	student, _ := s.userService.GetUserByID(ctx, userID)
+	studentName := "A student"
+	if student != nil {
+		studentName = fmt.Sprintf("%s %s", student.FirstName, student.LastName)
+	}
+
+	// Only the assigned counselor hears about the request; unassigned
+	// appointments fall back to every admin.
+	counselorIDs := []string{appt.CounselorID.String}
+	if !appt.CounselorID.Valid {
+		counselorIDs, _ = s.userService.GetUserIDsByRole(
+			ctx,
+			int(constants.AdminRoleID),
+		)
+	}
+
+	notifications := []audit.NotificationParams{
+		{
+			ReceiverID: structs.StringToNullableString(userID),
+			TargetID:   structs.StringToNullableString(appt.ID),
+			TargetType: structs.StringToNullableString(
+				constants.AppointmentEntityType,
+			),
+			Title:   "Appointment Created Successfully",
+			Message: "Your appointment has been created and is pending approval.",
+			Type:    constants.AppointmentEntityType,
+		},
+	}
+
+	for _, cid := range counselorIDs {
+		notifications = append(notifications, audit.NotificationParams{
+			ReceiverID: structs.StringToNullableString(cid),
+			TargetID:   structs.StringToNullableString(appt.ID),
+			TargetType: structs.StringToNullableString(
+				constants.AppointmentEntityType,
+			),
+			Title: "New Appointment Request",
+			Message: fmt.Sprintf(
+				"New appointment request received from %s.",
+				studentName,
+			),
+			Type: constants.AppointmentEntityType,
+		})
+	}
+
+	audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+		Log: &audit.LogParams{
+			Level:    audit.LevelInfo,
+			Category: audit.CategoryAudit,
+			Action:   audit.ActionAppointmentCreated,
+			Message:  fmt.Sprintf("Appointment #%s created", appt.ID),
+			Metadata: &audit.LogMetadata{
+				EntityType: constants.AppointmentEntityType,
+				EntityID:   appt.ID,
+				NewValues:  newValues,
+			},
+		},
+		Notifications: notifications,
+	})
+
+	if created, err := s.repo.GetAppointment(ctx, appt.ID); err == nil &&
+		created != nil {
+		go s.sendCalendarInvite(context.WithoutCancel(ctx), nil, created)
+	}
+
+}
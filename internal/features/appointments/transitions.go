@@ -0,0 +1,67 @@
+package appointments
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+)
+
+var (
+	ErrInvalidTransition   = errors.New("appointment status transition is not allowed")
+	ErrTransitionForbidden = errors.New("your role cannot perform this status change")
+)
+
+var (
+	staffRoles = []constants.RoleID{
+		constants.AdminRoleID,
+		constants.SuperAdminRoleID,
+	}
+	anyRole = []constants.RoleID{
+		constants.StudentRoleID,
+		constants.AdminRoleID,
+		constants.SuperAdminRoleID,
+	}
+)
+
+// statusTransitions lists every allowed status change and the roles that
+// may perform it. Completed, Cancelled, Rejected and No-show are terminal.
+var statusTransitions = map[constants.StatusID]map[constants.StatusID][]constants.RoleID{
+	constants.StatusPending: {
+		constants.StatusScheduled:   staffRoles,
+		constants.StatusRescheduled: staffRoles,
+		constants.StatusRejected:    staffRoles,
+		constants.StatusCancelled:   anyRole,
+	},
+	constants.StatusScheduled: {
+		constants.StatusRescheduled: staffRoles,
+		constants.StatusCompleted:   staffRoles,
+		constants.StatusNoShow:      staffRoles,
+		constants.StatusCancelled:   anyRole,
+	},
+	constants.StatusRescheduled: {
+		constants.StatusScheduled: staffRoles,
+		constants.StatusCompleted: staffRoles,
+		constants.StatusNoShow:    staffRoles,
+		constants.StatusCancelled: anyRole,
+	},
+}
+
+// checkTransition validates moving an appointment from one status to
+// another as the given role. Keeping the same status is always allowed so
+// other fields can still be edited.
+func checkTransition(from, to constants.StatusID, role constants.RoleID) error {
+	if from == to {
+		return nil
+	}
+
+	roles, ok := statusTransitions[from][to]
+	if !ok {
+		return ErrInvalidTransition
+	}
+	if !slices.Contains(roles, role) {
+		return ErrTransitionForbidden
+	}
+
+	return nil
+}
@@ -0,0 +1,753 @@
+package appointments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/olazo-johnalbert/duckload-api/internal/core/audit"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/datetime"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/structs"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/email"
+
+	"github.com/google/uuid"
+)
+
+const (
+	WaitlistStatusWaiting   = "waiting"
+	WaitlistStatusOffered   = "offered"
+	WaitlistStatusBooked    = "booked"
+	WaitlistStatusCancelled = "cancelled"
+
+	WaitlistOfferPending  = "pending"
+	WaitlistOfferAccepted = "accepted"
+	WaitlistOfferDeclined = "declined"
+	WaitlistOfferExpired  = "expired"
+)
+
+// maxWaitlistDays caps the date range of a waitlist entry.
+const maxWaitlistDays = 31
+
+var (
+	ErrWaitlistRange = fmt.Errorf(
+		"waitlist dates must start today or later and span at most %d days",
+		maxWaitlistDays,
+	)
+	ErrWaitlistCategory  = errors.New("appointment category not found")
+	ErrWaitlistDuplicate = errors.New(
+		"you are already on the waitlist for this category and dates",
+	)
+	ErrWaitlistNotFound = errors.New("waitlist entry not found")
+	ErrWaitlistClosed   = errors.New("waitlist entry is no longer active")
+	ErrOfferNotFound    = errors.New("waitlist offer not found")
+	ErrOfferExpired     = errors.New("waitlist offer is no longer available")
+)
+
+// JoinWaitlist puts the student in line for any slot of the category
+// between the requested dates.
+func (s *Service) JoinWaitlist(
+	ctx context.Context,
+	iirID string,
+	req JoinWaitlistRequest,
+) (*WaitlistEntryDTO, error) {
+	entry := &WaitlistEntry{
+		ID:                    uuid.New().String(),
+		IIRID:                 iirID,
+		AppointmentCategoryID: req.AppointmentCategoryID,
+		StartDate:             strings.Split(req.StartDate, "T")[0],
+		EndDate:               strings.Split(req.EndDate, "T")[0],
+		Reason:                structs.ToSqlNull(req.Reason),
+		Status:                WaitlistStatusWaiting,
+		CreatedAt:             time.Now(),
+	}
+
+	err := datastore.RunInTransaction(
+		ctx,
+		s.repo.GetDB(),
+		func(tx datastore.DB) error {
+			err := validateWaitlistRange(entry.StartDate, entry.EndDate)
+			if err != nil {
+				return err
+			}
+
+			category, err := s.repo.GetAppointmentCategoryByID(
+				ctx,
+				entry.AppointmentCategoryID,
+			)
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrWaitlistCategory
+			}
+			if err != nil {
+				return err
+			}
+			entry.CategoryName = category.Name
+
+			exists, err := s.repo.HasActiveWaitlistEntry(
+				ctx,
+				tx,
+				iirID,
+				entry.AppointmentCategoryID,
+				entry.StartDate,
+				entry.EndDate,
+			)
+			if err != nil {
+				return err
+			}
+			if exists {
+				return ErrWaitlistDuplicate
+			}
+
+			return s.repo.CreateWaitlistEntry(ctx, tx, entry)
+		},
+	)
+	if err != nil {
+		audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+			Log: &audit.LogParams{
+				Level:    audit.LevelError,
+				Category: audit.CategoryAudit,
+				Action:   audit.ActionWaitlistJoinFailed,
+				Message: fmt.Sprintf(
+					"Failed to add IIR #%s to the waitlist",
+					iirID,
+				),
+				Metadata: &audit.LogMetadata{
+					EntityType: constants.WaitlistEntityType,
+					NewValues:  req,
+					Error:      err.Error(),
+				},
+			},
+		})
+		return nil, err
+	}
+
+	dto := mapWaitlistEntry(entry)
+
+	audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+		Log: &audit.LogParams{
+			Level:    audit.LevelInfo,
+			Category: audit.CategoryAudit,
+			Action:   audit.ActionWaitlistJoined,
+			Message:  fmt.Sprintf("Waitlist entry #%s created", entry.ID),
+			Metadata: &audit.LogMetadata{
+				EntityType: constants.WaitlistEntityType,
+				EntityID:   entry.ID,
+				NewValues:  dto,
+			},
+		},
+		Notifications: []audit.NotificationParams{
+			{
+				ReceiverID: structs.StringToNullableString(
+					audit.ExtractUserID(ctx),
+				),
+				TargetID: structs.StringToNullableString(entry.ID),
+				TargetType: structs.StringToNullableString(
+					constants.WaitlistEntityType,
+				),
+				Title: "Added to Waitlist",
+				Message: fmt.Sprintf(
+					"We will let you know when a slot opens between %s and %s.",
+					datetime.FormatDate(entry.StartDate),
+					datetime.FormatDate(entry.EndDate),
+				),
+				Type: constants.AppointmentEntityType,
+			},
+		},
+	})
+
+	return &dto, nil
+}
+
+// ListWaitlist returns the student's waitlist entries, newest first, with
+// any slot currently held for them.
+func (s *Service) ListWaitlist(
+	ctx context.Context,
+	iirID string,
+) ([]WaitlistEntryDTO, error) {
+	entries, err := s.repo.ListWaitlistByIIRID(ctx, iirID)
+	if err != nil {
+		return nil, err
+	}
+
+	dtos := make([]WaitlistEntryDTO, len(entries))
+	for i := range entries {
+		dtos[i] = mapWaitlistEntry(&entries[i])
+	}
+
+	return dtos, nil
+}
+
+// LeaveWaitlist removes the student from the waitlist. A slot held for
+// them is released to the next student.
+func (s *Service) LeaveWaitlist(
+	ctx context.Context,
+	iirID, id string,
+) error {
+	var entry *WaitlistEntry
+
+	err := datastore.RunInTransaction(
+		ctx,
+		s.repo.GetDB(),
+		func(tx datastore.DB) error {
+			var err error
+			entry, err = s.repo.LockWaitlistEntry(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+			if entry == nil || entry.IIRID != iirID {
+				return ErrWaitlistNotFound
+			}
+			if entry.Status != WaitlistStatusWaiting &&
+				entry.Status != WaitlistStatusOffered {
+				return ErrWaitlistClosed
+			}
+
+			if entry.OfferID.Valid {
+				err := s.repo.UpdateWaitlistOfferStatus(
+					ctx,
+					tx,
+					entry.OfferID.String,
+					WaitlistOfferDeclined,
+				)
+				if err != nil {
+					return err
+				}
+			}
+
+			return s.repo.UpdateWaitlistStatus(
+				ctx,
+				tx,
+				id,
+				WaitlistStatusCancelled,
+				sql.NullString{},
+			)
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+		Log: &audit.LogParams{
+			Level:    audit.LevelInfo,
+			Category: audit.CategoryAudit,
+			Action:   audit.ActionWaitlistLeft,
+			Message:  fmt.Sprintf("Waitlist entry #%s cancelled", id),
+			Metadata: &audit.LogMetadata{
+				EntityType: constants.WaitlistEntityType,
+				EntityID:   id,
+				OldValues:  mapWaitlistEntry(entry),
+			},
+		},
+	})
+
+	if entry.OfferID.Valid {
+		go s.offerFreedSlot(
+			context.WithoutCancel(ctx),
+			entry.OfferWhenDate.String,
+			int(entry.OfferTimeSlotID.Int64),
+		)
+	}
+
+	return nil
+}
+
+// AcceptWaitlistOffer books the held slot for the student. The booking goes
+// through the same capacity and counselor checks as a regular one.
+func (s *Service) AcceptWaitlistOffer(
+	ctx context.Context,
+	iirID, offerID string,
+) (*Appointment, error) {
+	var offer *WaitlistOffer
+	var appt *Appointment
+
+	err := datastore.RunInTransaction(
+		ctx,
+		s.repo.GetDB(),
+		func(tx datastore.DB) error {
+			var err error
+			offer, err = s.lockOwnOffer(ctx, tx, iirID, offerID)
+			if err != nil {
+				return err
+			}
+
+			// Release the hold first so it does not count against the
+			// capacity check of its own booking.
+			err = s.repo.UpdateWaitlistOfferStatus(
+				ctx,
+				tx,
+				offerID,
+				WaitlistOfferAccepted,
+			)
+			if err != nil {
+				return err
+			}
+
+			err = s.calendarService.ValidateBookingDate(ctx, offer.WhenDate)
+			if err != nil {
+				return err
+			}
+
+			appt = &Appointment{
+				ID:                    uuid.New().String(),
+				IIRID:                 iirID,
+				Reason:                offer.Reason,
+				WhenDate:              offer.WhenDate,
+				TimeSlotID:            offer.TimeSlotID,
+				AppointmentCategoryID: offer.AppointmentCategoryID,
+				StatusID:              int(constants.StatusPending),
+			}
+			if err := s.bookAppointment(ctx, tx, appt, nil); err != nil {
+				return err
+			}
+
+			return s.repo.UpdateWaitlistStatus(
+				ctx,
+				tx,
+				offer.WaitlistID,
+				WaitlistStatusBooked,
+				sql.NullString{String: appt.ID, Valid: true},
+			)
+		},
+	)
+	if err != nil {
+		audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+			Log: &audit.LogParams{
+				Level:    audit.LevelError,
+				Category: audit.CategoryAudit,
+				Action:   audit.ActionWaitlistOfferAcceptFailed,
+				Message: fmt.Sprintf(
+					"Failed to accept waitlist offer #%s",
+					offerID,
+				),
+				Metadata: &audit.LogMetadata{
+					EntityType: constants.WaitlistEntityType,
+					EntityID:   offerID,
+					OldValues:  offer,
+					Error:      err.Error(),
+				},
+			},
+		})
+		return nil, err
+	}
+
+	audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+		Log: &audit.LogParams{
+			Level:    audit.LevelInfo,
+			Category: audit.CategoryAudit,
+			Action:   audit.ActionWaitlistOfferAccepted,
+			Message: fmt.Sprintf(
+				"Waitlist offer #%s accepted as appointment #%s",
+				offerID,
+				appt.ID,
+			),
+			Metadata: &audit.LogMetadata{
+				EntityType: constants.WaitlistEntityType,
+				EntityID:   offer.WaitlistID,
+				OldValues:  offer,
+				NewValues:  appt,
+			},
+		},
+	})
+	s.announceAppointment(ctx, appt, offer)
+
+	return appt, nil
+}
+
+// DeclineWaitlistOffer gives the held slot up. The student stays on the
+// waitlist for other slots and the slot moves on to the next student.
+func (s *Service) DeclineWaitlistOffer(
+	ctx context.Context,
+	iirID, offerID string,
+) error {
+	var offer *WaitlistOffer
+
+	err := datastore.RunInTransaction(
+		ctx,
+		s.repo.GetDB(),
+		func(tx datastore.DB) error {
+			var err error
+			offer, err = s.lockOwnOffer(ctx, tx, iirID, offerID)
+			if err != nil {
+				return err
+			}
+
+			return s.releaseOffer(ctx, tx, offer, WaitlistOfferDeclined)
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+		Log: &audit.LogParams{
+			Level:    audit.LevelInfo,
+			Category: audit.CategoryAudit,
+			Action:   audit.ActionWaitlistOfferDeclined,
+			Message:  fmt.Sprintf("Waitlist offer #%s declined", offerID),
+			Metadata: &audit.LogMetadata{
+				EntityType: constants.WaitlistEntityType,
+				EntityID:   offer.WaitlistID,
+				OldValues:  offer,
+			},
+		},
+	})
+
+	go s.offerFreedSlot(
+		context.WithoutCancel(ctx),
+		offer.WhenDate,
+		offer.TimeSlotID,
+	)
+
+	return nil
+}
+
+// ExpireWaitlistOffers closes waitlist entries whose dates have passed and
+// moves every offer that was not confirmed in time on to the next student.
+func (s *Service) ExpireWaitlistOffers(ctx context.Context) {
+	if err := s.repo.ExpireWaitlistEntries(ctx); err != nil {
+		log.Printf("[ExpireWaitlistOffers] {Expire Entries}: %v", err)
+	}
+
+	ids, err := s.repo.ListExpiredWaitlistOffers(ctx)
+	if err != nil {
+		log.Printf("[ExpireWaitlistOffers] {List Offers}: %v", err)
+		return
+	}
+
+	for _, id := range ids {
+		var offer *WaitlistOffer
+		err := datastore.RunInTransaction(
+			ctx,
+			s.repo.GetDB(),
+			func(tx datastore.DB) error {
+				locked, err := s.repo.LockWaitlistOffer(ctx, tx, id)
+				if err != nil {
+					return err
+				}
+				// Accepted or declined since it was listed
+				if locked == nil || locked.Status != WaitlistOfferPending ||
+					!locked.Expired {
+					return nil
+				}
+
+				offer = locked
+				return s.releaseOffer(ctx, tx, offer, WaitlistOfferExpired)
+			},
+		)
+		if err != nil {
+			log.Printf("[ExpireWaitlistOffers] {Expire Offer}: %v", err)
+			continue
+		}
+		if offer == nil {
+			continue
+		}
+
+		audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+			Log: &audit.LogParams{
+				Level:    audit.LevelInfo,
+				Category: audit.CategorySystem,
+				Action:   audit.ActionWaitlistOfferExpired,
+				Message:  fmt.Sprintf("Waitlist offer #%s expired", offer.ID),
+				TargetID: structs.StringToNullableString(offer.WaitlistID),
+				TargetType: structs.StringToNullableString(
+					constants.WaitlistEntityType,
+				),
+			},
+			Notifications: []audit.NotificationParams{
+				{
+					ReceiverID: structs.StringToNullableString(offer.UserID),
+					TargetID:   structs.StringToNullableString(offer.WaitlistID),
+					TargetType: structs.StringToNullableString(
+						constants.WaitlistEntityType,
+					),
+					Title: "Waitlist Offer Expired",
+					Message: fmt.Sprintf(
+						"The slot on %s at %s was not confirmed in time and has been offered to the next student. You are still on the waitlist.",
+						datetime.FormatDate(offer.WhenDate),
+						datetime.FormatTime(offer.TimeSlotTime),
+					),
+					Type: constants.AppointmentEntityType,
+				},
+			},
+		})
+
+		s.offerFreedSlot(ctx, offer.WhenDate, offer.TimeSlotID)
+	}
+}
+
+// offerFreedSlot holds a slot that just opened up for the next student on
+// the waitlist. Nothing is offered when the date is no longer bookable, the
+// slot was taken in the meantime or nobody is waiting for it.
+func (s *Service) offerFreedSlot(
+	ctx context.Context,
+	date string,
+	timeSlotID int,
+) {
+	if err := s.calendarService.ValidateBookingDate(ctx, date); err != nil {
+		return
+	}
+
+	offer := &WaitlistOffer{
+		ID:         uuid.New().String(),
+		WhenDate:   date,
+		TimeSlotID: timeSlotID,
+	}
+	var entry *WaitlistEntry
+
+	err := datastore.RunInTransaction(
+		ctx,
+		s.repo.GetDB(),
+		func(tx datastore.DB) error {
+			err := s.reserveSlot(ctx, tx, date, timeSlotID, "")
+			if err != nil {
+				return err
+			}
+
+			entry, err = s.repo.NextWaitlistCandidate(
+				ctx,
+				tx,
+				date,
+				timeSlotID,
+			)
+			if err != nil || entry == nil {
+				return err
+			}
+
+			offer.WaitlistID = entry.ID
+			err = s.repo.CreateWaitlistOffer(ctx, tx, offer, s.waitlistHold)
+			if err != nil {
+				return err
+			}
+
+			return s.repo.UpdateWaitlistStatus(
+				ctx,
+				tx,
+				entry.ID,
+				WaitlistStatusOffered,
+				sql.NullString{},
+			)
+		},
+	)
+	if errors.Is(err, ErrSlotFull) {
+		return
+	}
+	if err != nil {
+		log.Printf("[offerFreedSlot] {Create Offer}: %v", err)
+		return
+	}
+	if entry == nil {
+		return
+	}
+
+	held, err := s.repo.GetWaitlistOffer(ctx, offer.ID)
+	if err != nil || held == nil {
+		log.Printf("[offerFreedSlot] {Fetch Offer}: %v", err)
+		return
+	}
+	s.sendWaitlistOffer(ctx, held)
+}
+
+func (s *Service) sendWaitlistOffer(ctx context.Context, offer *WaitlistOffer) {
+	date := datetime.FormatDate(offer.WhenDate)
+	slot := datetime.FormatTime(offer.TimeSlotTime)
+	expiresAt := offer.ExpiresAt.In(time.Local).Format("January 2, 2006 3:04 PM")
+
+	audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+		Log: &audit.LogParams{
+			Level:    audit.LevelInfo,
+			Category: audit.CategorySystem,
+			Action:   audit.ActionWaitlistOffered,
+			Message: fmt.Sprintf(
+				"Offered %s %s to waitlist entry #%s",
+				offer.WhenDate,
+				offer.TimeSlotTime,
+				offer.WaitlistID,
+			),
+			TargetID: structs.StringToNullableString(offer.WaitlistID),
+			TargetType: structs.StringToNullableString(
+				constants.WaitlistEntityType,
+			),
+		},
+		Notifications: []audit.NotificationParams{
+			{
+				ReceiverID: structs.StringToNullableString(offer.UserID),
+				TargetID:   structs.StringToNullableString(offer.WaitlistID),
+				TargetType: structs.StringToNullableString(
+					constants.WaitlistEntityType,
+				),
+				Title: "Appointment Slot Available",
+				Message: fmt.Sprintf(
+					"A %s slot on %s at %s is held for you until %s.",
+					offer.CategoryName,
+					date,
+					slot,
+					expiresAt,
+				),
+				Type: constants.AppointmentEntityType,
+			},
+		},
+	})
+
+	_, err := s.emailer.SendEmail(
+		ctx,
+		offer.UserEmail,
+		"Appointment Slot Available",
+		email.WAITLIST_OFFER_TEMPLATE(
+			html.EscapeString(offer.UserFirstName),
+			date,
+			slot,
+			html.EscapeString(offer.CategoryName),
+			expiresAt,
+		),
+	)
+	if err != nil {
+		log.Printf("[sendWaitlistOffer] {Send Email}: %v", err)
+	}
+}
+
+// lockOwnOffer locks a pending, unexpired offer held for the student.
+func (s *Service) lockOwnOffer(
+	ctx context.Context,
+	tx datastore.DB,
+	iirID, offerID string,
+) (*WaitlistOffer, error) {
+	offer, err := s.repo.LockWaitlistOffer(ctx, tx, offerID)
+	if err != nil {
+		return nil, err
+	}
+	if offer == nil || offer.IIRID != iirID {
+		return nil, ErrOfferNotFound
+	}
+	if offer.Status != WaitlistOfferPending || offer.Expired {
+		return nil, ErrOfferExpired
+	}
+
+	return offer, nil
+}
+
+// releaseOffer closes an offer without a booking and puts its entry back in
+// line.
+func (s *Service) releaseOffer(
+	ctx context.Context,
+	tx datastore.DB,
+	offer *WaitlistOffer,
+	status string,
+) error {
+	err := s.repo.UpdateWaitlistOfferStatus(ctx, tx, offer.ID, status)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.UpdateWaitlistStatus(
+		ctx,
+		tx,
+		offer.WaitlistID,
+		WaitlistStatusWaiting,
+		sql.NullString{},
+	)
+}
+
+// releasesSlot reports whether an update gave up the appointment's original
+// slot, either by moving it or by cancelling or rejecting it.
+func releasesSlot(old, updated *AppointmentWithDetailsView) bool {
+	if old == nil || updated == nil || !isSlotActive(old.StatusID) {
+		return false
+	}
+
+	return !isSlotActive(updated.StatusID) ||
+		old.WhenDate != updated.WhenDate ||
+		old.TimeSlotID != updated.TimeSlotID
+}
+
+func isSlotActive(statusID int) bool {
+	status := constants.StatusID(statusID)
+	return status != constants.StatusCancelled &&
+		status != constants.StatusRejected
+}
+
+func validateWaitlistRange(startDate, endDate string) error {
+	start, err := time.ParseInLocation("2006-01-02", startDate, time.Local)
+	if err != nil {
+		return ErrWaitlistRange
+	}
+	end, err := time.ParseInLocation("2006-01-02", endDate, time.Local)
+	if err != nil {
+		return ErrWaitlistRange
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if start.Before(today) || end.Before(start) ||
+		end.Sub(start) > maxWaitlistDays*24*time.Hour {
+		return ErrWaitlistRange
+	}
+
+	return nil
+}
+
+func mapWaitlistEntry(entry *WaitlistEntry) WaitlistEntryDTO {
+	dto := WaitlistEntryDTO{
+		ID:        entry.ID,
+		StartDate: entry.StartDate,
+		EndDate:   entry.EndDate,
+		AppointmentCategory: AppointmentCategory{
+			ID:   entry.AppointmentCategoryID,
+			Name: entry.CategoryName,
+		},
+		Reason:        structs.FromSqlNull(entry.Reason),
+		Status:        entry.Status,
+		AppointmentID: structs.FromSqlNull(entry.AppointmentID),
+		CreatedAt:     entry.CreatedAt,
+	}
+
+	if entry.OfferID.Valid {
+		dto.Offer = &WaitlistOfferDTO{
+			ID:       entry.OfferID.String,
+			WhenDate: entry.OfferWhenDate.String,
+			TimeSlot: TimeSlot{
+				ID:   int(entry.OfferTimeSlotID.Int64),
+				Time: entry.OfferTimeSlotTime.String,
+			},
+			ExpiresAt: entry.OfferExpiresAt.Time,
+		}
+	}
+
+	return dto
+}
+
+// WaitlistScheduler periodically expires waitlist offers that were not
+// confirmed in time so their slots reach the next student.
+type WaitlistScheduler struct {
+	service  ServiceInterface
+	interval time.Duration
+}
+
+func NewWaitlistScheduler(
+	service ServiceInterface,
+	interval time.Duration,
+) *WaitlistScheduler {
+	return &WaitlistScheduler{service: service, interval: interval}
+}
+
+// Run checks for expired offers every interval until ctx is cancelled.
+func (s *WaitlistScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.service.ExpireWaitlistOffers(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
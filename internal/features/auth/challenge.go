@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/sessions"
+	"github.com/olazo-johnalbert/duckload-api/internal/features/twofactor"
+)
+
+// loginChallengePurpose marks login challenges in the token store.
+const loginChallengePurpose = "login_challenge"
+
+var ErrInvalidChallenge = errors.New("invalid or expired login challenge")
+
+// createLoginChallenge records that the user entered the right password
+// and returns the token that completes the login with a second factor.
+func (s *Service) createLoginChallenge(
+	ctx context.Context,
+	userID string,
+) (string, error) {
+	challengeToken := uuid.NewString()
+
+	err := s.sessionService.StoreToken(
+		ctx,
+		sessions.NewJTI(challengeToken),
+		map[string]string{
+			"purpose": loginChallengePurpose,
+			"userID":  userID,
+		},
+		constants.LoginChallengeMaxAge,
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to store login challenge: %v", err)
+	}
+
+	return challengeToken, nil
+}
+
+// VerifyLoginChallenge completes a login started by AuthenticateUser with
+// a code from the authenticator app or a recovery code. Wrong codes count
+// towards the login lockout and, after a few, invalidate the challenge.
+// The result carries the user's ID and email even when verification fails,
+// so the attempt can be audited.
+func (s *Service) VerifyLoginChallenge(
+	ctx context.Context,
+	challengeToken, code, ipAddress, userAgent string,
+) (*LoginResult, error) {
+	jti := sessions.NewJTI(challengeToken)
+
+	val, err := s.sessionService.GetToken(ctx, jti)
+	if err != nil || val["purpose"] != loginChallengePurpose {
+		return nil, ErrInvalidChallenge
+	}
+
+	user, err := s.repo.GetUserByID(ctx, val["userID"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user: %v", err)
+	}
+	attempt := &LoginResult{UserID: user.ID, Email: user.Email}
+	if user.IsActive == 0 {
+		return attempt, ErrInvalidChallenge
+	}
+
+	lock, err := s.lockout.Check(ctx, user.Email, ipAddress)
+	if err != nil {
+		log.Printf("[VerifyLoginChallenge] {Check Lockout}: %v", err)
+		return attempt, errors.New("Login is temporarily unavailable")
+	}
+	if lock != nil {
+		return attempt, &LoginLockedError{Lock: *lock}
+	}
+
+	usedRecoveryCode, err := s.twoFactor.Verify(ctx, user.ID, code)
+	switch {
+	case errors.Is(err, twofactor.ErrInvalidCode):
+		s.countWrongCode(
+			ctx,
+			jti,
+			jti.ToChallengeAttemptsKey(),
+			constants.LoginChallengeMaxAge,
+			constants.LoginChallengeMaxAttempts,
+		)
+
+		var locked *LoginLockedError
+		if err := s.loginFailed(ctx, user.Email, ipAddress); errors.As(
+			err,
+			&locked,
+		) {
+			return attempt, err
+		}
+		return attempt, twofactor.ErrInvalidCode
+	case errors.Is(err, twofactor.ErrNotEnabled):
+		// Two-factor was turned off after the password was checked
+		return attempt, ErrInvalidChallenge
+	case err != nil:
+		return attempt, err
+	}
+
+	// Deleting the challenge claims it, so it completes at most one login
+	deleted, err := s.redis.Client.Del(ctx, jti.ToSessionKey()).Result()
+	if err != nil {
+		return attempt, fmt.Errorf("failed to claim login challenge: %v", err)
+	}
+	if deleted == 0 {
+		return attempt, ErrInvalidChallenge
+	}
+	_ = s.redis.Del(ctx, jti.ToChallengeAttemptsKey())
+
+	if err := s.lockout.RecordSuccess(ctx, user.Email); err != nil {
+		log.Printf("[VerifyLoginChallenge] {Clear Failures}: %v", err)
+	}
+
+	result, err := s.issueNativeSession(ctx, user, ipAddress, userAgent, false)
+	if err != nil {
+		return attempt, err
+	}
+	result.UsedRecoveryCode = usedRecoveryCode
+
+	return result, nil
+}
@@ -34,6 +34,25 @@ type LoginDTO struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// LoginVerifyDTO completes a two-factor login. Code is a code from the
+// authenticator app or a recovery code.
+type LoginVerifyDTO struct {
+	ChallengeToken string `json:"challengeToken" binding:"required,uuid"`
+	Code           string `json:"code"           binding:"required"`
+}
+
+// LoginResult is the outcome of a native login. Either the token pair or,
+// when a second factor is needed, ChallengeToken is set.
+type LoginResult struct {
+	UserID                 string
+	Email                  string
+	AccessToken            string
+	RefreshToken           string
+	ChallengeToken         string
+	TwoFactorSetupRequired bool
+	UsedRecoveryCode       bool
+}
+
 type RegisterDTO struct {
 	Email      string `json:"email"      binding:"required,email"`
 	Password   string `json:"password"   binding:"required,min=8"`
@@ -46,3 +65,17 @@ type RegisterDTO struct {
 type VerifyDTO struct {
 	VerificationOTP string `json:"otp" binding:"required"`
 }
+
+type ForgotPasswordDTO struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordDTO struct {
+	OTP         string `json:"otp"         binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required,min=8"`
+}
+
+type ChangePasswordDTO struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword"     binding:"required,min=8"`
+}
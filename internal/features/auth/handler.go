@@ -1,41 +1,53 @@
 package auth
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/audit"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/config"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/lockout"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/response"
-	"github.com/olazo-johnalbert/duckload-api/internal/core/sessions"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/structs"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/tokens"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/logs"
+	"github.com/olazo-johnalbert/duckload-api/internal/features/twofactor"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/identity/idp"
 )
 
 type Handler struct {
 	service    ServiceInterface
 	logService logs.ServiceInterface
+	tokens     *tokens.Service
 	cfg        *config.Config
 }
 
 func NewHandler(
 	s ServiceInterface,
 	logService logs.ServiceInterface,
+	tokenService *tokens.Service,
 	cfg *config.Config,
 ) *Handler {
-	return &Handler{service: s, logService: logService, cfg: cfg}
+	return &Handler{
+		service:    s,
+		logService: logService,
+		tokens:     tokenService,
+		cfg:        cfg,
+	}
 }
 
 // PostLogin godoc
 // @Summary      User login
-// @Description  Authenticates a user and sets JWT cookies.
+// @Description  Authenticates a user and sets JWT cookies. Users with two-factor authentication get a challenge token instead, to be completed at /auth/login/verify.
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
@@ -44,6 +56,7 @@ func NewHandler(
 // (optional)"
 // @Failure      400     {object}  map[string]string
 // @Failure      401     {object}  map[string]string
+// @Failure      429     {object}  map[string]string
 // @Router       /auth/login [post]
 func (h *Handler) PostLogin(c *gin.Context) {
 	var req LoginDTO
@@ -55,7 +68,7 @@ func (h *Handler) PostLogin(c *gin.Context) {
 	ip := c.ClientIP()
 	ua := c.Request.UserAgent()
 
-	userID, token, refreshToken, err := h.service.AuthenticateUser(
+	result, err := h.service.AuthenticateUser(
 		c,
 		req.Email,
 		req.Password,
@@ -78,13 +91,19 @@ func (h *Handler) PostLogin(c *gin.Context) {
 					req.Email,
 					err.Error(),
 				),
-				UserID:    structs.StringToNullableString(userID),
 				UserEmail: structs.StringToNullableString(req.Email),
 				IPAddress: structs.StringToNullableString(ip),
 				UserAgent: structs.StringToNullableString(ua),
 			},
 		)
 		log.Printf("[PostLogin] {AuthenticateUser}: %v", err)
+
+		var locked *LoginLockedError
+		if errors.As(err, &locked) {
+			h.sendLoginLocked(c, locked, req.Email)
+			return
+		}
+
 		response.SendFail(
 			c,
 			gin.H{"error": err.Error()},
@@ -93,27 +112,181 @@ func (h *Handler) PostLogin(c *gin.Context) {
 		return
 	}
 
-	// Set cookies
-	h.setAuthCookies(c, token, refreshToken)
+	if result.ChallengeToken != "" {
+		h.clearAuthCookies(c)
+		response.SendSuccess(c, gin.H{
+			"message":           "Two-factor authentication required",
+			"twoFactorRequired": true,
+			"challengeToken":    result.ChallengeToken,
+			"expiresIn":         constants.LoginChallengeMaxAge,
+		})
+		return
+	}
+
+	h.completeLogin(c, result)
+}
 
-	// Log success
-	h.logService.Record(
+// PostLoginVerify godoc
+// @Summary      Verify two-factor login
+// @Description  Exchanges the challenge token from /auth/login and a code from the authenticator app, or a recovery code, for JWT cookies.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body      LoginVerifyDTO true "Challenge Token and Code"
+// @Success      200     {object}  map[string]interface{}
+// @Failure      400     {object}  map[string]string
+// @Failure      401     {object}  map[string]string
+// @Failure      429     {object}  map[string]string
+// @Router       /auth/login/verify [post]
+func (h *Handler) PostLoginVerify(c *gin.Context) {
+	var req LoginVerifyDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendFail(c, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	ip := c.ClientIP()
+	ua := c.Request.UserAgent()
+
+	result, err := h.service.VerifyLoginChallenge(
 		c.Request.Context(),
-		h.logService.GetDB(),
-		audit.LogEntry{
-			Level:     audit.LevelInfo,
-			Category:  audit.CategorySecurity,
-			Action:    audit.ActionLoginSuccess,
-			Message:   fmt.Sprintf("User %s logged in successfully", req.Email),
-			UserID:    structs.StringToNullableString(userID),
-			UserEmail: structs.StringToNullableString(req.Email),
-			IPAddress: structs.StringToNullableString(ip),
-			UserAgent: structs.StringToNullableString(ua),
-		},
+		req.ChallengeToken,
+		req.Code,
+		ip,
+		ua,
 	)
+	if err != nil {
+		h.clearAuthCookies(c)
+
+		var userID, userEmail string
+		if result != nil {
+			userID, userEmail = result.UserID, result.Email
+		}
 
-	// Optionally return user info (but no tokens)
-	response.SendSuccess(c, gin.H{"message": "Login successful"})
+		var locked *LoginLockedError
+		switch {
+		case errors.As(err, &locked):
+			h.sendLoginLocked(c, locked, userEmail)
+			return
+		case errors.Is(err, twofactor.ErrInvalidCode):
+			h.recordSecurity(c, audit.LogEntry{
+				Level:  audit.LevelWarning,
+				Action: audit.ActionTwoFactorFailed,
+				Message: fmt.Sprintf(
+					"Wrong two-factor code during login for %s",
+					userEmail,
+				),
+				UserID:    structs.StringToNullableString(userID),
+				UserEmail: structs.StringToNullableString(userEmail),
+			})
+			response.SendFail(
+				c,
+				gin.H{"error": err.Error()},
+				http.StatusUnauthorized,
+			)
+			return
+		case errors.Is(err, ErrInvalidChallenge):
+			response.SendFail(
+				c,
+				gin.H{"error": err.Error()},
+				http.StatusUnauthorized,
+			)
+			return
+		}
+
+		log.Printf("[PostLoginVerify] {VerifyLoginChallenge}: %v", err)
+		response.SendError(
+			c,
+			"Failed to complete login",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	if result.UsedRecoveryCode {
+		h.recordSecurity(c, audit.LogEntry{
+			Level:  audit.LevelWarning,
+			Action: audit.ActionRecoveryCodeUsed,
+			Message: fmt.Sprintf(
+				"User %s signed in with a recovery code",
+				result.Email,
+			),
+			UserID:    structs.StringToNullableString(result.UserID),
+			UserEmail: structs.StringToNullableString(result.Email),
+		})
+	}
+
+	h.completeLogin(c, result)
+}
+
+// completeLogin sets the session cookies of a successful login.
+func (h *Handler) completeLogin(c *gin.Context, result *LoginResult) {
+	h.setAuthCookies(c, result.AccessToken, result.RefreshToken)
+
+	h.recordSecurity(c, audit.LogEntry{
+		Level:     audit.LevelInfo,
+		Action:    audit.ActionLoginSuccess,
+		Message:   fmt.Sprintf("User %s logged in successfully", result.Email),
+		UserID:    structs.StringToNullableString(result.UserID),
+		UserEmail: structs.StringToNullableString(result.Email),
+	})
+
+	data := gin.H{"message": "Login successful"}
+	if result.TwoFactorSetupRequired {
+		data["twoFactorSetupRequired"] = true
+	}
+	response.SendSuccess(c, data)
+}
+
+// sendLoginLocked responds to a login refused by the lockout, auditing the
+// attempt that started it.
+func (h *Handler) sendLoginLocked(
+	c *gin.Context,
+	locked *LoginLockedError,
+	email string,
+) {
+	ip := c.ClientIP()
+
+	if locked.Started {
+		message := fmt.Sprintf(
+			"Locked logins to %s for %s after %d failed attempts (IP %s)",
+			email,
+			locked.RetryAfter.Round(time.Second),
+			locked.Failures,
+			ip,
+		)
+		if locked.Scope == lockout.ScopeIP {
+			message = fmt.Sprintf(
+				"Locked logins from IP %s for %s after %d failed attempts (last tried %s)",
+				ip,
+				locked.RetryAfter.Round(time.Second),
+				locked.Failures,
+				email,
+			)
+		}
+
+		h.logService.RecordSecurity(
+			c.Request.Context(),
+			h.logService.GetDB(),
+			audit.ActionLoginLocked,
+			message,
+			structs.StringToNullableString(email),
+			structs.NullableString{},
+			structs.StringToNullableString(ip),
+			structs.StringToNullableString(c.Request.UserAgent()),
+		)
+	}
+
+	c.Header(
+		"Retry-After",
+		strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))),
+	)
+	response.SendFail(
+		c,
+		gin.H{"error": locked.Error()},
+		http.StatusTooManyRequests,
+	)
 }
 
 // PostRegister godoc
@@ -217,39 +390,22 @@ func (h *Handler) PostVerify(c *gin.Context) {
 
 // PostRefreshToken godoc
 // @Summary      Refresh JWT token
-// @Description  Refreshes the JWT token using the refresh token cookie.
+// @Description  Issues a new access token and refresh token from the refresh
+// token cookie. Each refresh token works once; presenting a used one signs
+// out every session that descends from the same login.
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
-// @Success      200 {object} map[string]string "New access token (optional)"
+// @Success      200 {object} map[string]string "Session refreshed"
 // @Failure      401 {object} map[string]string
+// @Failure      409 {object} map[string]string "Refresh already in progress"
 // @Router       /auth/refresh [post]
 func (h *Handler) PostRefreshToken(c *gin.Context) {
-	// Get Access Token (which contains the session Handle/JTI)
-	accessToken, err := c.Cookie(constants.AccessTokenCookieName)
-	if err != nil {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader != "" && strings.HasPrefix(authHeader, "Bearer ") {
-			accessToken = strings.TrimPrefix(authHeader, "Bearer ")
-		}
-	}
-
-	if accessToken == "" {
+	refreshToken, err := c.Cookie(constants.RefreshTokenCookieName)
+	if err != nil || refreshToken == "" {
 		response.SendFail(
 			c,
-			gin.H{"error": "Access token missing"},
-			http.StatusUnauthorized,
-		)
-		return
-	}
-
-	// Parse token UNVERIFIED to get the JTI (it might be expired)
-	tokenService := tokens.NewService()
-	claims, err := tokenService.ParseTokenUnverified(accessToken)
-	if err != nil {
-		response.SendFail(
-			c,
-			gin.H{"error": "Invalid session handle"},
+			gin.H{"error": "Refresh token missing"},
 			http.StatusUnauthorized,
 		)
 		return
@@ -258,18 +414,57 @@ func (h *Handler) PostRefreshToken(c *gin.Context) {
 	ip := c.ClientIP()
 	ua := c.Request.UserAgent()
 
-	// Refresh using the JTI
-	newToken, _, err := h.service.RefreshToken(
+	newToken, newRefreshToken, err := h.service.RefreshToken(
 		c,
-		sessions.NewJTI(claims.ID),
+		refreshToken,
 		h.cfg,
 		ip,
 		ua,
 	)
 	if err != nil {
+		// Another request (e.g. a second tab) just rotated this token; its
+		// response carries the new cookies, so keep the current ones
+		if errors.Is(err, ErrRefreshInProgress) {
+			response.SendFail(
+				c,
+				gin.H{"error": err.Error()},
+				http.StatusConflict,
+			)
+			return
+		}
+
 		// Clear cookies on failure to prevent stale sessions
 		h.clearAuthCookies(c)
 
+		var reused *RefreshTokenReusedError
+		if errors.As(err, &reused) {
+			h.logService.Record(
+				c.Request.Context(),
+				h.logService.GetDB(),
+				audit.LogEntry{
+					Level:    audit.LevelCritical,
+					Category: audit.CategorySecurity,
+					Action:   audit.ActionRefreshTokenReused,
+					Message: fmt.Sprintf(
+						"A used refresh token for %s was presented from %s; "+
+							"all sessions from that login were signed out",
+						reused.UserEmail,
+						ip,
+					),
+					UserID:    structs.StringToNullableString(reused.UserID),
+					IPAddress: structs.StringToNullableString(ip),
+					UserAgent: structs.StringToNullableString(ua),
+				},
+			)
+			log.Printf("[PostRefreshToken] {RefreshToken}: %v", err)
+			response.SendFail(
+				c,
+				gin.H{"error": "Session expired or invalid"},
+				http.StatusUnauthorized,
+			)
+			return
+		}
+
 		h.logService.Record(
 			c.Request.Context(),
 			h.logService.GetDB(),
@@ -291,7 +486,7 @@ func (h *Handler) PostRefreshToken(c *gin.Context) {
 		return
 	}
 
-	h.setAuthCookies(c, newToken, "")
+	h.setAuthCookies(c, newToken, newRefreshToken)
 
 	response.SendSuccess(c, gin.H{"message": "Session refreshed"})
 }
@@ -4,7 +4,6 @@ import (
 	"context"
 
 	"github.com/olazo-johnalbert/duckload-api/internal/core/config"
-	"github.com/olazo-johnalbert/duckload-api/internal/core/sessions"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/users"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/identity/idp"
@@ -14,7 +13,11 @@ type ServiceInterface interface {
 	AuthenticateUser(
 		ctx context.Context,
 		email, password, ipAddress, userAgent string,
-	) (string, string, string, error)
+	) (*LoginResult, error)
+	VerifyLoginChallenge(
+		ctx context.Context,
+		challengeToken, code, ipAddress, userAgent string,
+	) (*LoginResult, error)
 	RegisterUser(
 		ctx context.Context,
 		req RegisterDTO,
@@ -27,7 +30,7 @@ type ServiceInterface interface {
 	) (string, string, error)
 	RefreshToken(
 		ctx context.Context,
-		accessTokenJTI sessions.JTIDTO,
+		refreshToken string,
 		cfg *config.Config,
 		ipAddress, userAgent string,
 	) (string, string, error)
@@ -62,6 +65,24 @@ type ServiceInterface interface {
 	) (*idp.IDPSessionResponse, error)
 	BlockUser(ctx context.Context, userID string) error
 	UnblockUser(ctx context.Context, userID string) error
+	RequestPasswordReset(
+		ctx context.Context,
+		email string,
+		ipAddress string,
+	) (string, string, error)
+	ResetPassword(
+		ctx context.Context,
+		resetID string,
+		otp string,
+		newPassword string,
+	) (*users.User, error)
+	ChangePassword(
+		ctx context.Context,
+		userID string,
+		currentJTI string,
+		currentPassword string,
+		newPassword string,
+	) (int, error)
 }
 
 type RepositoryInterface interface {
@@ -75,5 +96,11 @@ type RepositoryInterface interface {
 	CreateUser(ctx context.Context, tx datastore.DB, user users.User) error
 	BlockUser(ctx context.Context, tx datastore.DB, userID string) error
 	UnblockUser(ctx context.Context, tx datastore.DB, userID string) error
+	UpdatePassword(
+		ctx context.Context,
+		tx datastore.DB,
+		userID string,
+		passwordHash string,
+	) error
 	CheckUserWhitelist(ctx context.Context, email string) (int, error)
 }
@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/tokens"
+)
+
+// GetJWKS godoc
+// @Summary      Token signing keys
+// @Description  Publishes the public keys access tokens are signed with, as a JSON Web Key Set. Tokens name their key in the kid header.
+// @Tags         Auth
+// @Produce      json
+// @Success      200     {object}  tokens.JWKSet
+// @Router       /.well-known/jwks.json [get]
+func (h *Handler) GetJWKS(c *gin.Context) {
+	// Keys are published for longer than this before they sign, so a
+	// cached set never misses the active key
+	c.Header(
+		"Cache-Control",
+		fmt.Sprintf("public, max-age=%d", int(tokens.JWKSMaxAge.Seconds())),
+	)
+
+	// Served bare rather than in the response envelope, as JWKS clients
+	// expect
+	c.JSON(http.StatusOK, h.tokens.JWKS())
+}
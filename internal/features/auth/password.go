@@ -0,0 +1,338 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/lockout"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/sessions"
+	"github.com/olazo-johnalbert/duckload-api/internal/features/users"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/email"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// passwordResetPurpose marks reset entries in the token store, which
+// registrations share.
+const passwordResetPurpose = "password_reset"
+
+var (
+	ErrInvalidResetCode  = errors.New("invalid or expired reset code")
+	ErrIncorrectPassword = errors.New("current password is incorrect")
+	ErrSamePassword      = errors.New(
+		"new password must differ from the current password",
+	)
+	ErrPasswordNotSet       = errors.New("account does not use a password")
+	ErrTooManyResetRequests = errors.New(
+		"too many password reset requests; try again later",
+	)
+)
+
+// RequestPasswordReset emails a reset code to the native account with the
+// given email. It returns a reset ID whether or not the account exists, so
+// the response does not reveal which emails are registered. The user ID is
+// empty when no reset was started. Only ErrTooManyResetRequests, for an IP
+// address over its limit, is returned as an error.
+func (s *Service) RequestPasswordReset(
+	ctx context.Context,
+	emailAddress string,
+	ipAddress string,
+) (string, string, error) {
+	resetID := uuid.NewString()
+
+	if ipAddress != "" {
+		requests, err := s.sessionService.CountAttempt(
+			ctx,
+			resetRequestsKey(lockout.ScopeIP, ipAddress),
+			constants.PasswordResetRequestWindow,
+		)
+		if err != nil {
+			log.Printf("[RequestPasswordReset] {Count IP Requests}: %v", err)
+		}
+		if requests > constants.PasswordResetMaxRequestsPerIP {
+			return "", "", ErrTooManyResetRequests
+		}
+	}
+
+	// Counted before the lookup so unknown emails take the same path. An
+	// account over its limit gets the usual response but no new code.
+	requests, err := s.sessionService.CountAttempt(
+		ctx,
+		resetRequestsKey(
+			lockout.ScopeAccount,
+			strings.ToLower(strings.TrimSpace(emailAddress)),
+		),
+		constants.PasswordResetRequestWindow,
+	)
+	if err != nil {
+		log.Printf("[RequestPasswordReset] {Count Account Requests}: %v", err)
+	}
+	if requests > constants.PasswordResetMaxRequestsPerAccount {
+		return resetID, "", nil
+	}
+
+	user, err := s.repo.GetUserByEmail(
+		ctx,
+		emailAddress,
+		string(constants.AuthTypeNative),
+	)
+	if err != nil || user.IsActive == 0 || !user.PasswordHash.Valid {
+		return resetID, "", nil
+	}
+
+	// Hashing and mailing the code take long enough to tell a registered
+	// email apart by response time, so they happen after responding
+	go s.sendResetCode(context.WithoutCancel(ctx), resetID, user)
+
+	return resetID, user.ID, nil
+}
+
+// sendResetCode stores a new reset code under resetID and emails it. The
+// caller has already responded, so failures are only logged.
+func (s *Service) sendResetCode(
+	ctx context.Context,
+	resetID string,
+	user *users.User,
+) {
+	otp, err := s.get6DigitOTP()
+	if err != nil {
+		log.Printf("[sendResetCode] {Generate Code}: %v", err)
+		return
+	}
+	hashedOTP, err := bcrypt.GenerateFromPassword(
+		[]byte(otp),
+		bcrypt.DefaultCost,
+	)
+	if err != nil {
+		log.Printf("[sendResetCode] {Hash Code}: %v", err)
+		return
+	}
+
+	err = s.sessionService.StoreToken(
+		ctx,
+		sessions.NewJTI(resetID),
+		map[string]string{
+			"purpose":   passwordResetPurpose,
+			"userID":    user.ID,
+			"resetCode": string(hashedOTP),
+		},
+		constants.PasswordResetMaxAge,
+	)
+	if err != nil {
+		log.Printf("[sendResetCode] {Store Code}: %v", err)
+		return
+	}
+
+	// A new code replaces the user's previous one, so every code gets at
+	// most PasswordResetMaxAttempts guesses in total
+	previous, err := s.redis.Client.GetSet(
+		ctx,
+		activeResetKey(user.ID),
+		resetID,
+	).Result()
+	if err != nil && err != redis.Nil {
+		log.Printf("[sendResetCode] {Replace Active Reset}: %v", err)
+	}
+	s.redis.Client.Expire(
+		ctx,
+		activeResetKey(user.ID),
+		time.Duration(constants.PasswordResetMaxAge)*time.Second,
+	)
+	if previous != "" && previous != resetID {
+		previousJTI := sessions.NewJTI(previous)
+		_ = s.sessionService.DeleteToken(ctx, previousJTI)
+		_ = s.redis.Del(ctx, previousJTI.ToResetAttemptsKey())
+	}
+
+	validFor := time.Duration(constants.PasswordResetMaxAge) * time.Second
+	_, err = s.emailer.SendEmail(
+		ctx,
+		user.Email,
+		"Password Reset Code",
+		email.PASSWORD_RESET_TEMPLATE(
+			html.EscapeString(user.FirstName),
+			otp,
+			fmt.Sprintf("%d minutes", int(validFor.Minutes())),
+		),
+	)
+	if err != nil {
+		log.Printf("[sendResetCode] {Send Email}: %v", err)
+	}
+}
+
+// ResetPassword sets a new password using a code from RequestPasswordReset
+// and signs the user out everywhere. A code works once; too many wrong
+// codes invalidate the reset.
+func (s *Service) ResetPassword(
+	ctx context.Context,
+	resetID string,
+	otp string,
+	newPassword string,
+) (*users.User, error) {
+	jti := sessions.NewJTI(resetID)
+
+	val, err := s.sessionService.GetToken(ctx, jti)
+	if err != nil || val["purpose"] != passwordResetPurpose {
+		return nil, ErrInvalidResetCode
+	}
+
+	// Only the user's latest code is redeemable
+	active, err := s.redis.Get(ctx, activeResetKey(val["userID"]))
+	if err != nil || active != resetID {
+		_ = s.sessionService.DeleteToken(ctx, jti)
+		return nil, ErrInvalidResetCode
+	}
+
+	err = bcrypt.CompareHashAndPassword(
+		[]byte(val["resetCode"]),
+		[]byte(otp),
+	)
+	if err != nil {
+		s.countWrongCode(
+			ctx,
+			jti,
+			jti.ToResetAttemptsKey(),
+			constants.PasswordResetMaxAge,
+			constants.PasswordResetMaxAttempts,
+		)
+		return nil, ErrInvalidResetCode
+	}
+
+	// Deleting the entry claims it, so a code raced from two requests is
+	// only used once
+	deleted, err := s.redis.Client.Del(ctx, jti.ToSessionKey()).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim reset code: %v", err)
+	}
+	if deleted == 0 {
+		return nil, ErrInvalidResetCode
+	}
+	_ = s.redis.Del(ctx, jti.ToResetAttemptsKey())
+	_ = s.redis.Del(ctx, activeResetKey(val["userID"]))
+
+	user, err := s.repo.GetUserByID(ctx, val["userID"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user: %v", err)
+	}
+	if user.IsActive == 0 {
+		return nil, ErrInvalidResetCode
+	}
+
+	if err := s.setPassword(ctx, user.ID, newPassword); err != nil {
+		return nil, err
+	}
+	// Proving control of the mailbox also lifts a lockout on the account
+	if err := s.lockout.Unlock(ctx, user.Email); err != nil {
+		log.Printf("[ResetPassword] {Unlock Account}: %v", err)
+	}
+	if _, err := s.sessionService.RevokeUserSessions(
+		ctx,
+		user.ID,
+		"",
+	); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// countWrongCode counts a wrong code entered for the token store entry jti
+// and deletes the entry once maxAttempts is reached.
+func (s *Service) countWrongCode(
+	ctx context.Context,
+	jti sessions.JTIDTO,
+	attemptsKey string,
+	maxAge int,
+	maxAttempts int64,
+) {
+	attempts, err := s.redis.Client.Incr(ctx, attemptsKey).Result()
+	if err != nil {
+		log.Printf("[countWrongCode] {Count Attempt}: %v", err)
+		return
+	}
+	s.redis.Client.Expire(ctx, attemptsKey, time.Duration(maxAge)*time.Second)
+
+	if attempts >= maxAttempts {
+		_ = s.sessionService.DeleteToken(ctx, jti)
+		_ = s.redis.Del(ctx, attemptsKey)
+	}
+}
+
+// ChangePassword replaces the password of a signed-in native user and
+// signs out every other session. It returns how many were signed out.
+func (s *Service) ChangePassword(
+	ctx context.Context,
+	userID string,
+	currentJTI string,
+	currentPassword string,
+	newPassword string,
+) (int, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch user: %v", err)
+	}
+	if user.AuthType != string(constants.AuthTypeNative) ||
+		!user.PasswordHash.Valid {
+		return 0, ErrPasswordNotSet
+	}
+
+	err = bcrypt.CompareHashAndPassword(
+		[]byte(user.PasswordHash.String),
+		[]byte(currentPassword),
+	)
+	if err != nil {
+		return 0, ErrIncorrectPassword
+	}
+	if currentPassword == newPassword {
+		return 0, ErrSamePassword
+	}
+
+	if err := s.setPassword(ctx, user.ID, newPassword); err != nil {
+		return 0, err
+	}
+
+	return s.sessionService.RevokeUserSessions(ctx, user.ID, currentJTI)
+}
+
+func (s *Service) setPassword(
+	ctx context.Context,
+	userID string,
+	password string,
+) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword(
+		[]byte(password),
+		bcrypt.DefaultCost,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %v", err)
+	}
+
+	return datastore.RunInTransaction(
+		ctx,
+		s.repo.(*users.Repository).GetDB(),
+		func(tx datastore.DB) error {
+			return s.repo.UpdatePassword(ctx, tx, userID, string(hashedPassword))
+		},
+	)
+}
+
+func activeResetKey(userID string) string {
+	return constants.RedisActiveResetKeyPrefix + userID
+}
+
+func resetRequestsKey(scope, value string) string {
+	return fmt.Sprintf(
+		"%s%s:%s",
+		constants.RedisResetRequestsKeyPrefix,
+		scope,
+		value,
+	)
+}
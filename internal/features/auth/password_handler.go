@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/audit"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/response"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/structs"
+)
+
+// PostForgotPassword godoc
+// @Summary      Request a password reset
+// @Description  Emails a reset code to a native account, replacing any
+// earlier code. The response is the same whether or not the email is
+// registered. Requests are limited per account and per IP address.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body      ForgotPasswordDTO true "Account Email"
+// @Success      200     {object}  map[string]string "Reset ID"
+// @Failure      400     {object}  map[string]string
+// @Failure      429     {object}  map[string]string
+// @Router       /auth/password/forgot [post]
+func (h *Handler) PostForgotPassword(c *gin.Context) {
+	var req ForgotPasswordDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendFail(c, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	resetID, userID, err := h.service.RequestPasswordReset(
+		c.Request.Context(),
+		req.Email,
+		c.ClientIP(),
+	)
+	if errors.Is(err, ErrTooManyResetRequests) {
+		response.SendFail(
+			c,
+			gin.H{"error": err.Error()},
+			http.StatusTooManyRequests,
+		)
+		return
+	}
+
+	message := fmt.Sprintf("Password reset requested for %s", req.Email)
+	if userID == "" {
+		message += " (no code sent)"
+	}
+	h.recordSecurity(c, audit.LogEntry{
+		Level:     audit.LevelInfo,
+		Action:    audit.ActionPasswordResetRequested,
+		Message:   message,
+		UserID:    structs.StringToNullableString(userID),
+		UserEmail: structs.StringToNullableString(req.Email),
+	})
+
+	response.SendSuccess(c, gin.H{
+		"resetId": resetID,
+		"message": "If the account exists, a reset code was sent to its email",
+	})
+}
+
+// PostResetPassword godoc
+// @Summary      Reset a forgotten password
+// @Description  Sets a new password with the emailed reset code and signs
+// the user out of every session.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        reset_id query     string           true "Reset ID"
+// @Param        request  body      ResetPasswordDTO true "Code and New Password"
+// @Success      200      {object}  map[string]string
+// @Failure      400      {object}  map[string]string
+// @Router       /auth/password/reset [post]
+func (h *Handler) PostResetPassword(c *gin.Context) {
+	resetID := c.Query("reset_id")
+	if resetID == "" {
+		response.SendFail(c, gin.H{"error": "Reset ID is required"})
+		return
+	}
+
+	var req ResetPasswordDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendFail(c, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	user, err := h.service.ResetPassword(
+		c.Request.Context(),
+		resetID,
+		req.OTP,
+		req.NewPassword,
+	)
+	if errors.Is(err, ErrInvalidResetCode) {
+		h.recordSecurity(c, audit.LogEntry{
+			Level:   audit.LevelWarning,
+			Action:  audit.ActionPasswordResetFailed,
+			Message: fmt.Sprintf("Invalid reset code for reset %s", resetID),
+		})
+		response.SendFail(c, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("[PostResetPassword] {ResetPassword}: %v", err)
+		response.SendError(
+			c,
+			"Failed to reset password",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	h.clearAuthCookies(c)
+	h.recordSecurity(c, audit.LogEntry{
+		Level:  audit.LevelInfo,
+		Action: audit.ActionPasswordReset,
+		Message: fmt.Sprintf(
+			"User %s reset their password; all sessions were revoked",
+			user.Email,
+		),
+		UserID:    structs.StringToNullableString(user.ID),
+		UserEmail: structs.StringToNullableString(user.Email),
+	})
+
+	response.SendSuccess(c, gin.H{"message": "Password reset successfully"})
+}
+
+// PostChangePassword godoc
+// @Summary      Change password
+// @Description  Changes the signed-in user's password and signs out their
+// other sessions.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body      ChangePasswordDTO true "Current and New Password"
+// @Success      200     {object}  map[string]interface{}
+// @Failure      400     {object}  map[string]string
+// @Failure      401     {object}  map[string]string
+// @Router       /auth/password/change [post]
+func (h *Handler) PostChangePassword(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	userEmail := c.GetString("userEmail")
+
+	var req ChangePasswordDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendFail(c, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	// The current session is kept; the middleware already validated it
+	var currentJTI string
+	if claims, err := h.tokens.ParseTokenUnverified(
+		h.accessToken(c),
+	); err == nil {
+		currentJTI = claims.ID
+	}
+
+	revoked, err := h.service.ChangePassword(
+		c.Request.Context(),
+		userID,
+		currentJTI,
+		req.CurrentPassword,
+		req.NewPassword,
+	)
+	switch {
+	case errors.Is(err, ErrIncorrectPassword):
+		h.recordSecurity(c, audit.LogEntry{
+			Level:     audit.LevelWarning,
+			Action:    audit.ActionPasswordChangeFailed,
+			Message:   fmt.Sprintf("Wrong current password for %s", userEmail),
+			UserID:    structs.StringToNullableString(userID),
+			UserEmail: structs.StringToNullableString(userEmail),
+		})
+		response.SendFail(
+			c,
+			gin.H{"error": err.Error()},
+			http.StatusUnauthorized,
+		)
+		return
+	case errors.Is(err, ErrSamePassword), errors.Is(err, ErrPasswordNotSet):
+		response.SendFail(c, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("[PostChangePassword] {ChangePassword}: %v", err)
+		response.SendError(
+			c,
+			"Failed to change password",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	h.recordSecurity(c, audit.LogEntry{
+		Level:  audit.LevelInfo,
+		Action: audit.ActionPasswordChanged,
+		Message: fmt.Sprintf(
+			"User %s changed their password; %d other sessions were revoked",
+			userEmail,
+			revoked,
+		),
+		UserID:    structs.StringToNullableString(userID),
+		UserEmail: structs.StringToNullableString(userEmail),
+	})
+
+	response.SendSuccess(c, gin.H{
+		"message":         "Password changed successfully",
+		"revokedSessions": revoked,
+	})
+}
+
+// accessToken returns the request's access token from the cookie or the
+// Authorization header.
+func (h *Handler) accessToken(c *gin.Context) string {
+	if token, err := c.Cookie(constants.AccessTokenCookieName); err == nil &&
+		token != "" {
+		return token
+	}
+
+	authHeader := c.GetHeader("Authorization")
+	if strings.HasPrefix(authHeader, "Bearer ") {
+		return strings.TrimPrefix(authHeader, "Bearer ")
+	}
+
+	return ""
+}
+
+// recordSecurity writes a SECURITY log entry with the request's client
+// details.
+func (h *Handler) recordSecurity(c *gin.Context, entry audit.LogEntry) {
+	entry.Category = audit.CategorySecurity
+	entry.IPAddress = structs.StringToNullableString(c.ClientIP())
+	entry.UserAgent = structs.StringToNullableString(c.Request.UserAgent())
+
+	h.logService.Record(c.Request.Context(), h.logService.GetDB(), entry)
+}
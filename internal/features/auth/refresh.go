@@ -0,0 +1,252 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/config"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/sessions"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("Session expired or invalid")
+	ErrRefreshInProgress   = errors.New("Session is already being refreshed")
+)
+
+// RefreshTokenReusedError is returned when a refresh token that was already
+// exchanged is presented again. Only one of the two holders can be the
+// user, so every session of the token family has been signed out.
+type RefreshTokenReusedError struct {
+	UserID    string
+	UserEmail string
+	FamilyID  string
+}
+
+func (e *RefreshTokenReusedError) Error() string {
+	return "refresh token was already used"
+}
+
+// newRefreshFamily starts the refresh token family of a new login, held by
+// the session accessJTI, and returns its ID.
+func (s *Service) newRefreshFamily(
+	ctx context.Context,
+	userID, userEmail, accessJTI string,
+) (string, error) {
+	familyID := uuid.NewString()
+
+	err := s.sessionService.StoreRefreshFamily(
+		ctx,
+		familyID,
+		map[string]string{
+			"userID":    userID,
+			"userEmail": userEmail,
+			"accessJTI": accessJTI,
+		},
+		constants.RefreshTokenMaxAge,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return familyID, nil
+}
+
+// RefreshToken exchanges a refresh token for a new access and refresh
+// token. Each refresh token works once; presenting one again revokes its
+// whole family and returns a RefreshTokenReusedError.
+func (s *Service) RefreshToken(
+	ctx context.Context,
+	refreshToken string,
+	cfg *config.Config,
+	ipAddress, userAgent string,
+) (_ string, _ string, err error) {
+	claims, err := s.tokens.ValidateToken(refreshToken)
+	if err != nil || claims.FamilyID == "" || claims.M2MClientID != "" {
+		return "", "", ErrInvalidRefreshToken
+	}
+
+	family, err := s.sessionService.GetRefreshFamily(ctx, claims.FamilyID)
+	if err != nil {
+		return "", "", ErrInvalidRefreshToken
+	}
+
+	refreshJTI := sessions.NewJTI(claims.ID)
+	fresh, firstUsedAt, err := s.sessionService.ClaimRefreshToken(
+		ctx,
+		refreshJTI,
+		constants.RefreshTokenMaxAge,
+	)
+	if err != nil {
+		return "", "", err
+	}
+	if !fresh {
+		if time.Since(firstUsedAt) < constants.RefreshReuseGrace {
+			return "", "", ErrRefreshInProgress
+		}
+
+		s.revokeRefreshFamily(ctx, claims.FamilyID, family)
+		return "", "", &RefreshTokenReusedError{
+			UserID:    claims.UserID,
+			UserEmail: claims.UserEmail,
+			FamilyID:  claims.FamilyID,
+		}
+	}
+
+	// A rotation that fails part way, e.g. on an IDP or Redis outage, gives
+	// the token back so the client's retry is not mistaken for reuse
+	defer func() {
+		if err == nil {
+			return
+		}
+		if releaseErr := s.sessionService.ReleaseRefreshToken(
+			ctx,
+			refreshJTI,
+		); releaseErr != nil {
+			log.Printf("[RefreshToken] {Release Claim}: %v", releaseErr)
+		}
+	}()
+
+	accessTokenJTI := sessions.NewJTI(family["accessJTI"])
+	session, err := s.sessionService.GetToken(ctx, accessTokenJTI)
+	if err != nil {
+		// The session was signed out or revoked, which ends its family
+		_ = s.sessionService.DeleteRefreshFamily(ctx, claims.FamilyID)
+		return "", "", ErrInvalidRefreshToken
+	}
+
+	tokenType := string(constants.AuthTypeNative)
+	if claims.TokenType == string(constants.AuthTypeIDP) {
+		tokenType = string(constants.AuthTypeIDP)
+	}
+
+	// Generate NEW App Tokens in the same family
+	newAccessToken, accessClaims, err := s.tokens.GenerateToken(
+		claims.UserEmail,
+		claims.UserID,
+		claims.RoleID,
+		"",
+		tokenType,
+		constants.AccessTokenMaxAge,
+	)
+	if err != nil {
+		return "", "", err
+	}
+
+	newRefreshToken, refreshClaims, err := s.tokens.GenerateRefreshToken(
+		claims.UserEmail,
+		claims.UserID,
+		claims.RoleID,
+		tokenType,
+		claims.FamilyID,
+		constants.RefreshTokenMaxAge,
+	)
+	if err != nil {
+		return "", "", err
+	}
+
+	val := map[string]string{
+		"userID":          claims.UserID,
+		"tokenType":       tokenType,
+		"appRefreshToken": newRefreshToken,
+		"ipAddress":       ipAddress,
+		"userAgent":       userAgent,
+	}
+
+	if tokenType == string(constants.AuthTypeIDP) {
+		// Get IDP refresh token from Redis
+		idpRefreshKey := sessions.NewJTI(claims.ID).ToIDPRefreshKey()
+		idpRefreshToken, err := s.redis.Get(ctx, idpRefreshKey)
+		if err != nil {
+			return "", "", fmt.Errorf(
+				"[AuthService] {Get IDP Refresh Token}: idp token missing",
+			)
+		}
+
+		// Call IDP refresh endpoint
+		tokenResp, err := s.idpClient.RefreshToken(ctx, idpRefreshToken, cfg)
+		if err != nil {
+			return "", "", fmt.Errorf("[AuthService] {IDP Refresh}: %w", err)
+		}
+		val["idpAccessToken"] = tokenResp.AccessToken
+
+		// Link the IDP refresh token to the NEW App Refresh Token's ID
+		newIdpRefreshKey := sessions.NewJTI(refreshClaims.ID).ToIDPRefreshKey()
+		idpRefreshTokenToStore := tokenResp.RefreshToken
+		if idpRefreshTokenToStore == "" {
+			idpRefreshTokenToStore = idpRefreshToken
+		}
+		err = s.redis.Set(
+			ctx,
+			newIdpRefreshKey,
+			idpRefreshTokenToStore,
+			time.Duration(constants.RefreshTokenMaxAge)*time.Second,
+		)
+		if err != nil {
+			return "", "", err
+		}
+		_ = s.redis.Del(ctx, idpRefreshKey)
+	}
+
+	// A refreshed session stays restricted until two-factor is enabled
+	if flag := session[constants.SessionTwoFactorSetupRequired]; flag != "" {
+		val[constants.SessionTwoFactorSetupRequired] = flag
+	}
+
+	err = s.sessionService.StoreUserToken(
+		ctx,
+		claims.UserID,
+		sessions.NewJTI(accessClaims.ID),
+		val,
+		constants.RefreshTokenMaxAge,
+	)
+	if err != nil {
+		return "", "", err
+	}
+
+	family["accessJTI"] = accessClaims.ID
+	err = s.sessionService.StoreRefreshFamily(
+		ctx,
+		claims.FamilyID,
+		family,
+		constants.RefreshTokenMaxAge,
+	)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Clean up OLD session key
+	_ = s.sessionService.DeleteUserToken(ctx, claims.UserID, accessTokenJTI)
+
+	return newAccessToken, newRefreshToken, nil
+}
+
+// revokeRefreshFamily signs out the session holding a refresh token family
+// and invalidates the family's tokens.
+func (s *Service) revokeRefreshFamily(
+	ctx context.Context,
+	familyID string,
+	family map[string]string,
+) {
+	if err := s.sessionService.DeleteRefreshFamily(ctx, familyID); err != nil {
+		log.Printf("[revokeRefreshFamily] {Delete Family}: %v", err)
+	}
+
+	accessJTI := sessions.NewJTI(family["accessJTI"])
+	if session, err := s.sessionService.GetToken(ctx, accessJTI); err == nil {
+		if rClaims, err := s.tokens.ParseTokenUnverified(
+			session["appRefreshToken"],
+		); err == nil {
+			_ = s.redis.Del(ctx, sessions.NewJTI(rClaims.ID).ToIDPRefreshKey())
+		}
+	}
+
+	err := s.sessionService.DeleteUserToken(ctx, family["userID"], accessJTI)
+	if err != nil {
+		log.Printf("[revokeRefreshFamily] {Delete Session}: %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package auth
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/middleware"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/tokens"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
 )
 
@@ -10,22 +11,31 @@ func RegisterRoutes(
 	rg *gin.RouterGroup,
 	h *Handler,
 	redis *datastore.RedisClient,
+	tokenService *tokens.Service,
 ) {
 	authRoutes := rg.Group("/auth")
 	{
 		authRoutes.POST("/login", h.PostLogin)
+		authRoutes.POST("/login/verify", h.PostLoginVerify)
 		authRoutes.POST("/register", h.PostRegister)
 		authRoutes.POST("/verify/resend", h.PostResendVerification)
 		authRoutes.POST("/verify", h.PostVerify)
 		authRoutes.POST("/refresh", h.PostRefreshToken)
 		authRoutes.GET(
 			"/me",
-			middleware.AuthMiddleware(redis),
+			middleware.AuthMiddleware(redis, tokenService),
 			h.GetMe,
 		)
+		authRoutes.POST("/password/forgot", h.PostForgotPassword)
+		authRoutes.POST("/password/reset", h.PostResetPassword)
+		authRoutes.POST(
+			"/password/change",
+			middleware.AuthMiddleware(redis, tokenService),
+			h.PostChangePassword,
+		)
 		authRoutes.GET(
 			"/logout",
-			middleware.AuthMiddleware(redis),
+			middleware.AuthMiddleware(redis, tokenService),
 			h.GetLogout,
 		)
 
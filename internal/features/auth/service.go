@@ -15,8 +15,10 @@ import (
 	"github.com/google/uuid"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/config"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/lockout"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/sessions"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/tokens"
+	"github.com/olazo-johnalbert/duckload-api/internal/features/twofactor"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/users"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/email"
@@ -30,6 +32,9 @@ type Service struct {
 	idpClient      *idp.IDPClient
 	redis          *datastore.RedisClient
 	sessionService *sessions.Service
+	tokens         *tokens.Service
+	lockout        *lockout.Service
+	twoFactor      twofactor.ServiceInterface
 	emailer        email.Emailer
 }
 
@@ -37,6 +42,9 @@ func NewService(
 	repo RepositoryInterface,
 	redis *datastore.RedisClient,
 	sessionService *sessions.Service,
+	tokenService *tokens.Service,
+	lockoutService *lockout.Service,
+	twoFactorService twofactor.ServiceInterface,
 	emailer email.Emailer,
 ) *Service {
 	return &Service{
@@ -44,10 +52,28 @@ func NewService(
 		idpClient:      idp.NewIDPClient(),
 		redis:          redis,
 		sessionService: sessionService,
+		tokens:         tokenService,
+		lockout:        lockoutService,
+		twoFactor:      twoFactorService,
 		emailer:        emailer,
 	}
 }
 
+// LoginLockedError is returned while too many failed logins lock out the
+// account or the client IP. Started is set on the attempt that began the
+// lockout.
+type LoginLockedError struct {
+	lockout.Lock
+	Started bool
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf(
+		"Too many failed login attempts. Try again in %s",
+		e.RetryAfter.Round(time.Second),
+	)
+}
+
 // RegisterUser handles native user registration.
 func (s *Service) RegisterUser(
 	ctx context.Context,
@@ -297,10 +323,21 @@ func (s *Service) VerifyUser(
 	return user.ID, user.Email, nil
 }
 
-// AuthenticateUser handles native email/password authentication.
+// AuthenticateUser handles native email/password authentication. Users
+// with two-factor authentication get a challenge token instead of a
+// session, to be completed with VerifyLoginChallenge.
 func (s *Service) AuthenticateUser(
 	ctx context.Context, email, password, ipAddress, userAgent string,
-) (string, string, string, error) {
+) (*LoginResult, error) {
+	lock, err := s.lockout.Check(ctx, email, ipAddress)
+	if err != nil {
+		log.Printf("[AuthenticateUser] {Check Lockout}: %v", err)
+		return nil, errors.New("Login is temporarily unavailable")
+	}
+	if lock != nil {
+		return nil, &LoginLockedError{Lock: *lock}
+	}
+
 	// Fetch user from database (Native only)
 	user, err := s.repo.GetUserByEmail(
 		ctx,
@@ -308,27 +345,69 @@ func (s *Service) AuthenticateUser(
 		string(constants.AuthTypeNative),
 	)
 	if err != nil {
-		return "", "", "", errors.New("Invalid credentials")
+		return nil, s.loginFailed(ctx, email, ipAddress)
 	}
 
 	if user.IsActive == 0 {
-		return "", "", "", errors.New("User is not active")
+		return nil, errors.New("User is not active")
 	}
 
 	// Compare hashed password
 	if !user.PasswordHash.Valid {
-		return "", "", "", errors.New("Invalid credentials")
+		return nil, s.loginFailed(ctx, email, ipAddress)
 	}
 	err = bcrypt.CompareHashAndPassword(
 		[]byte(user.PasswordHash.String),
 		[]byte(password),
 	)
 	if err != nil {
-		return "", "", "", errors.New("Invalid credentials")
+		return nil, s.loginFailed(ctx, email, ipAddress)
+	}
+
+	twoFactorEnabled, err := s.twoFactor.IsEnabled(ctx, user.ID)
+	if err != nil {
+		log.Printf("[AuthenticateUser] {Check Two-Factor}: %v", err)
+		return nil, errors.New("Login is temporarily unavailable")
+	}
+	if twoFactorEnabled {
+		// Failures are only cleared once the second factor is verified,
+		// so a leaked password does not reset the count for guessed codes
+		challengeToken, err := s.createLoginChallenge(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		return &LoginResult{
+			UserID:         user.ID,
+			Email:          user.Email,
+			ChallengeToken: challengeToken,
+		}, nil
 	}
 
+	if err := s.lockout.RecordSuccess(ctx, email); err != nil {
+		log.Printf("[AuthenticateUser] {Clear Failures}: %v", err)
+	}
+
+	return s.issueNativeSession(
+		ctx,
+		user,
+		ipAddress,
+		userAgent,
+		s.twoFactor.IsRequired(user.RoleID),
+	)
+}
+
+// issueNativeSession creates the token pair and session of a native login.
+// A session with setupRequired set is limited to the /auth routes until the
+// user enables two-factor authentication.
+func (s *Service) issueNativeSession(
+	ctx context.Context,
+	user *users.User,
+	ipAddress, userAgent string,
+	setupRequired bool,
+) (*LoginResult, error) {
 	// Generate the token
-	token, claims, err := tokens.NewService().GenerateToken(
+	token, claims, err := s.tokens.GenerateToken(
 		user.Email,
 		user.ID,
 		user.RoleID,
@@ -337,20 +416,25 @@ func (s *Service) AuthenticateUser(
 		constants.AccessTokenMaxAge,
 	)
 	if err != nil {
-		return "", "", "", errors.New("Failed to generate session")
+		return nil, errors.New("Failed to generate session")
+	}
+
+	familyID, err := s.newRefreshFamily(ctx, user.ID, user.Email, claims.ID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to store token in redis: %v", err)
 	}
 
 	// Generate refresh token
-	refreshToken, _, err := tokens.NewService().GenerateToken(
+	refreshToken, _, err := s.tokens.GenerateRefreshToken(
 		user.Email,
 		user.ID,
 		user.RoleID,
-		"",
 		string(constants.AuthTypeNative),
+		familyID,
 		constants.RefreshTokenMaxAge,
 	)
 	if err != nil {
-		return "", "", "", errors.New("Failed to generate refresh token")
+		return nil, errors.New("Failed to generate refresh token")
 	}
 
 	// Store in Redis using the Token ID (jti)
@@ -361,6 +445,9 @@ func (s *Service) AuthenticateUser(
 		"ipAddress":       ipAddress,
 		"userAgent":       userAgent,
 	}
+	if setupRequired {
+		val[constants.SessionTwoFactorSetupRequired] = "true"
+	}
 	err = s.sessionService.StoreUserToken(
 		ctx,
 		user.ID,
@@ -369,168 +456,33 @@ func (s *Service) AuthenticateUser(
 		constants.RefreshTokenMaxAge,
 	)
 	if err != nil {
-		return "", "", "", fmt.Errorf("Failed to store token in redis: %v", err)
+		return nil, fmt.Errorf("Failed to store token in redis: %v", err)
 	}
 
-	return user.ID, token, refreshToken, nil
+	return &LoginResult{
+		UserID:                 user.ID,
+		Email:                  user.Email,
+		AccessToken:            token,
+		RefreshToken:           refreshToken,
+		TwoFactorSetupRequired: setupRequired,
+	}, nil
 }
 
-// RefreshToken generates a new access token using a valid session handle.
-func (s *Service) RefreshToken(
+// loginFailed counts a failed login and returns the error to report,
+// which is a LoginLockedError when the failure started a lockout.
+func (s *Service) loginFailed(
 	ctx context.Context,
-	accessTokenJTI sessions.JTIDTO,
-	cfg *config.Config,
-	ipAddress, userAgent string,
-) (string, string, error) {
-	// Get session from Redis
-	session, err := s.sessionService.GetToken(ctx, accessTokenJTI)
-	if err != nil {
-		return "", "", errors.New("Session expired or invalid")
-	}
-
-	refreshToken := session["appRefreshToken"]
-	if refreshToken == "" {
-		return "", "", errors.New("Refresh token missing from session")
-	}
-
-	// Validate App Refresh Token
-	claims, err := tokens.NewService().ValidateToken(refreshToken)
-	if err != nil {
-		return "", "", errors.New("Invalid refresh token")
-	}
-
-	// Check token type
-	if claims.TokenType == string(constants.AuthTypeIDP) {
-		// Get IDP refresh token from Redis
-		idpRefreshKey := sessions.NewJTI(claims.ID).ToIDPRefreshKey()
-		idpRefreshToken, err := s.redis.Get(ctx, idpRefreshKey)
-		if err != nil {
-			return "", "", fmt.Errorf(
-				"[AuthService] {Get IDP Refresh Token}: idp token missing",
-			)
-		}
-
-		// Call IDP refresh endpoint
-		tokenResp, err := s.idpClient.RefreshToken(ctx, idpRefreshToken, cfg)
-		if err != nil {
-			return "", "", fmt.Errorf("[AuthService] {IDP Refresh}: %w", err)
-		}
-
-		// Generate NEW App Tokens
-		newAppAccessToken, accessClaims, err := tokens.NewService().
-			GenerateToken(
-				claims.UserEmail,
-				claims.UserID,
-				claims.RoleID,
-				"",
-				string(constants.AuthTypeIDP),
-				constants.AccessTokenMaxAge,
-			)
-		if err != nil {
-			return "", "", err
-		}
-
-		newAppRefreshToken, refreshClaims, err := tokens.NewService().
-			GenerateToken(
-				claims.UserEmail,
-				claims.UserID,
-				claims.RoleID,
-				"",
-				string(constants.AuthTypeIDP),
-				constants.RefreshTokenMaxAge,
-			)
-		if err != nil {
-			return "", "", err
-		}
-
-		// Update Redis: App Access session
-		val := map[string]string{
-			"userID":          claims.UserID,
-			"tokenType":       string(constants.AuthTypeIDP),
-			"appRefreshToken": newAppRefreshToken,
-			"idpAccessToken":  tokenResp.AccessToken,
-		}
-		err = s.sessionService.StoreToken(
-			ctx,
-			sessions.NewJTI(accessClaims.ID),
-			val,
-			constants.RefreshTokenMaxAge,
-		)
-		if err != nil {
-			return "", "", err
-		}
-
-		// Update Redis: IDP Refresh linked to NEW App Refresh Token's ID
-		newIdpRefreshKey := sessions.NewJTI(refreshClaims.ID).ToIDPRefreshKey()
-		idpRefreshTokenToStore := tokenResp.RefreshToken
-		if idpRefreshTokenToStore == "" {
-			idpRefreshTokenToStore = idpRefreshToken
-		}
-		err = s.redis.Set(
-			ctx,
-			newIdpRefreshKey,
-			idpRefreshTokenToStore,
-			time.Duration(constants.RefreshTokenMaxAge)*time.Second,
-		)
-		if err != nil {
-			return "", "", err
-		}
-
-		// Clean up OLD session and IDP refresh keys
-		_ = s.sessionService.DeleteUserToken(ctx, claims.UserID, accessTokenJTI)
-		_ = s.redis.Del(ctx, idpRefreshKey)
-
-		return newAppAccessToken, newAppRefreshToken, nil
-	}
-
-	// Native flow
-	newToken, newClaims, err := tokens.NewService().GenerateToken(
-		claims.UserEmail,
-		claims.UserID,
-		claims.RoleID,
-		"",
-		string(constants.AuthTypeNative),
-		constants.AccessTokenMaxAge,
-	)
-	if err != nil {
-		return "", "", err
-	}
-
-	newRefreshToken, _, err := tokens.NewService().GenerateToken(
-		claims.UserEmail,
-		claims.UserID,
-		claims.RoleID,
-		"",
-		string(constants.AuthTypeNative),
-		constants.RefreshTokenMaxAge,
-	)
+	email, ipAddress string,
+) error {
+	lock, err := s.lockout.RecordFailure(ctx, email, ipAddress)
 	if err != nil {
-		return "", "", err
-	}
-
-	// Update Redis using new jti
-	val := map[string]string{
-		"userID":          claims.UserID,
-		"tokenType":       string(constants.AuthTypeNative),
-		"appRefreshToken": newRefreshToken,
-		"ipAddress":       ipAddress,
-		"userAgent":       userAgent,
+		log.Printf("[AuthenticateUser] {Record Failure}: %v", err)
 	}
-	err = s.sessionService.StoreUserToken(
-		ctx,
-		claims.UserID,
-		sessions.NewJTI(newClaims.ID),
-		val,
-		constants.RefreshTokenMaxAge,
-	)
-	if err != nil {
-		return "", "", err
+	if lock != nil {
+		return &LoginLockedError{Lock: *lock, Started: true}
 	}
 
-	// Clean up OLD session key
-	_ = s.sessionService.DeleteUserToken(ctx, claims.UserID, accessTokenJTI)
-
-	return newToken, newRefreshToken, nil
+	return errors.New("Invalid credentials")
 }
 
 // RefreshIDPToken handles token refresh for IDP-authenticated sessions.
@@ -594,7 +546,7 @@ func (s *Service) Logout(
 	cfg *config.Config,
 ) (string, error) {
 	// Identify the session using the Access Token JTI
-	claims, err := tokens.NewService().ParseTokenUnverified(token)
+	claims, err := s.tokens.ParseTokenUnverified(token)
 	if err != nil {
 		log.Printf("[AuthService:Logout] {Parse Error}: %v", err)
 		return "", nil // Move on since we can't identify the session
@@ -612,11 +564,18 @@ func (s *Service) Logout(
 	if sessionData != nil {
 		if appRefreshToken := sessionData["appRefreshToken"]; appRefreshToken != "" {
 			// Get refresh token claims to identify IDP refresh key
-			rClaims, err := tokens.NewService().
-				ParseTokenUnverified(appRefreshToken)
+			rClaims, err := s.tokens.ParseTokenUnverified(appRefreshToken)
 			if err == nil {
 				idpKey := sessions.NewJTI(rClaims.ID).ToIDPRefreshKey()
 				_ = s.redis.Del(ctx, idpKey)
+
+				// Signing out ends the refresh token family too
+				if rClaims.FamilyID != "" {
+					_ = s.sessionService.DeleteRefreshFamily(
+						ctx,
+						rClaims.FamilyID,
+					)
+				}
 			}
 		}
 	}
@@ -809,7 +768,7 @@ func (s *Service) PostIDPTokenExchange(
 	}
 
 	// Generate internal App Tokens using the actual app IDs
-	appAccessToken, accessClaims, err := tokens.NewService().GenerateToken(
+	appAccessToken, accessClaims, err := s.tokens.GenerateToken(
 		userInfo.Email,
 		appUserID,
 		localUser.RoleID,
@@ -824,12 +783,25 @@ func (s *Service) PostIDPTokenExchange(
 		)
 	}
 
-	appRefreshToken, refreshClaims, err := tokens.NewService().GenerateToken(
+	familyID, err := s.newRefreshFamily(
+		ctx,
+		appUserID,
+		userInfo.Email,
+		accessClaims.ID,
+	)
+	if err != nil {
+		return "", "", "", "", "", fmt.Errorf(
+			"[AuthService] {Store Refresh Family}: %w",
+			err,
+		)
+	}
+
+	appRefreshToken, refreshClaims, err := s.tokens.GenerateRefreshToken(
 		userInfo.Email,
 		appUserID,
 		localUser.RoleID,
-		"",
 		string(constants.AuthTypeIDP),
+		familyID,
 		constants.RefreshTokenMaxAge,
 	)
 	if err != nil {
@@ -0,0 +1,41 @@
+package calendar
+
+import (
+	"time"
+
+	"github.com/olazo-johnalbert/duckload-api/internal/core/structs"
+)
+
+type ListClosuresRequest struct {
+	StartDate   string `form:"start_date"`
+	EndDate     string `form:"end_date"`
+	Type        string `form:"type"`
+	CounselorID string `form:"counselor_id"`
+}
+
+type CreateClosureRequest struct {
+	StartDate   string `json:"startDate"   binding:"required"`
+	EndDate     string `json:"endDate"`
+	Type        string `json:"type"        binding:"required,oneof=holiday leave closure"`
+	CounselorID string `json:"counselorId"`
+	Reason      string `json:"reason"      binding:"required,max=255"`
+}
+
+type ClosureCounselor struct {
+	ID        string `json:"id"`
+	FirstName string `json:"firstName,omitempty"`
+	LastName  string `json:"lastName,omitempty"`
+}
+
+type ClosureDTO struct {
+	ID                  int                    `json:"id"`
+	StartDate           string                 `json:"startDate"`
+	EndDate             string                 `json:"endDate"`
+	Type                string                 `json:"type"`
+	Counselor           *ClosureCounselor      `json:"counselor,omitempty"`
+	Reason              string                 `json:"reason"`
+	CreatedBy           structs.NullableString `json:"createdBy,omitempty"`
+	FlaggedAppointments int                    `json:"flaggedAppointments,omitempty"`
+	CreatedAt           time.Time              `json:"createdAt"`
+	UpdatedAt           time.Time              `json:"updatedAt"`
+}
@@ -0,0 +1,131 @@
+package calendar
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/response"
+)
+
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new calendar handler.
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// sendCalendarError maps service errors to JSend responses.
+func sendCalendarError(c *gin.Context, tag string, err error) {
+	switch {
+	case errors.Is(err, ErrClosureNotFound),
+		errors.Is(err, ErrCounselorNotFound):
+		response.SendFail(
+			c,
+			gin.H{"error": err.Error()},
+			http.StatusNotFound,
+		)
+	case errors.Is(err, ErrInvalidDate),
+		errors.Is(err, ErrInvalidDateRange),
+		errors.Is(err, ErrCounselorRequired):
+		response.SendFail(c, gin.H{"error": err.Error()})
+	default:
+		log.Printf("[%s] {Service}: %v", tag, err)
+		response.SendError(
+			c,
+			string(constants.ErrInternalServerError),
+			http.StatusInternalServerError,
+			nil,
+		)
+	}
+}
+
+// GetClosureList godoc
+// @Summary      List calendar closures
+// @Description  Retrieves holidays, closures and counselor leave overlapping a date range.
+// @Tags         Calendar
+// @Produce      json
+// @Param        start_date   query string false "Start date (YYYY-MM-DD)"
+// @Param        end_date     query string false "End date (YYYY-MM-DD)"
+// @Param        type         query string false "holiday, leave or closure"
+// @Param        counselor_id query string false "Filter leave by counselor"
+// @Success      200  {object} []ClosureDTO
+// @Failure      400  {object} map[string]string
+// @Failure      500  {object} map[string]string
+// @Router       /calendar/closures [get]
+func (h *Handler) GetClosureList(c *gin.Context) {
+	var req ListClosuresRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.SendFail(c, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	closures, err := h.service.ListClosures(c.Request.Context(), req)
+	if err != nil {
+		sendCalendarError(c, "GetClosureList", err)
+		return
+	}
+
+	response.SendSuccess(c, closures)
+}
+
+// PostClosure godoc
+// @Summary      Create calendar closure
+// @Description  Closes a date range and flags the appointments that fall inside it.
+// @Tags         Calendar
+// @Accept       json
+// @Produce      json
+// @Param        request body     CreateClosureRequest true "Closure"
+// @Success      201     {object} ClosureDTO
+// @Failure      400     {object} map[string]string
+// @Failure      404     {object} map[string]string
+// @Failure      500     {object} map[string]string
+// @Router       /calendar/closures [post]
+func (h *Handler) PostClosure(c *gin.Context) {
+	var req CreateClosureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendFail(c, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	closure, err := h.service.CreateClosure(c.Request.Context(), req)
+	if err != nil {
+		sendCalendarError(c, "PostClosure", err)
+		return
+	}
+
+	response.SendSuccess(c, closure, http.StatusCreated)
+}
+
+// DeleteClosure godoc
+// @Summary      Delete calendar closure
+// @Description  Reopens the dates of a closure. Flagged appointments stay flagged.
+// @Tags         Calendar
+// @Produce      json
+// @Param        id   path     int true "Closure ID"
+// @Success      200  {object} map[string]string
+// @Failure      400  {object} map[string]string
+// @Failure      404  {object} map[string]string
+// @Failure      500  {object} map[string]string
+// @Router       /calendar/closures/id/{id} [delete]
+func (h *Handler) DeleteClosure(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.SendFail(c, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	if err := h.service.DeleteClosure(c.Request.Context(), id); err != nil {
+		sendCalendarError(c, "DeleteClosure", err)
+		return
+	}
+
+	response.SendSuccess(c, gin.H{
+		"message": "Closure deleted successfully",
+	})
+}
@@ -0,0 +1,45 @@
+package calendar
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
+)
+
+type ServiceInterface interface {
+	ListClosures(
+		ctx context.Context,
+		req ListClosuresRequest,
+	) ([]ClosureDTO, error)
+	CreateClosure(
+		ctx context.Context,
+		req CreateClosureRequest,
+	) (*ClosureDTO, error)
+	DeleteClosure(ctx context.Context, id int) error
+	ValidateBookingDate(ctx context.Context, date string) error
+}
+
+type RepositoryInterface interface {
+	GetDB() *sqlx.DB
+	List(
+		ctx context.Context,
+		startDate, endDate, closureType, counselorID string,
+	) ([]ClosureView, error)
+	GetByID(ctx context.Context, id int) (*ClosureView, error)
+	GetOfficeClosure(ctx context.Context, date string) (*Closure, error)
+	Create(ctx context.Context, tx datastore.DB, closure Closure) (int, error)
+	Delete(ctx context.Context, tx datastore.DB, id int) error
+	ListAffectedAppointments(
+		ctx context.Context,
+		tx datastore.DB,
+		startDate, endDate string,
+		counselorID *string,
+	) ([]AffectedAppointment, error)
+	FlagAppointments(
+		ctx context.Context,
+		tx datastore.DB,
+		ids []string,
+		reason string,
+	) error
+}
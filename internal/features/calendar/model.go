@@ -0,0 +1,40 @@
+package calendar
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Closure types stored in calendar_closures.closure_type.
+const (
+	ClosureTypeHoliday = "holiday"
+	ClosureTypeLeave   = "leave"
+	ClosureTypeClosure = "closure"
+)
+
+type Closure struct {
+	ID          int            `db:"id"`
+	StartDate   string         `db:"start_date"`
+	EndDate     string         `db:"end_date"`
+	ClosureType string         `db:"closure_type"`
+	CounselorID sql.NullString `db:"counselor_id"`
+	Reason      string         `db:"reason"`
+	CreatedBy   sql.NullString `db:"created_by"`
+	CreatedAt   time.Time      `db:"created_at"`
+	UpdatedAt   time.Time      `db:"updated_at"`
+}
+
+// ClosureView holds a closure joined with the counselor on leave, if any.
+type ClosureView struct {
+	Closure
+	CounselorFirstName sql.NullString `db:"counselor_first_name"`
+	CounselorLastName  sql.NullString `db:"counselor_last_name"`
+}
+
+// AffectedAppointment is an active booking that falls inside a closure.
+type AffectedAppointment struct {
+	ID            string         `db:"id"`
+	StudentUserID sql.NullString `db:"student_user_id"`
+	WhenDate      string         `db:"when_date"`
+	TimeSlotTime  string         `db:"time_slot_time"`
+}
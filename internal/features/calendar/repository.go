@@ -0,0 +1,244 @@
+package calendar
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
+)
+
+type Repository struct {
+	db *sqlx.DB
+}
+
+const closuresBaseQuery = `
+	SELECT
+		cc.id,
+		DATE_FORMAT(cc.start_date, '%Y-%m-%d') AS start_date,
+		DATE_FORMAT(cc.end_date, '%Y-%m-%d') AS end_date,
+		cc.closure_type,
+		cc.counselor_id,
+		cc.reason,
+		cc.created_by,
+		cc.created_at,
+		cc.updated_at,
+		u.first_name AS counselor_first_name,
+		u.last_name AS counselor_last_name
+	FROM calendar_closures cc
+	LEFT JOIN users u ON cc.counselor_id = u.id
+`
+
+// Appointments in these statuses are not affected by a closure.
+const inactiveAppointmentStatuses = `('Cancelled', 'Rejected', 'Completed')`
+
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) GetDB() *sqlx.DB {
+	return r.db
+}
+
+// List returns closures overlapping the given date range, ordered by start
+// date. Empty filters are ignored.
+func (r *Repository) List(
+	ctx context.Context,
+	startDate, endDate, closureType, counselorID string,
+) ([]ClosureView, error) {
+	where := " WHERE 1=1"
+	var args []interface{}
+
+	if startDate != "" {
+		where += " AND cc.end_date >= ?"
+		args = append(args, startDate)
+	}
+	if endDate != "" {
+		where += " AND cc.start_date <= ?"
+		args = append(args, endDate)
+	}
+	if closureType != "" {
+		where += " AND cc.closure_type = ?"
+		args = append(args, closureType)
+	}
+	if counselorID != "" {
+		where += " AND cc.counselor_id = ?"
+		args = append(args, counselorID)
+	}
+
+	query := closuresBaseQuery + where + " ORDER BY cc.start_date ASC, cc.id ASC"
+
+	var closures []ClosureView
+	err := r.db.SelectContext(ctx, &closures, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list closures: %w", err)
+	}
+
+	return closures, nil
+}
+
+func (r *Repository) GetByID(
+	ctx context.Context,
+	id int,
+) (*ClosureView, error) {
+	var closure ClosureView
+	err := r.db.GetContext(
+		ctx,
+		&closure,
+		closuresBaseQuery+" WHERE cc.id = ?",
+		id,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get closure: %w", err)
+	}
+
+	return &closure, nil
+}
+
+// GetOfficeClosure returns the holiday or closure covering the date, or nil
+// when the office is open. Counselor leave does not close the office.
+func (r *Repository) GetOfficeClosure(
+	ctx context.Context,
+	date string,
+) (*Closure, error) {
+	query := `
+		SELECT
+			id,
+			DATE_FORMAT(start_date, '%Y-%m-%d') AS start_date,
+			DATE_FORMAT(end_date, '%Y-%m-%d') AS end_date,
+			closure_type,
+			counselor_id,
+			reason,
+			created_by,
+			created_at,
+			updated_at
+		FROM calendar_closures
+		WHERE counselor_id IS NULL
+			AND closure_type IN ('holiday', 'closure')
+			AND ? BETWEEN start_date AND end_date
+		ORDER BY id ASC
+		LIMIT 1
+	`
+
+	var closure Closure
+	if err := r.db.GetContext(ctx, &closure, query, date); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get office closure: %w", err)
+	}
+
+	return &closure, nil
+}
+
+func (r *Repository) Create(
+	ctx context.Context,
+	tx datastore.DB,
+	closure Closure,
+) (int, error) {
+	cols, vals := datastore.GetInsertStatement(closure, nil)
+	query := fmt.Sprintf(`
+		INSERT INTO calendar_closures (%s)
+		VALUES (%s)
+	`, cols, vals)
+
+	res, err := tx.NamedExecContext(ctx, query, closure)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert closure: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get closure ID: %w", err)
+	}
+
+	return int(id), nil
+}
+
+func (r *Repository) Delete(
+	ctx context.Context,
+	tx datastore.DB,
+	id int,
+) error {
+	res, err := tx.ExecContext(
+		ctx,
+		"DELETE FROM calendar_closures WHERE id = ?",
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete closure: %w", err)
+	}
+
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// ListAffectedAppointments returns the active appointments inside the date
+// range. When counselorID is set only that counselor's bookings count.
+func (r *Repository) ListAffectedAppointments(
+	ctx context.Context,
+	tx datastore.DB,
+	startDate, endDate string,
+	counselorID *string,
+) ([]AffectedAppointment, error) {
+	query := fmt.Sprintf(`
+		SELECT
+			a.id,
+			ir.user_id AS student_user_id,
+			DATE_FORMAT(a.when_date, '%%Y-%%m-%%d') AS when_date,
+			ts.time AS time_slot_time
+		FROM appointments a
+		JOIN time_slots ts ON a.time_slot_id = ts.id
+		LEFT JOIN iir_records ir ON a.iir_id = ir.id
+		WHERE a.when_date BETWEEN ? AND ?
+			AND a.status_id NOT IN (
+				SELECT id FROM statuses WHERE name IN %s
+			)
+	`, inactiveAppointmentStatuses)
+	args := []interface{}{startDate, endDate}
+
+	if counselorID != nil {
+		query += " AND a.counselor_id = ?"
+		args = append(args, *counselorID)
+	}
+
+	var appts []AffectedAppointment
+	if err := tx.SelectContext(ctx, &appts, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list affected appointments: %w", err)
+	}
+
+	return appts, nil
+}
+
+func (r *Repository) FlagAppointments(
+	ctx context.Context,
+	tx datastore.DB,
+	ids []string,
+	reason string,
+) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query, args, err := sqlx.In(`
+		UPDATE appointments
+		SET flagged_at = CURRENT_TIMESTAMP, flag_reason = ?
+		WHERE id IN (?)
+	`, reason, ids)
+	if err != nil {
+		return fmt.Errorf("failed to build flag query: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, tx.Rebind(query), args...); err != nil {
+		return fmt.Errorf("failed to flag appointments: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package calendar
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/middleware"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/tokens"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
+)
+
+func RegisterRoutes(
+	rg *gin.RouterGroup,
+	h *Handler,
+	redis *datastore.RedisClient,
+	tokenService *tokens.Service,
+) {
+	routes := rg.Group("/calendar")
+	routes.Use(middleware.AuthMiddleware(redis, tokenService))
+	routes.Use(middleware.AuditContextMiddleware())
+
+	// Students see closed days when picking a date
+	sharedRoutes := routes.Group("")
+	sharedRoutes.Use(middleware.RoleMiddleware(
+		int(constants.StudentRoleID),
+		int(constants.AdminRoleID),
+	))
+	{
+		sharedRoutes.GET("/closures", h.GetClosureList)
+	}
+
+	adminOnly := routes.Group("")
+	adminOnly.Use(middleware.RoleMiddleware(int(constants.AdminRoleID)))
+	{
+		adminOnly.POST("/closures", h.PostClosure)
+		adminOnly.DELETE("/closures/id/:id", h.DeleteClosure)
+	}
+}
@@ -0,0 +1,349 @@
+package calendar
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/olazo-johnalbert/duckload-api/internal/core/audit"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/datetime"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/structs"
+	"github.com/olazo-johnalbert/duckload-api/internal/features/counselors"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
+)
+
+const dateLayout = "2006-01-02"
+
+var (
+	ErrClosureNotFound   = errors.New("closure not found")
+	ErrInvalidDateRange  = errors.New("end date must not be before start date")
+	ErrCounselorRequired = errors.New("counselor is required for leave")
+	ErrCounselorNotFound = errors.New("counselor not found")
+
+	// ErrDateUnavailable is wrapped by every reason a date cannot be booked.
+	ErrDateUnavailable = errors.New("date is not available for booking")
+	ErrInvalidDate     = fmt.Errorf("%w: invalid date format", ErrDateUnavailable)
+	ErrPastDate        = fmt.Errorf("%w: date is in the past", ErrDateUnavailable)
+	ErrWeekendDate     = fmt.Errorf("%w: office is closed on weekends", ErrDateUnavailable)
+	ErrOfficeClosed    = fmt.Errorf("%w: office is closed", ErrDateUnavailable)
+)
+
+type Service struct {
+	repo             RepositoryInterface
+	logService       audit.Logger
+	notifService     audit.Notifier
+	counselorService counselors.ServiceInterface
+}
+
+func NewService(
+	repo RepositoryInterface,
+	logService audit.Logger,
+	notifService audit.Notifier,
+	counselorService counselors.ServiceInterface,
+) *Service {
+	return &Service{
+		repo:             repo,
+		logService:       logService,
+		notifService:     notifService,
+		counselorService: counselorService,
+	}
+}
+
+func (s *Service) ListClosures(
+	ctx context.Context,
+	req ListClosuresRequest,
+) ([]ClosureDTO, error) {
+	closures, err := s.repo.List(
+		ctx,
+		req.StartDate,
+		req.EndDate,
+		req.Type,
+		req.CounselorID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	dtos := make([]ClosureDTO, 0, len(closures))
+	for _, c := range closures {
+		dtos = append(dtos, mapClosureToDTO(c))
+	}
+
+	return dtos, nil
+}
+
+// CreateClosure records a closed day range and flags every active booking
+// that falls inside it. Students of flagged bookings are notified.
+func (s *Service) CreateClosure(
+	ctx context.Context,
+	req CreateClosureRequest,
+) (*ClosureDTO, error) {
+	if req.EndDate == "" {
+		req.EndDate = req.StartDate
+	}
+
+	start, err := time.Parse(dateLayout, req.StartDate)
+	if err != nil {
+		return nil, ErrInvalidDate
+	}
+	end, err := time.Parse(dateLayout, req.EndDate)
+	if err != nil {
+		return nil, ErrInvalidDate
+	}
+	if end.Before(start) {
+		return nil, ErrInvalidDateRange
+	}
+
+	var counselorID *string
+	if req.Type == ClosureTypeLeave {
+		if req.CounselorID == "" {
+			return nil, ErrCounselorRequired
+		}
+		counselor, err := s.counselorService.GetCounselorByUserID(
+			ctx,
+			req.CounselorID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if counselor == nil {
+			return nil, ErrCounselorNotFound
+		}
+		counselorID = &req.CounselorID
+	}
+
+	actorID := audit.ExtractUserID(ctx)
+	closure := Closure{
+		StartDate:   req.StartDate,
+		EndDate:     req.EndDate,
+		ClosureType: req.Type,
+		CounselorID: sql.NullString{
+			String: req.CounselorID,
+			Valid:  counselorID != nil,
+		},
+		Reason: req.Reason,
+		CreatedBy: sql.NullString{
+			String: actorID,
+			Valid:  actorID != "",
+		},
+	}
+
+	var affected []AffectedAppointment
+	id, err := datastore.NewRunInTransaction(
+		ctx,
+		s.repo.GetDB(),
+		func(tx datastore.DB) (int, error) {
+			id, err := s.repo.Create(ctx, tx, closure)
+			if err != nil {
+				return 0, err
+			}
+
+			affected, err = s.repo.ListAffectedAppointments(
+				ctx,
+				tx,
+				req.StartDate,
+				req.EndDate,
+				counselorID,
+			)
+			if err != nil {
+				return 0, err
+			}
+
+			ids := make([]string, 0, len(affected))
+			for _, a := range affected {
+				ids = append(ids, a.ID)
+			}
+
+			return id, s.repo.FlagAppointments(ctx, tx, ids, req.Reason)
+		},
+	)
+	if err != nil {
+		audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+			Log: &audit.LogParams{
+				Level:    audit.LevelError,
+				Category: audit.CategoryAudit,
+				Action:   audit.ActionClosureCreateFailed,
+				Message: fmt.Sprintf(
+					"Failed to create %s closure for %s to %s",
+					req.Type,
+					req.StartDate,
+					req.EndDate,
+				),
+				Metadata: &audit.LogMetadata{
+					EntityType: constants.ClosureEntityType,
+					NewValues:  req,
+					Error:      err.Error(),
+				},
+			},
+		})
+		return nil, err
+	}
+
+	notifications := make([]audit.NotificationParams, 0, len(affected))
+	for _, a := range affected {
+		if !a.StudentUserID.Valid {
+			continue
+		}
+		notifications = append(notifications, audit.NotificationParams{
+			ReceiverID: structs.FromSqlNull(a.StudentUserID),
+			TargetID:   structs.StringToNullableString(a.ID),
+			TargetType: structs.StringToNullableString(
+				constants.AppointmentEntityType,
+			),
+			Title: "Appointment Needs Rescheduling",
+			Message: fmt.Sprintf(
+				"Your appointment on %s at %s is affected by a closure: %s. "+
+					"Please reschedule.",
+				datetime.FormatDate(a.WhenDate),
+				datetime.FormatTime(a.TimeSlotTime),
+				req.Reason,
+			),
+			Type: constants.AppointmentEntityType,
+		})
+	}
+
+	audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+		Log: &audit.LogParams{
+			Level:    audit.LevelInfo,
+			Category: audit.CategoryAudit,
+			Action:   audit.ActionClosureCreated,
+			Message: fmt.Sprintf(
+				"%s closure #%d created for %s to %s; %d appointment(s) flagged",
+				req.Type,
+				id,
+				req.StartDate,
+				req.EndDate,
+				len(affected),
+			),
+			Metadata: &audit.LogMetadata{
+				EntityType: constants.ClosureEntityType,
+				EntityID:   fmt.Sprintf("%d", id),
+				NewValues:  req,
+			},
+		},
+		Notifications: notifications,
+	})
+
+	created, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if created == nil {
+		return nil, ErrClosureNotFound
+	}
+
+	dto := mapClosureToDTO(*created)
+	dto.FlaggedAppointments = len(affected)
+	return &dto, nil
+}
+
+// DeleteClosure reopens the dates. Appointments flagged by the closure stay
+// flagged until they are rescheduled.
+func (s *Service) DeleteClosure(ctx context.Context, id int) error {
+	oldClosure, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if oldClosure == nil {
+		return ErrClosureNotFound
+	}
+
+	err = datastore.RunInTransaction(
+		ctx,
+		s.repo.GetDB(),
+		func(tx datastore.DB) error {
+			return s.repo.Delete(ctx, tx, id)
+		},
+	)
+	if err != nil {
+		audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+			Log: &audit.LogParams{
+				Level:    audit.LevelError,
+				Category: audit.CategoryAudit,
+				Action:   audit.ActionClosureDeleteFailed,
+				Message:  fmt.Sprintf("Failed to delete closure #%d", id),
+				Metadata: &audit.LogMetadata{
+					EntityType: constants.ClosureEntityType,
+					EntityID:   fmt.Sprintf("%d", id),
+					Error:      err.Error(),
+				},
+			},
+		})
+		return err
+	}
+
+	audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+		Log: &audit.LogParams{
+			Level:    audit.LevelWarning,
+			Category: audit.CategoryAudit,
+			Action:   audit.ActionClosureDeleted,
+			Message:  fmt.Sprintf("Closure #%d deleted", id),
+			Metadata: &audit.LogMetadata{
+				EntityType: constants.ClosureEntityType,
+				EntityID:   fmt.Sprintf("%d", id),
+				OldValues:  mapClosureToDTO(*oldClosure),
+			},
+		},
+	})
+
+	return nil
+}
+
+// ValidateBookingDate rejects malformed, past and weekend dates as well as
+// days covered by an office holiday or closure. Counselor leave is handled
+// by counselor availability instead.
+func (s *Service) ValidateBookingDate(
+	ctx context.Context,
+	date string,
+) error {
+	day, err := time.ParseInLocation(dateLayout, date, time.Local)
+	if err != nil {
+		return ErrInvalidDate
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if day.Before(today) {
+		return ErrPastDate
+	}
+
+	if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		return ErrWeekendDate
+	}
+
+	closure, err := s.repo.GetOfficeClosure(ctx, date)
+	if err != nil {
+		return err
+	}
+	if closure != nil {
+		return fmt.Errorf("%w (%s)", ErrOfficeClosed, closure.Reason)
+	}
+
+	return nil
+}
+
+func mapClosureToDTO(c ClosureView) ClosureDTO {
+	dto := ClosureDTO{
+		ID:        c.ID,
+		StartDate: c.StartDate,
+		EndDate:   c.EndDate,
+		Type:      c.ClosureType,
+		Reason:    c.Reason,
+		CreatedBy: structs.FromSqlNull(c.CreatedBy),
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+
+	if c.CounselorID.Valid {
+		dto.Counselor = &ClosureCounselor{
+			ID:        c.CounselorID.String,
+			FirstName: c.CounselorFirstName.String,
+			LastName:  c.CounselorLastName.String,
+		}
+	}
+
+	return dto
+}
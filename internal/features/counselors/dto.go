@@ -0,0 +1,48 @@
+package counselors
+
+import (
+	"time"
+
+	"github.com/olazo-johnalbert/duckload-api/internal/core/structs"
+)
+
+type ListCounselorsRequest struct {
+	structs.PaginationRequest
+	IsAvailable *bool `form:"is_available"`
+}
+
+type ListCounselorsDTO struct {
+	Counselors []CounselorDTO             `json:"counselors"`
+	Meta       structs.PaginationMetadata `json:"meta"`
+}
+
+type CreateCounselorRequest struct {
+	UserID         string `json:"userId"         binding:"required"`
+	LicenseNumber  string `json:"licenseNumber"  binding:"max=50"`
+	Specialization string `json:"specialization" binding:"max=100"`
+	IsAvailable    *bool  `json:"isAvailable"`
+}
+
+type UpdateCounselorRequest struct {
+	LicenseNumber  *string `json:"licenseNumber"  binding:"omitempty,max=50"`
+	Specialization *string `json:"specialization" binding:"omitempty,max=100"`
+	IsAvailable    *bool   `json:"isAvailable"`
+}
+
+type UpdateAvailabilityRequest struct {
+	IsAvailable *bool `json:"isAvailable" binding:"required"`
+}
+
+type CounselorDTO struct {
+	ID             int                    `json:"id"`
+	UserID         string                 `json:"userId"`
+	FirstName      string                 `json:"firstName"`
+	MiddleName     structs.NullableString `json:"middleName,omitempty"`
+	LastName       string                 `json:"lastName"`
+	Email          string                 `json:"email"`
+	LicenseNumber  structs.NullableString `json:"licenseNumber,omitempty"`
+	Specialization structs.NullableString `json:"specialization,omitempty"`
+	IsAvailable    bool                   `json:"isAvailable"`
+	CreatedAt      time.Time              `json:"createdAt"`
+	UpdatedAt      time.Time              `json:"updatedAt"`
+}
@@ -0,0 +1,283 @@
+package counselors
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/response"
+)
+
+type Handler struct {
+	service ServiceInterface
+}
+
+// NewHandler creates a new counselors handler.
+func NewHandler(service ServiceInterface) *Handler {
+	return &Handler{service: service}
+}
+
+// parseCounselorID reads the :id path parameter or aborts with a fail
+// response when it is not a number.
+func parseCounselorID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.SendFail(c, gin.H{"error": "Invalid ID format"})
+		return 0, false
+	}
+
+	return id, true
+}
+
+// sendCounselorError maps service errors to JSend responses.
+func sendCounselorError(c *gin.Context, tag string, err error) {
+	switch {
+	case errors.Is(err, ErrCounselorNotFound), errors.Is(err, ErrUserNotFound):
+		response.SendFail(
+			c,
+			gin.H{"error": err.Error()},
+			http.StatusNotFound,
+		)
+	case errors.Is(err, ErrCounselorExists):
+		response.SendFail(
+			c,
+			gin.H{"error": err.Error()},
+			http.StatusConflict,
+		)
+	case errors.Is(err, ErrNotCounselorRole):
+		response.SendFail(c, gin.H{"error": err.Error()})
+	default:
+		log.Printf("[%s] {Service}: %v", tag, err)
+		response.SendError(
+			c,
+			string(constants.ErrInternalServerError),
+			http.StatusInternalServerError,
+			nil,
+		)
+	}
+}
+
+// GetCounselorList godoc
+// @Summary      List counselors
+// @Description  Retrieves a paginated list of counselor profiles.
+// @Tags         Counselors
+// @Produce      json
+// @Param        search       query string false "Search by name, email or specialization"
+// @Param        is_available query bool   false "Filter by availability"
+// @Param        page         query int    false "Page number"
+// @Param        page_size    query int    false "Page size"
+// @Success      200  {object} ListCounselorsDTO
+// @Failure      400  {object} map[string]string
+// @Failure      500  {object} map[string]string
+// @Router       /counselors [get]
+func (h *Handler) GetCounselorList(c *gin.Context) {
+	var req ListCounselorsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.SendFail(c, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	counselors, err := h.service.ListCounselors(c.Request.Context(), req)
+	if err != nil {
+		sendCounselorError(c, "GetCounselorList", err)
+		return
+	}
+
+	response.SendSuccess(c, counselors)
+}
+
+// GetCounselorByID godoc
+// @Summary      Get counselor
+// @Description  Retrieves a counselor profile by ID.
+// @Tags         Counselors
+// @Produce      json
+// @Param        id   path     int true "Counselor ID"
+// @Success      200  {object} CounselorDTO
+// @Failure      400  {object} map[string]string
+// @Failure      404  {object} map[string]string
+// @Failure      500  {object} map[string]string
+// @Router       /counselors/id/{id} [get]
+func (h *Handler) GetCounselorByID(c *gin.Context) {
+	id, ok := parseCounselorID(c)
+	if !ok {
+		return
+	}
+
+	counselor, err := h.service.GetCounselorByID(c.Request.Context(), id)
+	if err != nil {
+		sendCounselorError(c, "GetCounselorByID", err)
+		return
+	}
+
+	if counselor == nil {
+		sendCounselorError(c, "GetCounselorByID", ErrCounselorNotFound)
+		return
+	}
+
+	response.SendSuccess(c, counselor)
+}
+
+// GetMyCounselorProfile godoc
+// @Summary      Get own counselor profile
+// @Description  Retrieves the counselor profile of the authenticated admin.
+// @Tags         Counselors
+// @Produce      json
+// @Success      200  {object} CounselorDTO
+// @Failure      404  {object} map[string]string
+// @Failure      500  {object} map[string]string
+// @Router       /counselors/me [get]
+func (h *Handler) GetMyCounselorProfile(c *gin.Context) {
+	counselor, err := h.service.GetCounselorByUserID(
+		c.Request.Context(),
+		c.GetString("userID"),
+	)
+	if err != nil {
+		sendCounselorError(c, "GetMyCounselorProfile", err)
+		return
+	}
+
+	if counselor == nil {
+		sendCounselorError(c, "GetMyCounselorProfile", ErrCounselorNotFound)
+		return
+	}
+
+	response.SendSuccess(c, counselor)
+}
+
+// PostCounselor godoc
+// @Summary      Create counselor profile
+// @Description  Creates a counselor profile for an admin user.
+// @Tags         Counselors
+// @Accept       json
+// @Produce      json
+// @Param        request body     CreateCounselorRequest true "Counselor details"
+// @Success      201     {object} CounselorDTO
+// @Failure      400     {object} map[string]string
+// @Failure      404     {object} map[string]string
+// @Failure      409     {object} map[string]string
+// @Failure      500     {object} map[string]string
+// @Router       /counselors [post]
+func (h *Handler) PostCounselor(c *gin.Context) {
+	var req CreateCounselorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendFail(c, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	counselor, err := h.service.CreateCounselor(c.Request.Context(), req)
+	if err != nil {
+		sendCounselorError(c, "PostCounselor", err)
+		return
+	}
+
+	response.SendSuccess(c, counselor, http.StatusCreated)
+}
+
+// PatchCounselor godoc
+// @Summary      Update counselor profile
+// @Description  Updates license, specialization or availability of a counselor.
+// @Tags         Counselors
+// @Accept       json
+// @Produce      json
+// @Param        id      path     int                    true "Counselor ID"
+// @Param        request body     UpdateCounselorRequest true "Fields to update"
+// @Success      200     {object} CounselorDTO
+// @Failure      400     {object} map[string]string
+// @Failure      404     {object} map[string]string
+// @Failure      500     {object} map[string]string
+// @Router       /counselors/id/{id} [patch]
+func (h *Handler) PatchCounselor(c *gin.Context) {
+	id, ok := parseCounselorID(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateCounselorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendFail(c, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	counselor, err := h.service.UpdateCounselor(c.Request.Context(), id, req)
+	if err != nil {
+		sendCounselorError(c, "PatchCounselor", err)
+		return
+	}
+
+	response.SendSuccess(c, counselor)
+}
+
+// PatchMyAvailability godoc
+// @Summary      Update own availability
+// @Description  Lets a counselor toggle whether they accept new appointments.
+// @Tags         Counselors
+// @Accept       json
+// @Produce      json
+// @Param        request body     UpdateAvailabilityRequest true "Availability"
+// @Success      200     {object} CounselorDTO
+// @Failure      400     {object} map[string]string
+// @Failure      404     {object} map[string]string
+// @Failure      500     {object} map[string]string
+// @Router       /counselors/me/availability [patch]
+func (h *Handler) PatchMyAvailability(c *gin.Context) {
+	var req UpdateAvailabilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendFail(c, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	profile, err := h.service.GetCounselorByUserID(
+		c.Request.Context(),
+		c.GetString("userID"),
+	)
+	if err != nil {
+		sendCounselorError(c, "PatchMyAvailability", err)
+		return
+	}
+	if profile == nil {
+		sendCounselorError(c, "PatchMyAvailability", ErrCounselorNotFound)
+		return
+	}
+
+	counselor, err := h.service.UpdateCounselor(
+		c.Request.Context(),
+		profile.ID,
+		UpdateCounselorRequest{IsAvailable: req.IsAvailable},
+	)
+	if err != nil {
+		sendCounselorError(c, "PatchMyAvailability", err)
+		return
+	}
+
+	response.SendSuccess(c, counselor)
+}
+
+// DeleteCounselor godoc
+// @Summary      Delete counselor profile
+// @Description  Removes a counselor profile. Existing appointments keep their history.
+// @Tags         Counselors
+// @Produce      json
+// @Param        id   path     int true "Counselor ID"
+// @Success      200  {object} map[string]string
+// @Failure      400  {object} map[string]string
+// @Failure      404  {object} map[string]string
+// @Failure      500  {object} map[string]string
+// @Router       /counselors/id/{id} [delete]
+func (h *Handler) DeleteCounselor(c *gin.Context) {
+	id, ok := parseCounselorID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteCounselor(c.Request.Context(), id); err != nil {
+		sendCounselorError(c, "DeleteCounselor", err)
+		return
+	}
+
+	response.SendSuccess(c, gin.H{
+		"message": "Counselor deleted successfully",
+	})
+}
@@ -0,0 +1,95 @@
+package counselors
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
+)
+
+type ServiceInterface interface {
+	ListCounselors(
+		ctx context.Context,
+		req ListCounselorsRequest,
+	) (*ListCounselorsDTO, error)
+	GetCounselorByID(ctx context.Context, id int) (*CounselorDTO, error)
+	GetCounselorByUserID(
+		ctx context.Context,
+		userID string,
+	) (*CounselorDTO, error)
+	CreateCounselor(
+		ctx context.Context,
+		req CreateCounselorRequest,
+	) (*CounselorDTO, error)
+	UpdateCounselor(
+		ctx context.Context,
+		id int,
+		req UpdateCounselorRequest,
+	) (*CounselorDTO, error)
+	DeleteCounselor(ctx context.Context, id int) error
+	FindAvailableCounselor(
+		ctx context.Context,
+		tx datastore.DB,
+		date string,
+		timeSlotID int,
+	) (string, error)
+	IsCounselorFree(
+		ctx context.Context,
+		tx datastore.DB,
+		userID, date string,
+		timeSlotID int,
+	) (bool, error)
+	CountAvailableCounselors(
+		ctx context.Context,
+		tx datastore.DB,
+		date string,
+	) (int, error)
+}
+
+type RepositoryInterface interface {
+	GetDB() *sqlx.DB
+	List(
+		ctx context.Context,
+		offset, limit int,
+		search, orderBy string,
+		isAvailable *bool,
+	) ([]CounselorProfileView, int, error)
+	GetByID(ctx context.Context, id int) (*CounselorProfileView, error)
+	GetByUserID(
+		ctx context.Context,
+		userID string,
+	) (*CounselorProfileView, error)
+	Create(
+		ctx context.Context,
+		tx datastore.DB,
+		profile CounselorProfile,
+	) (int, error)
+	Update(
+		ctx context.Context,
+		tx datastore.DB,
+		profile CounselorProfile,
+	) error
+	Delete(ctx context.Context, tx datastore.DB, id int) error
+	FindAvailableCounselorID(
+		ctx context.Context,
+		tx datastore.DB,
+		date string,
+		timeSlotID int,
+	) (string, error)
+	CountActiveAppointments(
+		ctx context.Context,
+		tx datastore.DB,
+		userID, date string,
+		timeSlotID int,
+	) (int, error)
+	CountAvailable(
+		ctx context.Context,
+		tx datastore.DB,
+		date string,
+	) (int, error)
+	IsOnLeave(
+		ctx context.Context,
+		tx datastore.DB,
+		userID, date string,
+	) (bool, error)
+}
@@ -0,0 +1,26 @@
+package counselors
+
+import (
+	"database/sql"
+	"time"
+)
+
+type CounselorProfile struct {
+	ID             int            `db:"id"`
+	UserID         string         `db:"user_id"`
+	LicenseNumber  sql.NullString `db:"license_number"`
+	Specialization sql.NullString `db:"specialization"`
+	IsAvailable    bool           `db:"is_available"`
+	CreatedAt      time.Time      `db:"created_at"`
+	UpdatedAt      time.Time      `db:"updated_at"`
+}
+
+// CounselorProfileView holds a counselor profile joined with the owning
+// user account.
+type CounselorProfileView struct {
+	CounselorProfile
+	FirstName  string         `db:"first_name"`
+	MiddleName sql.NullString `db:"middle_name"`
+	LastName   string         `db:"last_name"`
+	Email      string         `db:"email"`
+}
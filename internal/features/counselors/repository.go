@@ -0,0 +1,349 @@
+package counselors
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
+)
+
+type Repository struct {
+	db *sqlx.DB
+}
+
+const counselorsBaseQuery = `
+	SELECT
+		cp.id,
+		cp.user_id,
+		cp.license_number,
+		cp.specialization,
+		cp.is_available,
+		cp.created_at,
+		cp.updated_at,
+		u.first_name,
+		u.middle_name,
+		u.last_name,
+		u.email
+	FROM counselor_profiles cp
+	JOIN users u ON cp.user_id = u.id
+`
+
+// Appointments in these statuses no longer occupy a counselor's slot.
+const inactiveAppointmentStatuses = `('Cancelled', 'Rejected')`
+
+// onLeaveFilter excludes counselors with a leave entry covering the date.
+const onLeaveFilter = `
+	NOT EXISTS (
+		SELECT 1
+		FROM calendar_closures cc
+		WHERE cc.counselor_id = cp.user_id
+			AND cc.closure_type = 'leave'
+			AND ? BETWEEN cc.start_date AND cc.end_date
+	)
+`
+
+// counselorOrderColumns whitelists the sortable columns for List.
+var counselorOrderColumns = map[string]string{
+	"created_at": "cp.created_at",
+	"first_name": "u.first_name",
+	"last_name":  "u.last_name",
+	"email":      "u.email",
+}
+
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) GetDB() *sqlx.DB {
+	return r.db
+}
+
+func (r *Repository) List(
+	ctx context.Context,
+	offset, limit int,
+	search, orderBy string,
+	isAvailable *bool,
+) ([]CounselorProfileView, int, error) {
+	where := " WHERE u.is_active = 1"
+	var args []interface{}
+
+	if isAvailable != nil {
+		where += " AND cp.is_available = ?"
+		args = append(args, *isAvailable)
+	}
+
+	if search != "" {
+		where += ` AND (u.first_name LIKE ? OR u.last_name LIKE ?
+			OR u.email LIKE ? OR cp.specialization LIKE ?)`
+		searchTerm := "%" + search + "%"
+		args = append(args, searchTerm, searchTerm, searchTerm, searchTerm)
+	}
+
+	var total int
+	countQuery := `
+		SELECT COUNT(*)
+		FROM counselor_profiles cp
+		JOIN users u ON cp.user_id = u.id
+	` + where
+	err := r.db.GetContext(ctx, &total, countQuery, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count counselors: %w", err)
+	}
+
+	orderColumn, ok := counselorOrderColumns[orderBy]
+	if !ok {
+		orderColumn = counselorOrderColumns["created_at"]
+	}
+
+	query := counselorsBaseQuery + where + fmt.Sprintf(
+		" ORDER BY %s LIMIT %d OFFSET %d",
+		orderColumn,
+		limit,
+		offset,
+	)
+
+	var profiles []CounselorProfileView
+	err = r.db.SelectContext(ctx, &profiles, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list counselors: %w", err)
+	}
+
+	return profiles, total, nil
+}
+
+func (r *Repository) GetByID(
+	ctx context.Context,
+	id int,
+) (*CounselorProfileView, error) {
+	var profile CounselorProfileView
+	err := r.db.GetContext(
+		ctx,
+		&profile,
+		counselorsBaseQuery+" WHERE cp.id = ?",
+		id,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get counselor: %w", err)
+	}
+
+	return &profile, nil
+}
+
+func (r *Repository) GetByUserID(
+	ctx context.Context,
+	userID string,
+) (*CounselorProfileView, error) {
+	var profile CounselorProfileView
+	err := r.db.GetContext(
+		ctx,
+		&profile,
+		counselorsBaseQuery+" WHERE cp.user_id = ?",
+		userID,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get counselor by user ID: %w", err)
+	}
+
+	return &profile, nil
+}
+
+func (r *Repository) Create(
+	ctx context.Context,
+	tx datastore.DB,
+	profile CounselorProfile,
+) (int, error) {
+	cols, vals := datastore.GetInsertStatement(profile, nil)
+	query := fmt.Sprintf(`
+		INSERT INTO counselor_profiles (%s)
+		VALUES (%s)
+	`, cols, vals)
+
+	res, err := tx.NamedExecContext(ctx, query, profile)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert counselor profile: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get counselor profile ID: %w", err)
+	}
+
+	return int(id), nil
+}
+
+func (r *Repository) Update(
+	ctx context.Context,
+	tx datastore.DB,
+	profile CounselorProfile,
+) error {
+	query := `
+		UPDATE counselor_profiles
+		SET license_number = :license_number,
+			specialization = :specialization,
+			is_available = :is_available
+		WHERE id = :id
+	`
+
+	res, err := tx.NamedExecContext(ctx, query, profile)
+	if err != nil {
+		return fmt.Errorf("failed to update counselor profile: %w", err)
+	}
+
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		// MySQL reports zero rows when values are unchanged, so only treat
+		// a missing profile as not found.
+		var exists int
+		err := tx.GetContext(
+			ctx,
+			&exists,
+			"SELECT COUNT(*) FROM counselor_profiles WHERE id = ?",
+			profile.ID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update counselor profile: %w", err)
+		}
+		if exists == 0 {
+			return sql.ErrNoRows
+		}
+	}
+
+	return nil
+}
+
+func (r *Repository) Delete(
+	ctx context.Context,
+	tx datastore.DB,
+	id int,
+) error {
+	res, err := tx.ExecContext(
+		ctx,
+		"DELETE FROM counselor_profiles WHERE id = ?",
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete counselor profile: %w", err)
+	}
+
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// FindAvailableCounselorID picks the available counselor with the lightest
+// load on the given date who is not yet booked for the time slot. It returns
+// an empty string when nobody is free.
+func (r *Repository) FindAvailableCounselorID(
+	ctx context.Context,
+	tx datastore.DB,
+	date string,
+	timeSlotID int,
+) (string, error) {
+	query := fmt.Sprintf(`
+		SELECT cp.user_id
+		FROM counselor_profiles cp
+		JOIN users u ON cp.user_id = u.id
+		LEFT JOIN appointments a ON a.counselor_id = cp.user_id
+			AND a.when_date = ?
+			AND a.status_id NOT IN (
+				SELECT id FROM statuses WHERE name IN %s
+			)
+		WHERE cp.is_available = 1
+			AND u.is_active = 1
+			AND %s
+		GROUP BY cp.user_id
+		HAVING SUM(CASE WHEN a.time_slot_id = ? THEN 1 ELSE 0 END) = 0
+		ORDER BY COUNT(a.id) ASC, cp.user_id ASC
+		LIMIT 1
+	`, inactiveAppointmentStatuses, onLeaveFilter)
+
+	var userID string
+	err := tx.GetContext(ctx, &userID, query, date, date, timeSlotID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to find available counselor: %w", err)
+	}
+
+	return userID, nil
+}
+
+func (r *Repository) CountActiveAppointments(
+	ctx context.Context,
+	tx datastore.DB,
+	userID, date string,
+	timeSlotID int,
+) (int, error) {
+	query := fmt.Sprintf(`
+		SELECT COUNT(*)
+		FROM appointments a
+		WHERE a.counselor_id = ?
+			AND a.when_date = ?
+			AND a.time_slot_id = ?
+			AND a.status_id NOT IN (
+				SELECT id FROM statuses WHERE name IN %s
+			)
+	`, inactiveAppointmentStatuses)
+
+	var count int
+	err := tx.GetContext(ctx, &count, query, userID, date, timeSlotID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count counselor appointments: %w", err)
+	}
+
+	return count, nil
+}
+
+// CountAvailable counts active counselors accepting appointments who are not
+// on leave on the given date.
+func (r *Repository) CountAvailable(
+	ctx context.Context,
+	tx datastore.DB,
+	date string,
+) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM counselor_profiles cp
+		JOIN users u ON cp.user_id = u.id
+		WHERE cp.is_available = 1
+			AND u.is_active = 1
+			AND ` + onLeaveFilter
+
+	var count int
+	if err := tx.GetContext(ctx, &count, query, date); err != nil {
+		return 0, fmt.Errorf("failed to count available counselors: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *Repository) IsOnLeave(
+	ctx context.Context,
+	tx datastore.DB,
+	userID, date string,
+) (bool, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM calendar_closures
+		WHERE counselor_id = ?
+			AND closure_type = 'leave'
+			AND ? BETWEEN start_date AND end_date
+	`
+
+	var count int
+	if err := tx.GetContext(ctx, &count, query, userID, date); err != nil {
+		return false, fmt.Errorf("failed to check counselor leave: %w", err)
+	}
+
+	return count > 0, nil
+}
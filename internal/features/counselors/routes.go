@@ -0,0 +1,48 @@
+package counselors
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/middleware"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/tokens"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
+)
+
+func RegisterRoutes(
+	rg *gin.RouterGroup,
+	h *Handler,
+	redis *datastore.RedisClient,
+	tokenService *tokens.Service,
+) {
+	routes := rg.Group("/counselors")
+	routes.Use(middleware.AuthMiddleware(redis, tokenService))
+	routes.Use(middleware.AuditContextMiddleware())
+
+	// Students browse counselors when picking one for an appointment
+	sharedRoutes := routes.Group("")
+	sharedRoutes.Use(middleware.RoleMiddleware(
+		int(constants.StudentRoleID),
+		int(constants.AdminRoleID),
+	))
+	{
+		sharedRoutes.GET("", h.GetCounselorList)
+		sharedRoutes.GET("/id/:id", h.GetCounselorByID)
+	}
+
+	adminOnly := routes.Group("")
+	adminOnly.Use(middleware.RoleMiddleware(int(constants.AdminRoleID)))
+	{
+		adminOnly.GET("/me", h.GetMyCounselorProfile)
+		adminOnly.PATCH("/me/availability", h.PatchMyAvailability)
+	}
+
+	superAdminOnly := routes.Group("")
+	superAdminOnly.Use(middleware.RoleMiddleware(
+		int(constants.SuperAdminRoleID),
+	))
+	{
+		superAdminOnly.POST("", h.PostCounselor)
+		superAdminOnly.PATCH("/id/:id", h.PatchCounselor)
+		superAdminOnly.DELETE("/id/:id", h.DeleteCounselor)
+	}
+}
@@ -0,0 +1,403 @@
+package counselors
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/olazo-johnalbert/duckload-api/internal/core/audit"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/structs"
+	"github.com/olazo-johnalbert/duckload-api/internal/features/users"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
+)
+
+var (
+	ErrCounselorNotFound = errors.New("counselor not found")
+	ErrUserNotFound      = errors.New("user not found")
+	ErrNotCounselorRole  = errors.New("user must have the admin role to be a counselor")
+	ErrCounselorExists   = errors.New("user already has a counselor profile")
+)
+
+type Service struct {
+	repo         RepositoryInterface
+	logService   audit.Logger
+	notifService audit.Notifier
+	userService  users.ServiceInterface
+}
+
+func NewService(
+	repo RepositoryInterface,
+	logService audit.Logger,
+	notifService audit.Notifier,
+	userService users.ServiceInterface,
+) *Service {
+	return &Service{
+		repo:         repo,
+		logService:   logService,
+		notifService: notifService,
+		userService:  userService,
+	}
+}
+
+func (s *Service) ListCounselors(
+	ctx context.Context,
+	req ListCounselorsRequest,
+) (*ListCounselorsDTO, error) {
+	req.SetDefaults("created_at")
+
+	profiles, total, err := s.repo.List(
+		ctx,
+		req.GetOffset(),
+		req.PageSize,
+		req.Search,
+		req.OrderBy,
+		req.IsAvailable,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	dtos := make([]CounselorDTO, 0, len(profiles))
+	for _, p := range profiles {
+		dtos = append(dtos, mapProfileToDTO(p))
+	}
+
+	return &ListCounselorsDTO{
+		Counselors: dtos,
+		Meta:       structs.CalculateMetadata(total, req.Page, req.PageSize),
+	}, nil
+}
+
+func (s *Service) GetCounselorByID(
+	ctx context.Context,
+	id int,
+) (*CounselorDTO, error) {
+	profile, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		return nil, nil
+	}
+
+	dto := mapProfileToDTO(*profile)
+	return &dto, nil
+}
+
+func (s *Service) GetCounselorByUserID(
+	ctx context.Context,
+	userID string,
+) (*CounselorDTO, error) {
+	profile, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		return nil, nil
+	}
+
+	dto := mapProfileToDTO(*profile)
+	return &dto, nil
+}
+
+func (s *Service) CreateCounselor(
+	ctx context.Context,
+	req CreateCounselorRequest,
+) (*CounselorDTO, error) {
+	user, err := s.userService.GetUserByID(ctx, req.UserID)
+	if err != nil || user == nil {
+		return nil, ErrUserNotFound
+	}
+	if user.Role.ID != int(constants.AdminRoleID) {
+		return nil, ErrNotCounselorRole
+	}
+
+	existing, err := s.repo.GetByUserID(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrCounselorExists
+	}
+
+	profile := CounselorProfile{
+		UserID: req.UserID,
+		LicenseNumber: sql.NullString{
+			String: req.LicenseNumber,
+			Valid:  req.LicenseNumber != "",
+		},
+		Specialization: sql.NullString{
+			String: req.Specialization,
+			Valid:  req.Specialization != "",
+		},
+		IsAvailable: req.IsAvailable == nil || *req.IsAvailable,
+	}
+
+	id, err := datastore.NewRunInTransaction(
+		ctx,
+		s.repo.GetDB(),
+		func(tx datastore.DB) (int, error) {
+			return s.repo.Create(ctx, tx, profile)
+		},
+	)
+	if err != nil {
+		audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+			Log: &audit.LogParams{
+				Level:    audit.LevelError,
+				Category: audit.CategoryAudit,
+				Action:   audit.ActionCounselorCreateFailed,
+				Message: fmt.Sprintf(
+					"Failed to create counselor profile for user %s",
+					req.UserID,
+				),
+				Metadata: &audit.LogMetadata{
+					EntityType: constants.CounselorEntityType,
+					NewValues:  req,
+					Error:      err.Error(),
+				},
+			},
+		})
+		return nil, err
+	}
+
+	audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+		Log: &audit.LogParams{
+			Level:    audit.LevelInfo,
+			Category: audit.CategoryAudit,
+			Action:   audit.ActionCounselorCreated,
+			Message: fmt.Sprintf(
+				"Counselor profile #%d created for %s",
+				id,
+				user.Email,
+			),
+			TargetID: structs.StringToNullableString(req.UserID),
+			TargetType: structs.StringToNullableString(
+				constants.CounselorEntityType,
+			),
+			Metadata: &audit.LogMetadata{
+				EntityType: constants.CounselorEntityType,
+				EntityID:   fmt.Sprintf("%d", id),
+				NewValues:  req,
+			},
+		},
+		Notifications: []audit.NotificationParams{
+			{
+				ReceiverID: structs.StringToNullableString(req.UserID),
+				Title:      "Counselor Profile Created",
+				Message:    "You can now be assigned to student appointments.",
+				Type:       constants.GeneralEntityType,
+			},
+		},
+	})
+
+	return s.GetCounselorByID(ctx, id)
+}
+
+func (s *Service) UpdateCounselor(
+	ctx context.Context,
+	id int,
+	req UpdateCounselorRequest,
+) (*CounselorDTO, error) {
+	oldProfile, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if oldProfile == nil {
+		return nil, ErrCounselorNotFound
+	}
+
+	profile := oldProfile.CounselorProfile
+	if req.LicenseNumber != nil {
+		profile.LicenseNumber = sql.NullString{
+			String: *req.LicenseNumber,
+			Valid:  *req.LicenseNumber != "",
+		}
+	}
+	if req.Specialization != nil {
+		profile.Specialization = sql.NullString{
+			String: *req.Specialization,
+			Valid:  *req.Specialization != "",
+		}
+	}
+	if req.IsAvailable != nil {
+		profile.IsAvailable = *req.IsAvailable
+	}
+
+	err = datastore.RunInTransaction(
+		ctx,
+		s.repo.GetDB(),
+		func(tx datastore.DB) error {
+			return s.repo.Update(ctx, tx, profile)
+		},
+	)
+	if err != nil {
+		audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+			Log: &audit.LogParams{
+				Level:    audit.LevelError,
+				Category: audit.CategoryAudit,
+				Action:   audit.ActionCounselorUpdateFailed,
+				Message:  fmt.Sprintf("Failed to update counselor #%d", id),
+				Metadata: &audit.LogMetadata{
+					EntityType: constants.CounselorEntityType,
+					EntityID:   fmt.Sprintf("%d", id),
+					OldValues:  mapProfileToDTO(*oldProfile),
+					NewValues:  req,
+					Error:      err.Error(),
+				},
+			},
+		})
+		return nil, err
+	}
+
+	audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+		Log: &audit.LogParams{
+			Level:    audit.LevelInfo,
+			Category: audit.CategoryAudit,
+			Action:   audit.ActionCounselorUpdated,
+			Message:  fmt.Sprintf("Counselor #%d updated", id),
+			TargetID: structs.StringToNullableString(profile.UserID),
+			TargetType: structs.StringToNullableString(
+				constants.CounselorEntityType,
+			),
+			Metadata: &audit.LogMetadata{
+				EntityType: constants.CounselorEntityType,
+				EntityID:   fmt.Sprintf("%d", id),
+				OldValues:  mapProfileToDTO(*oldProfile),
+				NewValues:  req,
+			},
+		},
+	})
+
+	return s.GetCounselorByID(ctx, id)
+}
+
+func (s *Service) DeleteCounselor(ctx context.Context, id int) error {
+	oldProfile, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if oldProfile == nil {
+		return ErrCounselorNotFound
+	}
+
+	err = datastore.RunInTransaction(
+		ctx,
+		s.repo.GetDB(),
+		func(tx datastore.DB) error {
+			return s.repo.Delete(ctx, tx, id)
+		},
+	)
+	if err != nil {
+		audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+			Log: &audit.LogParams{
+				Level:    audit.LevelError,
+				Category: audit.CategoryAudit,
+				Action:   audit.ActionCounselorDeleteFailed,
+				Message:  fmt.Sprintf("Failed to delete counselor #%d", id),
+				Metadata: &audit.LogMetadata{
+					EntityType: constants.CounselorEntityType,
+					EntityID:   fmt.Sprintf("%d", id),
+					Error:      err.Error(),
+				},
+			},
+		})
+		return err
+	}
+
+	audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+		Log: &audit.LogParams{
+			Level:    audit.LevelWarning,
+			Category: audit.CategoryAudit,
+			Action:   audit.ActionCounselorDeleted,
+			Message:  fmt.Sprintf("Counselor #%d deleted", id),
+			TargetID: structs.StringToNullableString(oldProfile.UserID),
+			TargetType: structs.StringToNullableString(
+				constants.CounselorEntityType,
+			),
+			Metadata: &audit.LogMetadata{
+				EntityType: constants.CounselorEntityType,
+				EntityID:   fmt.Sprintf("%d", id),
+				OldValues:  mapProfileToDTO(*oldProfile),
+			},
+		},
+	})
+
+	return nil
+}
+
+// FindAvailableCounselor returns the user ID of the counselor to auto-assign
+// for a booking, or an empty string when no counselor is free.
+func (s *Service) FindAvailableCounselor(
+	ctx context.Context,
+	tx datastore.DB,
+	date string,
+	timeSlotID int,
+) (string, error) {
+	return s.repo.FindAvailableCounselorID(ctx, tx, date, timeSlotID)
+}
+
+// IsCounselorFree reports whether the counselor is marked available, is not
+// on leave and has no active appointment in the given slot.
+func (s *Service) IsCounselorFree(
+	ctx context.Context,
+	tx datastore.DB,
+	userID, date string,
+	timeSlotID int,
+) (bool, error) {
+	profile, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if profile == nil || !profile.IsAvailable {
+		return false, nil
+	}
+
+	onLeave, err := s.repo.IsOnLeave(ctx, tx, userID, date)
+	if err != nil {
+		return false, err
+	}
+	if onLeave {
+		return false, nil
+	}
+
+	count, err := s.repo.CountActiveAppointments(
+		ctx,
+		tx,
+		userID,
+		date,
+		timeSlotID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return count == 0, nil
+}
+
+func mapProfileToDTO(p CounselorProfileView) CounselorDTO {
+	return CounselorDTO{
+		ID:             p.ID,
+		UserID:         p.UserID,
+		FirstName:      p.FirstName,
+		MiddleName:     structs.FromSqlNull(p.MiddleName),
+		LastName:       p.LastName,
+		Email:          p.Email,
+		LicenseNumber:  structs.FromSqlNull(p.LicenseNumber),
+		Specialization: structs.FromSqlNull(p.Specialization),
+		IsAvailable:    p.IsAvailable,
+		CreatedAt:      p.CreatedAt,
+		UpdatedAt:      p.UpdatedAt,
+	}
+}
+
+// CountAvailableCounselors returns how many active counselors accept
+// appointments on the given date.
+func (s *Service) CountAvailableCounselors(
+	ctx context.Context,
+	tx datastore.DB,
+	date string,
+) (int, error) {
+	return s.repo.CountAvailable(ctx, tx, date)
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/middleware"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/tokens"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
 )
 
@@ -11,10 +12,11 @@ func RegisterRoutes(
 	rg *gin.RouterGroup,
 	h *Handler,
 	redis *datastore.RedisClient,
+	tokenService *tokens.Service,
 ) {
 	// Base group for all activity logs
 	activityGroup := rg.Group("/activity-meta")
-	activityGroup.Use(middleware.AuthMiddleware(redis))
+	activityGroup.Use(middleware.AuthMiddleware(redis, tokenService))
 
 	// User-specific activity route (No role check, just auth)
 	activityGroup.GET("/me", h.GetMyLogs)
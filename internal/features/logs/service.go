@@ -75,8 +75,8 @@ func (s *Service) Record(
 		return
 	}
 
-	// Notify Superadmins for critical events
-	if level == audit.LevelError {
+	// Notify Superadmins for error and critical events
+	if level == audit.LevelError || level == audit.LevelCritical {
 		s.notifySuperadmins(ctx, entry)
 	}
 }
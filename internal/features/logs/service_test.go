@@ -0,0 +1,68 @@
+package logs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/olazo-johnalbert/duckload-api/internal/core/audit"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
+)
+
+type stubRepo struct {
+	RepositoryInterface
+}
+
+func (stubRepo) Record(context.Context, datastore.DB, *SystemLog) error {
+	return nil
+}
+
+type stubUsers struct{}
+
+func (stubUsers) GetUserIDsByRole(_ context.Context, roleID int) ([]string, error) {
+	if roleID != int(constants.SuperAdminRoleID) {
+		return nil, nil
+	}
+	return []string{"sa-1", "sa-2"}, nil
+}
+
+type stubNotifier struct {
+	sent []audit.NotificationEntry
+}
+
+func (n *stubNotifier) Send(_ context.Context, notif audit.NotificationEntry) error {
+	n.sent = append(n.sent, notif)
+	return nil
+}
+
+func TestRecordNotifiesSuperadmins(t *testing.T) {
+	tests := []struct {
+		level string
+		want  int
+	}{
+		{audit.LevelInfo, 0},
+		{audit.LevelError, 2},
+		{audit.LevelCritical, 2},
+	}
+
+	for _, tt := range tests {
+		notifier := &stubNotifier{}
+		svc := NewService(stubRepo{}, notifier, stubUsers{})
+
+		svc.Record(context.Background(), nil, audit.LogEntry{
+			Level:    tt.level,
+			Category: audit.CategorySecurity,
+			Action:   audit.ActionRefreshTokenReused,
+			Message:  "refresh token reused",
+		})
+
+		if len(notifier.sent) != tt.want {
+			t.Errorf(
+				"level %s: got %d notifications, want %d",
+				tt.level,
+				len(notifier.sent),
+				tt.want,
+			)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package m2mclients
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -37,6 +38,10 @@ func (h *Handler) PostM2MClient(c *gin.Context) {
 			response.SendError(c, err.Error(), http.StatusConflict, nil)
 			return
 		}
+		if errors.Is(err, ErrUnknownScope) {
+			response.SendFail(c, gin.H{"error": err.Error()})
+			return
+		}
 		log.Printf("[PostM2MClient] {CreateClient}: %v", err)
 		response.SendError(
 			c,
@@ -147,6 +152,11 @@ func (h *Handler) PostM2MRefresh(c *gin.Context) {
 	response.SendSuccess(c, tokenResp)
 }
 
+// GetM2MScopes lists the scopes that can be granted to an M2M client.
+func (h *Handler) GetM2MScopes(c *gin.Context) {
+	response.SendSuccess(c, constants.M2MScopes)
+}
+
 // GetM2MClients lists all M2M clients.
 func (h *Handler) GetM2MClients(c *gin.Context) {
 	includeRevoked := c.Query("include_revoked") == "true"
@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/middleware"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/tokens"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
 )
 
@@ -12,6 +13,7 @@ func RegisterRoutes(
 	rg *gin.RouterGroup,
 	h *Handler,
 	redis *datastore.RedisClient,
+	tokenService *tokens.Service,
 ) {
 	// Public M2M Auth Routes
 	m2mAuth := rg.Group("/auth/m2m")
@@ -22,7 +24,7 @@ func RegisterRoutes(
 
 	// Protected Management Routes
 	m2mMgmt := rg.Group("/m2m-clients")
-	m2mMgmt.Use(middleware.AuthMiddleware(redis))
+	m2mMgmt.Use(middleware.AuthMiddleware(redis, tokenService))
 	{
 		// Common routes for both Developer and Superadmin
 		common := m2mMgmt.Group("")
@@ -32,6 +34,7 @@ func RegisterRoutes(
 		))
 		{
 			common.GET("", h.GetM2MClients)
+			common.GET("/scopes", h.GetM2MScopes)
 			common.POST("", h.PostM2MClient)
 			common.POST("/:id/secret", h.PostM2MSecret)
 			common.DELETE("/:id", h.DeleteM2MClient)
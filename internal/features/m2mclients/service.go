@@ -8,6 +8,7 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -19,6 +20,10 @@ import (
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
 )
 
+// ErrUnknownScope is returned when a client requests a scope outside the
+// catalog in constants.M2MScopes.
+var ErrUnknownScope = errors.New("unknown scope")
+
 type Service struct {
 	repo           RepositoryInterface
 	logService     audit.Logger
@@ -68,6 +73,12 @@ func (s *Service) CreateClient(
 
 	clientID := uuid.New().String()
 
+	for _, scope := range req.Scopes {
+		if !constants.IsValidScope(scope) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownScope, scope)
+		}
+	}
+
 	var scopesJSON sql.NullString
 	if len(req.Scopes) > 0 {
 		b, _ := json.Marshal(req.Scopes)
@@ -194,21 +205,18 @@ func (s *Service) IssueToken(
 	ctx context.Context,
 	client *M2MClient,
 ) (*M2MTokenResponse, error) {
-	accessToken, claims, err := s.tokenService.GenerateToken(
+	scopes := parseScopes(client.Scopes)
+
+	accessToken, claims, err := s.tokenService.GenerateM2MToken(
+		client.ClientID,
 		client.ClientName,
-		"", // No userID for M2M
-		0,  // No roleID for M2M
-		"m2m_client",
-		string(constants.AuthTypeM2M),
+		scopes,
 		constants.M2MAccessTokenMaxAge,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate access token: %w", err)
 	}
 
-	// Set M2M marker in claims
-	claims.M2MClientID = client.ClientID
-
 	// Access Token session in Redis
 	accessSession := map[string]string{
 		"clientId":   client.ClientID,
@@ -227,12 +235,12 @@ func (s *Service) IssueToken(
 	}
 
 	// Refresh Token (24 hours)
-	refreshToken, rClaims, err := s.tokenService.GenerateToken(
+	// Refresh tokens carry no scopes so they cannot be used as bearer
+	// tokens on integration routes
+	refreshToken, rClaims, err := s.tokenService.GenerateM2MToken(
+		client.ClientID,
 		client.ClientName,
-		"",
-		0,
-		"m2m_client",
-		string(constants.AuthTypeM2M),
+		nil,
 		constants.M2MRefreshTokenMaxAge,
 	)
 	if err != nil {
@@ -517,11 +525,7 @@ func mapClientToDTO(client M2MClient) M2MClientDTO {
 		CreatedAt:         client.CreatedAt,
 	}
 
-	if client.Scopes.Valid {
-		var scopes []string
-		_ = json.Unmarshal([]byte(client.Scopes.String), &scopes)
-		dto.Scopes = scopes
-	}
+	dto.Scopes = parseScopes(client.Scopes)
 
 	if client.LastUsedAt.Valid {
 		dto.LastUsedAt = &client.LastUsedAt.Time
@@ -532,3 +536,13 @@ func mapClientToDTO(client M2MClient) M2MClientDTO {
 
 	return dto
 }
+
+func parseScopes(raw sql.NullString) []string {
+	if !raw.Valid {
+		return nil
+	}
+
+	var scopes []string
+	_ = json.Unmarshal([]byte(raw.String), &scopes)
+	return scopes
+}
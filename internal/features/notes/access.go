@@ -0,0 +1,58 @@
+package notes
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+)
+
+// Confidentiality decides which admin roles may read and edit a note.
+type Confidentiality string
+
+const (
+	ConfidentialityStandard     Confidentiality = "standard"
+	ConfidentialityConfidential Confidentiality = "confidential"
+	ConfidentialityPrivate      Confidentiality = "private"
+)
+
+var (
+	ErrNoteNotFound     = errors.New("note not found")
+	ErrNoteAccessDenied = errors.New("you cannot access this note")
+	ErrNoteLink         = errors.New(
+		"a note can link to an appointment or an admission slip, not both",
+	)
+	ErrNoteLinkMismatch = errors.New(
+		"linked record does not belong to this student",
+	)
+)
+
+// confidentialityRoles lists the roles that may read each level. Standard
+// notes are also open to super admins, confidential notes stay with the
+// counselors, and private notes are further limited to their author.
+var confidentialityRoles = map[Confidentiality][]constants.RoleID{
+	ConfidentialityStandard: {
+		constants.AdminRoleID,
+		constants.SuperAdminRoleID,
+	},
+	ConfidentialityConfidential: {constants.AdminRoleID},
+	ConfidentialityPrivate:      {constants.AdminRoleID},
+}
+
+// canWrite reports whether the role may create, edit or delete notes. Only
+// counselors write notes; super admins may only read them.
+func canWrite(role constants.RoleID) bool {
+	return role == constants.AdminRoleID
+}
+
+// canAccess reports whether the user may read or change the note.
+func canAccess(note *SignificantNote, userID string, role constants.RoleID) bool {
+	if !slices.Contains(confidentialityRoles[note.Confidentiality], role) {
+		return false
+	}
+	if note.Confidentiality == ConfidentialityPrivate {
+		return note.CreatedBy.Valid && note.CreatedBy.String == userID
+	}
+
+	return true
+}
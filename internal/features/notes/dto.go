@@ -3,10 +3,31 @@ package notes
 import "time"
 
 type SignificantNoteDTO struct {
-	ID            string    `json:"id,omitempty"`
-	AppointmentID string    `json:"appointmentId,omitempty"`
-	Note          string    `json:"note"                    binding:"required"`
-	Remarks       string    `json:"remarks"                 binding:"required"`
-	CreatedAt     time.Time `json:"createdAt,omitempty"                        db:"created_at"`
-	UpdatedAt     time.Time `json:"updatedAt,omitempty"                        db:"updated_at"`
+	ID              string          `json:"id,omitempty"`
+	AppointmentID   string          `json:"appointmentId,omitempty"`
+	AdmissionSlipID string          `json:"admissionSlipId,omitempty"`
+	Note            string          `json:"note"                      binding:"required"`
+	Remarks         string          `json:"remarks"                   binding:"required"`
+	Confidentiality Confidentiality `json:"confidentiality,omitempty" binding:"omitempty,oneof=standard confidential private"`
+	Version         int             `json:"version,omitempty"`
+	CreatedBy       string          `json:"createdBy,omitempty"`
+	CreatedAt       time.Time       `json:"createdAt,omitempty"                                                               db:"created_at"`
+	UpdatedAt       time.Time       `json:"updatedAt,omitempty"                                                               db:"updated_at"`
+}
+
+// DeleteSignificantNoteRequest records why a note was removed.
+type DeleteSignificantNoteRequest struct {
+	Reason string `json:"reason" binding:"required,max=1000"`
+}
+
+// SignificantNoteVersionDTO is one entry in a note's edit history.
+type SignificantNoteVersionDTO struct {
+	Version         int             `json:"version"`
+	Note            string          `json:"note"`
+	Remarks         string          `json:"remarks"`
+	Confidentiality Confidentiality `json:"confidentiality"`
+	AppointmentID   string          `json:"appointmentId,omitempty"`
+	AdmissionSlipID string          `json:"admissionSlipId,omitempty"`
+	EditedBy        string          `json:"editedBy,omitempty"`
+	CreatedAt       time.Time       `json:"createdAt"`
 }
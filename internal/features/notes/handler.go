@@ -1,6 +1,7 @@
 package notes
 
 import (
+	"errors"
 	"log"
 	"net/http"
 
@@ -72,6 +73,9 @@ func (h *Handler) PostSignificantNote(
 		iirID,
 		noteReq,
 	)
+	if err != nil && sendNoteError(c, err) {
+		return
+	}
 	if err != nil {
 		log.Printf("[PostSignificantNote] {Database Insert}: %v", err)
 		response.SendError(
@@ -88,3 +92,110 @@ func (h *Handler) PostSignificantNote(
 		gin.H{"message": "Significant note saved successfully"},
 	)
 }
+
+func (h *Handler) PutSignificantNote(
+	c *gin.Context,
+) {
+	var noteReq SignificantNoteDTO
+	if err := c.ShouldBindJSON(&noteReq); err != nil {
+		log.Printf("[PutSignificantNote] {JSON Bind}: %v", err)
+		response.SendFail(c, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	note, err := h.service.UpdateSignificantNote(
+		c.Request.Context(),
+		c.Param("noteID"),
+		noteReq,
+	)
+	if err != nil {
+		if !sendNoteError(c, err) {
+			log.Printf("[PutSignificantNote] {Database Update}: %v", err)
+			response.SendError(
+				c,
+				"Failed to update significant note",
+				http.StatusInternalServerError,
+				nil,
+			)
+		}
+		return
+	}
+
+	response.SendSuccess(c, note)
+}
+
+func (h *Handler) DeleteSignificantNote(
+	c *gin.Context,
+) {
+	var req DeleteSignificantNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[DeleteSignificantNote] {JSON Bind}: %v", err)
+		response.SendFail(c, gin.H{"error": "A reason is required"})
+		return
+	}
+
+	err := h.service.DeleteSignificantNote(
+		c.Request.Context(),
+		c.Param("noteID"),
+		req.Reason,
+	)
+	if err != nil {
+		if !sendNoteError(c, err) {
+			log.Printf("[DeleteSignificantNote] {Database Update}: %v", err)
+			response.SendError(
+				c,
+				"Failed to delete significant note",
+				http.StatusInternalServerError,
+				nil,
+			)
+		}
+		return
+	}
+
+	response.SendSuccess(
+		c,
+		gin.H{"message": "Significant note deleted successfully"},
+	)
+}
+
+func (h *Handler) GetSignificantNoteVersions(
+	c *gin.Context,
+) {
+	versions, err := h.service.GetSignificantNoteVersions(
+		c.Request.Context(),
+		c.Param("noteID"),
+	)
+	if err != nil {
+		if !sendNoteError(c, err) {
+			log.Printf("[GetSignificantNoteVersions] {Database Query}: %v", err)
+			response.SendError(
+				c,
+				"Failed to get note history",
+				http.StatusInternalServerError,
+				nil,
+			)
+		}
+		return
+	}
+
+	response.SendSuccess(c, versions)
+}
+
+// sendNoteError responds to the note errors callers can act on and
+// reports whether it did.
+func sendNoteError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, ErrNoteNotFound):
+		response.SendFail(c, gin.H{"error": err.Error()}, http.StatusNotFound)
+	case errors.Is(err, ErrNoteAccessDenied):
+		response.SendFail(c, gin.H{"error": err.Error()}, http.StatusForbidden)
+	case errors.Is(err, ErrNoteConflict):
+		response.SendFail(c, gin.H{"error": err.Error()}, http.StatusConflict)
+	case errors.Is(err, ErrNoteLink), errors.Is(err, ErrNoteLinkMismatch):
+		response.SendFail(c, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+
+	return true
+}
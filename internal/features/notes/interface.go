@@ -2,6 +2,7 @@ package notes
 
 import (
 	"context"
+	"database/sql"
 )
 
 // ServiceInterface defines the business logic for managing student notes.
@@ -15,6 +16,20 @@ type ServiceInterface interface {
 		iirID string,
 		noteReq SignificantNoteDTO,
 	) error
+	UpdateSignificantNote(
+		ctx context.Context,
+		noteID string,
+		noteReq SignificantNoteDTO,
+	) (*SignificantNoteDTO, error)
+	DeleteSignificantNote(
+		ctx context.Context,
+		noteID string,
+		reason string,
+	) error
+	GetSignificantNoteVersions(
+		ctx context.Context,
+		noteID string,
+	) ([]SignificantNoteVersionDTO, error)
 	HasNoteForAppointment(
 		ctx context.Context,
 		appointmentID string,
@@ -31,6 +46,35 @@ type RepositoryInterface interface {
 		ctx context.Context,
 		sn *SignificantNote,
 	) (string, error)
+	GetSignificantNote(
+		ctx context.Context,
+		id string,
+	) (*SignificantNote, error)
+	UpdateSignificantNote(
+		ctx context.Context,
+		sn *SignificantNote,
+		editedBy sql.NullString,
+	) (bool, error)
+	SoftDeleteSignificantNote(
+		ctx context.Context,
+		id string,
+		deletedBy sql.NullString,
+		reason string,
+	) (bool, error)
+	ListNoteVersions(
+		ctx context.Context,
+		noteID string,
+	) ([]SignificantNoteVersion, error)
+	AppointmentBelongsToIIR(
+		ctx context.Context,
+		appointmentID string,
+		iirID string,
+	) (bool, error)
+	SlipBelongsToIIR(
+		ctx context.Context,
+		slipID string,
+		iirID string,
+	) (bool, error)
 	HasNoteForAppointment(
 		ctx context.Context,
 		appointmentID string,
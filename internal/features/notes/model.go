@@ -7,12 +7,33 @@ import (
 
 // Significant Notes and Incidents
 type SignificantNote struct {
-	ID              string         `db:"id"                json:"id"`
-	IIRID           sql.NullString `db:"iir_id"            json:"iirId"`
-	AppointmentID   sql.NullString `db:"appointment_id"    json:"appointmentId"`
-	AdmissionSlipID sql.NullString `db:"admission_slip_id" json:"admissionSlipId"`
-	Note            string         `db:"note"              json:"note"`
-	Remarks         string         `db:"remarks"           json:"remarks"`
-	CreatedAt       time.Time      `db:"created_at"        json:"createdAt"`
-	UpdatedAt       time.Time      `db:"updated_at"        json:"updatedAt"`
+	ID              string          `db:"id"                json:"id"`
+	IIRID           sql.NullString  `db:"iir_id"            json:"iirId"`
+	AppointmentID   sql.NullString  `db:"appointment_id"    json:"appointmentId"`
+	AdmissionSlipID sql.NullString  `db:"admission_slip_id" json:"admissionSlipId"`
+	Note            string          `db:"note"              json:"note"`
+	Remarks         string          `db:"remarks"           json:"remarks"`
+	Confidentiality Confidentiality `db:"confidentiality"   json:"confidentiality"`
+	Version         int             `db:"version"           json:"version"`
+	CreatedBy       sql.NullString  `db:"created_by"        json:"createdBy"`
+	DeletedAt       sql.NullTime    `db:"deleted_at"        json:"deletedAt"`
+	DeletedBy       sql.NullString  `db:"deleted_by"        json:"deletedBy"`
+	DeleteReason    sql.NullString  `db:"delete_reason"     json:"deleteReason"`
+	CreatedAt       time.Time       `db:"created_at"        json:"createdAt"`
+	UpdatedAt       time.Time       `db:"updated_at"        json:"updatedAt"`
+}
+
+// SignificantNoteVersion is one saved state of a note. Versions are never
+// changed once written.
+type SignificantNoteVersion struct {
+	ID              int             `db:"id"`
+	NoteID          string          `db:"note_id"`
+	Version         int             `db:"version"`
+	Note            string          `db:"note"`
+	Remarks         string          `db:"remarks"`
+	Confidentiality Confidentiality `db:"confidentiality"`
+	AppointmentID   sql.NullString  `db:"appointment_id"`
+	AdmissionSlipID sql.NullString  `db:"admission_slip_id"`
+	EditedBy        sql.NullString  `db:"edited_by"`
+	CreatedAt       time.Time       `db:"created_at"`
 }
@@ -2,19 +2,30 @@ package notes
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/encryption"
+)
+
+// Encrypted columns. The field names are bound into each ciphertext.
+const (
+	noteField           = "significant_notes.note"
+	remarksField        = "significant_notes.remarks"
+	versionNoteField    = "significant_note_versions.note"
+	versionRemarksField = "significant_note_versions.remarks"
 )
 
 type Repository struct {
-	db *sqlx.DB
+	db     *sqlx.DB
+	cipher *encryption.Cipher
 }
 
-func NewRepository(db *sqlx.DB) *Repository {
-	return &Repository{db: db}
+func NewRepository(db *sqlx.DB, cipher *encryption.Cipher) *Repository {
+	return &Repository{db: db, cipher: cipher}
 }
 
 func (r *Repository) GetStudentSignificantNotes(
@@ -24,7 +35,7 @@ func (r *Repository) GetStudentSignificantNotes(
 	query := fmt.Sprintf(`
 		SELECT %s
 		FROM significant_notes
-		WHERE iir_id = ?
+		WHERE iir_id = ? AND deleted_at IS NULL
 	`, datastore.GetColumns(SignificantNote{}))
 
 	var notes []SignificantNote
@@ -35,6 +46,11 @@ func (r *Repository) GetStudentSignificantNotes(
 			err,
 		)
 	}
+	for i := range notes {
+		if err := r.decryptNote(ctx, &notes[i]); err != nil {
+			return nil, err
+		}
+	}
 
 	log.Printf(
 		"[GetStudentSignificantNotes] {Database Query}: Retrieved %d notes for IIR ID %s",
@@ -53,6 +69,11 @@ func (r *Repository) CreateSignificantNote(
 		ctx,
 		r.db,
 		func(tx datastore.DB) (string, error) {
+			encrypted, err := r.encryptNote(ctx, sn)
+			if err != nil {
+				return "", err
+			}
+
 			cols, vals := datastore.GetInsertStatement(
 				SignificantNote{},
 				[]string{"created_at", "updated_at"},
@@ -63,10 +84,10 @@ func (r *Repository) CreateSignificantNote(
 				VALUES (:id, %s)
 			`, cols, vals)
 
-			_, err := tx.NamedExecContext(
+			_, err = tx.NamedExecContext(
 				ctx,
 				query,
-				sn,
+				encrypted,
 			)
 			if err != nil {
 				return "", fmt.Errorf(
@@ -75,6 +96,10 @@ func (r *Repository) CreateSignificantNote(
 				)
 			}
 
+			if err := r.createNoteVersion(ctx, tx, sn, sn.CreatedBy); err != nil {
+				return "", err
+			}
+
 			return sn.ID, nil
 		},
 	)
@@ -85,7 +110,11 @@ func (r *Repository) HasNoteForAppointment(
 	appointmentID string,
 ) (bool, error) {
 	var count int
-	query := "SELECT COUNT(*) FROM significant_notes WHERE appointment_id = ?"
+	query := `
+		SELECT COUNT(*)
+		FROM significant_notes
+		WHERE appointment_id = ? AND deleted_at IS NULL
+	`
 	err := r.db.GetContext(ctx, &count, query, appointmentID)
 	if err != nil {
 		return false, fmt.Errorf(
@@ -96,3 +125,258 @@ func (r *Repository) HasNoteForAppointment(
 
 	return count > 0, nil
 }
+
+// GetSignificantNote returns a note, including soft-deleted ones.
+func (r *Repository) GetSignificantNote(
+	ctx context.Context,
+	id string,
+) (*SignificantNote, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM significant_notes
+		WHERE id = ?
+	`, datastore.GetColumns(SignificantNote{}))
+
+	var note SignificantNote
+	err := r.db.GetContext(ctx, &note, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get significant note: %w", err)
+	}
+	if err := r.decryptNote(ctx, &note); err != nil {
+		return nil, err
+	}
+
+	return &note, nil
+}
+
+// UpdateSignificantNote saves an edit as the next version. It reports false
+// when the note was deleted or edited since sn.Version was read.
+func (r *Repository) UpdateSignificantNote(
+	ctx context.Context,
+	sn *SignificantNote,
+	editedBy sql.NullString,
+) (bool, error) {
+	return datastore.NewRunInTransaction(
+		ctx,
+		r.db,
+		func(tx datastore.DB) (bool, error) {
+			encrypted, err := r.encryptNote(ctx, sn)
+			if err != nil {
+				return false, err
+			}
+
+			query := `
+				UPDATE significant_notes
+				SET note = ?, remarks = ?, confidentiality = ?,
+					appointment_id = ?, admission_slip_id = ?,
+					version = version + 1
+				WHERE id = ? AND version = ? AND deleted_at IS NULL
+			`
+			res, err := tx.ExecContext(
+				ctx,
+				query,
+				encrypted.Note,
+				encrypted.Remarks,
+				sn.Confidentiality,
+				sn.AppointmentID,
+				sn.AdmissionSlipID,
+				sn.ID,
+				sn.Version,
+			)
+			if err != nil {
+				return false, fmt.Errorf(
+					"failed to update significant note: %w",
+					err,
+				)
+			}
+
+			rows, err := res.RowsAffected()
+			if err != nil {
+				return false, fmt.Errorf(
+					"failed to update significant note: %w",
+					err,
+				)
+			}
+			if rows == 0 {
+				return false, nil
+			}
+
+			sn.Version++
+			if err := r.createNoteVersion(ctx, tx, sn, editedBy); err != nil {
+				return false, err
+			}
+
+			return true, nil
+		},
+	)
+}
+
+// SoftDeleteSignificantNote hides a note and records who removed it and
+// why. It reports false when the note was already deleted.
+func (r *Repository) SoftDeleteSignificantNote(
+	ctx context.Context,
+	id string,
+	deletedBy sql.NullString,
+	reason string,
+) (bool, error) {
+	query := `
+		UPDATE significant_notes
+		SET deleted_at = NOW(), deleted_by = ?, delete_reason = ?
+		WHERE id = ? AND deleted_at IS NULL
+	`
+	res, err := r.db.ExecContext(ctx, query, deletedBy, reason, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete significant note: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete significant note: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// ListNoteVersions returns a note's history, oldest first.
+func (r *Repository) ListNoteVersions(
+	ctx context.Context,
+	noteID string,
+) ([]SignificantNoteVersion, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM significant_note_versions
+		WHERE note_id = ?
+		ORDER BY version ASC
+	`, datastore.GetColumns(SignificantNoteVersion{}))
+
+	var versions []SignificantNoteVersion
+	err := r.db.SelectContext(ctx, &versions, query, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list note versions: %w", err)
+	}
+	for i := range versions {
+		v := &versions[i]
+		if v.Note, err = r.cipher.Decrypt(ctx, versionNoteField, v.Note); err != nil {
+			return nil, err
+		}
+		if v.Remarks, err = r.cipher.Decrypt(
+			ctx,
+			versionRemarksField,
+			v.Remarks,
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	return versions, nil
+}
+
+// AppointmentBelongsToIIR reports whether the appointment was booked by
+// the student.
+func (r *Repository) AppointmentBelongsToIIR(
+	ctx context.Context,
+	appointmentID string,
+	iirID string,
+) (bool, error) {
+	var count int
+	query := "SELECT COUNT(*) FROM appointments WHERE id = ? AND iir_id = ?"
+	err := r.db.GetContext(ctx, &count, query, appointmentID, iirID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check appointment: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// SlipBelongsToIIR reports whether the admission slip was submitted by the
+// student.
+func (r *Repository) SlipBelongsToIIR(
+	ctx context.Context,
+	slipID string,
+	iirID string,
+) (bool, error) {
+	var count int
+	query := "SELECT COUNT(*) FROM admission_slips WHERE id = ? AND iir_id = ?"
+	err := r.db.GetContext(ctx, &count, query, slipID, iirID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check admission slip: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+func (r *Repository) createNoteVersion(
+	ctx context.Context,
+	tx datastore.DB,
+	sn *SignificantNote,
+	editedBy sql.NullString,
+) error {
+	note, err := r.cipher.Encrypt(ctx, versionNoteField, sn.Note)
+	if err != nil {
+		return err
+	}
+	remarks, err := r.cipher.Encrypt(ctx, versionRemarksField, sn.Remarks)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO significant_note_versions (
+			note_id, version, note, remarks, confidentiality,
+			appointment_id, admission_slip_id, edited_by
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = tx.ExecContext(
+		ctx,
+		query,
+		sn.ID,
+		sn.Version,
+		note,
+		remarks,
+		sn.Confidentiality,
+		sn.AppointmentID,
+		sn.AdmissionSlipID,
+		editedBy,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save note version: %w", err)
+	}
+
+	return nil
+}
+
+// encryptNote returns a copy of sn with its sensitive fields encrypted.
+func (r *Repository) encryptNote(
+	ctx context.Context,
+	sn *SignificantNote,
+) (*SignificantNote, error) {
+	encrypted := *sn
+
+	var err error
+	if encrypted.Note, err = r.cipher.Encrypt(ctx, noteField, sn.Note); err != nil {
+		return nil, err
+	}
+	if encrypted.Remarks, err = r.cipher.Encrypt(
+		ctx,
+		remarksField,
+		sn.Remarks,
+	); err != nil {
+		return nil, err
+	}
+
+	return &encrypted, nil
+}
+
+func (r *Repository) decryptNote(ctx context.Context, sn *SignificantNote) error {
+	var err error
+	if sn.Note, err = r.cipher.Decrypt(ctx, noteField, sn.Note); err != nil {
+		return err
+	}
+	sn.Remarks, err = r.cipher.Decrypt(ctx, remarksField, sn.Remarks)
+
+	return err
+}
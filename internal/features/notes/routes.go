@@ -5,6 +5,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/middleware"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/tokens"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
 )
 
@@ -13,13 +14,20 @@ func RegisterRoutes(
 	rg *gin.RouterGroup,
 	h *Handler,
 	redis *datastore.RedisClient,
+	tokenService *tokens.Service,
 ) {
 	routes := rg.Group("/notes")
-	routes.Use(middleware.AuthMiddleware(redis))
+	routes.Use(middleware.AuthMiddleware(redis, tokenService))
 	routes.Use(middleware.HydrateStudentContext(db))
+	// Super admins pass this check too; the service limits what they see
+	// and keeps writes to counselors
 	routes.Use(middleware.RoleMiddleware(int(constants.AdminRoleID)))
+	routes.Use(middleware.AuditContextMiddleware())
 	{
 		routes.GET("/user/id/:iirID", h.GetSignificantNotes)
 		routes.POST("/user/id/:iirID", h.PostSignificantNote)
+		routes.PUT("/id/:noteID", h.PutSignificantNote)
+		routes.DELETE("/id/:noteID", h.DeleteSignificantNote)
+		routes.GET("/id/:noteID/versions", h.GetSignificantNoteVersions)
 	}
 }
@@ -3,10 +3,16 @@ package notes
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/audit"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+)
+
+var ErrNoteConflict = errors.New(
+	"note was changed by someone else; reload it and try again",
 )
 
 type Service struct {
@@ -27,6 +33,8 @@ func NewService(
 	}
 }
 
+// GetStudentSignificantNotes returns the student's notes the caller is
+// allowed to read. Deleted notes are left out.
 func (s *Service) GetStudentSignificantNotes(
 	ctx context.Context,
 	iirID string,
@@ -42,16 +50,15 @@ func (s *Service) GetStudentSignificantNotes(
 		)
 	}
 
+	userID := audit.ExtractUserID(ctx)
+	role := constants.RoleID(audit.ExtractRoleID(ctx))
+
 	var noteDTOs []SignificantNoteDTO
-	for _, n := range notes {
-		noteDTOs = append(noteDTOs, SignificantNoteDTO{
-			ID:            n.ID,
-			AppointmentID: n.AppointmentID.String,
-			Note:          n.Note,
-			Remarks:       n.Remarks,
-			CreatedAt:     n.CreatedAt,
-			UpdatedAt:     n.UpdatedAt,
-		})
+	for i := range notes {
+		if !canAccess(&notes[i], userID, role) {
+			continue
+		}
+		noteDTOs = append(noteDTOs, mapNote(&notes[i]))
 	}
 
 	return noteDTOs, nil
@@ -62,6 +69,13 @@ func (s *Service) CreateSignificantNote(
 	iirID string,
 	noteReq SignificantNoteDTO,
 ) error {
+	if !canWrite(constants.RoleID(audit.ExtractRoleID(ctx))) {
+		return ErrNoteAccessDenied
+	}
+	if err := s.checkLinks(ctx, iirID, noteReq); err != nil {
+		return err
+	}
+
 	note := &SignificantNote{
 		ID:    uuid.New().String(),
 		IIRID: sql.NullString{String: iirID, Valid: true},
@@ -69,8 +83,24 @@ func (s *Service) CreateSignificantNote(
 			String: noteReq.AppointmentID,
 			Valid:  noteReq.AppointmentID != "",
 		},
-		Note:    noteReq.Note,
-		Remarks: noteReq.Remarks,
+		AdmissionSlipID: sql.NullString{
+			String: noteReq.AdmissionSlipID,
+			Valid:  noteReq.AdmissionSlipID != "",
+		},
+		Note:            noteReq.Note,
+		Remarks:         noteReq.Remarks,
+		Confidentiality: confidentialityOrDefault(noteReq.Confidentiality),
+		Version:         1,
+		CreatedBy:       actorID(ctx),
+	}
+
+	// Authors may not pick a level they themselves cannot read
+	if !canAccess(
+		note,
+		audit.ExtractUserID(ctx),
+		constants.RoleID(audit.ExtractRoleID(ctx)),
+	) {
+		return ErrNoteAccessDenied
 	}
 
 	_, err := s.repo.CreateSignificantNote(ctx, note)
@@ -85,8 +115,8 @@ func (s *Service) CreateSignificantNote(
 					iirID,
 				),
 				Metadata: &audit.LogMetadata{
-					EntityType: "Note",
-					NewValues:  note,
+					EntityType: constants.NoteEntityType,
+					NewValues:  auditValues(note),
 					Error:      err.Error(),
 				},
 			},
@@ -107,9 +137,166 @@ func (s *Service) CreateSignificantNote(
 				iirID,
 			),
 			Metadata: &audit.LogMetadata{
-				EntityType: "Note",
+				EntityType: constants.NoteEntityType,
 				EntityID:   note.ID,
-				NewValues:  note,
+				NewValues:  auditValues(note),
+			},
+		},
+	})
+
+	return nil
+}
+
+// UpdateSignificantNote saves an edit as a new version; earlier versions
+// stay in the note's history. When noteReq.Version is set, the edit is
+// rejected if the note has moved past that version.
+func (s *Service) UpdateSignificantNote(
+	ctx context.Context,
+	noteID string,
+	noteReq SignificantNoteDTO,
+) (*SignificantNoteDTO, error) {
+	if !canWrite(constants.RoleID(audit.ExtractRoleID(ctx))) {
+		return nil, ErrNoteAccessDenied
+	}
+	existing, err := s.getAccessibleNote(ctx, noteID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkLinks(ctx, existing.IIRID.String, noteReq); err != nil {
+		return nil, err
+	}
+
+	updated := *existing
+	updated.Note = noteReq.Note
+	updated.Remarks = noteReq.Remarks
+	updated.AppointmentID = sql.NullString{
+		String: noteReq.AppointmentID,
+		Valid:  noteReq.AppointmentID != "",
+	}
+	updated.AdmissionSlipID = sql.NullString{
+		String: noteReq.AdmissionSlipID,
+		Valid:  noteReq.AdmissionSlipID != "",
+	}
+	if noteReq.Confidentiality != "" {
+		updated.Confidentiality = noteReq.Confidentiality
+	}
+	if noteReq.Version != 0 {
+		updated.Version = noteReq.Version
+	}
+
+	// Making a note private would lock out everyone but its author
+	if updated.Confidentiality == ConfidentialityPrivate &&
+		existing.CreatedBy.String != audit.ExtractUserID(ctx) {
+		return nil, ErrNoteAccessDenied
+	}
+
+	ok, err := s.repo.UpdateSignificantNote(ctx, &updated, actorID(ctx))
+	if err == nil && !ok {
+		err = ErrNoteConflict
+	}
+	if err != nil {
+		audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+			Log: &audit.LogParams{
+				Level:    audit.LevelError,
+				Category: audit.CategoryAudit,
+				Action:   audit.ActionNoteUpdateFailed,
+				Message: fmt.Sprintf(
+					"Failed to update significant note #%s",
+					noteID,
+				),
+				Metadata: &audit.LogMetadata{
+					EntityType: constants.NoteEntityType,
+					EntityID:   noteID,
+					Error:      err.Error(),
+				},
+			},
+		})
+		return nil, err
+	}
+
+	audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+		Log: &audit.LogParams{
+			Level:    audit.LevelInfo,
+			Category: audit.CategoryAudit,
+			Action:   audit.ActionNoteUpdated,
+			Message: fmt.Sprintf(
+				"Significant note #%s updated to version %d",
+				noteID,
+				updated.Version,
+			),
+			Metadata: &audit.LogMetadata{
+				EntityType: constants.NoteEntityType,
+				EntityID:   noteID,
+				OldValues:  auditValues(existing),
+				NewValues:  auditValues(&updated),
+			},
+		},
+	})
+
+	dto := mapNote(&updated)
+	return &dto, nil
+}
+
+// DeleteSignificantNote hides a note and records the reason. The note and
+// its history stay in the database.
+func (s *Service) DeleteSignificantNote(
+	ctx context.Context,
+	noteID string,
+	reason string,
+) error {
+	if !canWrite(constants.RoleID(audit.ExtractRoleID(ctx))) {
+		return ErrNoteAccessDenied
+	}
+	existing, err := s.getAccessibleNote(ctx, noteID)
+	if err != nil {
+		return err
+	}
+
+	ok, err := s.repo.SoftDeleteSignificantNote(
+		ctx,
+		noteID,
+		actorID(ctx),
+		reason,
+	)
+	if err == nil && !ok {
+		err = ErrNoteNotFound
+	}
+	if err != nil {
+		audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+			Log: &audit.LogParams{
+				Level:    audit.LevelError,
+				Category: audit.CategoryAudit,
+				Action:   audit.ActionNoteDeleteFailed,
+				Message: fmt.Sprintf(
+					"Failed to delete significant note #%s",
+					noteID,
+				),
+				Metadata: &audit.LogMetadata{
+					EntityType: constants.NoteEntityType,
+					EntityID:   noteID,
+					Error:      err.Error(),
+				},
+			},
+		})
+		return err
+	}
+
+	audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+		Log: &audit.LogParams{
+			Level:    audit.LevelInfo,
+			Category: audit.CategoryAudit,
+			Action:   audit.ActionNoteDeleted,
+			Message: fmt.Sprintf(
+				"Significant note #%s deleted",
+				noteID,
+			),
+			Metadata: &audit.LogMetadata{
+				EntityType: constants.NoteEntityType,
+				EntityID:   noteID,
+				OldValues:  auditValues(existing),
+				NewValues: map[string]interface{}{
+					"reason": reason,
+				},
 			},
 		},
 	})
@@ -117,9 +304,140 @@ func (s *Service) CreateSignificantNote(
 	return nil
 }
 
+// GetSignificantNoteVersions returns every saved version of a note, oldest
+// first.
+func (s *Service) GetSignificantNoteVersions(
+	ctx context.Context,
+	noteID string,
+) ([]SignificantNoteVersionDTO, error) {
+	if _, err := s.getAccessibleNote(ctx, noteID); err != nil {
+		return nil, err
+	}
+
+	versions, err := s.repo.ListNoteVersions(ctx, noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	dtos := make([]SignificantNoteVersionDTO, 0, len(versions))
+	for _, v := range versions {
+		dtos = append(dtos, SignificantNoteVersionDTO{
+			Version:         v.Version,
+			Note:            v.Note,
+			Remarks:         v.Remarks,
+			Confidentiality: v.Confidentiality,
+			AppointmentID:   v.AppointmentID.String,
+			AdmissionSlipID: v.AdmissionSlipID.String,
+			EditedBy:        v.EditedBy.String,
+			CreatedAt:       v.CreatedAt,
+		})
+	}
+
+	return dtos, nil
+}
+
 func (s *Service) HasNoteForAppointment(
 	ctx context.Context,
 	appointmentID string,
 ) (bool, error) {
 	return s.repo.HasNoteForAppointment(ctx, appointmentID)
 }
+
+// getAccessibleNote loads a note that is not deleted and that the caller
+// may access.
+func (s *Service) getAccessibleNote(
+	ctx context.Context,
+	noteID string,
+) (*SignificantNote, error) {
+	note, err := s.repo.GetSignificantNote(ctx, noteID)
+	if err != nil {
+		return nil, err
+	}
+	if note == nil || note.DeletedAt.Valid {
+		return nil, ErrNoteNotFound
+	}
+	if !canAccess(
+		note,
+		audit.ExtractUserID(ctx),
+		constants.RoleID(audit.ExtractRoleID(ctx)),
+	) {
+		return nil, ErrNoteAccessDenied
+	}
+
+	return note, nil
+}
+
+// checkLinks makes sure a note links to at most one record and that the
+// record belongs to the note's student.
+func (s *Service) checkLinks(
+	ctx context.Context,
+	iirID string,
+	noteReq SignificantNoteDTO,
+) error {
+	if noteReq.AppointmentID != "" && noteReq.AdmissionSlipID != "" {
+		return ErrNoteLink
+	}
+
+	var (
+		ok  = true
+		err error
+	)
+	switch {
+	case noteReq.AppointmentID != "":
+		ok, err = s.repo.AppointmentBelongsToIIR(
+			ctx,
+			noteReq.AppointmentID,
+			iirID,
+		)
+	case noteReq.AdmissionSlipID != "":
+		ok, err = s.repo.SlipBelongsToIIR(ctx, noteReq.AdmissionSlipID, iirID)
+	}
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNoteLinkMismatch
+	}
+
+	return nil
+}
+
+func mapNote(n *SignificantNote) SignificantNoteDTO {
+	return SignificantNoteDTO{
+		ID:              n.ID,
+		AppointmentID:   n.AppointmentID.String,
+		AdmissionSlipID: n.AdmissionSlipID.String,
+		Note:            n.Note,
+		Remarks:         n.Remarks,
+		Confidentiality: n.Confidentiality,
+		Version:         n.Version,
+		CreatedBy:       n.CreatedBy.String,
+		CreatedAt:       n.CreatedAt,
+		UpdatedAt:       n.UpdatedAt,
+	}
+}
+
+// auditValues describes a note for the system log. The note and remarks
+// text are left out: the log is readable by roles the note may be hidden
+// from, and it would keep a plaintext copy of encrypted notes.
+func auditValues(n *SignificantNote) map[string]interface{} {
+	return map[string]interface{}{
+		"id":              n.ID,
+		"version":         n.Version,
+		"confidentiality": n.Confidentiality,
+		"appointmentId":   n.AppointmentID.String,
+		"admissionSlipId": n.AdmissionSlipID.String,
+	}
+}
+
+func confidentialityOrDefault(c Confidentiality) Confidentiality {
+	if c == "" {
+		return ConfidentialityStandard
+	}
+	return c
+}
+
+func actorID(ctx context.Context) sql.NullString {
+	id := audit.ExtractUserID(ctx)
+	return sql.NullString{String: id, Valid: id != ""}
+}
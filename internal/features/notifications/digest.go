@@ -0,0 +1,143 @@
+package notifications
+
+import (
+	"context"
+	"html"
+	"log"
+	"time"
+
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/email"
+)
+
+const digestTimeLayout = "Jan 2, 3:04 PM"
+
+// DigestScheduler sends each user who chose digest delivery one email a
+// day with their unread notifications. Digests are claimed per user and
+// day in notification_digests, so replicas never send the same one twice.
+type DigestScheduler struct {
+	service  ServiceInterface
+	hour     int
+	interval time.Duration
+}
+
+// NewDigestScheduler creates a scheduler that sends digests from the given
+// hour of the day, server time.
+func NewDigestScheduler(
+	service ServiceInterface,
+	hour int,
+	interval time.Duration,
+) *DigestScheduler {
+	return &DigestScheduler{service: service, hour: hour, interval: interval}
+}
+
+// Run checks for due digests every interval until ctx is cancelled.
+func (s *DigestScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.service.SendDigests(ctx, s.lastCutoff(time.Now()))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// lastCutoff returns the most recent digest time at or before now.
+func (s *DigestScheduler) lastCutoff(now time.Time) time.Time {
+	cutoff := time.Date(
+		now.Year(), now.Month(), now.Day(),
+		s.hour, 0, 0, 0,
+		now.Location(),
+	)
+	if now.Before(cutoff) {
+		cutoff = cutoff.AddDate(0, 0, -1)
+	}
+
+	return cutoff
+}
+
+// SendDigests sends the digest for the day of cutoff to every user with
+// items queued before it. A digest that fails to send is retried on the
+// next run.
+func (s *Service) SendDigests(ctx context.Context, cutoff time.Time) {
+	userIDs, err := s.repo.ListDigestRecipients(ctx, cutoff)
+	if err != nil {
+		log.Printf("[SendDigests] {List Recipients}: %v", err)
+		return
+	}
+
+	date := cutoff.Format("2006-01-02")
+	for _, userID := range userIDs {
+		claimed, err := s.repo.ClaimDigest(ctx, userID, date)
+		if err != nil {
+			log.Printf("[SendDigests] {Claim Digest}: %v", err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := s.sendDigest(ctx, userID, cutoff); err != nil {
+			log.Printf("[SendDigests] {Send Digest}: %v", err)
+			if err := s.repo.ReleaseDigest(ctx, userID, date); err != nil {
+				log.Printf("[SendDigests] {Release Digest}: %v", err)
+			}
+			continue
+		}
+
+		err = datastore.RunInTransaction(
+			ctx,
+			s.repo.GetDB(),
+			func(tx datastore.DB) error {
+				return s.repo.CompleteDigest(ctx, tx, userID, date, cutoff)
+			},
+		)
+		if err != nil {
+			log.Printf("[SendDigests] {Complete Digest}: %v", err)
+		}
+	}
+}
+
+// sendDigest emails the user's unread items. Nothing is sent when they
+// read everything in the inbox already.
+func (s *Service) sendDigest(
+	ctx context.Context,
+	userID string,
+	cutoff time.Time,
+) error {
+	items, err := s.repo.ListDigestItems(ctx, userID, cutoff)
+	if err != nil || len(items) == 0 {
+		return err
+	}
+
+	recipient, err := s.repo.GetRecipient(ctx, userID)
+	if err != nil || recipient == nil {
+		return err
+	}
+
+	entries := make([]email.DigestItem, 0, len(items))
+	for _, item := range items {
+		entries = append(entries, email.DigestItem{
+			Title:   html.EscapeString(item.Title),
+			Message: html.EscapeString(item.Message),
+			Time:    item.CreatedAt.Local().Format(digestTimeLayout),
+		})
+	}
+
+	_, err = s.emailer.SendEmail(
+		ctx,
+		recipient.Email,
+		"Your Daily Notification Digest",
+		email.NOTIFICATION_DIGEST_TEMPLATE(
+			html.EscapeString(recipient.FirstName),
+			entries,
+		),
+	)
+
+	return err
+}
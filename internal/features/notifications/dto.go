@@ -1,7 +1,10 @@
 package notifications
 
 import (
+	"time"
+
 	"github.com/olazo-johnalbert/duckload-api/internal/core/audit"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/structs"
 )
 
 type NotificationResponse struct {
@@ -10,10 +13,67 @@ type NotificationResponse struct {
 	Data    []audit.NotificationEntry `json:"data"`
 }
 
+// ListNotificationsParams holds the query parameters of the inbox. Cursor
+// is the nextCursor of the previous page.
+type ListNotificationsParams struct {
+	Cursor    string `form:"cursor"`
+	Limit     int    `form:"limit"      binding:"omitempty,min=1,max=100"`
+	Type      string `form:"type"       binding:"omitempty,oneof=Appointment Slip Guidance System General"`
+	IsRead    *bool  `form:"is_read"`
+	Archived  bool   `form:"archived"`
+	StartDate string `form:"start_date" binding:"omitempty,datetime=2006-01-02"`
+	EndDate   string `form:"end_date"   binding:"omitempty,datetime=2006-01-02"`
+}
+
+// ListNotificationsResponse is one page of the inbox. NextCursor is empty
+// on the last page.
 type ListNotificationsResponse struct {
-	Notifications []audit.NotificationEntry `json:"notifications"`
-	Total         int                       `json:"total"`
-	Page          int                       `json:"page"`
-	PageSize      int                       `json:"pageSize"`
-	TotalPages    int                       `json:"totalPages"`
+	Notifications []NotificationDTO `json:"notifications"`
+	NextCursor    string            `json:"nextCursor,omitempty"`
+	HasMore       bool              `json:"hasMore"`
+}
+
+type NotificationDTO struct {
+	ID         string                 `json:"id"`
+	ActorID    structs.NullableString `json:"actorId,omitempty"`
+	TargetID   structs.NullableString `json:"targetId,omitempty"`
+	TargetType structs.NullableString `json:"targetType,omitempty"`
+	Title      string                 `json:"title"`
+	Message    string                 `json:"message"`
+	Type       string                 `json:"type"`
+	IsRead     bool                   `json:"isRead"`
+	ArchivedAt *time.Time             `json:"archivedAt,omitempty"`
+	CreatedAt  time.Time              `json:"createdAt"`
+}
+
+type UnreadCountDTO struct {
+	Count int `json:"count"`
+}
+
+// NotificationIDsRequest selects notifications for a bulk action.
+type NotificationIDsRequest struct {
+	IDs []string `json:"ids" binding:"required,min=1,max=100,dive,uuid"`
+}
+
+// BulkActionDTO reports how many notifications a bulk action changed.
+type BulkActionDTO struct {
+	Affected int `json:"affected"`
+}
+
+type PreferenceDTO struct {
+	Type  string `json:"type"`
+	InApp bool   `json:"inApp"`
+	Email string `json:"email"`
+}
+
+// UpdatePreferencesRequest replaces the user's preferences for the listed
+// types. Types left out keep their current preference.
+type UpdatePreferencesRequest struct {
+	Preferences []PreferenceUpdate `json:"preferences" binding:"required,min=1,max=5,dive"`
+}
+
+type PreferenceUpdate struct {
+	Type  string `json:"type"  binding:"required,oneof=Appointment Slip Guidance System General"`
+	InApp *bool  `json:"inApp" binding:"required"`
+	Email string `json:"email" binding:"required,oneof=off immediate digest"`
 }
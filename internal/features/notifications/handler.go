@@ -1,13 +1,24 @@
 package notifications
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/response"
 )
 
+const (
+	// heartbeatInterval keeps idle streams open through proxies that close
+	// quiet connections
+	heartbeatInterval = 25 * time.Second
+	// reconnectDelay is the retry hint sent to EventSource clients
+	reconnectDelay = 3 * time.Second
+)
+
 type Handler struct {
 	service ServiceInterface
 }
@@ -19,10 +30,22 @@ func NewHandler(service ServiceInterface) *Handler {
 func (h *Handler) GetNotifications(c *gin.Context) {
 	userID := c.MustGet("userID").(string)
 
-	notifications, err := h.service.GetUserNotifications(
+	var params ListNotificationsParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		log.Printf("[GetNotifications] {Bind Query}: %v", err)
+		response.SendFail(c, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.service.ListUserNotifications(
 		c.Request.Context(),
 		userID,
+		params,
 	)
+	if errors.Is(err, ErrInvalidCursor) {
+		response.SendFail(c, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		log.Printf("[GetNotifications] {Database Query}: %v", err)
 		response.SendError(
@@ -34,20 +57,40 @@ func (h *Handler) GetNotifications(c *gin.Context) {
 		return
 	}
 
-	data := ListNotificationsResponse{
-		Notifications: notifications,
-		Total:         len(notifications),
-		Page:          1,
-		PageSize:      len(notifications),
-		TotalPages:    1,
+	response.SendSuccess(c, result)
+}
+
+func (h *Handler) GetUnreadCount(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	count, err := h.service.GetUnreadCount(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("[GetUnreadCount] {Database Query}: %v", err)
+		response.SendError(
+			c,
+			"Failed to count unread notifications",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
 	}
 
-	response.SendSuccess(c, data)
+	response.SendSuccess(c, UnreadCountDTO{Count: count})
 }
 
 func (h *Handler) PatchNotificationRead(c *gin.Context) {
-	id := c.Param("id")
-	if err := h.service.MarkAsRead(c.Request.Context(), id); err != nil {
+	userID := c.MustGet("userID").(string)
+
+	err := h.service.MarkAsRead(c.Request.Context(), c.Param("id"), userID)
+	switch {
+	case errors.Is(err, ErrNotificationNotFound):
+		response.SendFail(c, gin.H{"error": err.Error()}, http.StatusNotFound)
+		return
+	case errors.Is(err, ErrNotificationForbidden):
+		response.SendFail(c, gin.H{"error": err.Error()}, http.StatusForbidden)
+		return
+	case err != nil:
+		log.Printf("[PatchNotificationRead] {Database Update}: %v", err)
 		response.SendError(
 			c,
 			"Failed to mark notification as read",
@@ -59,3 +102,207 @@ func (h *Handler) PatchNotificationRead(c *gin.Context) {
 
 	response.SendSuccess(c, gin.H{"message": "Notification marked as read"})
 }
+
+func (h *Handler) PatchAllNotificationsRead(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	affected, err := h.service.MarkAllAsRead(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("[PatchAllNotificationsRead] {Database Update}: %v", err)
+		response.SendError(
+			c,
+			"Failed to mark notifications as read",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	response.SendSuccess(c, BulkActionDTO{Affected: affected})
+}
+
+func (h *Handler) PatchArchiveNotifications(c *gin.Context) {
+	h.setArchived(c, true)
+}
+
+func (h *Handler) PatchUnarchiveNotifications(c *gin.Context) {
+	h.setArchived(c, false)
+}
+
+func (h *Handler) setArchived(c *gin.Context, archived bool) {
+	userID := c.MustGet("userID").(string)
+
+	var req NotificationIDsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[setArchived] {JSON Bind}: %v", err)
+		response.SendFail(c, gin.H{"error": err.Error()})
+		return
+	}
+
+	affected, err := h.service.Archive(
+		c.Request.Context(),
+		userID,
+		req.IDs,
+		archived,
+	)
+	if err != nil {
+		log.Printf("[setArchived] {Database Update}: %v", err)
+		response.SendError(
+			c,
+			"Failed to update notifications",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	response.SendSuccess(c, BulkActionDTO{Affected: affected})
+}
+
+func (h *Handler) DeleteNotifications(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	var req NotificationIDsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[DeleteNotifications] {JSON Bind}: %v", err)
+		response.SendFail(c, gin.H{"error": err.Error()})
+		return
+	}
+
+	affected, err := h.service.Delete(c.Request.Context(), userID, req.IDs)
+	if err != nil {
+		log.Printf("[DeleteNotifications] {Database Delete}: %v", err)
+		response.SendError(
+			c,
+			"Failed to delete notifications",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	response.SendSuccess(c, BulkActionDTO{Affected: affected})
+}
+
+func (h *Handler) GetPreferences(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	prefs, err := h.service.GetPreferences(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("[GetPreferences] {Database Query}: %v", err)
+		response.SendError(
+			c,
+			"Failed to fetch notification preferences",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	response.SendSuccess(c, prefs)
+}
+
+func (h *Handler) PutPreferences(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	var req UpdatePreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[PutPreferences] {JSON Bind}: %v", err)
+		response.SendFail(c, gin.H{"error": err.Error()})
+		return
+	}
+
+	prefs, err := h.service.UpdatePreferences(
+		c.Request.Context(),
+		userID,
+		req,
+	)
+	if err != nil {
+		log.Printf("[PutPreferences] {Database Update}: %v", err)
+		response.SendError(
+			c,
+			"Failed to update notification preferences",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	response.SendSuccess(c, prefs)
+}
+
+// StreamNotifications pushes the caller's new notifications as server-sent
+// events. Each event's ID is the notification ID; a reconnecting client
+// sends it back as Last-Event-ID (or ?lastEventId=) to receive what it
+// missed. A "resync" event asks the client to reload its inbox instead.
+func (h *Handler) StreamNotifications(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	ctx := c.Request.Context()
+
+	// Subscribe before replaying so nothing created in between is lost
+	events, unsubscribe := h.service.Subscribe(userID)
+	defer unsubscribe()
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("lastEventId")
+	}
+
+	var missed []NotificationDTO
+	resync := false
+	if lastEventID != "" {
+		var err error
+		missed, resync, err = h.service.ReplaySince(ctx, userID, lastEventID)
+		if err != nil {
+			log.Printf("[StreamNotifications] {Replay}: %v", err)
+			resync = true
+		}
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+	fmt.Fprintf(w, "retry: %d\n\n", reconnectDelay.Milliseconds())
+	if resync {
+		_ = writeEvent(w, "", "resync", gin.H{})
+	}
+
+	replayed := make(map[string]struct{}, len(missed))
+	for _, notif := range missed {
+		if err := writeEvent(w, notif.ID, "notification", notif); err != nil {
+			return
+		}
+		replayed[notif.ID] = struct{}{}
+	}
+	w.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case notif, ok := <-events:
+			if !ok {
+				return
+			}
+			if _, seen := replayed[notif.ID]; seen {
+				continue
+			}
+			if err := writeEvent(w, notif.ID, "notification", notif); err != nil {
+				return
+			}
+			w.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			w.Flush()
+		}
+	}
+}
@@ -2,6 +2,7 @@ package notifications
 
 import (
 	"context"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/audit"
@@ -14,21 +15,103 @@ type ServiceInterface interface {
 		ctx context.Context,
 		notif audit.NotificationEntry,
 	) error
-	GetUserNotifications(
+	ListUserNotifications(
 		ctx context.Context,
 		userID string,
-	) ([]audit.NotificationEntry, error)
-	MarkAsRead(ctx context.Context, id string) error
+		params ListNotificationsParams,
+	) (*ListNotificationsResponse, error)
+	GetUnreadCount(ctx context.Context, userID string) (int, error)
+	Subscribe(userID string) (<-chan NotificationDTO, func())
+	ReplaySince(
+		ctx context.Context,
+		userID string,
+		lastEventID string,
+	) ([]NotificationDTO, bool, error)
+	MarkAsRead(ctx context.Context, id string, userID string) error
+	MarkAllAsRead(ctx context.Context, userID string) (int, error)
+	Archive(
+		ctx context.Context,
+		userID string,
+		ids []string,
+		archived bool,
+	) (int, error)
+	Delete(ctx context.Context, userID string, ids []string) (int, error)
+	GetPreferences(ctx context.Context, userID string) ([]PreferenceDTO, error)
+	UpdatePreferences(
+		ctx context.Context,
+		userID string,
+		req UpdatePreferencesRequest,
+	) ([]PreferenceDTO, error)
+	SendDigests(ctx context.Context, cutoff time.Time)
 }
 
 // RepositoryInterface defines the data access layer for managing notifications.
 type RepositoryInterface interface {
 	GetDB() *sqlx.DB
-	GetByUserID(ctx context.Context, userID string) ([]NotificationModel, error)
-	MarkAsRead(ctx context.Context, tx datastore.DB, id string) error
+	List(
+		ctx context.Context,
+		receiverID string,
+		params ListNotificationsParams,
+		after *InboxCursor,
+		limit int,
+	) ([]NotificationModel, error)
+	ListSince(
+		ctx context.Context,
+		receiverID string,
+		since InboxCursor,
+		limit int,
+	) ([]NotificationModel, error)
+	GetByID(ctx context.Context, id string) (*NotificationModel, error)
+	CountUnread(ctx context.Context, receiverID string) (int, error)
+	MarkAsRead(
+		ctx context.Context,
+		tx datastore.DB,
+		id string,
+		receiverID string,
+	) error
+	MarkAllAsRead(ctx context.Context, receiverID string) (int, error)
+	SetArchived(
+		ctx context.Context,
+		receiverID string,
+		ids []string,
+		archived bool,
+	) (int, error)
+	DeleteMany(
+		ctx context.Context,
+		receiverID string,
+		ids []string,
+	) (int, error)
 	Create(
 		ctx context.Context,
 		tx datastore.DB,
 		notif *NotificationModel,
 	) error
+	GetPreference(
+		ctx context.Context,
+		userID string,
+		notifType string,
+	) (*PreferenceModel, error)
+	ListPreferences(ctx context.Context, userID string) ([]PreferenceModel, error)
+	UpsertPreference(
+		ctx context.Context,
+		tx datastore.DB,
+		pref PreferenceModel,
+	) error
+	GetRecipient(ctx context.Context, userID string) (*Recipient, error)
+	QueueDigestItem(ctx context.Context, item DigestQueueModel) error
+	ListDigestRecipients(ctx context.Context, before time.Time) ([]string, error)
+	ListDigestItems(
+		ctx context.Context,
+		userID string,
+		before time.Time,
+	) ([]DigestQueueModel, error)
+	ClaimDigest(ctx context.Context, userID string, date string) (bool, error)
+	ReleaseDigest(ctx context.Context, userID string, date string) error
+	CompleteDigest(
+		ctx context.Context,
+		tx datastore.DB,
+		userID string,
+		date string,
+		before time.Time,
+	) error
 }
@@ -14,10 +14,71 @@ type NotificationModel struct {
 	TargetID   sql.NullString `db:"target_id"   json:"targetId,omitempty"`
 	TargetType sql.NullString `db:"target_type" json:"targetType,omitempty"`
 
-	Title     string    `db:"title"      json:"title"`
-	Message   string    `db:"message"    json:"message"`
-	Type      string    `db:"type"       json:"type"`
-	IsRead    bool      `db:"is_read"    json:"isRead"`
-	CreatedAt time.Time `db:"created_at" json:"createdAt"`
-	UpdatedAt time.Time `db:"updated_at" json:"updatedAt"`
+	Title      string       `db:"title"       json:"title"`
+	Message    string       `db:"message"     json:"message"`
+	Type       string       `db:"type"        json:"type"`
+	IsRead     bool         `db:"is_read"     json:"isRead"`
+	ArchivedAt sql.NullTime `db:"archived_at" json:"archivedAt"`
+	CreatedAt  time.Time    `db:"created_at"  json:"createdAt"`
+	UpdatedAt  time.Time    `db:"updated_at"  json:"updatedAt"`
+}
+
+// InboxCursor marks the last notification of a page. Notifications are
+// listed newest first; the ID breaks ties between equal timestamps.
+type InboxCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// Email delivery options of a notification preference
+const (
+	EmailOff       = "off"
+	EmailImmediate = "immediate"
+	EmailDigest    = "digest"
+)
+
+// notificationTypes are the types a preference can be set for, matching
+// the type column of notifications.
+var notificationTypes = []string{
+	"Appointment",
+	"Slip",
+	"Guidance",
+	"System",
+	"General",
+}
+
+// PreferenceModel is how a user receives one type of notification. Types
+// without a stored row use defaultPreference.
+type PreferenceModel struct {
+	UserID string `db:"user_id" json:"userId"`
+	Type   string `db:"type"    json:"type"`
+	InApp  bool   `db:"in_app"  json:"inApp"`
+	Email  string `db:"email"   json:"email"`
+}
+
+func defaultPreference(userID, notifType string) PreferenceModel {
+	return PreferenceModel{
+		UserID: userID,
+		Type:   notifType,
+		InApp:  true,
+		Email:  EmailOff,
+	}
+}
+
+// DigestQueueModel is a notification waiting for the receiver's next
+// digest. NotificationID is empty when the type is kept out of the inbox.
+type DigestQueueModel struct {
+	ID             int64          `db:"id"              json:"id"`
+	UserID         string         `db:"user_id"         json:"userId"`
+	NotificationID sql.NullString `db:"notification_id" json:"notificationId"`
+	Type           string         `db:"type"            json:"type"`
+	Title          string         `db:"title"           json:"title"`
+	Message        string         `db:"message"         json:"message"`
+	CreatedAt      time.Time      `db:"created_at"      json:"createdAt"`
+}
+
+// Recipient is who a notification email is addressed to.
+type Recipient struct {
+	Email     string `db:"email"`
+	FirstName string `db:"first_name"`
 }
@@ -0,0 +1,120 @@
+package notifications
+
+import (
+	"context"
+	"html"
+	"log"
+
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/email"
+)
+
+// GetPreferences returns the user's preference for every notification
+// type, filling in the default for types they have not set.
+func (s *Service) GetPreferences(
+	ctx context.Context,
+	userID string,
+) ([]PreferenceDTO, error) {
+	stored, err := s.repo.ListPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byType := make(map[string]PreferenceModel, len(stored))
+	for _, pref := range stored {
+		byType[pref.Type] = pref
+	}
+
+	prefs := make([]PreferenceDTO, 0, len(notificationTypes))
+	for _, notifType := range notificationTypes {
+		pref, ok := byType[notifType]
+		if !ok {
+			pref = defaultPreference(userID, notifType)
+		}
+		prefs = append(prefs, PreferenceDTO{
+			Type:  pref.Type,
+			InApp: pref.InApp,
+			Email: pref.Email,
+		})
+	}
+
+	return prefs, nil
+}
+
+// UpdatePreferences saves the listed preferences and returns the full set.
+func (s *Service) UpdatePreferences(
+	ctx context.Context,
+	userID string,
+	req UpdatePreferencesRequest,
+) ([]PreferenceDTO, error) {
+	err := datastore.RunInTransaction(
+		ctx,
+		s.repo.GetDB(),
+		func(tx datastore.DB) error {
+			for _, update := range req.Preferences {
+				if err := s.repo.UpsertPreference(ctx, tx, PreferenceModel{
+					UserID: userID,
+					Type:   update.Type,
+					InApp:  *update.InApp,
+					Email:  update.Email,
+				}); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetPreferences(ctx, userID)
+}
+
+// preferenceFor falls back to the default when the preference cannot be
+// read, so a lookup failure never loses a notification.
+func (s *Service) preferenceFor(
+	ctx context.Context,
+	userID string,
+	notifType string,
+) PreferenceModel {
+	pref, err := s.repo.GetPreference(ctx, userID, notifType)
+	if err != nil {
+		log.Printf("[preferenceFor] {Database Query}: %v", err)
+	}
+	if pref == nil {
+		return defaultPreference(userID, notifType)
+	}
+
+	return *pref
+}
+
+func (s *Service) sendEmail(
+	ctx context.Context,
+	userID string,
+	notif NotificationModel,
+) {
+	recipient, err := s.repo.GetRecipient(ctx, userID)
+	if err != nil {
+		log.Printf("[sendEmail] {Fetch Recipient}: %v", err)
+		return
+	}
+	if recipient == nil {
+		return
+	}
+
+	_, err = s.emailer.SendEmail(
+		ctx,
+		recipient.Email,
+		notif.Title,
+		email.NOTIFICATION_TEMPLATE(
+			html.EscapeString(recipient.FirstName),
+			html.EscapeString(notif.Title),
+			html.EscapeString(notif.Message),
+		),
+	)
+	if err != nil {
+		log.Printf("[sendEmail] {Send Email}: %v", err)
+	}
+}
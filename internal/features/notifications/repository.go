@@ -2,7 +2,9 @@ package notifications
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
@@ -16,22 +18,97 @@ func NewRepository(db *sqlx.DB) *Repository {
 	return &Repository{db: db}
 }
 
-func (r *Repository) GetByUserID(
+// List returns one page of a user's notifications, newest first, starting
+// after the cursor when one is given.
+func (r *Repository) List(
 	ctx context.Context,
-	userID string,
+	receiverID string,
+	params ListNotificationsParams,
+	after *InboxCursor,
+	limit int,
+) ([]NotificationModel, error) {
+	query := fmt.Sprintf(
+		"SELECT %s FROM notifications WHERE receiver_id = ?",
+		datastore.GetColumns(NotificationModel{}),
+	)
+	args := []interface{}{receiverID}
+
+	if params.Archived {
+		query += " AND archived_at IS NOT NULL"
+	} else {
+		query += " AND archived_at IS NULL"
+	}
+
+	if params.Type != "" {
+		query += " AND type = ?"
+		args = append(args, params.Type)
+	}
+
+	if params.IsRead != nil {
+		query += " AND is_read = ?"
+		args = append(args, *params.IsRead)
+	}
+
+	if params.StartDate != "" {
+		query += " AND created_at >= ?"
+		args = append(args, params.StartDate)
+	}
+
+	if params.EndDate != "" {
+		query += " AND created_at <= ?"
+		args = append(args, params.EndDate+" 23:59:59")
+	}
+
+	if after != nil {
+		query += " AND (created_at < ? OR (created_at = ? AND id < ?))"
+		args = append(args, after.CreatedAt, after.CreatedAt, after.ID)
+	}
+
+	query += " ORDER BY created_at DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	var results []NotificationModel
+	err := r.db.SelectContext(ctx, &results, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to list notifications for user %s: %w",
+			receiverID,
+			err,
+		)
+	}
+
+	return results, nil
+}
+
+// ListSince returns a user's notifications created at or after the
+// cursor's time, oldest first, leaving out the cursor's own notification.
+func (r *Repository) ListSince(
+	ctx context.Context,
+	receiverID string,
+	since InboxCursor,
+	limit int,
 ) ([]NotificationModel, error) {
 	query := fmt.Sprintf(`
 		SELECT %s FROM notifications
-		WHERE receiver_id = ?
-		ORDER BY created_at DESC
+		WHERE receiver_id = ? AND created_at >= ? AND id <> ?
+		ORDER BY created_at ASC, id ASC
+		LIMIT ?
 	`, datastore.GetColumns(NotificationModel{}))
 
 	var results []NotificationModel
-	err := r.db.SelectContext(ctx, &results, query, userID)
+	err := r.db.SelectContext(
+		ctx,
+		&results,
+		query,
+		receiverID,
+		since.CreatedAt,
+		since.ID,
+		limit,
+	)
 	if err != nil {
 		return nil, fmt.Errorf(
-			"failed to get notifications for user %s: %w",
-			userID,
+			"failed to list notifications for user %s: %w",
+			receiverID,
 			err,
 		)
 	}
@@ -39,23 +116,150 @@ func (r *Repository) GetByUserID(
 	return results, nil
 }
 
+func (r *Repository) GetByID(
+	ctx context.Context,
+	id string,
+) (*NotificationModel, error) {
+	query := fmt.Sprintf(
+		"SELECT %s FROM notifications WHERE id = ?",
+		datastore.GetColumns(NotificationModel{}),
+	)
+
+	var notif NotificationModel
+	err := r.db.GetContext(ctx, &notif, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get notification %s: %w", id, err)
+	}
+
+	return &notif, nil
+}
+
+// CountUnread counts unread notifications still in the inbox.
+func (r *Repository) CountUnread(
+	ctx context.Context,
+	receiverID string,
+) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM notifications
+		WHERE receiver_id = ? AND is_read = FALSE AND archived_at IS NULL
+	`
+
+	var count int
+	if err := r.db.GetContext(ctx, &count, query, receiverID); err != nil {
+		return 0, fmt.Errorf(
+			"failed to count unread notifications for user %s: %w",
+			receiverID,
+			err,
+		)
+	}
+
+	return count, nil
+}
+
 func (r *Repository) GetDB() *sqlx.DB {
 	return r.db
 }
 
+// MarkAsRead marks a notification read if it belongs to receiverID.
 func (r *Repository) MarkAsRead(
 	ctx context.Context,
 	tx datastore.DB,
 	id string,
+	receiverID string,
 ) error {
-	query := `UPDATE notifications SET is_read = TRUE WHERE id = ?`
-	_, err := tx.ExecContext(ctx, query, id)
+	query := `
+		UPDATE notifications SET is_read = TRUE
+		WHERE id = ? AND receiver_id = ?
+	`
+	_, err := tx.ExecContext(ctx, query, id, receiverID)
 	if err != nil {
 		return fmt.Errorf("failed to mark notification %s as read: %w", id, err)
 	}
 	return nil
 }
 
+// MarkAllAsRead marks every unread notification of receiverID read and
+// returns how many changed.
+func (r *Repository) MarkAllAsRead(
+	ctx context.Context,
+	receiverID string,
+) (int, error) {
+	query := `
+		UPDATE notifications SET is_read = TRUE
+		WHERE receiver_id = ? AND is_read = FALSE
+	`
+	res, err := r.db.ExecContext(ctx, query, receiverID)
+	if err != nil {
+		return 0, fmt.Errorf(
+			"failed to mark notifications of user %s as read: %w",
+			receiverID,
+			err,
+		)
+	}
+
+	return rowsAffected(res)
+}
+
+// SetArchived archives or restores the given notifications of receiverID.
+// IDs belonging to other users are ignored.
+func (r *Repository) SetArchived(
+	ctx context.Context,
+	receiverID string,
+	ids []string,
+	archived bool,
+) (int, error) {
+	set, cond := "archived_at = NOW()", "archived_at IS NULL"
+	if !archived {
+		set, cond = "archived_at = NULL", "archived_at IS NOT NULL"
+	}
+
+	query, args, err := sqlx.In(
+		fmt.Sprintf(`
+			UPDATE notifications SET %s
+			WHERE receiver_id = ? AND %s AND id IN (?)
+		`, set, cond),
+		receiverID,
+		ids,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build archive query: %w", err)
+	}
+
+	res, err := r.db.ExecContext(ctx, r.db.Rebind(query), args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive notifications: %w", err)
+	}
+
+	return rowsAffected(res)
+}
+
+// DeleteMany deletes the given notifications of receiverID. IDs belonging
+// to other users are ignored.
+func (r *Repository) DeleteMany(
+	ctx context.Context,
+	receiverID string,
+	ids []string,
+) (int, error) {
+	query, args, err := sqlx.In(
+		"DELETE FROM notifications WHERE receiver_id = ? AND id IN (?)",
+		receiverID,
+		ids,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build delete query: %w", err)
+	}
+
+	res, err := r.db.ExecContext(ctx, r.db.Rebind(query), args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete notifications: %w", err)
+	}
+
+	return rowsAffected(res)
+}
+
 func (r *Repository) Create(
 	ctx context.Context,
 	tx datastore.DB,
@@ -76,3 +280,249 @@ func (r *Repository) Create(
 	}
 	return nil
 }
+
+// GetPreference returns the user's preference for one notification type,
+// or nil when they have not set one.
+func (r *Repository) GetPreference(
+	ctx context.Context,
+	userID string,
+	notifType string,
+) (*PreferenceModel, error) {
+	var pref PreferenceModel
+	err := r.db.GetContext(
+		ctx,
+		&pref,
+		`SELECT user_id, type, in_app, email
+		FROM notification_preferences
+		WHERE user_id = ? AND type = ?`,
+		userID,
+		notifType,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch notification preference: %w", err)
+	}
+
+	return &pref, nil
+}
+
+func (r *Repository) ListPreferences(
+	ctx context.Context,
+	userID string,
+) ([]PreferenceModel, error) {
+	var prefs []PreferenceModel
+	err := r.db.SelectContext(
+		ctx,
+		&prefs,
+		`SELECT user_id, type, in_app, email
+		FROM notification_preferences
+		WHERE user_id = ?`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch notification preferences: %w", err)
+	}
+
+	return prefs, nil
+}
+
+func (r *Repository) UpsertPreference(
+	ctx context.Context,
+	tx datastore.DB,
+	pref PreferenceModel,
+) error {
+	_, err := tx.NamedExecContext(
+		ctx,
+		`INSERT INTO notification_preferences (user_id, type, in_app, email)
+		VALUES (:user_id, :type, :in_app, :email)
+		ON DUPLICATE KEY UPDATE in_app = VALUES(in_app), email = VALUES(email)`,
+		pref,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save notification preference: %w", err)
+	}
+
+	return nil
+}
+
+// GetRecipient returns the name and address notification emails for the
+// user are sent to, or nil when the user does not exist.
+func (r *Repository) GetRecipient(
+	ctx context.Context,
+	userID string,
+) (*Recipient, error) {
+	var recipient Recipient
+	err := r.db.GetContext(
+		ctx,
+		&recipient,
+		"SELECT email, first_name FROM users WHERE id = ? AND is_active = 1",
+		userID,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch recipient %s: %w", userID, err)
+	}
+
+	return &recipient, nil
+}
+
+func (r *Repository) QueueDigestItem(
+	ctx context.Context,
+	item DigestQueueModel,
+) error {
+	_, err := r.db.NamedExecContext(
+		ctx,
+		`INSERT INTO notification_digest_queue
+			(user_id, notification_id, type, title, message)
+		VALUES (:user_id, :notification_id, :type, :title, :message)`,
+		item,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to queue digest item: %w", err)
+	}
+
+	return nil
+}
+
+// ListDigestRecipients returns the users with digest items queued before
+// the cutoff.
+func (r *Repository) ListDigestRecipients(
+	ctx context.Context,
+	before time.Time,
+) ([]string, error) {
+	var userIDs []string
+	err := r.db.SelectContext(
+		ctx,
+		&userIDs,
+		`SELECT DISTINCT user_id FROM notification_digest_queue
+		WHERE created_at < ?`,
+		before,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list digest recipients: %w", err)
+	}
+
+	return userIDs, nil
+}
+
+// ListDigestItems returns the user's queued items from before the cutoff,
+// oldest first. Items whose inbox notification was already read are left
+// out.
+func (r *Repository) ListDigestItems(
+	ctx context.Context,
+	userID string,
+	before time.Time,
+) ([]DigestQueueModel, error) {
+	var items []DigestQueueModel
+	err := r.db.SelectContext(
+		ctx,
+		&items,
+		`SELECT q.id, q.user_id, q.notification_id, q.type, q.title,
+			q.message, q.created_at
+		FROM notification_digest_queue q
+		LEFT JOIN notifications n ON n.id = q.notification_id
+		WHERE q.user_id = ? AND q.created_at < ?
+			AND (n.id IS NULL OR n.is_read = FALSE)
+		ORDER BY q.created_at, q.id`,
+		userID,
+		before,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list digest items: %w", err)
+	}
+
+	return items, nil
+}
+
+// ClaimDigest records that the user's digest for the date is being sent.
+// It returns false when another run or replica already claimed it.
+func (r *Repository) ClaimDigest(
+	ctx context.Context,
+	userID string,
+	date string,
+) (bool, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		`INSERT IGNORE INTO notification_digests (user_id, digest_date)
+		VALUES (?, ?)`,
+		userID,
+		date,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim digest: %w", err)
+	}
+
+	rows, err := rowsAffected(res)
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+// ReleaseDigest drops an unsent claim so the digest is retried.
+func (r *Repository) ReleaseDigest(
+	ctx context.Context,
+	userID string,
+	date string,
+) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		`DELETE FROM notification_digests
+		WHERE user_id = ? AND digest_date = ? AND sent_at IS NULL`,
+		userID,
+		date,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to release digest: %w", err)
+	}
+
+	return nil
+}
+
+// CompleteDigest marks the digest sent and clears the items it covered,
+// including read ones that were left out of it.
+func (r *Repository) CompleteDigest(
+	ctx context.Context,
+	tx datastore.DB,
+	userID string,
+	date string,
+	before time.Time,
+) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`UPDATE notification_digests SET sent_at = NOW()
+		WHERE user_id = ? AND digest_date = ?`,
+		userID,
+		date,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark digest sent: %w", err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`DELETE FROM notification_digest_queue
+		WHERE user_id = ? AND created_at < ?`,
+		userID,
+		before,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to clear digest items: %w", err)
+	}
+
+	return nil
+}
+
+func rowsAffected(res sql.Result) (int, error) {
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read affected rows: %w", err)
+	}
+
+	return int(rows), nil
+}
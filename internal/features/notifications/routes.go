@@ -5,6 +5,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/middleware"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/tokens"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
 )
 
@@ -13,9 +14,10 @@ func RegisterRoutes(
 	rg *gin.RouterGroup,
 	h *Handler,
 	redis *datastore.RedisClient,
+	tokenService *tokens.Service,
 ) {
 	routes := rg.Group("/notifications")
-	routes.Use(middleware.AuthMiddleware(redis))
+	routes.Use(middleware.AuthMiddleware(redis, tokenService))
 
 	userRoutes := routes.Group("/")
 	userRoutes.Use(middleware.RoleMiddleware(
@@ -25,6 +27,15 @@ func RegisterRoutes(
 	))
 	{
 		userRoutes.GET("/me", h.GetNotifications)
+		userRoutes.GET("/me/unread-count", h.GetUnreadCount)
+		userRoutes.GET("/me/stream", h.StreamNotifications)
+		userRoutes.GET("/me/preferences", h.GetPreferences)
+		userRoutes.PUT("/me/preferences", h.PutPreferences)
+
+		userRoutes.PATCH("/me/read", h.PatchAllNotificationsRead)
+		userRoutes.PATCH("/me/archive", h.PatchArchiveNotifications)
+		userRoutes.PATCH("/me/unarchive", h.PatchUnarchiveNotifications)
+		userRoutes.DELETE("/me", h.DeleteNotifications)
 
 		userRoutes.PATCH("/:id/read", h.PatchNotificationRead)
 	}
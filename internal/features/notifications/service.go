@@ -2,27 +2,61 @@ package notifications
 
 import (
 	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"log"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/audit"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/structs"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/email"
 )
 
+var (
+	ErrNotificationNotFound  = errors.New("notification not found")
+	ErrNotificationForbidden = errors.New(
+		"notification belongs to another user",
+	)
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// replayLimit caps how many missed notifications a reconnecting stream
+// receives; beyond that the client is told to reload its inbox instead.
+const replayLimit = 100
+
 type Service struct {
-	repo *Repository
+	repo    *Repository
+	broker  *Broker
+	emailer email.Emailer
 }
 
-func NewService(repo *Repository) *Service {
-	return &Service{repo: repo}
+func NewService(
+	repo *Repository,
+	broker *Broker,
+	emailer email.Emailer,
+) *Service {
+	return &Service{repo: repo, broker: broker, emailer: emailer}
 }
 
-// Send handles creating a new notification using the email string as identifier
+// Send delivers a notification the way its receiver prefers for its type:
+// in the inbox, by email right away, in the next daily digest, or a mix.
+// A notification the receiver muted everywhere is dropped.
 func (s *Service) Send(
 	ctx context.Context,
 	notif audit.NotificationEntry,
 ) error {
-	return s.repo.Create(ctx, s.repo.GetDB(), &NotificationModel{
+	receiverID := notif.ReceiverID.String
+	pref := s.preferenceFor(ctx, receiverID, notif.Type)
+	if !pref.InApp && pref.Email == EmailOff {
+		return nil
+	}
+
+	model := &NotificationModel{
 		ID: uuid.New().String(),
 
 		ReceiverID: structs.ToSqlNull(notif.ReceiverID),
@@ -33,16 +67,69 @@ func (s *Service) Send(
 		Title:   notif.Title,
 		Message: notif.Message,
 		Type:    notif.Type,
-	})
+	}
+
+	var notificationID sql.NullString
+	if pref.InApp {
+		if err := s.repo.Create(ctx, s.repo.GetDB(), model); err != nil {
+			return err
+		}
+		notificationID = sql.NullString{String: model.ID, Valid: true}
+
+		// The notification is saved; a missed push is recovered when the
+		// client reconnects or reloads its inbox
+		model.CreatedAt = time.Now().UTC()
+		if err := s.broker.Publish(
+			ctx,
+			receiverID,
+			toDTO(*model),
+		); err != nil {
+			log.Printf("[Send] {Publish Notification}: %v", err)
+		}
+	}
+
+	switch pref.Email {
+	case EmailImmediate:
+		go s.sendEmail(context.WithoutCancel(ctx), receiverID, *model)
+	case EmailDigest:
+		if err := s.repo.QueueDigestItem(ctx, DigestQueueModel{
+			UserID:         receiverID,
+			NotificationID: notificationID,
+			Type:           model.Type,
+			Title:          model.Title,
+			Message:        model.Message,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-func (s *Service) GetUserNotifications(
+// ListUserNotifications returns one page of the user's inbox, or of the
+// archive when params.Archived is set.
+func (s *Service) ListUserNotifications(
 	ctx context.Context,
 	userID string,
-) ([]audit.NotificationEntry, error) {
-	models, err := s.repo.GetByUserID(ctx, userID)
+	params ListNotificationsParams,
+) (*ListNotificationsResponse, error) {
+	var after *InboxCursor
+	if params.Cursor != "" {
+		cursor, err := decodeCursor(params.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		after = cursor
+	}
+
+	limit := params.Limit
+	if limit <= 0 {
+		limit = constants.DefaultPageSize
+	}
+
+	// One extra row tells whether another page follows
+	models, err := s.repo.List(ctx, userID, params, after, limit+1)
 	if err != nil {
-		// Consistent error wrapping with %w
 		return nil, fmt.Errorf(
 			"failed to fetch notifications for user %s: %w",
 			userID,
@@ -50,21 +137,163 @@ func (s *Service) GetUserNotifications(
 		)
 	}
 
-	var dtos []audit.NotificationEntry
-	for _, m := range models {
-		dtos = append(dtos, audit.NotificationEntry{
-			ID:         m.ID,
-			ReceiverID: structs.NullableString(m.ReceiverID),
-			Title:      m.Title,
-			Message:    m.Message,
-			Type:       m.Type,
-			IsRead:     m.IsRead,
-			CreatedAt:  m.CreatedAt,
+	result := &ListNotificationsResponse{
+		Notifications: []NotificationDTO{},
+		HasMore:       len(models) > limit,
+	}
+	if result.HasMore {
+		models = models[:limit]
+		last := models[len(models)-1]
+		result.NextCursor = encodeCursor(InboxCursor{
+			CreatedAt: last.CreatedAt,
+			ID:        last.ID,
 		})
 	}
-	return dtos, nil
+
+	for _, m := range models {
+		result.Notifications = append(result.Notifications, toDTO(m))
+	}
+
+	return result, nil
+}
+
+func (s *Service) GetUnreadCount(
+	ctx context.Context,
+	userID string,
+) (int, error) {
+	return s.repo.CountUnread(ctx, userID)
+}
+
+// MarkAsRead marks one of the user's notifications read. Notifications of
+// other users are refused.
+func (s *Service) MarkAsRead(
+	ctx context.Context,
+	id string,
+	userID string,
+) error {
+	notif, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if notif == nil {
+		return ErrNotificationNotFound
+	}
+	if !notif.ReceiverID.Valid || notif.ReceiverID.String != userID {
+		return ErrNotificationForbidden
+	}
+
+	return s.repo.MarkAsRead(ctx, s.repo.GetDB(), id, userID)
+}
+
+func (s *Service) MarkAllAsRead(
+	ctx context.Context,
+	userID string,
+) (int, error) {
+	return s.repo.MarkAllAsRead(ctx, userID)
 }
 
-func (s *Service) MarkAsRead(ctx context.Context, id string) error {
-	return s.repo.MarkAsRead(ctx, s.repo.GetDB(), id)
+// Archive moves the user's notifications out of the inbox, or back when
+// archived is false.
+func (s *Service) Archive(
+	ctx context.Context,
+	userID string,
+	ids []string,
+	archived bool,
+) (int, error) {
+	return s.repo.SetArchived(ctx, userID, ids, archived)
+}
+
+func (s *Service) Delete(
+	ctx context.Context,
+	userID string,
+	ids []string,
+) (int, error) {
+	return s.repo.DeleteMany(ctx, userID, ids)
+}
+
+// Subscribe opens a live feed of the user's new notifications.
+func (s *Service) Subscribe(userID string) (<-chan NotificationDTO, func()) {
+	return s.broker.Subscribe(userID)
+}
+
+// ReplaySince returns the user's notifications created after lastEventID,
+// oldest first. Notifications from the same second as lastEventID are
+// included, so clients should ignore IDs they have already seen. It
+// reports resync when too many were missed or lastEventID is unknown, and
+// the client should reload its inbox instead.
+func (s *Service) ReplaySince(
+	ctx context.Context,
+	userID string,
+	lastEventID string,
+) ([]NotificationDTO, bool, error) {
+	last, err := s.repo.GetByID(ctx, lastEventID)
+	if err != nil {
+		return nil, false, err
+	}
+	if last == nil || last.ReceiverID.String != userID {
+		return nil, true, nil
+	}
+
+	models, err := s.repo.ListSince(
+		ctx,
+		userID,
+		InboxCursor{CreatedAt: last.CreatedAt, ID: last.ID},
+		replayLimit+1,
+	)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(models) > replayLimit {
+		return nil, true, nil
+	}
+
+	missed := make([]NotificationDTO, 0, len(models))
+	for _, m := range models {
+		missed = append(missed, toDTO(m))
+	}
+
+	return missed, false, nil
+}
+
+func toDTO(m NotificationModel) NotificationDTO {
+	dto := NotificationDTO{
+		ID:         m.ID,
+		ActorID:    structs.NullableString(m.ActorID),
+		TargetID:   structs.NullableString(m.TargetID),
+		TargetType: structs.NullableString(m.TargetType),
+		Title:      m.Title,
+		Message:    m.Message,
+		Type:       m.Type,
+		IsRead:     m.IsRead,
+		CreatedAt:  m.CreatedAt,
+	}
+	if m.ArchivedAt.Valid {
+		dto.ArchivedAt = &m.ArchivedAt.Time
+	}
+
+	return dto
+}
+
+// encodeCursor makes an opaque cursor from the last notification of a page.
+func encodeCursor(c InboxCursor) string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (*InboxCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return nil, ErrInvalidCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &InboxCursor{CreatedAt: t, ID: id}, nil
 }
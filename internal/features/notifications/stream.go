@@ -0,0 +1,157 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
+)
+
+const (
+	// streamChannel carries new notifications to every API replica, each
+	// of which delivers them to the users connected to it
+	streamChannel = "notifications:stream"
+	// clientBuffer is how many events a slow client may fall behind before
+	// its stream is closed; it resumes with Last-Event-ID on reconnect
+	clientBuffer = 32
+)
+
+type streamEvent struct {
+	ReceiverID   string          `json:"receiverId"`
+	Notification NotificationDTO `json:"notification"`
+}
+
+// Broker fans new notifications out to the SSE streams open on this
+// replica. Events travel through Redis pub/sub so a notification created
+// on one replica reaches a user connected to another.
+type Broker struct {
+	redis *datastore.RedisClient
+
+	mu      sync.Mutex
+	clients map[string]map[chan NotificationDTO]struct{}
+}
+
+func NewBroker(redis *datastore.RedisClient) *Broker {
+	return &Broker{
+		redis:   redis,
+		clients: map[string]map[chan NotificationDTO]struct{}{},
+	}
+}
+
+// Publish sends a notification to every replica.
+func (b *Broker) Publish(
+	ctx context.Context,
+	receiverID string,
+	notif NotificationDTO,
+) error {
+	payload, err := json.Marshal(streamEvent{
+		ReceiverID:   receiverID,
+		Notification: notif,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode notification event: %w", err)
+	}
+
+	return b.redis.Client.Publish(ctx, streamChannel, payload).Err()
+}
+
+// Subscribe registers a stream for userID. The channel is closed when the
+// client falls too far behind; call the returned func once the stream ends.
+func (b *Broker) Subscribe(
+	userID string,
+) (<-chan NotificationDTO, func()) {
+	events := make(chan NotificationDTO, clientBuffer)
+
+	b.mu.Lock()
+	if b.clients[userID] == nil {
+		b.clients[userID] = map[chan NotificationDTO]struct{}{}
+	}
+	b.clients[userID][events] = struct{}{}
+	b.mu.Unlock()
+
+	return events, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.remove(userID, events)
+	}
+}
+
+// Run relays events from Redis to local streams until ctx is cancelled.
+// The Redis client resubscribes by itself after a dropped connection.
+func (b *Broker) Run(ctx context.Context) {
+	pubsub := b.redis.Client.Subscribe(ctx, streamChannel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			var event streamEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				log.Printf("[Broker.Run] {Decode Event}: %v", err)
+				continue
+			}
+			b.deliver(event)
+		}
+	}
+}
+
+func (b *Broker) deliver(event streamEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for events := range b.clients[event.ReceiverID] {
+		select {
+		case events <- event.Notification:
+		default:
+			// Dropping one event silently would leave a gap; closing makes
+			// the client reconnect and replay what it missed
+			b.remove(event.ReceiverID, events)
+		}
+	}
+}
+
+// remove must be called with mu held. It is safe to call twice.
+func (b *Broker) remove(userID string, events chan NotificationDTO) {
+	if _, ok := b.clients[userID][events]; !ok {
+		return
+	}
+
+	delete(b.clients[userID], events)
+	if len(b.clients[userID]) == 0 {
+		delete(b.clients, userID)
+	}
+	close(events)
+}
+
+// writeEvent writes one server-sent event. id and event may be empty.
+func writeEvent(w io.Writer, id string, event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if id != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	if event != "" {
+		if _, err := fmt.Fprintf(w, "event: %s\n", event); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "data: %s\n\n", payload)
+
+	return err
+}
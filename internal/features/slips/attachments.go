@@ -0,0 +1,402 @@
+package slips
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/olazo-johnalbert/duckload-api/internal/core/audit"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/structs"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/storage"
+)
+
+// ScanStatus tracks an attachment through the malware scan. Uploads start
+// quarantined and can only be downloaded once they are clean. Attachments
+// whose file cannot be read for scanning end up failed.
+type ScanStatus string
+
+const (
+	ScanQuarantined ScanStatus = "quarantined"
+	ScanClean       ScanStatus = "clean"
+	ScanInfected    ScanStatus = "infected"
+	ScanFailed      ScanStatus = "failed"
+)
+
+const (
+	// maxImagePixels stops decompression bombs before an image is decoded
+	maxImagePixels    = 40_000_000
+	jpegQuality       = 90
+	scanBatchSize     = 50
+	pdfTrailerWindow  = 1024
+	attachmentTimeout = 2 * time.Minute
+	// scanMaxAttempts is how many times an unreadable file is retried
+	// before its attachment is marked failed
+	scanMaxAttempts = 5
+)
+
+var (
+	ErrInvalidAttachment     = errors.New("invalid attachment")
+	ErrAttachmentNotFound    = errors.New("attachment not found")
+	ErrAttachmentQuarantined = errors.New("attachment is still being scanned")
+	ErrAttachmentInfected    = errors.New("attachment failed the malware scan")
+	ErrAttachmentScanFailed  = errors.New("attachment could not be scanned")
+)
+
+// allowedAttachmentTypes maps each accepted content type, as sniffed from
+// the file's magic bytes, to the extensions it may be uploaded with.
+var allowedAttachmentTypes = map[string][]string{
+	"application/pdf": {".pdf"},
+	"image/jpeg":      {".jpg", ".jpeg"},
+	"image/png":       {".png"},
+}
+
+// pdfActiveContent matches PDF names that run code, launch programs or
+// carry other files. Slips only need static documents.
+var pdfActiveContent = regexp.MustCompile(
+	`/(JavaScript|JS|Launch|EmbeddedFile|EmbeddedFiles|RichMedia|XFA)\b`,
+)
+
+// preparedAttachment is an upload that passed validation, with images
+// already re-encoded.
+type preparedAttachment struct {
+	header      *multipart.FileHeader
+	data        []byte
+	contentType string
+	ext         string
+}
+
+// prepareAttachments validates every upload before any of them is stored.
+func prepareAttachments(
+	files []*multipart.FileHeader,
+) ([]preparedAttachment, error) {
+	prepared := make([]preparedAttachment, 0, len(files))
+	for _, file := range files {
+		p, err := prepareAttachment(file)
+		if err != nil {
+			return nil, err
+		}
+		prepared = append(prepared, *p)
+	}
+
+	return prepared, nil
+}
+
+// prepareAttachment checks the file's real type against its extension and
+// sanitizes it: images are re-encoded, which drops EXIF and GPS metadata,
+// and PDFs must be well formed and free of active content.
+func prepareAttachment(file *multipart.FileHeader) (*preparedAttachment, error) {
+	if file.Size > MaxFileSize {
+		return nil, fmt.Errorf(
+			"%w: file '%s' is too large: maximum 5MB allowed",
+			ErrInvalidAttachment,
+			file.Filename,
+		)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	// The header size comes from the client, so cap the read as well
+	data, err := io.ReadAll(io.LimitReader(src, MaxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxFileSize {
+		return nil, fmt.Errorf(
+			"%w: file '%s' is too large: maximum 5MB allowed",
+			ErrInvalidAttachment,
+			file.Filename,
+		)
+	}
+
+	contentType := http.DetectContentType(data)
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	exts, ok := allowedAttachmentTypes[contentType]
+	if !ok {
+		return nil, fmt.Errorf(
+			"%w: invalid file type for '%s': PDF and images only",
+			ErrInvalidAttachment,
+			file.Filename,
+		)
+	}
+	if !slices.Contains(exts, ext) {
+		return nil, fmt.Errorf(
+			"%w: '%s' is not a %s file",
+			ErrInvalidAttachment,
+			file.Filename,
+			strings.TrimPrefix(ext, "."),
+		)
+	}
+
+	switch contentType {
+	case "application/pdf":
+		if err := checkPDF(data); err != nil {
+			return nil, fmt.Errorf(
+				"%w: '%s' %s",
+				ErrInvalidAttachment,
+				file.Filename,
+				err.Error(),
+			)
+		}
+	default:
+		data, err = reencodeImage(data, contentType)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"%w: '%s' could not be read as an image",
+				ErrInvalidAttachment,
+				file.Filename,
+			)
+		}
+	}
+
+	return &preparedAttachment{
+		header:      file,
+		data:        data,
+		contentType: contentType,
+		ext:         ext,
+	}, nil
+}
+
+// checkPDF performs structural checks that catch truncated files, files
+// that only start like a PDF, and documents with active content. It is not
+// a full parser; the malware scan runs afterwards.
+func checkPDF(data []byte) error {
+	trailer := bytes.TrimRight(data, " \t\r\n\x00")
+	if len(trailer) > pdfTrailerWindow {
+		trailer = trailer[len(trailer)-pdfTrailerWindow:]
+	}
+	if !bytes.HasSuffix(trailer, []byte("%%EOF")) ||
+		!bytes.Contains(trailer, []byte("startxref")) {
+		return errors.New("is not a complete PDF document")
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return errors.New("is encrypted or password protected")
+	}
+	if pdfActiveContent.Match(data) {
+		return errors.New("contains scripts or embedded files")
+	}
+
+	return nil
+}
+
+// reencodeImage decodes and re-encodes an image so only its pixels are
+// kept. Orientation stored in EXIF is dropped along with everything else.
+func reencodeImage(data []byte, contentType string) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return nil, errors.New("image dimensions are too large")
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	switch contentType {
+	case "image/png":
+		err = png.Encode(&buf, img)
+	default:
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ScanQuarantinedAttachments scans attachments left in quarantine, e.g.
+// because the scanner was unreachable when they were uploaded.
+func (s *Service) ScanQuarantinedAttachments(ctx context.Context) {
+	attachments, err := s.repo.ListQuarantinedAttachments(ctx, scanBatchSize)
+	if err != nil {
+		log.Printf("[ScanQuarantinedAttachments] {List Attachments}: %v", err)
+		return
+	}
+
+	s.scanAttachments(ctx, attachments)
+}
+
+// scanAttachments runs the malware scan on each attachment. Scanner
+// failures leave the attachment in quarantine for the next run, while
+// files that are missing or keep failing to download are marked failed.
+func (s *Service) scanAttachments(
+	ctx context.Context,
+	attachments []SlipAttachment,
+) {
+	for _, att := range attachments {
+		if err := s.scanAttachment(ctx, att); err != nil {
+			log.Printf(
+				"[scanAttachments] {Scan Attachment %s}: %v",
+				att.ID,
+				err,
+			)
+		}
+	}
+}
+
+func (s *Service) scanAttachment(
+	ctx context.Context,
+	att SlipAttachment,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, attachmentTimeout)
+	defer cancel()
+
+	if err := s.repo.RecordAttachmentScanAttempt(ctx, att.ID); err != nil {
+		return err
+	}
+
+	blobPath := strings.TrimPrefix(att.FileURL, "/")
+	var buf bytes.Buffer
+	if err := s.fileStorage.Download(ctx, blobPath, &buf); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			return s.failAttachmentScan(ctx, att, "file not found")
+		case att.ScanAttempts+1 >= scanMaxAttempts:
+			return s.failAttachmentScan(ctx, att, "file could not be read")
+		}
+		return fmt.Errorf("failed to download attachment: %w", err)
+	}
+
+	result, err := s.scanner.Scan(ctx, &buf)
+	if err != nil {
+		return err
+	}
+
+	if !result.Infected {
+		_, err := s.repo.UpdateAttachmentScan(ctx, att.ID, ScanClean, "")
+		return err
+	}
+
+	updated, err := s.repo.UpdateAttachmentScan(
+		ctx,
+		att.ID,
+		ScanInfected,
+		result.Signature,
+	)
+	if err != nil || !updated {
+		return err
+	}
+
+	if err := s.fileStorage.Delete(ctx, blobPath); err != nil {
+		log.Printf("[scanAttachment] {Delete Infected File}: %v", err)
+	}
+
+	slipID := ""
+	if att.SlipID != nil {
+		slipID = *att.SlipID
+	}
+	studentUserID, _ := s.repo.GetUserIDBySlipID(ctx, slipID)
+
+	audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+		Log: &audit.LogParams{
+			Level:    audit.LevelWarning,
+			Category: audit.CategorySecurity,
+			Action:   audit.ActionSlipAttachmentInfected,
+			Message: fmt.Sprintf(
+				"Malware found in attachment '%s' of admission slip #%s",
+				att.FileName,
+				slipID,
+			),
+			Metadata: &audit.LogMetadata{
+				EntityType: constants.SlipEntityType,
+				EntityID:   slipID,
+				NewValues: map[string]interface{}{
+					"attachmentId": att.ID,
+					"fileName":     att.FileName,
+					"signature":    result.Signature,
+				},
+			},
+		},
+		Notifications: []audit.NotificationParams{
+			{
+				ReceiverID: structs.StringToNullableString(studentUserID),
+				TargetID:   structs.StringToNullableString(slipID),
+				TargetType: structs.StringToNullableString(
+					constants.SlipEntityType,
+				),
+				Title: "Admission Slip Attachment Removed",
+				Message: fmt.Sprintf(
+					"'%s' was removed because it failed our security scan. Please upload a clean copy.",
+					att.FileName,
+				),
+				Type: constants.SlipEntityType,
+			},
+		},
+	})
+
+	return nil
+}
+
+// failAttachmentScan takes an attachment whose file cannot be scanned out
+// of the quarantine queue. It stays unavailable for download.
+func (s *Service) failAttachmentScan(
+	ctx context.Context,
+	att SlipAttachment,
+	reason string,
+) error {
+	updated, err := s.repo.UpdateAttachmentScan(ctx, att.ID, ScanFailed, reason)
+	if err != nil {
+		return err
+	}
+	if updated {
+		log.Printf(
+			"[failAttachmentScan] {Attachment %s}: %s",
+			att.ID,
+			reason,
+		)
+	}
+
+	return nil
+}
+
+// AttachmentScanScheduler periodically rescans attachments that are still
+// in quarantine.
+type AttachmentScanScheduler struct {
+	service  ServiceInterface
+	interval time.Duration
+}
+
+func NewAttachmentScanScheduler(
+	service ServiceInterface,
+	interval time.Duration,
+) *AttachmentScanScheduler {
+	return &AttachmentScanScheduler{service: service, interval: interval}
+}
+
+// Run scans the quarantine every interval until ctx is cancelled.
+func (s *AttachmentScanScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.service.ScanQuarantinedAttachments(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package slips
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	_ "embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"image/png"
+	"log"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/qr"
+	"github.com/google/uuid"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/audit"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/hash"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/storage"
+)
+
+//go:embed assets/slip.html
+var slipTemplate string
+
+const (
+	// Letters and digits that cannot be misread for one another on paper
+	verificationCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	verificationCodeLength   = 10
+	qrCodeSize               = 240
+)
+
+var (
+	ErrSlipNotApproved     = errors.New("slip is not approved")
+	ErrCertificateNotFound = errors.New("no approved slip matches this code")
+)
+
+// GetSlipCertificateURL returns a signed download URL for the PDF of an
+// approved slip, issuing the PDF first if the background issuance after
+// approval did not complete.
+func (s *Service) GetSlipCertificateURL(
+	ctx context.Context,
+	slipID string,
+) (string, error) {
+	cert, err := s.issueCertificate(ctx, slipID)
+	if err != nil {
+		return "", err
+	}
+
+	blobPath := strings.TrimPrefix(cert.FileURL, "/")
+	url, err := s.fileStorage.SignedURL(ctx, blobPath, storage.SignedURLOptions{
+		Expiry: s.cfg.FileURLExpiry,
+		FileName: fmt.Sprintf(
+			"Admission_Slip_%s.pdf",
+			formatVerificationCode(cert.VerificationCode),
+		),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to sign slip pdf url: %w", err)
+	}
+
+	return url, nil
+}
+
+// VerifySlip checks a printed verification code. A slip is valid when its
+// details still match the signature made at issuance and it is still
+// approved.
+func (s *Service) VerifySlip(
+	ctx context.Context,
+	code string,
+) (*SlipVerificationDTO, error) {
+	cert, err := s.repo.GetSlipCertificateByCode(
+		ctx,
+		normalizeVerificationCode(code),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if cert == nil {
+		return nil, ErrCertificateNotFound
+	}
+
+	slip, err := s.repo.GetSlipByIDWithDetails(ctx, cert.SlipID)
+	if err != nil {
+		return nil, err
+	}
+	if slip == nil {
+		return nil, ErrCertificateNotFound
+	}
+
+	authentic := hmac.Equal(
+		[]byte(s.signCertificate(slip, cert)),
+		[]byte(cert.Signature),
+	)
+
+	return &SlipVerificationDTO{
+		Code:          formatVerificationCode(cert.VerificationCode),
+		Valid:         authentic && slip.StatusID == int(constants.StatusApproved),
+		Authentic:     authentic,
+		Status:        slip.StatusName,
+		StudentName:   fmt.Sprintf("%s %s", slip.UserFirstName, slip.UserLastName),
+		StudentNumber: slip.StudentNumber,
+		Category: SlipCategory{
+			ID:   slip.CategoryID,
+			Name: slip.CategoryName,
+		},
+		DateOfAbsence: slip.DateOfAbsence,
+		DateNeeded:    slip.DateNeeded,
+		IssuedAt:      cert.IssuedAt,
+	}, nil
+}
+
+// issueCertificate renders, stores and records the signed PDF of an
+// approved slip. A slip is issued once; later calls return the existing
+// certificate.
+func (s *Service) issueCertificate(
+	ctx context.Context,
+	slipID string,
+) (*SlipCertificate, error) {
+	existing, err := s.repo.GetSlipCertificateBySlipID(ctx, slipID)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+
+	slip, err := s.repo.GetSlipByIDWithDetails(ctx, slipID)
+	if err != nil {
+		return nil, err
+	}
+	if slip == nil {
+		return nil, ErrSlipNotFound
+	}
+	if slip.StatusID != int(constants.StatusApproved) {
+		return nil, ErrSlipNotApproved
+	}
+
+	code, err := newVerificationCode()
+	if err != nil {
+		return nil, err
+	}
+
+	cert := &SlipCertificate{
+		ID:               uuid.New().String(),
+		SlipID:           slipID,
+		VerificationCode: code,
+		IssuedBy:         toNullString(audit.ExtractUserID(ctx)),
+		// TIMESTAMP columns keep whole seconds; the signature must match
+		// what is read back
+		IssuedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	cert.FileURL = fmt.Sprintf("/slips/certificates/%s.pdf", cert.ID)
+	cert.Signature = s.signCertificate(slip, cert)
+
+	pdfBytes, err := s.renderCertificate(ctx, slip, cert)
+	if err != nil {
+		return nil, err
+	}
+
+	blobPath := strings.TrimPrefix(cert.FileURL, "/")
+	if err := s.fileStorage.Upload(
+		ctx,
+		blobPath,
+		bytes.NewReader(pdfBytes),
+		"application/pdf",
+	); err != nil {
+		return nil, fmt.Errorf("failed to upload slip pdf: %w", err)
+	}
+
+	if err := s.repo.CreateSlipCertificate(ctx, cert); err != nil {
+		_ = s.fileStorage.Delete(ctx, blobPath)
+
+		// Another request may have issued the slip first
+		existing, getErr := s.repo.GetSlipCertificateBySlipID(ctx, slipID)
+		if getErr == nil && existing != nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+
+	audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+		Log: &audit.LogParams{
+			Level:    audit.LevelInfo,
+			Category: audit.CategoryAudit,
+			Action:   audit.ActionSlipCertificateIssued,
+			Message: fmt.Sprintf(
+				"Signed PDF issued for admission slip #%s",
+				slipID,
+			),
+			Metadata: &audit.LogMetadata{
+				EntityType: constants.SlipEntityType,
+				EntityID:   slipID,
+				NewValues: map[string]interface{}{
+					"certificateId": cert.ID,
+					"issuedAt":      cert.IssuedAt,
+				},
+			},
+		},
+	})
+
+	return cert, nil
+}
+
+// issueCertificateAfterApproval runs in the background once a slip is
+// approved; failures are logged and retried when the PDF is requested.
+func (s *Service) issueCertificateAfterApproval(
+	ctx context.Context,
+	slipID string,
+) {
+	if _, err := s.issueCertificate(ctx, slipID); err != nil {
+		log.Printf("[issueCertificateAfterApproval] {Issue Certificate}: %v", err)
+
+		audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+			Log: &audit.LogParams{
+				Level:    audit.LevelError,
+				Category: audit.CategoryAudit,
+				Action:   audit.ActionSlipCertificateIssueFailed,
+				Message: fmt.Sprintf(
+					"Failed to issue signed PDF for admission slip #%s: %s",
+					slipID,
+					err.Error(),
+				),
+				Metadata: &audit.LogMetadata{
+					EntityType: constants.SlipEntityType,
+					EntityID:   slipID,
+					Error:      err.Error(),
+				},
+			},
+		})
+	}
+}
+
+func (s *Service) renderCertificate(
+	ctx context.Context,
+	slip *SlipWithDetailsView,
+	cert *SlipCertificate,
+) ([]byte, error) {
+	verifyURL := s.cfg.SlipVerifyURL + "/" + cert.VerificationCode
+	qrCode, err := qrCodeDataURI(verifyURL)
+	if err != nil {
+		return nil, err
+	}
+
+	data := struct {
+		Code          string
+		QRCode        template.URL
+		VerifyURL     string
+		StudentName   string
+		StudentNumber string
+		Category      string
+		DateOfAbsence string
+		DateNeeded    string
+		IssuedAt      string
+	}{
+		Code:          formatVerificationCode(cert.VerificationCode),
+		QRCode:        template.URL(qrCode),
+		VerifyURL:     s.cfg.SlipVerifyURL,
+		StudentName:   fmt.Sprintf("%s %s", slip.UserFirstName, slip.UserLastName),
+		StudentNumber: slip.StudentNumber,
+		Category:      slip.CategoryName,
+		DateOfAbsence: slip.DateOfAbsence,
+		DateNeeded:    slip.DateNeeded,
+		IssuedAt:      cert.IssuedAt.Format("January 02, 2006"),
+	}
+
+	pdfBytes, err := s.pdfService.GenerateFromContent(
+		ctx,
+		"slip.html",
+		slipTemplate,
+		data,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate slip pdf: %w", err)
+	}
+
+	return pdfBytes, nil
+}
+
+// signCertificate signs the slip details printed on the PDF together with
+// the verification code and issue time.
+func (s *Service) signCertificate(
+	slip *SlipWithDetailsView,
+	cert *SlipCertificate,
+) string {
+	payload := strings.Join([]string{
+		cert.VerificationCode,
+		slip.ID,
+		slip.IIRID,
+		slip.StudentNumber,
+		slip.DateOfAbsence,
+		slip.DateNeeded,
+		strconv.Itoa(slip.CategoryID),
+		cert.IssuedAt.UTC().Format(time.RFC3339),
+	}, "|")
+
+	return hash.GetHMACSHA256(s.cfg.SlipSigningKey, payload)
+}
+
+func qrCodeDataURI(content string) (string, error) {
+	code, err := qr.Encode(content, qr.M, qr.Auto)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode qr code: %w", err)
+	}
+	code, err = barcode.Scale(code, qrCodeSize, qrCodeSize)
+	if err != nil {
+		return "", fmt.Errorf("failed to scale qr code: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, code); err != nil {
+		return "", fmt.Errorf("failed to encode qr png: %w", err)
+	}
+
+	return "data:image/png;base64," +
+		base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+func newVerificationCode() (string, error) {
+	max := big.NewInt(int64(len(verificationCodeAlphabet)))
+	code := make([]byte, verificationCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate verification code: %w", err)
+		}
+		code[i] = verificationCodeAlphabet[n.Int64()]
+	}
+
+	return string(code), nil
+}
+
+// formatVerificationCode splits a code in two halves for readability,
+// e.g. ABCDE-23456.
+func formatVerificationCode(code string) string {
+	half := len(code) / 2
+	return code[:half] + "-" + code[half:]
+}
+
+// normalizeVerificationCode accepts codes typed with dashes, spaces or in
+// lower case.
+func normalizeVerificationCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -30,14 +30,78 @@ type SlipDTO struct {
 	AdminNotes    structs.NullableString `json:"adminNotes,omitempty"`
 	Category      SlipCategory           `json:"category"                form:"categoryId"    binding:"required"`
 	Status        SlipStatus             `json:"status,omitempty"`
+	Urgency       *UrgencyBreakdown      `json:"urgency,omitempty"`
+	RevisionRound int                    `json:"revisionRound,omitempty"`
+	Rounds        []SlipRevisionDTO      `json:"rounds,omitempty"`
+	Timeline      []SlipStatusChangeDTO  `json:"timeline,omitempty"`
+	Comments      []SlipCommentDTO       `json:"comments,omitempty"`
 	CreatedAt     time.Time              `json:"createdAt,omitempty"`
 	UpdatedAt     time.Time              `json:"updatedAt,omitempty"`
 }
 
+// UrgencyBreakdown explains a slip's urgency score factor by factor; the
+// points of all factors add up to Score.
+type UrgencyBreakdown struct {
+	Score   int             `json:"score"`
+	Factors []UrgencyFactor `json:"factors"`
+}
+
+type UrgencyFactor struct {
+	Factor string `json:"factor"`
+	Value  int    `json:"value"`
+	Points int    `json:"points"`
+}
+
 type AttachmentDTO struct {
-	ID       string `json:"id"`
-	FileName string `json:"fileName"`
-	FileURL  string `json:"fileUrl"`
+	ID            string     `json:"id"`
+	FileName      string     `json:"fileName"`
+	FileURL       string     `json:"fileUrl"`
+	RevisionRound int        `json:"revisionRound"`
+	ScanStatus    ScanStatus `json:"scanStatus"`
+}
+
+// SlipRevisionDTO is one submission of a slip with the files sent in it.
+type SlipRevisionDTO struct {
+	Round         int             `json:"round"`
+	Reason        string          `json:"reason"`
+	DateOfAbsence string          `json:"dateOfAbsence"`
+	DateNeeded    string          `json:"dateNeeded"`
+	Category      SlipCategory    `json:"category"`
+	Attachments   []AttachmentDTO `json:"attachments"`
+	SubmittedAt   time.Time       `json:"submittedAt"`
+}
+
+type SlipStatusChangeDTO struct {
+	ID        int                    `json:"id"`
+	Round     int                    `json:"round"`
+	From      *SlipStatus            `json:"from"`
+	To        SlipStatus             `json:"to"`
+	ChangedBy *SlipActor             `json:"changedBy"`
+	Note      structs.NullableString `json:"note"`
+	CreatedAt time.Time              `json:"createdAt"`
+}
+
+type SlipActor struct {
+	ID        string `json:"id"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	RoleID    int    `json:"roleId,omitempty"`
+}
+
+// SlipCommentDTO is a comment with its replies nested below it.
+type SlipCommentDTO struct {
+	ID        string                 `json:"id"`
+	ParentID  structs.NullableString `json:"parentId"`
+	Round     int                    `json:"round"`
+	Author    SlipActor              `json:"author"`
+	Body      string                 `json:"body"`
+	CreatedAt time.Time              `json:"createdAt"`
+	Replies   []SlipCommentDTO       `json:"replies"`
+}
+
+type CreateSlipCommentRequest struct {
+	Body     string `json:"body"     binding:"required,max=2000"`
+	ParentID string `json:"parentId"`
 }
 
 type CreateSlipRequest struct {
@@ -51,3 +115,47 @@ type UpdateStatusRequest struct {
 	Status     string `json:"status"     binding:"required"`
 	AdminNotes string `json:"adminNotes"`
 }
+
+type UrgencyPolicyDTO struct {
+	NeededHorizonDays  int                     `json:"neededHorizonDays"`
+	NeededWeight       int                     `json:"neededWeight"`
+	AbsenceHorizonDays int                     `json:"absenceHorizonDays"`
+	AbsenceWeight      int                     `json:"absenceWeight"`
+	RepeatLookbackDays int                     `json:"repeatLookbackDays"`
+	RepeatWeight       int                     `json:"repeatWeight"`
+	AttachmentWeight   int                     `json:"attachmentWeight"`
+	Categories         []UrgencyCategoryWeight `json:"categories"`
+	UpdatedBy          structs.NullableString  `json:"updatedBy"`
+	UpdatedAt          time.Time               `json:"updatedAt"`
+}
+
+type UpdateUrgencyPolicyRequest struct {
+	NeededHorizonDays  *int                           `json:"neededHorizonDays"  binding:"required,min=0"`
+	NeededWeight       *int                           `json:"neededWeight"       binding:"required,min=0"`
+	AbsenceHorizonDays *int                           `json:"absenceHorizonDays" binding:"required,min=0"`
+	AbsenceWeight      *int                           `json:"absenceWeight"      binding:"required,min=0"`
+	RepeatLookbackDays *int                           `json:"repeatLookbackDays" binding:"required,min=0"`
+	RepeatWeight       *int                           `json:"repeatWeight"       binding:"required,min=0"`
+	AttachmentWeight   *int                           `json:"attachmentWeight"   binding:"required,min=0"`
+	Categories         []UrgencyCategoryWeightRequest `json:"categories"         binding:"dive"`
+}
+
+type UrgencyCategoryWeightRequest struct {
+	CategoryID int  `json:"categoryId" binding:"required"`
+	Weight     *int `json:"weight"     binding:"required,min=0"`
+}
+
+// SlipVerificationDTO is the public result of checking a slip's
+// verification code. It leaves out the reason and contact details.
+type SlipVerificationDTO struct {
+	Code          string       `json:"code"`
+	Valid         bool         `json:"valid"`
+	Authentic     bool         `json:"authentic"`
+	Status        string       `json:"status"`
+	StudentName   string       `json:"studentName"`
+	StudentNumber string       `json:"studentNumber"`
+	Category      SlipCategory `json:"category"`
+	DateOfAbsence string       `json:"dateOfAbsence"`
+	DateNeeded    string       `json:"dateNeeded"`
+	IssuedAt      time.Time    `json:"issuedAt"`
+}
@@ -1,11 +1,11 @@
 package slips
 
 import (
-	"fmt"
+	"database/sql"
+	"errors"
 	"log"
 	"mime/multipart"
 	"net/http"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
@@ -106,6 +106,10 @@ func (h *Handler) PostSlip(c *gin.Context) {
 		files,
 	)
 	if err != nil {
+		if errors.Is(err, ErrInvalidAttachment) {
+			response.SendFail(c, gin.H{"error": err.Error()})
+			return
+		}
 		log.Printf("[PostSlip] {Submit Excuse Slip}: %v", err)
 		response.SendError(
 			c,
@@ -337,9 +341,14 @@ func (h *Handler) GetSlipListByIIR(c *gin.Context) {
 // @Router       /slips/id/{id} [get]
 func (h *Handler) GetSlipByID(c *gin.Context) {
 	idParam := c.Param("id")
+	if c.GetInt("roleID") == int(constants.StudentRoleID) &&
+		!h.checkSlipOwner(c, idParam) {
+		return
+	}
+
 	slip, err := h.service.GetSlipByID(c.Request.Context(), idParam)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
+		if errors.Is(err, ErrSlipNotFound) {
 			response.SendFail(
 				c,
 				gin.H{"error": "Excuse slip not found"},
@@ -357,6 +366,11 @@ func (h *Handler) GetSlipByID(c *gin.Context) {
 		return
 	}
 
+	// The urgency breakdown is for counselors triaging the queue
+	if c.GetInt("roleID") == int(constants.StudentRoleID) {
+		slip.Urgency = nil
+	}
+
 	response.SendSuccess(c, slip)
 }
 
@@ -368,10 +382,17 @@ func (h *Handler) GetSlipByID(c *gin.Context) {
 // @Param        id   path      int  true  "Slip ID"
 // @Success      200  {object} []AttachmentDTO
 // @Failure      400  {object} map[string]string
+// @Failure      403  {object} map[string]string
+// @Failure      404  {object} map[string]string
 // @Failure      500  {object} map[string]string
 // @Router       /slips/id/{id}/attachments [get]
 func (h *Handler) GetSlipAttachmentList(c *gin.Context) {
 	idParam := c.Param("id")
+	if c.GetInt("roleID") == int(constants.StudentRoleID) &&
+		!h.checkSlipOwner(c, idParam) {
+		return
+	}
+
 	attachments, err := h.service.GetSlipAttachments(
 		c.Request.Context(),
 		idParam,
@@ -392,44 +413,59 @@ func (h *Handler) GetSlipAttachmentList(c *gin.Context) {
 
 // GetAttachmentFile godoc
 // @Summary      Download attachment
-// @Description  Downloads a specific attachment file.
+// @Description  Redirects to a short-lived signed URL for the attachment.
+// @Description  Files still in quarantine, infected, or that could not be
+// @Description  scanned are refused.
 // @Tags         ExcuseSlips
-// @Param        attachmentId path int true "Attachment ID"
-// @Success      200  {file} binary
-// @Failure      400  {object} map[string]string
+// @Param        id           path string true "Slip ID"
+// @Param        attachmentId path string true "Attachment ID"
+// @Success      302
+// @Failure      403  {object} map[string]string
 // @Failure      404  {object} map[string]string
+// @Failure      409  {object} map[string]string
 // @Failure      500  {object} map[string]string
 // @Router       /slips/id/{id}/attachments/{attachmentId} [get]
 func (h *Handler) GetAttachmentFile(c *gin.Context) {
-	attachmentIDParam := c.Param("attachmentId")
-	attachment, err := h.service.DownloadAttachment(
+	idParam := c.Param("id")
+	if c.GetInt("roleID") == int(constants.StudentRoleID) &&
+		!h.checkSlipOwner(c, idParam) {
+		return
+	}
+
+	url, err := h.service.GetAttachmentURL(
 		c.Request.Context(),
-		attachmentIDParam,
-		c.Writer,
+		idParam,
+		c.Param("attachmentId"),
 	)
 	if err != nil {
-		if strings.Contains(err.Error(), "attachment not found") {
+		switch {
+		case errors.Is(err, ErrAttachmentNotFound):
 			response.SendFail(
 				c,
 				gin.H{"error": "Attachment not found"},
 				http.StatusNotFound,
 			)
-			return
+		case errors.Is(err, ErrAttachmentQuarantined),
+			errors.Is(err, ErrAttachmentInfected),
+			errors.Is(err, ErrAttachmentScanFailed):
+			response.SendFail(
+				c,
+				gin.H{"error": err.Error()},
+				http.StatusConflict,
+			)
+		default:
+			log.Printf("[GetAttachmentFile] {Download Attachment}: %v", err)
+			response.SendError(
+				c,
+				"Failed to download file",
+				http.StatusInternalServerError,
+				nil,
+			)
 		}
-		log.Printf("[GetAttachmentFile] {Download Attachment}: %v", err)
-		response.SendError(
-			c,
-			"Failed to download file",
-			http.StatusInternalServerError,
-			nil,
-		)
 		return
 	}
 
-	c.Header(
-		"Content-Disposition",
-		fmt.Sprintf("attachment; filename=%q", attachment.FileName),
-	)
+	c.Redirect(http.StatusFound, url)
 }
 
 // PatchSlipStatus godoc
@@ -485,13 +521,37 @@ func (h *Handler) PatchSlip(c *gin.Context) {
 		files,
 	)
 	if err != nil {
-		log.Printf("[PatchSlip] {Update Excuse Slip}: %v", err)
-		response.SendError(
-			c,
-			err.Error(),
-			http.StatusInternalServerError,
-			nil,
-		)
+		switch {
+		case errors.Is(err, ErrSlipNotFound):
+			response.SendFail(
+				c,
+				gin.H{"error": "Excuse slip not found"},
+				http.StatusNotFound,
+			)
+		case errors.Is(err, ErrSlipAccessDenied),
+			errors.Is(err, ErrTransitionForbidden):
+			response.SendFail(
+				c,
+				gin.H{"error": err.Error()},
+				http.StatusForbidden,
+			)
+		case errors.Is(err, ErrSlipNotEditable):
+			response.SendFail(
+				c,
+				gin.H{"error": err.Error()},
+				http.StatusConflict,
+			)
+		case errors.Is(err, ErrInvalidAttachment):
+			response.SendFail(c, gin.H{"error": err.Error()})
+		default:
+			log.Printf("[PatchSlip] {Update Excuse Slip}: %v", err)
+			response.SendError(
+				c,
+				err.Error(),
+				http.StatusInternalServerError,
+				nil,
+			)
+		}
 		return
 	}
 
@@ -517,17 +577,31 @@ func (h *Handler) PatchSlipStatus(c *gin.Context) {
 		req.AdminNotes,
 	)
 	if err != nil {
-		if err.Error() == "sql: no rows in result set" {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
 			response.SendFail(
 				c,
 				gin.H{"error": "Slip not found"},
 				http.StatusNotFound,
 			)
 			return
-		}
-		if strings.Contains(err.Error(), "invalid status") {
+		case errors.Is(err, ErrInvalidSlipStatus):
 			response.SendFail(c, gin.H{"error": err.Error()})
 			return
+		case errors.Is(err, ErrInvalidTransition):
+			response.SendFail(
+				c,
+				gin.H{"error": err.Error()},
+				http.StatusConflict,
+			)
+			return
+		case errors.Is(err, ErrTransitionForbidden):
+			response.SendFail(
+				c,
+				gin.H{"error": err.Error()},
+				http.StatusForbidden,
+			)
+			return
 		}
 		log.Printf("[PatchSlipStatus] {Update Status}: %v", err)
 		response.SendError(
@@ -541,3 +615,300 @@ func (h *Handler) PatchSlipStatus(c *gin.Context) {
 
 	response.SendSuccess(c, gin.H{"message": "Status updated successfully"})
 }
+
+// GetSlipCommentList godoc
+// @Summary      Get slip comments
+// @Description  Retrieves the comment threads of a slip, oldest first,
+// @Description  with replies nested under their parent.
+// @Tags         ExcuseSlips
+// @Produce      json
+// @Param        id   path      string  true  "Slip ID"
+// @Success      200  {object} []SlipCommentDTO
+// @Failure      403  {object} map[string]string
+// @Failure      404  {object} map[string]string
+// @Failure      500  {object} map[string]string
+// @Router       /slips/id/{id}/comments [get]
+func (h *Handler) GetSlipCommentList(c *gin.Context) {
+	id := c.Param("id")
+	if c.GetInt("roleID") == int(constants.StudentRoleID) &&
+		!h.checkSlipOwner(c, id) {
+		return
+	}
+
+	comments, err := h.service.ListSlipComments(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, ErrSlipNotFound) {
+			response.SendFail(
+				c,
+				gin.H{"error": "Excuse slip not found"},
+				http.StatusNotFound,
+			)
+			return
+		}
+		log.Printf("[GetSlipCommentList] {Fetch Comments}: %v", err)
+		response.SendError(
+			c,
+			"Failed to retrieve comments",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	response.SendSuccess(c, comments)
+}
+
+// PostSlipComment godoc
+// @Summary      Comment on a slip
+// @Description  Adds a comment, or a reply when parentId is set, to the
+// @Description  slip's current revision round. Returns the updated threads.
+// @Tags         ExcuseSlips
+// @Accept       json
+// @Produce      json
+// @Param        id      path  string                    true  "Slip ID"
+// @Param        request body  CreateSlipCommentRequest  true  "Comment"
+// @Success      201  {object} []SlipCommentDTO
+// @Failure      400  {object} map[string]string
+// @Failure      403  {object} map[string]string
+// @Failure      404  {object} map[string]string
+// @Failure      500  {object} map[string]string
+// @Router       /slips/id/{id}/comments [post]
+func (h *Handler) PostSlipComment(c *gin.Context) {
+	id := c.Param("id")
+	if c.GetInt("roleID") == int(constants.StudentRoleID) &&
+		!h.checkSlipOwner(c, id) {
+		return
+	}
+
+	var req CreateSlipCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[PostSlipComment] {Bind Request}: %v", err)
+		response.SendFail(c, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	comments, err := h.service.AddSlipComment(c.Request.Context(), id, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrSlipNotFound):
+			response.SendFail(
+				c,
+				gin.H{"error": "Excuse slip not found"},
+				http.StatusNotFound,
+			)
+		case errors.Is(err, ErrCommentParent):
+			response.SendFail(c, gin.H{"error": err.Error()})
+		default:
+			log.Printf("[PostSlipComment] {Add Comment}: %v", err)
+			response.SendError(
+				c,
+				"Failed to add comment",
+				http.StatusInternalServerError,
+				nil,
+			)
+		}
+		return
+	}
+
+	response.SendSuccess(c, comments, http.StatusCreated)
+}
+
+// GetSlipCertificate godoc
+// @Summary      Download the signed admission slip
+// @Description  Redirects to a short-lived signed URL for the PDF of an
+// @Description  approved slip. The PDF carries a QR code and code that
+// @Description  anyone can verify.
+// @Tags         ExcuseSlips
+// @Param        id   path      string  true  "Slip ID"
+// @Success      302
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /slips/id/{id}/pdf [get]
+func (h *Handler) GetSlipCertificate(c *gin.Context) {
+	id := c.Param("id")
+	if c.GetInt("roleID") == int(constants.StudentRoleID) &&
+		!h.checkSlipOwner(c, id) {
+		return
+	}
+
+	url, err := h.service.GetSlipCertificateURL(c.Request.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrSlipNotFound):
+			response.SendFail(
+				c,
+				gin.H{"error": "Excuse slip not found"},
+				http.StatusNotFound,
+			)
+		case errors.Is(err, ErrSlipNotApproved):
+			response.SendFail(
+				c,
+				gin.H{"error": err.Error()},
+				http.StatusConflict,
+			)
+		default:
+			log.Printf("[GetSlipCertificate] {Service Error}: %v", err)
+			response.SendError(
+				c,
+				"Failed to generate admission slip PDF",
+				http.StatusInternalServerError,
+				nil,
+			)
+		}
+		return
+	}
+
+	c.Redirect(http.StatusFound, url)
+}
+
+// GetSlipVerification godoc
+// @Summary      Verify an admission slip
+// @Description  Public. Looks up the code printed on a slip PDF and reports
+// @Description  whether it is authentic and still approved.
+// @Tags         ExcuseSlips
+// @Produce      json
+// @Param        code  path      string  true  "Verification code"
+// @Success      200   {object}  SlipVerificationDTO
+// @Failure      404   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /slips/verify/{code} [get]
+func (h *Handler) GetSlipVerification(c *gin.Context) {
+	result, err := h.service.VerifySlip(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		if errors.Is(err, ErrCertificateNotFound) {
+			response.SendFail(
+				c,
+				gin.H{"error": err.Error()},
+				http.StatusNotFound,
+			)
+			return
+		}
+		log.Printf("[GetSlipVerification] {Verify Slip}: %v", err)
+		response.SendError(
+			c,
+			"Failed to verify admission slip",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	response.SendSuccess(c, result)
+}
+
+// checkSlipOwner responds with 404 or 403 and returns false unless the
+// caller is the student who submitted the slip.
+func (h *Handler) checkSlipOwner(c *gin.Context, id string) bool {
+	ownerID, err := h.service.GetSlipOwnerID(c.Request.Context(), id)
+	if err == sql.ErrNoRows {
+		response.SendFail(
+			c,
+			gin.H{"error": "Excuse slip not found"},
+			http.StatusNotFound,
+		)
+		return false
+	}
+	if err != nil {
+		log.Printf("[checkSlipOwner] {Fetch Owner}: %v", err)
+		response.SendError(
+			c,
+			"Failed to verify ownership",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return false
+	}
+	if ownerID != c.GetString("userID") {
+		response.SendFail(
+			c,
+			gin.H{"error": "Access denied"},
+			http.StatusForbidden,
+		)
+		return false
+	}
+
+	return true
+}
+
+// GetUrgencyPolicy godoc
+// @Summary      Get the slip urgency policy
+// @Description  Retrieves the weights used to score slips on the urgent
+// @Description  queue, including the weight of every slip category.
+// @Tags         ExcuseSlips
+// @Produce      json
+// @Success      200  {object} UrgencyPolicyDTO
+// @Failure      404  {object} map[string]string
+// @Failure      500  {object} map[string]string
+// @Router       /slips/urgency-policy [get]
+func (h *Handler) GetUrgencyPolicy(c *gin.Context) {
+	policy, err := h.service.GetUrgencyPolicy(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, ErrUrgencyPolicyNotFound) {
+			response.SendFail(
+				c,
+				gin.H{"error": "Urgency policy not found"},
+				http.StatusNotFound,
+			)
+			return
+		}
+		log.Printf("[GetUrgencyPolicy] {Fetch Urgency Policy}: %v", err)
+		response.SendError(
+			c,
+			"Failed to retrieve urgency policy",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	response.SendSuccess(c, policy)
+}
+
+// PutUrgencyPolicy godoc
+// @Summary      Update the slip urgency policy
+// @Description  Replaces the policy weights. Only the listed categories
+// @Description  have their weight changed.
+// @Tags         ExcuseSlips
+// @Accept       json
+// @Produce      json
+// @Param        request body UpdateUrgencyPolicyRequest true "Policy weights"
+// @Success      200  {object} UrgencyPolicyDTO
+// @Failure      400  {object} map[string]string
+// @Failure      404  {object} map[string]string
+// @Failure      500  {object} map[string]string
+// @Router       /slips/urgency-policy [put]
+func (h *Handler) PutUrgencyPolicy(c *gin.Context) {
+	var req UpdateUrgencyPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[PutUrgencyPolicy] {Bind Request}: %v", err)
+		response.SendFail(c, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	policy, err := h.service.UpdateUrgencyPolicy(c.Request.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUrgencyCategory):
+			response.SendFail(c, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrUrgencyPolicyNotFound):
+			response.SendFail(
+				c,
+				gin.H{"error": "Urgency policy not found"},
+				http.StatusNotFound,
+			)
+		default:
+			log.Printf("[PutUrgencyPolicy] {Update Urgency Policy}: %v", err)
+			response.SendError(
+				c,
+				"Failed to update urgency policy",
+				http.StatusInternalServerError,
+				nil,
+			)
+		}
+		return
+	}
+
+	response.SendSuccess(c, policy)
+}
@@ -2,7 +2,6 @@ package slips
 
 import (
 	"context"
-	"io"
 	"mime/multipart"
 
 	"github.com/jmoiron/sqlx"
@@ -46,11 +45,11 @@ type ServiceInterface interface {
 		req CreateSlipRequest,
 		files []*multipart.FileHeader,
 	) (*Slip, error)
-	DownloadAttachment(
+	GetAttachmentURL(
 		ctx context.Context,
+		slipID string,
 		attachmentID string,
-		writer io.Writer,
-	) (*SlipAttachment, error)
+	) (string, error)
 	UpdateExcuseSlipStatus(
 		ctx context.Context,
 		id string,
@@ -64,6 +63,24 @@ type ServiceInterface interface {
 		req CreateSlipRequest,
 		files []*multipart.FileHeader,
 	) (*Slip, error)
+	GetSlipOwnerID(ctx context.Context, slipID string) (string, error)
+	AddSlipComment(
+		ctx context.Context,
+		slipID string,
+		req CreateSlipCommentRequest,
+	) ([]SlipCommentDTO, error)
+	ListSlipComments(
+		ctx context.Context,
+		slipID string,
+	) ([]SlipCommentDTO, error)
+	GetUrgencyPolicy(ctx context.Context) (*UrgencyPolicyDTO, error)
+	UpdateUrgencyPolicy(
+		ctx context.Context,
+		req UpdateUrgencyPolicyRequest,
+	) (*UrgencyPolicyDTO, error)
+	GetSlipCertificateURL(ctx context.Context, slipID string) (string, error)
+	VerifySlip(ctx context.Context, code string) (*SlipVerificationDTO, error)
+	ScanQuarantinedAttachments(ctx context.Context)
 }
 
 // RepositoryInterface defines the data access layer for managing excuse slips.
@@ -89,6 +106,7 @@ type RepositoryInterface interface {
 		ctx context.Context,
 		tx datastore.DB,
 		slipID string,
+		round int,
 	) error
 	CheckStudentExistence(ctx context.Context, studentID int) (bool, error)
 	GetSlipStatuses(ctx context.Context) ([]SlipStatus, error)
@@ -134,13 +152,86 @@ type RepositoryInterface interface {
 		ctx context.Context,
 		attachmentID string,
 	) (*SlipAttachment, error)
+	ListQuarantinedAttachments(
+		ctx context.Context,
+		limit int,
+	) ([]SlipAttachment, error)
+	RecordAttachmentScanAttempt(
+		ctx context.Context,
+		attachmentID string,
+	) error
+	UpdateAttachmentScan(
+		ctx context.Context,
+		attachmentID string,
+		status ScanStatus,
+		result string,
+	) (bool, error)
+	LockSlip(
+		ctx context.Context,
+		tx datastore.DB,
+		id string,
+	) (*Slip, error)
+	GetSlipStatusByName(ctx context.Context, name string) (*SlipStatus, error)
 	UpdateStatus(
 		ctx context.Context,
 		tx datastore.DB,
 		id string,
-		statusName string,
+		statusID int,
 		adminNotes string,
 	) error
 	GetUserIDBySlipID(ctx context.Context, id string) (string, error)
 	Delete(ctx context.Context, id string) error
+	CreateSlipRevision(
+		ctx context.Context,
+		tx datastore.DB,
+		revision *SlipRevision,
+	) error
+	UpdateSlipRevision(
+		ctx context.Context,
+		tx datastore.DB,
+		revision *SlipRevision,
+	) error
+	ListSlipRevisions(
+		ctx context.Context,
+		slipID string,
+	) ([]SlipRevision, error)
+	CreateStatusChange(
+		ctx context.Context,
+		tx datastore.DB,
+		change *SlipStatusChange,
+	) error
+	ListStatusHistory(
+		ctx context.Context,
+		slipID string,
+	) ([]SlipStatusChangeView, error)
+	CreateSlipComment(ctx context.Context, comment *SlipComment) error
+	GetSlipComment(ctx context.Context, id string) (*SlipComment, error)
+	ListSlipComments(
+		ctx context.Context,
+		slipID string,
+	) ([]SlipCommentView, error)
+	GetUrgencyPolicy(ctx context.Context) (*UrgencyPolicy, error)
+	ListUrgencyCategoryWeights(
+		ctx context.Context,
+	) ([]UrgencyCategoryWeight, error)
+	UpdateUrgencyPolicy(
+		ctx context.Context,
+		tx datastore.DB,
+		policy *UrgencyPolicy,
+	) error
+	SaveUrgencyCategoryWeight(
+		ctx context.Context,
+		tx datastore.DB,
+		categoryID int,
+		weight int,
+	) error
+	CreateSlipCertificate(ctx context.Context, cert *SlipCertificate) error
+	GetSlipCertificateBySlipID(
+		ctx context.Context,
+		slipID string,
+	) (*SlipCertificate, error)
+	GetSlipCertificateByCode(
+		ctx context.Context,
+		code string,
+	) (*SlipCertificate, error)
 }
@@ -35,8 +35,11 @@ type SlipWithDetailsView struct {
 	StatusName     string         `json:"statusName"           db:"status_name"`
 	UrgencyScore   int            `json:"urgencyScore"         db:"urgency_score"`
 	StatusColorKey string         `json:"statusColorKey"       db:"status_color_key"`
+	RevisionRound  int            `json:"revisionRound"        db:"revision_round"`
 	CreatedAt      time.Time      `json:"createdAt"            db:"created_at"`
 	UpdatedAt      time.Time      `json:"updatedAt"            db:"updated_at"`
+
+	UrgencyInputs
 }
 
 type SlipStatusCount struct {
@@ -55,13 +58,122 @@ type Slip struct {
 	AdminNotes    sql.NullString `json:"adminNotes,omitempty" db:"admin_notes"`
 	CategoryID    int            `json:"categoryId"           db:"category_id"`
 	StatusID      int            `json:"statusId"             db:"status_id"`
+	RevisionRound int            `json:"revisionRound"        db:"revision_round"`
 	CreatedAt     time.Time      `json:"createdAt"            db:"created_at"`
 	UpdatedAt     time.Time      `json:"updatedAt"            db:"updated_at"`
 }
 
 type SlipAttachment struct {
-	ID       string  `json:"id"       db:"id"`
-	SlipID   *string `json:"slipId"   db:"admission_slip_id"`
-	FileName string  `json:"fileName" db:"file_name"`
-	FileURL  string  `json:"fileUrl"  db:"file_url"`
+	ID            string         `json:"id"            db:"id"`
+	SlipID        *string        `json:"slipId"        db:"admission_slip_id"`
+	FileName      string         `json:"fileName"      db:"file_name"`
+	FileURL       string         `json:"fileUrl"       db:"file_url"`
+	ContentType   sql.NullString `json:"contentType"   db:"content_type"`
+	RevisionRound int            `json:"revisionRound" db:"revision_round"`
+	ScanStatus    ScanStatus     `json:"scanStatus"    db:"scan_status"`
+	ScanResult    sql.NullString `json:"scanResult"    db:"scan_result"`
+	ScanAttempts  int            `json:"-"             db:"scan_attempts"`
+	LastScanAt    sql.NullTime   `json:"-"             db:"last_scan_at"`
+	ScannedAt     sql.NullTime   `json:"scannedAt"     db:"scanned_at"`
+}
+
+// SlipRevision is what the student submitted in one revision round.
+type SlipRevision struct {
+	ID            string    `db:"id"`
+	SlipID        string    `db:"admission_slip_id"`
+	RevisionRound int       `db:"revision_round"`
+	Reason        string    `db:"reason"`
+	DateOfAbsence string    `db:"date_of_absence"`
+	DateNeeded    string    `db:"date_needed"`
+	CategoryID    int       `db:"category_id"`
+	CategoryName  string    `db:"category_name"`
+	CreatedAt     time.Time `db:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at"`
+}
+
+type SlipStatusChange struct {
+	SlipID        string         `db:"admission_slip_id"`
+	RevisionRound int            `db:"revision_round"`
+	FromStatusID  sql.NullInt64  `db:"from_status_id"`
+	ToStatusID    int            `db:"to_status_id"`
+	ChangedBy     sql.NullString `db:"changed_by"`
+	Note          sql.NullString `db:"note"`
+}
+
+type SlipStatusChangeView struct {
+	ID                 int            `db:"id"`
+	RevisionRound      int            `db:"revision_round"`
+	FromStatusID       sql.NullInt64  `db:"from_status_id"`
+	FromStatusName     sql.NullString `db:"from_status_name"`
+	FromStatusColorKey sql.NullString `db:"from_status_color_key"`
+	ToStatusID         int            `db:"to_status_id"`
+	ToStatusName       string         `db:"to_status_name"`
+	ToStatusColorKey   string         `db:"to_status_color_key"`
+	ChangedBy          sql.NullString `db:"changed_by"`
+	ChangedByFirstName sql.NullString `db:"changed_by_first_name"`
+	ChangedByLastName  sql.NullString `db:"changed_by_last_name"`
+	Note               sql.NullString `db:"note"`
+	CreatedAt          time.Time      `db:"created_at"`
+}
+
+type SlipComment struct {
+	ID            string         `db:"id"`
+	SlipID        string         `db:"admission_slip_id"`
+	ParentID      sql.NullString `db:"parent_id"`
+	AuthorID      string         `db:"author_id"`
+	RevisionRound int            `db:"revision_round"`
+	Body          string         `db:"body"`
+	CreatedAt     time.Time      `db:"created_at"`
+	UpdatedAt     time.Time      `db:"updated_at"`
+}
+
+type SlipCommentView struct {
+	SlipComment
+	AuthorFirstName string `db:"author_first_name"`
+	AuthorLastName  string `db:"author_last_name"`
+	AuthorRoleID    int    `db:"author_role_id"`
+}
+
+// UrgencyInputs holds the per-factor values and points computed by the
+// urgency policy. Queries that do not score slips leave it zeroed.
+type UrgencyInputs struct {
+	DaysUntilNeeded  int `db:"days_until_needed"`
+	DaysSinceAbsence int `db:"days_since_absence"`
+	RepeatAbsences   int `db:"repeat_absences"`
+	AttachmentCount  int `db:"attachment_count"`
+	CategoryPoints   int `db:"category_points"`
+	NeededPoints     int `db:"needed_points"`
+	AbsencePoints    int `db:"absence_points"`
+	RepeatPoints     int `db:"repeat_points"`
+	AttachmentPoints int `db:"attachment_points"`
+}
+
+type UrgencyPolicy struct {
+	NeededHorizonDays  int            `json:"neededHorizonDays"  db:"needed_horizon_days"`
+	NeededWeight       int            `json:"neededWeight"       db:"needed_weight"`
+	AbsenceHorizonDays int            `json:"absenceHorizonDays" db:"absence_horizon_days"`
+	AbsenceWeight      int            `json:"absenceWeight"      db:"absence_weight"`
+	RepeatLookbackDays int            `json:"repeatLookbackDays" db:"repeat_lookback_days"`
+	RepeatWeight       int            `json:"repeatWeight"       db:"repeat_weight"`
+	AttachmentWeight   int            `json:"attachmentWeight"   db:"attachment_weight"`
+	UpdatedBy          sql.NullString `json:"updatedBy"          db:"updated_by"`
+	UpdatedAt          time.Time      `json:"updatedAt"          db:"updated_at"`
+}
+
+type UrgencyCategoryWeight struct {
+	CategoryID   int    `json:"categoryId"   db:"category_id"`
+	CategoryName string `json:"categoryName" db:"category_name"`
+	Weight       int    `json:"weight"       db:"weight"`
+}
+
+// SlipCertificate is the signed PDF issued for an approved slip.
+type SlipCertificate struct {
+	ID               string         `db:"id"`
+	SlipID           string         `db:"admission_slip_id"`
+	VerificationCode string         `db:"verification_code"`
+	Signature        string         `db:"signature"`
+	FileURL          string         `db:"file_url"`
+	IssuedBy         sql.NullString `db:"issued_by"`
+	IssuedAt         time.Time      `db:"issued_at"`
+	CreatedAt        time.Time      `db:"created_at"`
 }
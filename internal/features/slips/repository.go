@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
 )
 
@@ -37,6 +38,7 @@ const (
 		s.id AS status_id,
 		s.name AS status_name,
 		s.color_key AS status_color_key,
+		slp.revision_round AS revision_round,
 		slp.created_at AS created_at,
 		slp.updated_at AS updated_at
 	FROM admission_slips slp
@@ -108,7 +110,7 @@ func (r *Repository) UpdateSlip(
 ) error {
 	query := `
 		UPDATE admission_slips
-		SET reason = ?, date_of_absence = ?, date_needed = ?, category_id = ?, status_id = ?, updated_at = NOW()
+		SET reason = ?, date_of_absence = ?, date_needed = ?, category_id = ?, status_id = ?, revision_round = ?, updated_at = NOW()
 		WHERE id = ?
 	`
 	_, err := tx.ExecContext(
@@ -119,6 +121,7 @@ func (r *Repository) UpdateSlip(
 		slip.DateNeeded,
 		slip.CategoryID,
 		slip.StatusID,
+		slip.RevisionRound,
 		slip.ID,
 	)
 	if err != nil {
@@ -128,13 +131,19 @@ func (r *Repository) UpdateSlip(
 	return nil
 }
 
+// DeleteSlipAttachments removes the attachment records of one revision
+// round, leaving earlier rounds intact.
 func (r *Repository) DeleteSlipAttachments(
 	ctx context.Context,
 	tx datastore.DB,
 	slipID string,
+	round int,
 ) error {
-	query := `DELETE FROM slip_attachments WHERE admission_slip_id = ?`
-	_, err := tx.ExecContext(ctx, query, slipID)
+	query := `
+		DELETE FROM slip_attachments
+		WHERE admission_slip_id = ? AND revision_round = ?
+	`
+	_, err := tx.ExecContext(ctx, query, slipID, round)
 	if err != nil {
 		return fmt.Errorf("failed to delete slip attachments: %w", err)
 	}
@@ -291,9 +300,12 @@ func (r *Repository) GetTotalUrgentSlipsCount(
 	query := `
         SELECT COUNT(*)
         FROM admission_slips slp
-        WHERE slp.status_id IN (1, 9)
+        WHERE slp.status_id IN (?, ?)
     `
-	var args []interface{}
+	args := []interface{}{
+		constants.StatusPending,
+		constants.StatusForRevision,
+	}
 
 	if req.StartDate != "" {
 		query += " AND slp.date_needed >= ?"
@@ -317,17 +329,13 @@ func (r *Repository) GetUrgentSlips(
 	ctx context.Context,
 	req *ListSlipRequest,
 ) ([]SlipWithDetailsView, error) {
-	// Add urgency_score to the base query
-	query := strings.Replace(
-		slipsBaseQuery,
-		"slp.updated_at AS updated_at",
-		`slp.updated_at AS updated_at, `+
-			r.getUrgencyScoreSQL()+" AS urgency_score",
-		1,
-	)
+	query := withUrgencyScore(slipsBaseQuery)
 
-	query += " WHERE slp.status_id IN (1, 9)"
-	var args []interface{}
+	query += " WHERE slp.status_id IN (?, ?)"
+	args := []interface{}{
+		constants.StatusPending,
+		constants.StatusForRevision,
+	}
 
 	if req.StartDate != "" {
 		query += " AND slp.date_needed >= ?"
@@ -340,8 +348,8 @@ func (r *Repository) GetUrgentSlips(
 
 	query += `
 		ORDER BY
-			slp.date_needed ASC,
-			urgency_score DESC
+			urgency_score DESC,
+			slp.date_needed ASC
 		LIMIT ? OFFSET ?
 	`
 	args = append(args, req.PageSize, req.GetOffset())
@@ -355,19 +363,100 @@ func (r *Repository) GetUrgentSlips(
 	return slips, nil
 }
 
-func (r *Repository) getUrgencyScoreSQL() string {
-	return `(
-		(1000 - DATEDIFF(slp.date_needed, CURRENT_DATE)) * 10
-		+
-		CASE WHEN slp.category_id = 1 THEN 500 ELSE 0 END
+// Raw inputs of the urgency policy. The policy row is joined as "usp" and
+// the category weight as "ucw" by withUrgencyScore.
+const (
+	urgencyDaysUntilNeededSQL  = "DATEDIFF(slp.date_needed, CURRENT_DATE)"
+	urgencyDaysSinceAbsenceSQL = "GREATEST(DATEDIFF(CURRENT_DATE, slp.date_of_absence), 0)"
+	urgencyRepeatAbsencesSQL   = `(
+		SELECT COUNT(*)
+		FROM admission_slips prev
+		WHERE prev.iir_id = slp.iir_id
+			AND prev.id <> slp.id
+			AND prev.date_of_absence BETWEEN
+				DATE_SUB(slp.date_of_absence, INTERVAL usp.repeat_lookback_days DAY)
+				AND slp.date_of_absence
+	)`
+	urgencyAttachmentCountSQL = `(
+		SELECT COUNT(*)
+		FROM slip_attachments sa
+		WHERE sa.admission_slip_id = slp.id
+			AND sa.scan_status <> 'infected'
 	)`
+)
+
+// urgencyFactorSQL maps each factor's points column to its expression.
+var urgencyFactorSQL = []struct {
+	column string
+	expr   string
+}{
+	{"category_points", "COALESCE(ucw.weight, 0)"},
+	{"needed_points", fmt.Sprintf(
+		"GREATEST(usp.needed_horizon_days - %s, 0) * usp.needed_weight",
+		urgencyDaysUntilNeededSQL,
+	)},
+	{"absence_points", fmt.Sprintf(
+		"LEAST(%s, usp.absence_horizon_days) * usp.absence_weight",
+		urgencyDaysSinceAbsenceSQL,
+	)},
+	{"repeat_points", fmt.Sprintf(
+		"%s * usp.repeat_weight",
+		urgencyRepeatAbsencesSQL,
+	)},
+	{"attachment_points", fmt.Sprintf(
+		"CASE WHEN %s > 0 THEN usp.attachment_weight ELSE 0 END",
+		urgencyAttachmentCountSQL,
+	)},
+}
+
+// withUrgencyScore extends slipsBaseQuery with the urgency factors, their
+// points and the total urgency_score under the stored policy.
+func withUrgencyScore(query string) string {
+	columns := []string{
+		"slp.updated_at AS updated_at",
+		urgencyDaysUntilNeededSQL + " AS days_until_needed",
+		urgencyDaysSinceAbsenceSQL + " AS days_since_absence",
+		urgencyRepeatAbsencesSQL + " AS repeat_absences",
+		urgencyAttachmentCountSQL + " AS attachment_count",
+	}
+	var total []string
+	for _, f := range urgencyFactorSQL {
+		columns = append(columns, f.expr+" AS "+f.column)
+		total = append(total, "("+f.expr+")")
+	}
+	columns = append(
+		columns,
+		strings.Join(total, " + ")+" AS urgency_score",
+	)
+
+	query = strings.Replace(
+		query,
+		"slp.updated_at AS updated_at",
+		strings.Join(columns, ",\n\t\t"),
+		1,
+	)
+
+	return strings.Replace(
+		query,
+		"JOIN statuses s ON slp.status_id = s.id",
+		`JOIN statuses s ON slp.status_id = s.id
+	JOIN slip_urgency_policy usp ON usp.id = 1
+	LEFT JOIN slip_urgency_category_weights ucw
+		ON ucw.category_id = slp.category_id`,
+		1,
+	)
 }
 
 func (r *Repository) GetAll(
 	ctx context.Context,
 	req *ListSlipRequest,
 ) ([]SlipWithDetailsView, error) {
-	query, args := r.applyFilters(slipsBaseQuery+" WHERE 1=1", nil, req, nil)
+	query, args := r.applyFilters(
+		withUrgencyScore(slipsBaseQuery)+" WHERE 1=1",
+		nil,
+		req,
+		nil,
+	)
 
 	query += orderSlipsCreatedDesc
 	args = append(args, req.PageSize, req.GetOffset())
@@ -455,12 +544,54 @@ func (r *Repository) GetSlipByID(
 	return &slip, nil
 }
 
+// LockSlip reads a slip with a row lock held until tx ends.
+func (r *Repository) LockSlip(
+	ctx context.Context,
+	tx datastore.DB,
+	id string,
+) (*Slip, error) {
+	var slip Slip
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM admission_slips
+		WHERE id = ?
+		FOR UPDATE
+	`, datastore.GetColumns(Slip{}))
+	err := tx.GetContext(ctx, &slip, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to lock slip: %w", err)
+	}
+	return &slip, nil
+}
+
+func (r *Repository) GetSlipStatusByName(
+	ctx context.Context,
+	name string,
+) (*SlipStatus, error) {
+	var status SlipStatus
+	query := fmt.Sprintf(`
+		SELECT %s FROM statuses
+		WHERE name = ? AND status_type IN ('slip', 'both')
+	`, datastore.GetColumns(SlipStatus{}))
+	err := r.db.GetContext(ctx, &status, query, name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get slip status: %w", err)
+	}
+	return &status, nil
+}
+
 func (r *Repository) GetSlipByIDWithDetails(
 	ctx context.Context,
 	id string,
 ) (*SlipWithDetailsView, error) {
 	var slip SlipWithDetailsView
-	query := slipsBaseQuery + " WHERE slp.id = ?"
+	query := withUrgencyScore(slipsBaseQuery) + " WHERE slp.id = ?"
 	err := r.db.GetContext(ctx, &slip, query, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -504,6 +635,88 @@ func (r *Repository) GetSlipAttachments(
 	return attachments, nil
 }
 
+// ListQuarantinedAttachments returns attachments still waiting for a
+// malware scan. Attachments never tried come first, then those tried
+// longest ago, so one that keeps failing cannot hold up the rest.
+func (r *Repository) ListQuarantinedAttachments(
+	ctx context.Context,
+	limit int,
+) ([]SlipAttachment, error) {
+	var attachments []SlipAttachment
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM slip_attachments
+		WHERE scan_status = ?
+		ORDER BY last_scan_at IS NOT NULL, last_scan_at ASC, created_at ASC
+		LIMIT ?
+	`, datastore.GetColumns(SlipAttachment{}))
+	err := r.db.SelectContext(
+		ctx,
+		&attachments,
+		query,
+		ScanQuarantined,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quarantined attachments: %w", err)
+	}
+
+	return attachments, nil
+}
+
+// RecordAttachmentScanAttempt counts a scan attempt on a quarantined
+// attachment and moves it to the back of the queue.
+func (r *Repository) RecordAttachmentScanAttempt(
+	ctx context.Context,
+	attachmentID string,
+) error {
+	query := `
+		UPDATE slip_attachments
+		SET scan_attempts = scan_attempts + 1, last_scan_at = NOW()
+		WHERE id = ? AND scan_status = ?
+	`
+	_, err := r.db.ExecContext(ctx, query, attachmentID, ScanQuarantined)
+	if err != nil {
+		return fmt.Errorf("failed to record attachment scan attempt: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateAttachmentScan records the scan verdict of a quarantined
+// attachment. It reports false when the attachment already left
+// quarantine, e.g. because another scan finished first.
+func (r *Repository) UpdateAttachmentScan(
+	ctx context.Context,
+	attachmentID string,
+	status ScanStatus,
+	result string,
+) (bool, error) {
+	query := `
+		UPDATE slip_attachments
+		SET scan_status = ?, scan_result = ?, scanned_at = NOW()
+		WHERE id = ? AND scan_status = ?
+	`
+	res, err := r.db.ExecContext(
+		ctx,
+		query,
+		status,
+		toNullString(result),
+		attachmentID,
+		ScanQuarantined,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to update attachment scan: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update attachment scan: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
 func (r *Repository) GetAttachmentByID(
 	ctx context.Context,
 	attachmentID string,
@@ -529,28 +742,16 @@ func (r *Repository) UpdateStatus(
 	ctx context.Context,
 	tx datastore.DB,
 	id string,
-	statusName string,
+	statusID int,
 	adminNotes string,
 ) error {
-	// First, get the status ID from the status name
-	var statusID int
-	query := `SELECT id FROM statuses WHERE name = ?`
-	err := tx.GetContext(ctx, &statusID, query, statusName)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return fmt.Errorf("status '%s' not found", statusName)
-		}
-		return fmt.Errorf("failed to get status ID: %w", err)
-	}
-
-	// Now update the slip with the status ID and admin notes
-	updateQuery := `
+	query := `
 		UPDATE admission_slips
 		SET status_id = ?, admin_notes = ?, updated_at = NOW()
 		WHERE id = ?
 	`
 
-	result, err := tx.ExecContext(ctx, updateQuery, statusID, adminNotes, id)
+	result, err := tx.ExecContext(ctx, query, statusID, adminNotes, id)
 	if err != nil {
 		return fmt.Errorf("failed to update status: %w", err)
 	}
@@ -587,3 +788,370 @@ func (r *Repository) Delete(ctx context.Context, id string) error {
 
 	return nil
 }
+
+func (r *Repository) GetUrgencyPolicy(
+	ctx context.Context,
+) (*UrgencyPolicy, error) {
+	var policy UrgencyPolicy
+	query := fmt.Sprintf(`
+		SELECT %s FROM slip_urgency_policy WHERE id = 1
+	`, datastore.GetColumns(UrgencyPolicy{}))
+	err := r.db.GetContext(ctx, &policy, query)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get urgency policy: %w", err)
+	}
+
+	return &policy, nil
+}
+
+// ListUrgencyCategoryWeights returns every slip category with its weight,
+// using 0 for categories that have none configured.
+func (r *Repository) ListUrgencyCategoryWeights(
+	ctx context.Context,
+) ([]UrgencyCategoryWeight, error) {
+	var weights []UrgencyCategoryWeight
+	query := `
+		SELECT
+			c.id AS category_id,
+			c.name AS category_name,
+			COALESCE(ucw.weight, 0) AS weight
+		FROM admission_slip_categories c
+		LEFT JOIN slip_urgency_category_weights ucw
+			ON ucw.category_id = c.id
+		ORDER BY c.id ASC
+	`
+	err := r.db.SelectContext(ctx, &weights, query)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to get urgency category weights: %w",
+			err,
+		)
+	}
+
+	return weights, nil
+}
+
+func (r *Repository) UpdateUrgencyPolicy(
+	ctx context.Context,
+	tx datastore.DB,
+	policy *UrgencyPolicy,
+) error {
+	query := `
+		UPDATE slip_urgency_policy
+		SET
+			needed_horizon_days = :needed_horizon_days,
+			needed_weight = :needed_weight,
+			absence_horizon_days = :absence_horizon_days,
+			absence_weight = :absence_weight,
+			repeat_lookback_days = :repeat_lookback_days,
+			repeat_weight = :repeat_weight,
+			attachment_weight = :attachment_weight,
+			updated_by = :updated_by,
+			updated_at = NOW()
+		WHERE id = 1
+	`
+	_, err := tx.NamedExecContext(ctx, query, policy)
+	if err != nil {
+		return fmt.Errorf("failed to update urgency policy: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) SaveUrgencyCategoryWeight(
+	ctx context.Context,
+	tx datastore.DB,
+	categoryID int,
+	weight int,
+) error {
+	query := `
+		INSERT INTO slip_urgency_category_weights (category_id, weight)
+		VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE weight = VALUES(weight)
+	`
+	_, err := tx.ExecContext(ctx, query, categoryID, weight)
+	if err != nil {
+		return fmt.Errorf(
+			"failed to save urgency category weight: %w",
+			err,
+		)
+	}
+
+	return nil
+}
+
+func (r *Repository) CreateSlipRevision(
+	ctx context.Context,
+	tx datastore.DB,
+	revision *SlipRevision,
+) error {
+	query := `
+		INSERT INTO slip_revisions (
+			id,
+			admission_slip_id,
+			revision_round,
+			reason,
+			date_of_absence,
+			date_needed,
+			category_id
+		) VALUES (
+			:id,
+			:admission_slip_id,
+			:revision_round,
+			:reason,
+			:date_of_absence,
+			:date_needed,
+			:category_id
+		)
+	`
+	_, err := tx.NamedExecContext(ctx, query, revision)
+	if err != nil {
+		return fmt.Errorf("failed to insert slip revision: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateSlipRevision overwrites the details of the slip's current round,
+// used when a student edits a slip that has not been reviewed yet.
+func (r *Repository) UpdateSlipRevision(
+	ctx context.Context,
+	tx datastore.DB,
+	revision *SlipRevision,
+) error {
+	query := `
+		UPDATE slip_revisions
+		SET
+			reason = :reason,
+			date_of_absence = :date_of_absence,
+			date_needed = :date_needed,
+			category_id = :category_id,
+			updated_at = NOW()
+		WHERE admission_slip_id = :admission_slip_id
+			AND revision_round = :revision_round
+	`
+	_, err := tx.NamedExecContext(ctx, query, revision)
+	if err != nil {
+		return fmt.Errorf("failed to update slip revision: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) ListSlipRevisions(
+	ctx context.Context,
+	slipID string,
+) ([]SlipRevision, error) {
+	var revisions []SlipRevision
+	query := `
+		SELECT
+			rv.id,
+			rv.admission_slip_id,
+			rv.revision_round,
+			rv.reason,
+			DATE_FORMAT(rv.date_of_absence, '%Y-%m-%d') AS date_of_absence,
+			DATE_FORMAT(rv.date_needed, '%Y-%m-%d') AS date_needed,
+			rv.category_id,
+			c.name AS category_name,
+			rv.created_at,
+			rv.updated_at
+		FROM slip_revisions rv
+		JOIN admission_slip_categories c ON rv.category_id = c.id
+		WHERE rv.admission_slip_id = ?
+		ORDER BY rv.revision_round ASC
+	`
+	err := r.db.SelectContext(ctx, &revisions, query, slipID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get slip revisions: %w", err)
+	}
+
+	return revisions, nil
+}
+
+func (r *Repository) CreateStatusChange(
+	ctx context.Context,
+	tx datastore.DB,
+	change *SlipStatusChange,
+) error {
+	query := `
+		INSERT INTO slip_status_history (
+			admission_slip_id,
+			revision_round,
+			from_status_id,
+			to_status_id,
+			changed_by,
+			note
+		) VALUES (
+			:admission_slip_id,
+			:revision_round,
+			:from_status_id,
+			:to_status_id,
+			:changed_by,
+			:note
+		)
+	`
+	_, err := tx.NamedExecContext(ctx, query, change)
+	if err != nil {
+		return fmt.Errorf("failed to insert slip status change: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) ListStatusHistory(
+	ctx context.Context,
+	slipID string,
+) ([]SlipStatusChangeView, error) {
+	var changes []SlipStatusChangeView
+	query := `
+		SELECT
+			h.id,
+			h.revision_round,
+			h.from_status_id,
+			fs.name AS from_status_name,
+			fs.color_key AS from_status_color_key,
+			h.to_status_id,
+			ts.name AS to_status_name,
+			ts.color_key AS to_status_color_key,
+			h.changed_by,
+			u.first_name AS changed_by_first_name,
+			u.last_name AS changed_by_last_name,
+			h.note,
+			h.created_at
+		FROM slip_status_history h
+		JOIN statuses ts ON h.to_status_id = ts.id
+		LEFT JOIN statuses fs ON h.from_status_id = fs.id
+		LEFT JOIN users u ON h.changed_by = u.id
+		WHERE h.admission_slip_id = ?
+		ORDER BY h.id ASC
+	`
+	err := r.db.SelectContext(ctx, &changes, query, slipID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get slip status history: %w", err)
+	}
+
+	return changes, nil
+}
+
+func (r *Repository) CreateSlipComment(
+	ctx context.Context,
+	comment *SlipComment,
+) error {
+	cols, vals := datastore.GetInsertStatement(comment, nil)
+	query := fmt.Sprintf(`
+		INSERT INTO slip_comments (id, %s)
+		VALUES (:id, %s)
+	`, cols, vals)
+
+	_, err := r.db.NamedExecContext(ctx, query, comment)
+	if err != nil {
+		return fmt.Errorf("failed to insert slip comment: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetSlipComment(
+	ctx context.Context,
+	id string,
+) (*SlipComment, error) {
+	var comment SlipComment
+	query := fmt.Sprintf(`
+		SELECT %s FROM slip_comments WHERE id = ?
+	`, datastore.GetColumns(SlipComment{}))
+	err := r.db.GetContext(ctx, &comment, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get slip comment: %w", err)
+	}
+
+	return &comment, nil
+}
+
+func (r *Repository) ListSlipComments(
+	ctx context.Context,
+	slipID string,
+) ([]SlipCommentView, error) {
+	var comments []SlipCommentView
+	query := `
+		SELECT
+			sc.id,
+			sc.admission_slip_id,
+			sc.parent_id,
+			sc.author_id,
+			sc.revision_round,
+			sc.body,
+			sc.created_at,
+			sc.updated_at,
+			u.first_name AS author_first_name,
+			u.last_name AS author_last_name,
+			u.role_id AS author_role_id
+		FROM slip_comments sc
+		JOIN users u ON sc.author_id = u.id
+		WHERE sc.admission_slip_id = ?
+		ORDER BY sc.created_at ASC, sc.id ASC
+	`
+	err := r.db.SelectContext(ctx, &comments, query, slipID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get slip comments: %w", err)
+	}
+
+	return comments, nil
+}
+
+func (r *Repository) CreateSlipCertificate(
+	ctx context.Context,
+	cert *SlipCertificate,
+) error {
+	cols, vals := datastore.GetInsertStatement(cert, nil)
+	query := fmt.Sprintf(`
+		INSERT INTO slip_certificates (id, %s)
+		VALUES (:id, %s)
+	`, cols, vals)
+
+	_, err := r.db.NamedExecContext(ctx, query, cert)
+	if err != nil {
+		return fmt.Errorf("failed to insert slip certificate: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetSlipCertificateBySlipID(
+	ctx context.Context,
+	slipID string,
+) (*SlipCertificate, error) {
+	return r.getSlipCertificate(ctx, "admission_slip_id", slipID)
+}
+
+func (r *Repository) GetSlipCertificateByCode(
+	ctx context.Context,
+	code string,
+) (*SlipCertificate, error) {
+	return r.getSlipCertificate(ctx, "verification_code", code)
+}
+
+func (r *Repository) getSlipCertificate(
+	ctx context.Context,
+	column string,
+	value string,
+) (*SlipCertificate, error) {
+	var cert SlipCertificate
+	query := fmt.Sprintf(`
+		SELECT %s FROM slip_certificates WHERE %s = ?
+	`, datastore.GetColumns(SlipCertificate{}), column)
+	err := r.db.GetContext(ctx, &cert, query, value)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get slip certificate: %w", err)
+	}
+
+	return &cert, nil
+}
@@ -0,0 +1,381 @@
+package slips
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/audit"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/structs"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
+)
+
+var (
+	ErrSlipNotFound     = errors.New("slip not found")
+	ErrCommentParent    = errors.New("parent comment does not belong to this slip")
+	ErrSlipNotEditable  = errors.New("cannot edit slip in current status")
+	ErrSlipAccessDenied = errors.New("access denied")
+)
+
+// GetSlipOwnerID returns the user ID of the student who submitted the slip.
+func (s *Service) GetSlipOwnerID(
+	ctx context.Context,
+	slipID string,
+) (string, error) {
+	return s.repo.GetUserIDBySlipID(ctx, slipID)
+}
+
+// AddSlipComment posts a comment, or a reply when ParentID is set, in the
+// slip's current revision round and notifies the other side of the thread.
+// It returns the slip's updated threads.
+func (s *Service) AddSlipComment(
+	ctx context.Context,
+	slipID string,
+	req CreateSlipCommentRequest,
+) ([]SlipCommentDTO, error) {
+	slip, err := s.repo.GetSlipByID(ctx, slipID)
+	if err != nil {
+		return nil, err
+	}
+	if slip == nil {
+		return nil, ErrSlipNotFound
+	}
+
+	if req.ParentID != "" {
+		parent, err := s.repo.GetSlipComment(ctx, req.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil || parent.SlipID != slipID {
+			return nil, ErrCommentParent
+		}
+	}
+
+	authorID := audit.ExtractUserID(ctx)
+	comment := &SlipComment{
+		ID:            uuid.New().String(),
+		SlipID:        slipID,
+		ParentID:      toNullString(req.ParentID),
+		AuthorID:      authorID,
+		RevisionRound: slip.RevisionRound,
+		Body:          req.Body,
+	}
+	if err := s.repo.CreateSlipComment(ctx, comment); err != nil {
+		return nil, err
+	}
+
+	authorName := "Someone"
+	if author, _ := s.userService.GetUserByID(ctx, authorID); author != nil {
+		authorName = fmt.Sprintf("%s %s", author.FirstName, author.LastName)
+	}
+
+	audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+		Log: &audit.LogParams{
+			Level:    audit.LevelInfo,
+			Category: audit.CategoryAudit,
+			Action:   audit.ActionSlipCommentCreated,
+			Message: fmt.Sprintf(
+				"Comment added to admission slip #%s",
+				slipID,
+			),
+			Metadata: &audit.LogMetadata{
+				EntityType: constants.SlipEntityType,
+				EntityID:   slipID,
+				NewValues:  comment,
+			},
+		},
+		Notifications: s.commentNotifications(
+			ctx,
+			slipID,
+			authorID,
+			authorName,
+		),
+	})
+
+	comments, err := s.repo.ListSlipComments(ctx, slipID)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildCommentThreads(comments), nil
+}
+
+// ListSlipComments returns the slip's comments as threads, oldest first.
+func (s *Service) ListSlipComments(
+	ctx context.Context,
+	slipID string,
+) ([]SlipCommentDTO, error) {
+	slip, err := s.repo.GetSlipByID(ctx, slipID)
+	if err != nil {
+		return nil, err
+	}
+	if slip == nil {
+		return nil, ErrSlipNotFound
+	}
+
+	comments, err := s.repo.ListSlipComments(ctx, slipID)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildCommentThreads(comments), nil
+}
+
+// loadReviewHistory fills in the revision rounds, status timeline and
+// comment threads of a slip.
+func (s *Service) loadReviewHistory(ctx context.Context, dto *SlipDTO) error {
+	revisions, err := s.repo.ListSlipRevisions(ctx, dto.ID)
+	if err != nil {
+		return err
+	}
+	attachments, err := s.repo.GetSlipAttachments(ctx, dto.ID)
+	if err != nil {
+		return err
+	}
+	history, err := s.repo.ListStatusHistory(ctx, dto.ID)
+	if err != nil {
+		return err
+	}
+	comments, err := s.repo.ListSlipComments(ctx, dto.ID)
+	if err != nil {
+		return err
+	}
+
+	byRound := make(map[int][]AttachmentDTO)
+	for _, a := range attachments {
+		byRound[a.RevisionRound] = append(
+			byRound[a.RevisionRound],
+			mapAttachment(a),
+		)
+	}
+
+	dto.Rounds = make([]SlipRevisionDTO, 0, len(revisions))
+	for _, rv := range revisions {
+		files := byRound[rv.RevisionRound]
+		if files == nil {
+			files = []AttachmentDTO{}
+		}
+		dto.Rounds = append(dto.Rounds, SlipRevisionDTO{
+			Round:         rv.RevisionRound,
+			Reason:        rv.Reason,
+			DateOfAbsence: rv.DateOfAbsence,
+			DateNeeded:    rv.DateNeeded,
+			Category: SlipCategory{
+				ID:   rv.CategoryID,
+				Name: rv.CategoryName,
+			},
+			Attachments: files,
+			SubmittedAt: rv.UpdatedAt,
+		})
+	}
+
+	dto.Timeline = make([]SlipStatusChangeDTO, 0, len(history))
+	for _, h := range history {
+		dto.Timeline = append(dto.Timeline, mapStatusChange(h))
+	}
+
+	dto.Comments = buildCommentThreads(comments)
+
+	return nil
+}
+
+// recordStatusChange appends a row to the slip's timeline on behalf of the
+// user in ctx.
+func (s *Service) recordStatusChange(
+	ctx context.Context,
+	tx datastore.DB,
+	slip *Slip,
+	toStatusID int,
+	note string,
+) error {
+	return s.repo.CreateStatusChange(ctx, tx, &SlipStatusChange{
+		SlipID:        slip.ID,
+		RevisionRound: slip.RevisionRound,
+		FromStatusID: sql.NullInt64{
+			Int64: int64(slip.StatusID),
+			Valid: slip.StatusID != 0,
+		},
+		ToStatusID: toStatusID,
+		ChangedBy:  toNullString(audit.ExtractUserID(ctx)),
+		Note:       toNullString(note),
+	})
+}
+
+// commentNotifications notifies the student when staff comment and every
+// counselor when the student does.
+func (s *Service) commentNotifications(
+	ctx context.Context,
+	slipID string,
+	authorID string,
+	authorName string,
+) []audit.NotificationParams {
+	var receivers []string
+	studentUserID, _ := s.repo.GetUserIDBySlipID(ctx, slipID)
+	if authorID == studentUserID {
+		receivers, _ = s.userService.GetUserIDsByRole(
+			ctx,
+			int(constants.AdminRoleID),
+		)
+	} else if studentUserID != "" {
+		receivers = []string{studentUserID}
+	}
+
+	notifications := make([]audit.NotificationParams, 0, len(receivers))
+	for _, id := range receivers {
+		notifications = append(notifications, audit.NotificationParams{
+			ReceiverID: structs.StringToNullableString(id),
+			TargetID:   structs.StringToNullableString(slipID),
+			TargetType: structs.StringToNullableString(
+				constants.SlipEntityType,
+			),
+			Title: "New Comment on Admission Slip",
+			Message: fmt.Sprintf(
+				"%s commented on an admission slip.",
+				authorName,
+			),
+			Type: constants.SlipEntityType,
+		})
+	}
+
+	return notifications
+}
+
+// resubmissionNotifications tells every counselor that a slip sent back
+// for revision is ready for review again.
+func (s *Service) resubmissionNotifications(
+	ctx context.Context,
+	slip *Slip,
+) []audit.NotificationParams {
+	studentName := "A student"
+	student, _ := s.userService.GetUserByID(ctx, audit.ExtractUserID(ctx))
+	if student != nil {
+		studentName = fmt.Sprintf("%s %s", student.FirstName, student.LastName)
+	}
+
+	counselorIDs, _ := s.userService.GetUserIDsByRole(
+		ctx,
+		int(constants.AdminRoleID),
+	)
+
+	notifications := make([]audit.NotificationParams, 0, len(counselorIDs))
+	for _, cid := range counselorIDs {
+		notifications = append(notifications, audit.NotificationParams{
+			ReceiverID: structs.StringToNullableString(cid),
+			TargetID:   structs.StringToNullableString(slip.ID),
+			TargetType: structs.StringToNullableString(
+				constants.SlipEntityType,
+			),
+			Title: "Admission Slip Resubmitted",
+			Message: fmt.Sprintf(
+				"%s resubmitted their admission slip for review (round %d).",
+				studentName,
+				slip.RevisionRound,
+			),
+			Type: constants.SlipEntityType,
+		})
+	}
+
+	return notifications
+}
+
+// buildCommentThreads nests replies under their parent comment. Comments
+// arrive oldest first, so each thread keeps that order.
+func buildCommentThreads(comments []SlipCommentView) []SlipCommentDTO {
+	children := make(map[string][]SlipCommentView)
+	var roots []SlipCommentView
+	for _, c := range comments {
+		if c.ParentID.Valid {
+			children[c.ParentID.String] = append(children[c.ParentID.String], c)
+			continue
+		}
+		roots = append(roots, c)
+	}
+
+	var build func(c SlipCommentView) SlipCommentDTO
+	build = func(c SlipCommentView) SlipCommentDTO {
+		dto := SlipCommentDTO{
+			ID:       c.ID,
+			ParentID: structs.FromSqlNull(c.ParentID),
+			Round:    c.RevisionRound,
+			Author: SlipActor{
+				ID:        c.AuthorID,
+				FirstName: c.AuthorFirstName,
+				LastName:  c.AuthorLastName,
+				RoleID:    c.AuthorRoleID,
+			},
+			Body:      c.Body,
+			CreatedAt: c.CreatedAt,
+			Replies:   []SlipCommentDTO{},
+		}
+		for _, child := range children[c.ID] {
+			dto.Replies = append(dto.Replies, build(child))
+		}
+		return dto
+	}
+
+	threads := make([]SlipCommentDTO, 0, len(roots))
+	for _, c := range roots {
+		threads = append(threads, build(c))
+	}
+
+	return threads
+}
+
+func mapStatusChange(h SlipStatusChangeView) SlipStatusChangeDTO {
+	dto := SlipStatusChangeDTO{
+		ID:    h.ID,
+		Round: h.RevisionRound,
+		To: SlipStatus{
+			ID:       h.ToStatusID,
+			Name:     h.ToStatusName,
+			ColorKey: h.ToStatusColorKey,
+		},
+		Note:      structs.FromSqlNull(h.Note),
+		CreatedAt: h.CreatedAt,
+	}
+	if h.FromStatusID.Valid {
+		dto.From = &SlipStatus{
+			ID:       int(h.FromStatusID.Int64),
+			Name:     h.FromStatusName.String,
+			ColorKey: h.FromStatusColorKey.String,
+		}
+	}
+	if h.ChangedBy.Valid {
+		dto.ChangedBy = &SlipActor{
+			ID:        h.ChangedBy.String,
+			FirstName: h.ChangedByFirstName.String,
+			LastName:  h.ChangedByLastName.String,
+		}
+	}
+
+	return dto
+}
+
+func newSlipRevision(slip *Slip) *SlipRevision {
+	return &SlipRevision{
+		ID:            uuid.New().String(),
+		SlipID:        slip.ID,
+		RevisionRound: slip.RevisionRound,
+		Reason:        slip.Reason,
+		DateOfAbsence: slip.DateOfAbsence,
+		DateNeeded:    slip.DateNeeded,
+		CategoryID:    slip.CategoryID,
+	}
+}
+
+func mapAttachment(a SlipAttachment) AttachmentDTO {
+	return AttachmentDTO{
+		ID:            a.ID,
+		FileName:      a.FileName,
+		FileURL:       a.FileURL,
+		RevisionRound: a.RevisionRound,
+		ScanStatus:    a.ScanStatus,
+	}
+}
+
+func toNullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
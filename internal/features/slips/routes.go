@@ -5,6 +5,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/middleware"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/tokens"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
 )
 
@@ -13,9 +14,14 @@ func RegisterRoutes(
 	rg *gin.RouterGroup,
 	h *Handler,
 	redis *datastore.RedisClient,
+	tokenService *tokens.Service,
 ) {
+	// Anyone holding a printed slip, e.g. a professor, can check it without
+	// an account; the code is the lookup key.
+	rg.GET("/slips/verify/:code", h.GetSlipVerification)
+
 	routes := rg.Group("/slips")
-	routes.Use(middleware.AuthMiddleware(redis))
+	routes.Use(middleware.AuthMiddleware(redis, tokenService))
 	routes.Use(middleware.HydrateStudentContext(db))
 	routes.Use(middleware.AuditContextMiddleware())
 
@@ -27,6 +33,8 @@ func RegisterRoutes(
 		adminOnly.GET("", h.GetSlipList)
 		adminOnly.GET("/urgent", h.GetUrgentSlipList)
 		adminOnly.PATCH("/id/:id/status", h.PatchSlipStatus)
+		adminOnly.GET("/urgency-policy", h.GetUrgencyPolicy)
+		adminOnly.PUT("/urgency-policy", h.PutUrgencyPolicy)
 	}
 
 	studentOnly := routes.Group("")
@@ -46,6 +54,9 @@ func RegisterRoutes(
 	))
 	{
 		sharedRoutes.GET("/id/:id", h.GetSlipByID)
+		sharedRoutes.GET("/id/:id/comments", h.GetSlipCommentList)
+		sharedRoutes.POST("/id/:id/comments", h.PostSlipComment)
+		sharedRoutes.GET("/id/:id/pdf", h.GetSlipCertificate)
 		sharedRoutes.GET("/stats", h.GetSlipStatsList)
 		sharedRoutes.GET(
 			"/id/:id/attachments",
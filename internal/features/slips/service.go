@@ -3,21 +3,22 @@ package slips
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"fmt"
-	"io"
 	"mime/multipart"
-	"net/http"
-	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/audit"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/config"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/hash"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/pdf"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/structs"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/users"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/scanner"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/storage"
 )
 
@@ -29,6 +30,9 @@ type Service struct {
 	notifService audit.Notifier
 	fileStorage  storage.FileStorage
 	userService  users.ServiceInterface
+	pdfService   *pdf.Service
+	scanner      scanner.Scanner
+	cfg          *config.Config
 }
 
 func NewService(
@@ -37,6 +41,9 @@ func NewService(
 	notifService audit.Notifier,
 	fileStorage storage.FileStorage,
 	userService users.ServiceInterface,
+	pdfService *pdf.Service,
+	virusScanner scanner.Scanner,
+	cfg *config.Config,
 ) *Service {
 	return &Service{
 		repo:         repo,
@@ -44,6 +51,9 @@ func NewService(
 		notifService: notifService,
 		fileStorage:  fileStorage,
 		userService:  userService,
+		pdfService:   pdfService,
+		scanner:      virusScanner,
+		cfg:          cfg,
 	}
 }
 
@@ -74,10 +84,10 @@ func (s *Service) GetSlipByID(
 		return nil, err
 	}
 	if slip == nil {
-		return nil, fmt.Errorf("slip not found")
+		return nil, ErrSlipNotFound
 	}
 
-	return &SlipDTO{
+	dto := &SlipDTO{
 		ID:    slip.ID,
 		IIRID: slip.IIRID,
 		User: users.GetUserResponse{
@@ -104,9 +114,17 @@ func (s *Service) GetSlipByID(
 			Name:     slip.StatusName,
 			ColorKey: slip.StatusColorKey,
 		},
-		CreatedAt: slip.CreatedAt,
-		UpdatedAt: slip.UpdatedAt,
-	}, nil
+		Urgency:       urgencyBreakdown(slip),
+		RevisionRound: slip.RevisionRound,
+		CreatedAt:     slip.CreatedAt,
+		UpdatedAt:     slip.UpdatedAt,
+	}
+
+	if err := s.loadReviewHistory(ctx, dto); err != nil {
+		return nil, err
+	}
+
+	return dto, nil
 }
 
 func (s *Service) GetUrgentSlips(
@@ -148,6 +166,7 @@ func (s *Service) GetUrgentSlips(
 				Name:     slips[s].StatusName,
 				ColorKey: slips[s].StatusColorKey,
 			},
+			Urgency:   urgencyBreakdown(&slips[s]),
 			CreatedAt: slips[s].CreatedAt,
 			UpdatedAt: slips[s].UpdatedAt,
 		})
@@ -219,6 +238,7 @@ func (s *Service) GetAllExcuseSlips(
 				Name:     slips[s].StatusName,
 				ColorKey: slips[s].StatusColorKey,
 			},
+			Urgency:   urgencyBreakdown(&slips[s]),
 			CreatedAt: slips[s].CreatedAt,
 			UpdatedAt: slips[s].UpdatedAt,
 		})
@@ -309,11 +329,7 @@ func (s *Service) GetSlipAttachments(
 	for a := range attachments {
 		// Keep FileURL as the URL path (e.g., /slips/{hash}/{filename})
 		// Don't convert it to filesystem path - the frontend needs the URL path
-		attachmentDTOs = append(attachmentDTOs, AttachmentDTO{
-			ID:       attachments[a].ID,
-			FileName: attachments[a].FileName,
-			FileURL:  attachments[a].FileURL,
-		})
+		attachmentDTOs = append(attachmentDTOs, mapAttachment(attachments[a]))
 	}
 
 	return attachmentDTOs, nil
@@ -329,7 +345,7 @@ func (s *Service) GetAttachmentFile(
 	}
 
 	if attachment == nil {
-		return nil, fmt.Errorf("attachment not found")
+		return nil, ErrAttachmentNotFound
 	}
 
 	return attachment, nil
@@ -342,31 +358,10 @@ func (s *Service) SubmitExcuseSlip(
 	req CreateSlipRequest,
 	files []*multipart.FileHeader,
 ) (*Slip, error) {
-	// Validate all files
-	allowedTypes := map[string]bool{
-		".pdf":  true,
-		".jpg":  true,
-		".jpeg": true,
-		".png":  true,
-	}
-
-	for _, file := range files {
-		// Check File Size
-		if file.Size > MaxFileSize {
-			return nil, fmt.Errorf(
-				"file '%s' is too large: maximum 5MB allowed",
-				file.Filename,
-			)
-		}
-
-		// Check File Type
-		ext := strings.ToLower(filepath.Ext(file.Filename))
-		if !allowedTypes[ext] {
-			return nil, fmt.Errorf(
-				"invalid file type for '%s': PDF and images only",
-				file.Filename,
-			)
-		}
+	// Validate and sanitize all files
+	prepared, err := prepareAttachments(files)
+	if err != nil {
+		return nil, err
 	}
 
 	dateOfAbsence := strings.Split(req.DateOfAbsence, "T")[0]
@@ -396,19 +391,16 @@ func (s *Service) SubmitExcuseSlip(
 
 	var fileURLs []string
 
-	for _, file := range files {
-		ext := strings.ToLower(
-			filepath.Ext(file.Filename),
-		)
+	for _, file := range prepared {
 		fileHash := hash.GetSHA256Hash(
 			fmt.Sprintf(
 				"%s%d",
-				file.Filename,
+				file.header.Filename,
 				time.Now().UnixNano(),
 			),
 			16,
 		)
-		uniqueFileName := fileHash + ext
+		uniqueFileName := fileHash + file.ext
 
 		blobPath := fmt.Sprintf(
 			"slips/%s/%s",
@@ -423,7 +415,7 @@ func (s *Service) SubmitExcuseSlip(
 		); err != nil {
 			return nil, fmt.Errorf(
 				"failed to upload %s: %w",
-				file.Filename,
+				file.header.Filename,
 				err,
 			)
 		}
@@ -445,9 +437,11 @@ func (s *Service) SubmitExcuseSlip(
 		DateOfAbsence: req.DateOfAbsence,
 		DateNeeded:    req.DateNeeded,
 		CategoryID:    req.CategoryID,
-		StatusID:      1,
+		StatusID:      int(constants.StatusPending),
+		RevisionRound: 1,
 	}
 
+	var attachments []SlipAttachment
 	err = datastore.RunInTransaction(
 		ctx,
 		s.repo.GetDB(),
@@ -456,15 +450,32 @@ func (s *Service) SubmitExcuseSlip(
 			if err != nil {
 				return err
 			}
+			if err := s.repo.CreateSlipRevision(
+				ctx,
+				tx,
+				newSlipRevision(slip),
+			); err != nil {
+				return err
+			}
+			// A new slip has no previous status to record
+			if err := s.recordStatusChange(
+				ctx,
+				tx,
+				&Slip{ID: slip.ID, RevisionRound: slip.RevisionRound},
+				slip.StatusID,
+				"",
+			); err != nil {
+				return err
+			}
 
 			// Loop to create attachment records
 			for i, url := range fileURLs {
-				attachment := &SlipAttachment{
-					ID:       uuid.New().String(),
-					SlipID:   slipID,
-					FileName: files[i].Filename,
-					FileURL:  url,
-				}
+				attachment := newQuarantinedAttachment(
+					*slipID,
+					prepared[i],
+					url,
+					slip.RevisionRound,
+				)
 				if err := s.repo.SaveSlipAttachment(
 					ctx,
 					tx,
@@ -472,11 +483,15 @@ func (s *Service) SubmitExcuseSlip(
 				); err != nil {
 					return err
 				}
+				attachments = append(attachments, *attachment)
 			}
 			return nil
 		},
 	)
 	if err != nil {
+		for _, url := range fileURLs {
+			_ = s.fileStorage.Delete(ctx, strings.TrimPrefix(url, "/"))
+		}
 		audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
 			Log: &audit.LogParams{
 				Level:    audit.LevelError,
@@ -567,9 +582,14 @@ func (s *Service) SubmitExcuseSlip(
 		Notifications: notifications,
 	})
 
+	go s.scanAttachments(context.WithoutCancel(ctx), attachments)
+
 	return slip, nil
 }
 
+// UpdateExcuseSlip edits a pending slip in place or, when the slip was
+// sent back for revision, resubmits it as a new revision round. Earlier
+// rounds keep their details and attachments.
 func (s *Service) UpdateExcuseSlip(
 	ctx context.Context,
 	iirID string,
@@ -583,65 +603,46 @@ func (s *Service) UpdateExcuseSlip(
 		return nil, err
 	}
 	if existingSlip == nil {
-		return nil, fmt.Errorf("slip not found")
+		return nil, ErrSlipNotFound
 	}
 	if existingSlip.IIRID != iirID {
-		return nil, fmt.Errorf("access denied")
-	}
-
-	// Only allow editing if status is Pending (1) or For Revision (9)
-	if existingSlip.StatusID != 1 && existingSlip.StatusID != 9 {
-		return nil, fmt.Errorf("cannot edit slip in current status")
+		return nil, ErrSlipAccessDenied
 	}
 
-	// 2. Validate all files
-	allowedTypes := map[string]bool{
-		".pdf":  true,
-		".jpg":  true,
-		".jpeg": true,
-		".png":  true,
-	}
-	for _, file := range files {
-		if file.Size > MaxFileSize {
-			return nil, fmt.Errorf("file '%s' is too large", file.Filename)
-		}
-		ext := strings.ToLower(filepath.Ext(file.Filename))
-		if !allowedTypes[ext] {
-			return nil, fmt.Errorf("invalid file type for '%s'", file.Filename)
-		}
+	// Only allow editing if status is Pending or For Revision
+	status := constants.StatusID(existingSlip.StatusID)
+	if status != constants.StatusPending &&
+		status != constants.StatusForRevision {
+		return nil, ErrSlipNotEditable
 	}
 
-	// 3. Delete old attachments from storage
-	oldAttachments, err := s.repo.GetSlipAttachments(ctx, slipID)
-	if err == nil {
-		for _, att := range oldAttachments {
-			blobPath := strings.TrimPrefix(att.FileURL, "/")
-			_ = s.fileStorage.Delete(ctx, blobPath)
-		}
+	// 2. Validate and sanitize all files
+	prepared, err := prepareAttachments(files)
+	if err != nil {
+		return nil, err
 	}
 
-	// 4. Upload new files
+	// 3. Upload new files
 	folderHash := hash.GetSHA256Hash(
 		fmt.Sprintf("%s%d", iirID, time.Now().UnixNano()),
 		8,
 	)
 	var fileURLs []string
-	for _, file := range files {
-		ext := strings.ToLower(filepath.Ext(file.Filename))
+	for _, file := range prepared {
 		fileHash := hash.GetSHA256Hash(
-			fmt.Sprintf("%s%d", file.Filename, time.Now().UnixNano()),
+			fmt.Sprintf("%s%d", file.header.Filename, time.Now().UnixNano()),
 			16,
 		)
-		uniqueFileName := fileHash + ext
+		uniqueFileName := fileHash + file.ext
 		blobPath := fmt.Sprintf("slips/%s/%s", folderHash, uniqueFileName)
 
 		if err := s.uploadToBlob(ctx, file, blobPath); err != nil {
-			return nil, fmt.Errorf("failed to upload %s: %w", file.Filename, err)
+			return nil, fmt.Errorf("failed to upload %s: %w", file.header.Filename, err)
 		}
 		fileURLs = append(fileURLs, fmt.Sprintf("/slips/%s/%s", folderHash, uniqueFileName))
 	}
 
-	// 5. Update database in transaction
+	// 4. Update database in transaction
 	updatedSlip := &Slip{
 		ID:            slipID,
 		IIRID:         iirID,
@@ -649,232 +650,384 @@ func (s *Service) UpdateExcuseSlip(
 		DateOfAbsence: req.DateOfAbsence,
 		DateNeeded:    req.DateNeeded,
 		CategoryID:    req.CategoryID,
-		StatusID:      1, // Reset to Pending
+		StatusID:      int(constants.StatusPending),
 	}
 
+	var replaced, attachments []SlipAttachment
+	resubmitted := false
 	err = datastore.RunInTransaction(
 		ctx,
 		s.repo.GetDB(),
 		func(tx datastore.DB) error {
-			// Delete old attachment records
-			if err := s.repo.DeleteSlipAttachments(ctx, tx, slipID); err != nil {
+			current, err := s.repo.LockSlip(ctx, tx, slipID)
+			if err != nil {
 				return err
 			}
-			// Update slip
+			if current == nil {
+				return ErrSlipNotFound
+			}
+			updatedSlip.RevisionRound = current.RevisionRound
+
+			switch constants.StatusID(current.StatusID) {
+			case constants.StatusPending:
+				// Not reviewed yet, so the edit replaces the current round
+				attachments, err := s.repo.GetSlipAttachments(ctx, slipID)
+				if err != nil {
+					return err
+				}
+				for _, att := range attachments {
+					if att.RevisionRound == current.RevisionRound {
+						replaced = append(replaced, att)
+					}
+				}
+				if err := s.repo.DeleteSlipAttachments(
+					ctx,
+					tx,
+					slipID,
+					current.RevisionRound,
+				); err != nil {
+					return err
+				}
+				if err := s.repo.UpdateSlipRevision(
+					ctx,
+					tx,
+					newSlipRevision(updatedSlip),
+				); err != nil {
+					return err
+				}
+			case constants.StatusForRevision:
+				if err := checkTransition(
+					constants.StatusForRevision,
+					constants.StatusPending,
+					constants.RoleID(audit.ExtractRoleID(ctx)),
+				); err != nil {
+					return err
+				}
+				updatedSlip.RevisionRound++
+				resubmitted = true
+				if err := s.repo.CreateSlipRevision(
+					ctx,
+					tx,
+					newSlipRevision(updatedSlip),
+				); err != nil {
+					return err
+				}
+				if err := s.recordStatusChange(
+					ctx,
+					tx,
+					&Slip{
+						ID:            slipID,
+						StatusID:      current.StatusID,
+						RevisionRound: updatedSlip.RevisionRound,
+					},
+					updatedSlip.StatusID,
+					"",
+				); err != nil {
+					return err
+				}
+			default:
+				return ErrSlipNotEditable
+			}
+
 			if err := s.repo.UpdateSlip(ctx, tx, updatedSlip); err != nil {
 				return err
 			}
-			// Save new attachments
 			for i, url := range fileURLs {
-				attachment := &SlipAttachment{
-					ID:       uuid.New().String(),
-					SlipID:   &slipID,
-					FileName: files[i].Filename,
-					FileURL:  url,
-				}
+				attachment := newQuarantinedAttachment(
+					slipID,
+					prepared[i],
+					url,
+					updatedSlip.RevisionRound,
+				)
 				if err := s.repo.SaveSlipAttachment(ctx, tx, attachment); err != nil {
 					return err
 				}
+				attachments = append(attachments, *attachment)
 			}
 			return nil
 		},
 	)
 	if err != nil {
+		for _, url := range fileURLs {
+			_ = s.fileStorage.Delete(ctx, strings.TrimPrefix(url, "/"))
+		}
+		audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+			Log: &audit.LogParams{
+				Level:    audit.LevelError,
+				Category: audit.CategoryAudit,
+				Action:   audit.ActionSlipUpdateFailed,
+				Message: fmt.Sprintf(
+					"Failed to update excuse slip #%s",
+					slipID,
+				),
+				Metadata: &audit.LogMetadata{
+					EntityType: constants.SlipEntityType,
+					EntityID:   slipID,
+					Error:      err.Error(),
+				},
+			},
+		})
 		return nil, err
 	}
 
+	// 5. Remove the files the edit replaced from storage
+	for _, att := range replaced {
+		_ = s.fileStorage.Delete(ctx, strings.TrimPrefix(att.FileURL, "/"))
+	}
+
+	action := audit.ActionSlipUpdated
+	message := fmt.Sprintf("Excuse slip #%s updated", slipID)
+	var notifications []audit.NotificationParams
+	if resubmitted {
+		action = audit.ActionSlipResubmitted
+		message = fmt.Sprintf(
+			"Excuse slip #%s resubmitted for round %d",
+			slipID,
+			updatedSlip.RevisionRound,
+		)
+		notifications = s.resubmissionNotifications(ctx, updatedSlip)
+	}
+
 	audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
 		Log: &audit.LogParams{
 			Level:    audit.LevelInfo,
 			Category: audit.CategoryAudit,
-			Action:   audit.ActionSlipUpdated,
-			Message:  fmt.Sprintf("Excuse slip #%s updated", slipID),
+			Action:   action,
+			Message:  message,
 			Metadata: &audit.LogMetadata{
 				EntityType: constants.SlipEntityType,
 				EntityID:   slipID,
+				OldValues:  existingSlip,
 				NewValues:  updatedSlip,
 			},
 		},
+		Notifications: notifications,
 	})
 
+	go s.scanAttachments(context.WithoutCancel(ctx), attachments)
+
 	return updatedSlip, nil
 }
 
 func (s *Service) uploadToBlob(
 	ctx context.Context,
-	fileHeader *multipart.FileHeader,
+	file preparedAttachment,
 	blobPath string,
 ) error {
-	src, err := fileHeader.Open()
-	if err != nil {
-		return err
-	}
-	defer src.Close()
+	return s.fileStorage.Upload(
+		ctx,
+		blobPath,
+		bytes.NewReader(file.data),
+		file.contentType,
+	)
+}
 
-	data, err := io.ReadAll(src)
-	if err != nil {
-		return err
+func newQuarantinedAttachment(
+	slipID string,
+	file preparedAttachment,
+	fileURL string,
+	round int,
+) *SlipAttachment {
+	return &SlipAttachment{
+		ID:            uuid.New().String(),
+		SlipID:        &slipID,
+		FileName:      file.header.Filename,
+		FileURL:       fileURL,
+		ContentType:   toNullString(file.contentType),
+		RevisionRound: round,
+		ScanStatus:    ScanQuarantined,
 	}
-
-	contentType := http.DetectContentType(data)
-	reader := bytes.NewReader(data)
-
-	return s.fileStorage.Upload(ctx, blobPath, reader, contentType)
 }
 
-// DownloadAttachment streams the attachment from Azure Blob Storage.
-func (s *Service) DownloadAttachment(
+// GetAttachmentURL returns a short-lived signed URL for downloading an
+// attachment of the given slip. Only attachments that passed the malware
+// scan are served.
+func (s *Service) GetAttachmentURL(
 	ctx context.Context,
+	slipID string,
 	attachmentID string,
-	writer io.Writer,
-) (*SlipAttachment, error) {
+) (string, error) {
 	attachment, err := s.repo.GetAttachmentByID(ctx, attachmentID)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	if attachment == nil {
-		return nil, fmt.Errorf("attachment not found")
+	if attachment == nil || attachment.SlipID == nil ||
+		*attachment.SlipID != slipID {
+		return "", ErrAttachmentNotFound
+	}
+
+	switch attachment.ScanStatus {
+	case ScanClean:
+	case ScanInfected:
+		return "", ErrAttachmentInfected
+	case ScanFailed:
+		return "", ErrAttachmentScanFailed
+	default:
+		return "", ErrAttachmentQuarantined
 	}
 
 	// Convert URL path "/slips/hash/file" to blob path "slips/hash/file"
 	blobPath := strings.TrimPrefix(attachment.FileURL, "/")
 
-	if err := s.fileStorage.Download(ctx, blobPath, writer); err != nil {
-		return nil, fmt.Errorf("failed to download file: %w", err)
+	url, err := s.fileStorage.SignedURL(ctx, blobPath, storage.SignedURLOptions{
+		Expiry:   s.cfg.FileURLExpiry,
+		FileName: attachment.FileName,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to sign download url: %w", err)
 	}
 
-	return attachment, nil
+	return url, nil
 }
 
+// UpdateExcuseSlipStatus moves a slip to the named status, enforcing the
+// transition table and recording the change in the slip's timeline.
 func (s *Service) UpdateExcuseSlipStatus(
 	ctx context.Context,
 	id string,
 	newStatus string,
 	adminNotes string,
 ) error {
-	// ... (validation code was here, I'll keep it)
-	validStatuses := map[string]bool{
-		"Pending":      true,
-		"Approved":     true,
-		"Rejected":     true,
-		"For Revision": true,
+	status, err := s.repo.GetSlipStatusByName(ctx, newStatus)
+	if err != nil {
+		return err
 	}
-
-	if !validStatuses[newStatus] {
-		return fmt.Errorf(
-			"invalid status: must be 'Pending', 'Approved', 'Rejected', or 'For Revision'",
-		)
+	if status == nil {
+		return ErrInvalidSlipStatus
 	}
 
-	// Fetch old state for audit trail
-	oldSlip, _ := s.repo.GetSlipByID(ctx, id)
-
-	return datastore.RunInTransaction(
+	var oldSlip *Slip
+	err = datastore.RunInTransaction(
 		ctx,
 		s.repo.GetDB(),
 		func(tx datastore.DB) error {
-			err := s.repo.UpdateStatus(ctx, tx, id, newStatus, adminNotes)
+			slip, err := s.repo.LockSlip(ctx, tx, id)
 			if err != nil {
-				audit.Dispatch(
-					ctx,
-					s.logService,
-					s.notifService,
-					audit.DispatchParams{
-						Tx: tx,
-						Log: &audit.LogParams{
-							Level:    audit.LevelError,
-							Category: audit.CategoryAudit,
-							Action:   audit.ActionSlipFailed,
-							Message: fmt.Sprintf(
-								"Failed to update status for admission slip #%s: %s",
-								id,
-								err.Error(),
-							),
-							Metadata: &audit.LogMetadata{
-								EntityType: constants.SlipEntityType,
-								EntityID:   id,
-								Error:      err.Error(),
-							},
-						},
-						Notifications: []audit.NotificationParams{
-							{
-								Title: "Admission Slip Status Update Failed",
-								Message: fmt.Sprintf(
-									"Failed to update status for admission slip #%s: %s",
-									id,
-									err.Error(),
-								),
-								Type: constants.SlipEntityType,
-							},
-						},
-					},
-				)
 				return err
 			}
+			if slip == nil {
+				return sql.ErrNoRows
+			}
+			if err := checkTransition(
+				constants.StatusID(slip.StatusID),
+				constants.StatusID(status.ID),
+				constants.RoleID(audit.ExtractRoleID(ctx)),
+			); err != nil {
+				return err
+			}
+			oldSlip = slip
 
-			// Fetch student UserID for notification
-			studentUserID, _ := s.repo.GetUserIDBySlipID(ctx, id)
+			if err := s.repo.UpdateStatus(
+				ctx,
+				tx,
+				id,
+				status.ID,
+				adminNotes,
+			); err != nil {
+				return err
+			}
 
-			notifications := []audit.NotificationParams{
-				{
-					ReceiverID: structs.StringToNullableString(studentUserID),
-					TargetID:   structs.StringToNullableString(id),
-					TargetType: structs.StringToNullableString(
-						constants.SlipEntityType,
-					),
-					Title: "Admission Slip Updated",
-					Message: fmt.Sprintf(
-						"Status for your admission slip has been updated to '%s'",
-						newStatus,
-					),
-					Type: constants.SlipEntityType,
+			return s.recordStatusChange(ctx, tx, slip, status.ID, adminNotes)
+		},
+	)
+	if err != nil {
+		audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+			Log: &audit.LogParams{
+				Level:    audit.LevelError,
+				Category: audit.CategoryAudit,
+				Action:   audit.ActionSlipFailed,
+				Message: fmt.Sprintf(
+					"Failed to update status for admission slip #%s: %s",
+					id,
+					err.Error(),
+				),
+				Metadata: &audit.LogMetadata{
+					EntityType: constants.SlipEntityType,
+					EntityID:   id,
+					Error:      err.Error(),
 				},
+			},
+			Notifications: []audit.NotificationParams{
 				{
-					TargetID: structs.StringToNullableString(id),
-					TargetType: structs.StringToNullableString(
-						constants.SlipEntityType,
-					),
-					Title: "Admission Slip Updated Successfully",
+					Title: "Admission Slip Status Update Failed",
 					Message: fmt.Sprintf(
-						"You have successfully updated the status of admission slip #%s to '%s'.",
+						"Failed to update status for admission slip #%s: %s",
 						id,
-						newStatus,
+						err.Error(),
 					),
 					Type: constants.SlipEntityType,
 				},
-			}
+			},
+		})
+		return err
+	}
 
-			audit.Dispatch(
-				ctx,
-				s.logService,
-				s.notifService,
-				audit.DispatchParams{
-					Tx: tx,
-					Log: &audit.LogParams{
-						Level:    audit.LevelInfo,
-						Category: audit.CategoryAudit,
-						Action:   audit.ActionSlipStatusUpdated,
-						Message: fmt.Sprintf(
-							"Admission slip #%s status changed to '%s'",
-							id,
-							newStatus,
-						),
-						Metadata: &audit.LogMetadata{
-							EntityType: constants.SlipEntityType,
-							EntityID:   id,
-							OldValues:  oldSlip,
-							NewValues: map[string]interface{}{
-								"status":     newStatus,
-								"adminNotes": adminNotes,
-							},
-						},
-					},
-					Notifications: notifications,
+	// Fetch student UserID for notification
+	studentUserID, _ := s.repo.GetUserIDBySlipID(ctx, id)
+
+	studentMessage := fmt.Sprintf(
+		"Status for your admission slip has been updated to '%s'",
+		status.Name,
+	)
+	if status.ID == int(constants.StatusForRevision) {
+		studentMessage += ". Please review the counselor's comments and resubmit it."
+	}
+
+	notifications := []audit.NotificationParams{
+		{
+			ReceiverID: structs.StringToNullableString(studentUserID),
+			TargetID:   structs.StringToNullableString(id),
+			TargetType: structs.StringToNullableString(
+				constants.SlipEntityType,
+			),
+			Title:   "Admission Slip Updated",
+			Message: studentMessage,
+			Type:    constants.SlipEntityType,
+		},
+		{
+			TargetID: structs.StringToNullableString(id),
+			TargetType: structs.StringToNullableString(
+				constants.SlipEntityType,
+			),
+			Title: "Admission Slip Updated Successfully",
+			Message: fmt.Sprintf(
+				"You have successfully updated the status of admission slip #%s to '%s'.",
+				id,
+				status.Name,
+			),
+			Type: constants.SlipEntityType,
+		},
+	}
+
+	audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+		Log: &audit.LogParams{
+			Level:    audit.LevelInfo,
+			Category: audit.CategoryAudit,
+			Action:   audit.ActionSlipStatusUpdated,
+			Message: fmt.Sprintf(
+				"Admission slip #%s status changed to '%s'",
+				id,
+				status.Name,
+			),
+			Metadata: &audit.LogMetadata{
+				EntityType: constants.SlipEntityType,
+				EntityID:   id,
+				OldValues:  oldSlip,
+				NewValues: map[string]interface{}{
+					"status":        status.Name,
+					"adminNotes":    adminNotes,
+					"revisionRound": oldSlip.RevisionRound,
 				},
-			)
-			return nil
+			},
 		},
-	)
+		Notifications: notifications,
+	})
+
+	if status.ID == int(constants.StatusApproved) {
+		go s.issueCertificateAfterApproval(context.WithoutCancel(ctx), id)
+	}
+
+	return nil
 }
 
 // func (s *Service) DeleteExcuseSlip(ctx context.Context, id int) error {
@@ -0,0 +1,48 @@
+package slips
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+)
+
+var (
+	ErrInvalidSlipStatus   = errors.New("invalid slip status")
+	ErrInvalidTransition   = errors.New("slip status transition is not allowed")
+	ErrTransitionForbidden = errors.New("your role cannot perform this status change")
+)
+
+var staffRoles = []constants.RoleID{
+	constants.AdminRoleID,
+	constants.SuperAdminRoleID,
+}
+
+// statusTransitions lists every allowed status change and the roles that
+// may perform it. A slip only returns to Pending when the student resubmits
+// it; Approved and Rejected are terminal.
+var statusTransitions = map[constants.StatusID]map[constants.StatusID][]constants.RoleID{
+	constants.StatusPending: {
+		constants.StatusApproved:    staffRoles,
+		constants.StatusRejected:    staffRoles,
+		constants.StatusForRevision: staffRoles,
+	},
+	constants.StatusForRevision: {
+		constants.StatusPending:  {constants.StudentRoleID},
+		constants.StatusRejected: staffRoles,
+	},
+}
+
+// checkTransition validates moving a slip from one status to another as
+// the given role.
+func checkTransition(from, to constants.StatusID, role constants.RoleID) error {
+	roles, ok := statusTransitions[from][to]
+	if !ok {
+		return ErrInvalidTransition
+	}
+	if !slices.Contains(roles, role) {
+		return ErrTransitionForbidden
+	}
+
+	return nil
+}
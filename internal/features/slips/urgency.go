@@ -0,0 +1,196 @@
+package slips
+
+import (
+	"context"
+	"errors"
+
+	"github.com/olazo-johnalbert/duckload-api/internal/core/audit"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/structs"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
+)
+
+// Factor names reported in an UrgencyBreakdown.
+const (
+	UrgencyFactorCategory   = "category"
+	UrgencyFactorNeeded     = "days_until_needed"
+	UrgencyFactorAbsence    = "days_since_absence"
+	UrgencyFactorRepeat     = "repeat_absences"
+	UrgencyFactorAttachment = "attachments"
+)
+
+var (
+	ErrUrgencyPolicyNotFound = errors.New("urgency policy not found")
+	ErrUrgencyCategory       = errors.New("unknown slip category")
+)
+
+func (s *Service) GetUrgencyPolicy(
+	ctx context.Context,
+) (*UrgencyPolicyDTO, error) {
+	policy, err := s.repo.GetUrgencyPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return nil, ErrUrgencyPolicyNotFound
+	}
+
+	weights, err := s.repo.ListUrgencyCategoryWeights(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapUrgencyPolicy(policy, weights), nil
+}
+
+// UpdateUrgencyPolicy replaces the policy weights. Only the categories
+// listed in the request have their weight changed.
+func (s *Service) UpdateUrgencyPolicy(
+	ctx context.Context,
+	req UpdateUrgencyPolicyRequest,
+) (*UrgencyPolicyDTO, error) {
+	oldPolicy, err := s.GetUrgencyPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[int]bool, len(oldPolicy.Categories))
+	for _, w := range oldPolicy.Categories {
+		known[w.CategoryID] = true
+	}
+	for _, w := range req.Categories {
+		if !known[w.CategoryID] {
+			return nil, ErrUrgencyCategory
+		}
+	}
+
+	policy := &UrgencyPolicy{
+		NeededHorizonDays:  *req.NeededHorizonDays,
+		NeededWeight:       *req.NeededWeight,
+		AbsenceHorizonDays: *req.AbsenceHorizonDays,
+		AbsenceWeight:      *req.AbsenceWeight,
+		RepeatLookbackDays: *req.RepeatLookbackDays,
+		RepeatWeight:       *req.RepeatWeight,
+		AttachmentWeight:   *req.AttachmentWeight,
+		UpdatedBy:          toNullString(audit.ExtractUserID(ctx)),
+	}
+
+	err = datastore.RunInTransaction(
+		ctx,
+		s.repo.GetDB(),
+		func(tx datastore.DB) error {
+			if err := s.repo.UpdateUrgencyPolicy(
+				ctx,
+				tx,
+				policy,
+			); err != nil {
+				return err
+			}
+			for _, w := range req.Categories {
+				if err := s.repo.SaveUrgencyCategoryWeight(
+					ctx,
+					tx,
+					w.CategoryID,
+					*w.Weight,
+				); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+			Log: &audit.LogParams{
+				Level:    audit.LevelError,
+				Category: audit.CategoryAudit,
+				Action:   audit.ActionSlipUrgencyPolicyUpdateFailed,
+				Message:  "Failed to update the slip urgency policy",
+				Metadata: &audit.LogMetadata{
+					EntityType: constants.SlipUrgencyPolicyEntityType,
+					Error:      err.Error(),
+				},
+			},
+		})
+		return nil, err
+	}
+
+	newPolicy, err := s.GetUrgencyPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+		Log: &audit.LogParams{
+			Level:    audit.LevelInfo,
+			Category: audit.CategoryAudit,
+			Action:   audit.ActionSlipUrgencyPolicyUpdated,
+			Message:  "Slip urgency policy updated",
+			Metadata: &audit.LogMetadata{
+				EntityType: constants.SlipUrgencyPolicyEntityType,
+				OldValues:  oldPolicy,
+				NewValues:  newPolicy,
+			},
+		},
+	})
+
+	return newPolicy, nil
+}
+
+// urgencyBreakdown explains the score of a slip loaded with
+// withUrgencyScore. The value of each factor is the raw input the policy
+// weighed; for the category it is the category ID.
+func urgencyBreakdown(slip *SlipWithDetailsView) *UrgencyBreakdown {
+	return &UrgencyBreakdown{
+		Score: slip.UrgencyScore,
+		Factors: []UrgencyFactor{
+			{
+				Factor: UrgencyFactorCategory,
+				Value:  slip.CategoryID,
+				Points: slip.CategoryPoints,
+			},
+			{
+				Factor: UrgencyFactorNeeded,
+				Value:  slip.DaysUntilNeeded,
+				Points: slip.NeededPoints,
+			},
+			{
+				Factor: UrgencyFactorAbsence,
+				Value:  slip.DaysSinceAbsence,
+				Points: slip.AbsencePoints,
+			},
+			{
+				Factor: UrgencyFactorRepeat,
+				Value:  slip.RepeatAbsences,
+				Points: slip.RepeatPoints,
+			},
+			{
+				Factor: UrgencyFactorAttachment,
+				Value:  slip.AttachmentCount,
+				Points: slip.AttachmentPoints,
+			},
+		},
+	}
+}
+
+func mapUrgencyPolicy(
+	policy *UrgencyPolicy,
+	weights []UrgencyCategoryWeight,
+) *UrgencyPolicyDTO {
+	if weights == nil {
+		weights = []UrgencyCategoryWeight{}
+	}
+
+	return &UrgencyPolicyDTO{
+		NeededHorizonDays:  policy.NeededHorizonDays,
+		NeededWeight:       policy.NeededWeight,
+		AbsenceHorizonDays: policy.AbsenceHorizonDays,
+		AbsenceWeight:      policy.AbsenceWeight,
+		RepeatLookbackDays: policy.RepeatLookbackDays,
+		RepeatWeight:       policy.RepeatWeight,
+		AttachmentWeight:   policy.AttachmentWeight,
+		Categories:         weights,
+		UpdatedBy:          structs.FromSqlNull(policy.UpdatedBy),
+		UpdatedAt:          policy.UpdatedAt,
+	}
+}
@@ -0,0 +1,68 @@
+package students
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Encrypted health and consultation columns. The field names are bound into
+// each ciphertext.
+const (
+	visionDetailsField        = "student_health_records.vision_details"
+	hearingDetailsField       = "student_health_records.hearing_details"
+	speechDetailsField        = "student_health_records.speech_details"
+	generalHealthDetailsField = "student_health_records.general_health_details"
+	consultationWhenField     = "student_consultations.when_date"
+	consultationForWhatField  = "student_consultations.for_what"
+)
+
+// cryptFunc is Cipher.EncryptNullString or Cipher.DecryptNullString.
+type cryptFunc func(
+	ctx context.Context,
+	field string,
+	value sql.NullString,
+) (sql.NullString, error)
+
+// cryptHealthRecord encrypts or decrypts the free-text details of a health
+// record in place.
+func (r *Repository) cryptHealthRecord(
+	ctx context.Context,
+	hr *StudentHealthRecord,
+	crypt cryptFunc,
+) error {
+	return cryptFields(ctx, crypt, map[string]*sql.NullString{
+		visionDetailsField:        &hr.VisionDetails,
+		hearingDetailsField:       &hr.HearingDetails,
+		speechDetailsField:        &hr.SpeechDetails,
+		generalHealthDetailsField: &hr.GeneralHealthDetails,
+	})
+}
+
+// cryptConsultation encrypts or decrypts when and why a student consulted
+// a professional, in place.
+func (r *Repository) cryptConsultation(
+	ctx context.Context,
+	sc *StudentConsultation,
+	crypt cryptFunc,
+) error {
+	return cryptFields(ctx, crypt, map[string]*sql.NullString{
+		consultationWhenField:    &sc.WhenDate,
+		consultationForWhatField: &sc.ForWhat,
+	})
+}
+
+func cryptFields(
+	ctx context.Context,
+	crypt cryptFunc,
+	fields map[string]*sql.NullString,
+) error {
+	for field, value := range fields {
+		result, err := crypt(ctx, field, *value)
+		if err != nil {
+			return err
+		}
+		*value = result
+	}
+
+	return nil
+}
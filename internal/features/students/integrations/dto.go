@@ -1,13 +1,33 @@
 package integrations
 
 import (
+	"time"
+
 	"github.com/olazo-johnalbert/duckload-api/internal/core/structs"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/locations"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/students"
 )
 
+type OGOSLinkCodeRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type OGOSLinkCodeResponse struct {
+	LinkRequestID string `json:"linkRequestId"`
+	ExpiresIn     int    `json:"expiresIn"`
+}
+
 type OGOSLinkStudentRequest struct {
-	VerificationCode string `json:"verificationCode"`
+	LinkRequestID    string `json:"linkRequestId"    binding:"required"`
+	StudentNumber    string `json:"studentNumber"    binding:"required"`
+	ExternalID       string `json:"externalId"       binding:"required,max=100"`
+	VerificationCode string `json:"verificationCode" binding:"required,len=6"`
+}
+
+type OGOSStudentLinkDTO struct {
+	StudentNumber string    `json:"studentNumber"`
+	ExternalID    string    `json:"externalId"`
+	LinkedAt      time.Time `json:"linkedAt"`
 }
 
 type OGOSListStudentsRequest struct {
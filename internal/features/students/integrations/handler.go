@@ -1,6 +1,7 @@
 package integrations
 
 import (
+	"errors"
 	"log"
 	"net/http"
 
@@ -17,9 +18,118 @@ func NewHandler(service ServiceInterface) *Handler {
 	return &Handler{service: service}
 }
 
-func (h *Handler) PostLinkStudent(c *gin.Context) {}
+// getM2MClientID extracts the calling partner's client ID or aborts with
+// Forbidden status when the caller is not an M2M client.
+func getM2MClientID(c *gin.Context) (string, bool) {
+	clientID := c.GetString("m2mClientID")
+	if clientID == "" {
+		response.SendFail(
+			c,
+			gin.H{"error": "Only partner systems can link student accounts"},
+			http.StatusForbidden,
+		)
+		return "", false
+	}
+
+	return clientID, true
+}
+
+// PostLinkStudent godoc
+// @Summary Link a student account
+// @Description Exchanges a mailed verification code and the student number for a binding between the partner's external identity and the student record
+// @Tags External Students
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body OGOSLinkStudentRequest true "Link details"
+// @Success 200 {object} StudentLinkSuccessResponse
+// @Failure 400 {object} response.CommonErrorResponse "Bad Request"
+// @Failure 401 {object} response.CommonErrorResponse "Unauthorized"
+// @Failure 403 {object} response.CommonErrorResponse "Forbidden"
+// @Failure 409 {object} response.CommonErrorResponse "Conflict"
+// @Failure 500 {object} response.CommonErrorResponse "Internal Server Error"
+// @Router /integrations/students/linker [post]
+func (h *Handler) PostLinkStudent(c *gin.Context) {
+	clientID, ok := getM2MClientID(c)
+	if !ok {
+		return
+	}
+
+	var req OGOSLinkStudentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendFail(c, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	link, err := h.service.LinkStudent(c.Request.Context(), clientID, req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidLinkCode) ||
+			errors.Is(err, ErrLinkStudentMismatch) {
+			response.SendFail(c, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, ErrExternalIDTaken) {
+			response.SendFail(
+				c,
+				gin.H{"error": err.Error()},
+				http.StatusConflict,
+			)
+			return
+		}
+
+		log.Printf("[PostLinkStudent] {Service Link}: %v", err)
+		response.SendError(
+			c,
+			string(constants.ErrInternalServerError),
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
 
-func (h *Handler) PostEmailVerificationCode(c *gin.Context) {}
+	response.SendSuccess(c, link)
+}
+
+// PostEmailVerificationCode godoc
+// @Summary Request a student link code
+// @Description Mails a one-time verification code to the student; the code expires after 5 minutes
+// @Tags External Students
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body OGOSLinkCodeRequest true "Student email"
+// @Success 200 {object} LinkCodeSuccessResponse
+// @Failure 400 {object} response.CommonErrorResponse "Bad Request"
+// @Failure 401 {object} response.CommonErrorResponse "Unauthorized"
+// @Failure 403 {object} response.CommonErrorResponse "Forbidden"
+// @Failure 500 {object} response.CommonErrorResponse "Internal Server Error"
+// @Router /integrations/students/linker/code [post]
+func (h *Handler) PostEmailVerificationCode(c *gin.Context) {
+	clientID, ok := getM2MClientID(c)
+	if !ok {
+		return
+	}
+
+	var req OGOSLinkCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendFail(c, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	resp, err := h.service.RequestLinkCode(c.Request.Context(), clientID, req)
+	if err != nil {
+		log.Printf("[PostEmailVerificationCode] {Service Request}: %v", err)
+		response.SendError(
+			c,
+			string(constants.ErrInternalServerError),
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	response.SendSuccess(c, resp)
+}
 
 // HandleListStudents godoc
 // @Summary List students
@@ -2,6 +2,9 @@ package integrations
 
 import (
 	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
 )
 
 type ServiceInterface interface {
@@ -25,9 +28,20 @@ type ServiceInterface interface {
 		ctx context.Context,
 		studentNumber string,
 	) ([]OGOSStudentAddressDTO, error)
+	RequestLinkCode(
+		ctx context.Context,
+		clientID string,
+		req OGOSLinkCodeRequest,
+	) (*OGOSLinkCodeResponse, error)
+	LinkStudent(
+		ctx context.Context,
+		clientID string,
+		req OGOSLinkStudentRequest,
+	) (*OGOSStudentLinkDTO, error)
 }
 
 type RepositoryInterface interface {
+	GetDB() *sqlx.DB
 	ListStudents(
 		ctx context.Context,
 		req OGOSListStudentsRequest,
@@ -48,4 +62,18 @@ type RepositoryInterface interface {
 		ctx context.Context,
 		studentNumber string,
 	) ([]OGOSStudentAddressView, error)
+	GetLinkTargetByEmail(
+		ctx context.Context,
+		email string,
+	) (*OGOSStudentLinkTargetView, error)
+	UpsertStudentLink(
+		ctx context.Context,
+		tx datastore.DB,
+		link PartnerStudentLink,
+	) error
+	GetStudentLink(
+		ctx context.Context,
+		tx datastore.DB,
+		clientID, iirID string,
+	) (*PartnerStudentLink, error)
 }
@@ -1,6 +1,9 @@
 package integrations
 
-import "database/sql"
+import (
+	"database/sql"
+	"time"
+)
 
 type OGOSStudentView struct {
 	StudentNumber string `db:"student_number"`
@@ -44,3 +47,19 @@ type OGOSStudentAddressView struct {
 	RegionCode   string         `db:"region_code,omitempty"`
 	RegionName   string         `db:"region_name,omitempty"`
 }
+
+type OGOSStudentLinkTargetView struct {
+	IIRID         string `db:"iir_id"`
+	UserID        string `db:"user_id"`
+	StudentNumber string `db:"student_number"`
+	Email         string `db:"email"`
+}
+
+type PartnerStudentLink struct {
+	ID         int       `db:"id"`
+	IIRID      string    `db:"iir_id"`
+	ClientID   string    `db:"client_id"`
+	ExternalID string    `db:"external_id"`
+	LinkedAt   time.Time `db:"linked_at"`
+	UpdatedAt  time.Time `db:"updated_at"`
+}
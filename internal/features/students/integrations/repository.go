@@ -2,10 +2,16 @@ package integrations
 
 import (
 	"context"
+	"errors"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
 )
 
+// mysqlDuplicateEntry is MySQL's ER_DUP_ENTRY error number.
+const mysqlDuplicateEntry = 1062
+
 type Repository struct {
 	db *sqlx.DB
 }
@@ -14,6 +20,10 @@ func NewRepository(db *sqlx.DB) *Repository {
 	return &Repository{db: db}
 }
 
+func (r *Repository) GetDB() *sqlx.DB {
+	return r.db
+}
+
 func (r *Repository) ListStudents(
 	ctx context.Context,
 	req OGOSListStudentsRequest,
@@ -217,3 +227,96 @@ func (r *Repository) GetAddressByStudentNumber(
 
 	return addresses, nil
 }
+
+func (r *Repository) GetLinkTargetByEmail(
+	ctx context.Context,
+	email string,
+) (*OGOSStudentLinkTargetView, error) {
+	query := `
+		SELECT
+			i.id AS iir_id,
+			u.id AS user_id,
+			sp.student_number AS student_number,
+			u.email AS email
+		FROM users u
+		JOIN iir_records i ON i.user_id = u.id
+		JOIN student_personal_info sp ON sp.iir_id = i.id
+		WHERE u.email = ? AND u.is_active = 1
+		LIMIT 1
+	`
+
+	var target OGOSStudentLinkTargetView
+	err := r.db.GetContext(ctx, &target, query, email)
+	if err != nil {
+		return nil, err
+	}
+
+	return &target, nil
+}
+
+// UpsertStudentLink links the student to the partner's external ID,
+// replacing the student's previous link to that partner. It returns
+// ErrExternalIDTaken when the partner linked the ID to another student.
+func (r *Repository) UpsertStudentLink(
+	ctx context.Context,
+	tx datastore.DB,
+	link PartnerStudentLink,
+) error {
+	var exists bool
+	err := tx.GetContext(
+		ctx,
+		&exists,
+		`SELECT EXISTS(
+			SELECT 1 FROM partner_student_links
+			WHERE client_id = ? AND iir_id = ?
+		)`,
+		link.ClientID,
+		link.IIRID,
+	)
+	if err != nil {
+		return err
+	}
+
+	// A plain insert or update, unlike ON DUPLICATE KEY UPDATE, fails on
+	// the external ID index instead of rewriting another student's row
+	query := `
+		INSERT INTO partner_student_links (iir_id, client_id, external_id)
+		VALUES (:iir_id, :client_id, :external_id)
+	`
+	if exists {
+		query = `
+			UPDATE partner_student_links
+			SET external_id = :external_id, linked_at = CURRENT_TIMESTAMP
+			WHERE client_id = :client_id AND iir_id = :iir_id
+		`
+	}
+
+	_, err = tx.NamedExecContext(ctx, query, link)
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+		return ErrExternalIDTaken
+	}
+
+	return err
+}
+
+func (r *Repository) GetStudentLink(
+	ctx context.Context,
+	tx datastore.DB,
+	clientID, iirID string,
+) (*PartnerStudentLink, error) {
+	query := `
+		SELECT id, iir_id, client_id, external_id, linked_at, updated_at
+		FROM partner_student_links
+		WHERE client_id = ? AND iir_id = ?
+		LIMIT 1
+	`
+
+	var link PartnerStudentLink
+	err := tx.GetContext(ctx, &link, query, clientID, iirID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &link, nil
+}
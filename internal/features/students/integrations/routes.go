@@ -2,7 +2,9 @@ package integrations
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/middleware"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/tokens"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
 )
 
@@ -10,19 +12,39 @@ func RegisterRoutes(
 	rg *gin.RouterGroup,
 	h *Handler,
 	redis *datastore.RedisClient,
+	tokenService *tokens.Service,
 ) {
 	routes := rg.Group("/integrations/students")
-	routes.Use(middleware.AuthMiddleware(redis))
+	routes.Use(middleware.AuthMiddleware(redis, tokenService))
+	routes.Use(middleware.AuditContextMiddleware())
 
-	routes.POST("/linker", h.PostLinkStudent)
-	routes.POST("/linker/code", h.PostEmailVerificationCode)
+	routes.POST(
+		"/linker",
+		middleware.ScopeMiddleware(constants.ScopeStudentsLink),
+		h.PostLinkStudent,
+	)
+	routes.POST(
+		"/linker/code",
+		middleware.ScopeMiddleware(constants.ScopeStudentsLink),
+		h.PostEmailVerificationCode,
+	)
+
+	readRoutes := routes.Group("")
+	readRoutes.Use(middleware.ScopeMiddleware(constants.ScopeStudentsRead))
+	{
+		readRoutes.GET("/profiles", h.GetStudents)
+		readRoutes.GET("/profile", h.GetStudentByEmail)
+		readRoutes.GET("/:studentNumber", h.GetStudentByStudentNumber)
+	}
 
-	routes.GET("/profiles", h.GetStudents)
-	routes.GET("/profile", h.GetStudentByEmail)
-	routes.GET("/:studentNumber", h.GetStudentByStudentNumber)
 	routes.GET(
 		"/:studentNumber/personal-info",
+		middleware.ScopeMiddleware(constants.ScopeStudentsPersonalInfo),
 		h.GetPersonalInfoByStudentNumber,
 	)
-	routes.GET("/:studentNumber/addresses", h.GetAddressByStudentNumber)
+	routes.GET(
+		"/:studentNumber/addresses",
+		middleware.ScopeMiddleware(constants.ScopeStudentsAddresses),
+		h.GetAddressByStudentNumber,
+	)
 }
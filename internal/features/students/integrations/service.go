@@ -3,19 +3,60 @@ package integrations
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/audit"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/sessions"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/structs"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/locations"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/students"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/email"
+	"github.com/pquerna/otp/totp"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// linkCodeTTL mirrors the lifetime of the registration OTP.
+const (
+	linkCodeTTL         = 300
+	linkCodeMaxAttempts = 5
+)
+
+var (
+	ErrInvalidLinkCode     = errors.New("invalid or expired verification code")
+	ErrLinkStudentMismatch = errors.New("student number does not match the verified email")
+	ErrExternalIDTaken     = errors.New(
+		"this external ID is already linked to another student",
+	)
 )
 
 type Service struct {
-	repo RepositoryInterface
+	repo           RepositoryInterface
+	sessionService *sessions.Service
+	emailer        email.Emailer
+	logService     audit.Logger
+	notifService   audit.Notifier
 }
 
-func NewService(repo RepositoryInterface) *Service {
-	return &Service{repo: repo}
+func NewService(
+	repo RepositoryInterface,
+	sessionService *sessions.Service,
+	emailer email.Emailer,
+	logService audit.Logger,
+	notifService audit.Notifier,
+) *Service {
+	return &Service{
+		repo:           repo,
+		sessionService: sessionService,
+		emailer:        emailer,
+		logService:     logService,
+		notifService:   notifService,
+	}
 }
 
 func (s *Service) ListStudents(
@@ -180,3 +221,244 @@ func (s *Service) GetAddressByStudentNumber(
 
 	return addresesDTO, nil
 }
+
+// RequestLinkCode mails a one-time code to the student so that a partner
+// system can prove it acts on the student's behalf. The response is the
+// same whether or not the email belongs to a student, so partners cannot
+// use it to find out who is enrolled.
+func (s *Service) RequestLinkCode(
+	ctx context.Context,
+	clientID string,
+	req OGOSLinkCodeRequest,
+) (*OGOSLinkCodeResponse, error) {
+	linkRequestID := uuid.NewString()
+	resp := &OGOSLinkCodeResponse{
+		LinkRequestID: linkRequestID,
+		ExpiresIn:     linkCodeTTL,
+	}
+
+	target, err := s.repo.GetLinkTargetByEmail(ctx, req.Email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return resp, nil
+		}
+		return nil, fmt.Errorf("failed to fetch link target: %w", err)
+	}
+
+	// Hashing and mailing the code would make known emails answer slower
+	go s.sendLinkCode(
+		context.WithoutCancel(ctx),
+		clientID,
+		linkRequestID,
+		target,
+	)
+
+	return resp, nil
+}
+
+// sendLinkCode stores a link request and mails its code to the student.
+// The partner has already been answered, so failures are only logged.
+func (s *Service) sendLinkCode(
+	ctx context.Context,
+	clientID string,
+	linkRequestID string,
+	target *OGOSStudentLinkTargetView,
+) {
+	verificationOTP, err := s.get6DigitOTP()
+	if err != nil {
+		log.Printf("[sendLinkCode] {Generate Code}: %v", err)
+		return
+	}
+	hashedOTP, err := bcrypt.GenerateFromPassword(
+		[]byte(verificationOTP),
+		bcrypt.DefaultCost,
+	)
+	if err != nil {
+		log.Printf("[sendLinkCode] {Hash Code}: %v", err)
+		return
+	}
+
+	val := map[string]string{
+		"linkRequestID":     linkRequestID,
+		"clientId":          clientID,
+		"iirID":             target.IIRID,
+		"userID":            target.UserID,
+		"studentNumber":     target.StudentNumber,
+		"verificationToken": string(hashedOTP),
+	}
+	err = s.sessionService.StoreToken(
+		ctx,
+		sessions.NewJTI(linkRequestID),
+		val,
+		linkCodeTTL,
+	)
+	if err != nil {
+		log.Printf("[sendLinkCode] {Store Request}: %v", err)
+		return
+	}
+
+	isSent, err := s.emailer.SendOTP(ctx, target.Email, verificationOTP)
+	if err != nil || !isSent {
+		log.Printf("[sendLinkCode] {Send Email}: sent=%t, %v", isSent, err)
+	}
+}
+
+// LinkStudent exchanges a verification code and student number for a
+// persistent binding between the partner's external identity and the
+// student's IIR record.
+func (s *Service) LinkStudent(
+	ctx context.Context,
+	clientID string,
+	req OGOSLinkStudentRequest,
+) (*OGOSStudentLinkDTO, error) {
+	jti := sessions.NewJTI(req.LinkRequestID)
+	val, err := s.sessionService.GetToken(ctx, jti)
+	if err != nil {
+		return nil, ErrInvalidLinkCode
+	}
+
+	// A link request is only redeemable by the client that started it
+	if val["clientId"] != clientID {
+		return nil, ErrInvalidLinkCode
+	}
+
+	// Each try is counted before the code is checked, so concurrent
+	// guesses cannot get past the limit
+	attempts, err := s.sessionService.CountAttempt(
+		ctx,
+		jti.ToLinkAttemptsKey(),
+		linkCodeTTL,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify code: %w", err)
+	}
+	if attempts > linkCodeMaxAttempts {
+		_ = s.sessionService.DeleteToken(ctx, jti)
+		return nil, ErrInvalidLinkCode
+	}
+
+	err = bcrypt.CompareHashAndPassword(
+		[]byte(val["verificationToken"]),
+		[]byte(req.VerificationCode),
+	)
+	if err != nil {
+		s.recordFailedAttempt(ctx, jti, attempts)
+		return nil, ErrInvalidLinkCode
+	}
+
+	if val["studentNumber"] != req.StudentNumber {
+		s.recordFailedAttempt(ctx, jti, attempts)
+		return nil, ErrLinkStudentMismatch
+	}
+
+	link := PartnerStudentLink{
+		IIRID:      val["iirID"],
+		ClientID:   clientID,
+		ExternalID: req.ExternalID,
+	}
+
+	var saved *PartnerStudentLink
+	err = datastore.RunInTransaction(
+		ctx,
+		s.repo.GetDB(),
+		func(tx datastore.DB) error {
+			if err := s.repo.UpsertStudentLink(ctx, tx, link); err != nil {
+				return err
+			}
+
+			found, err := s.repo.GetStudentLink(ctx, tx, clientID, link.IIRID)
+			if err != nil {
+				return err
+			}
+
+			saved = found
+			return nil
+		},
+	)
+	if err != nil {
+		audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+			Log: &audit.LogParams{
+				Level:    audit.LevelError,
+				Category: audit.CategorySecurity,
+				Action:   audit.ActionStudentLinkFailed,
+				Message: fmt.Sprintf(
+					"Failed to link student %s to partner %s",
+					req.StudentNumber,
+					clientID,
+				),
+				Metadata: &audit.LogMetadata{
+					EntityType: constants.IIREntityType,
+					EntityID:   link.IIRID,
+					Error:      err.Error(),
+				},
+			},
+		})
+		return nil, fmt.Errorf("failed to link student: %w", err)
+	}
+
+	// The code is single-use
+	_ = s.sessionService.DeleteToken(ctx, jti)
+
+	audit.Dispatch(ctx, s.logService, s.notifService, audit.DispatchParams{
+		Log: &audit.LogParams{
+			Level:    audit.LevelInfo,
+			Category: audit.CategorySecurity,
+			Action:   audit.ActionStudentLinked,
+			Message: fmt.Sprintf(
+				"Student %s linked to partner %s",
+				req.StudentNumber,
+				clientID,
+			),
+			TargetID:   structs.StringToNullableString(link.IIRID),
+			TargetType: structs.StringToNullableString(constants.IIREntityType),
+			Metadata: &audit.LogMetadata{
+				EntityType: constants.IIREntityType,
+				EntityID:   link.IIRID,
+				NewValues:  saved,
+			},
+		},
+		Notifications: []audit.NotificationParams{
+			{
+				ReceiverID: structs.StringToNullableString(val["userID"]),
+				Title:      "Account Linked",
+				Message:    "Your student record has been linked to a partner system.",
+				Type:       constants.SystemEntityType,
+			},
+		},
+	})
+
+	return &OGOSStudentLinkDTO{
+		StudentNumber: req.StudentNumber,
+		ExternalID:    saved.ExternalID,
+		LinkedAt:      saved.LinkedAt,
+	}, nil
+}
+
+// recordFailedAttempt burns the link request once the last allowed try
+// was wrong. The request keeps its original expiry otherwise.
+func (s *Service) recordFailedAttempt(
+	ctx context.Context,
+	jti sessions.JTIDTO,
+	attempts int64,
+) {
+	if attempts >= linkCodeMaxAttempts {
+		_ = s.sessionService.DeleteToken(ctx, jti)
+	}
+}
+
+func (s *Service) get6DigitOTP() (string, error) {
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      constants.ClaimsIssuer,
+		AccountName: constants.FromEmail(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate verification code: %w", err)
+	}
+
+	verificationOTP, err := totp.GenerateCode(key.Secret(), time.Now())
+	if err != nil {
+		return "", fmt.Errorf("failed to generate verification code: %w", err)
+	}
+
+	return verificationOTP, nil
+}
@@ -27,3 +27,15 @@ type StudentAddressSuccessResponse struct {
 	Status response.JSendStatus    `json:"status" example:"success"`
 	Data   []OGOSStudentAddressDTO `json:"data"`
 }
+
+// LinkCodeSuccessResponse is a flat JSend response for PostEmailVerificationCode.
+type LinkCodeSuccessResponse struct {
+	Status response.JSendStatus `json:"status" example:"success"`
+	Data   OGOSLinkCodeResponse `json:"data"`
+}
+
+// StudentLinkSuccessResponse is a flat JSend response for PostLinkStudent.
+type StudentLinkSuccessResponse struct {
+	Status response.JSendStatus `json:"status" example:"success"`
+	Data   OGOSStudentLinkDTO   `json:"data"`
+}
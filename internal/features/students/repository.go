@@ -8,14 +8,16 @@ import (
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/encryption"
 )
 
 type Repository struct {
-	db *sqlx.DB
+	db     *sqlx.DB
+	cipher *encryption.Cipher
 }
 
-func NewRepository(db *sqlx.DB) *Repository {
-	return &Repository{db: db}
+func NewRepository(db *sqlx.DB, cipher *encryption.Cipher) *Repository {
+	return &Repository{db: db, cipher: cipher}
 }
 
 func (r *Repository) GetDB() *sqlx.DB {
@@ -941,6 +943,9 @@ func (r *Repository) GetStudentHealthRecord(
 	if err != nil {
 		return nil, fmt.Errorf("failed to get student health record: %w", err)
 	}
+	if err := r.cryptHealthRecord(ctx, &hr, r.cipher.DecryptNullString); err != nil {
+		return nil, err
+	}
 
 	return &hr, nil
 }
@@ -976,6 +981,15 @@ func (r *Repository) GetStudentConsultations(
 	if err != nil {
 		return nil, fmt.Errorf("failed to get student consultations: %w", err)
 	}
+	for i := range consultations {
+		if err := r.cryptConsultation(
+			ctx,
+			&consultations[i],
+			r.cipher.DecryptNullString,
+		); err != nil {
+			return nil, err
+		}
+	}
 
 	return consultations, nil
 }
@@ -1769,6 +1783,15 @@ func (r *Repository) upsertStudentHealthRecordTx(
 	tx datastore.DB,
 	hr *StudentHealthRecord,
 ) (int, error) {
+	encrypted := *hr
+	if err := r.cryptHealthRecord(
+		ctx,
+		&encrypted,
+		r.cipher.EncryptNullString,
+	); err != nil {
+		return 0, err
+	}
+
 	cols, vals := datastore.GetInsertStatement(
 		StudentHealthRecord{},
 		[]string{"created_at", "updated_at"},
@@ -1781,7 +1804,7 @@ func (r *Repository) upsertStudentHealthRecordTx(
 	query := fmt.Sprintf(`
 		INSERT INTO student_health_records (%s) VALUES (%s) ON DUPLICATE KEY UPDATE %s
 	`, cols, vals, updateCols)
-	result, err := tx.NamedExecContext(ctx, query, hr)
+	result, err := tx.NamedExecContext(ctx, query, &encrypted)
 	if err != nil {
 		return 0, fmt.Errorf("failed to upsert student health record: %w", err)
 	}
@@ -1820,6 +1843,15 @@ func (r *Repository) upsertStudentConsultationTx(
 	tx datastore.DB,
 	sc *StudentConsultation,
 ) (int, error) {
+	encrypted := *sc
+	if err := r.cryptConsultation(
+		ctx,
+		&encrypted,
+		r.cipher.EncryptNullString,
+	); err != nil {
+		return 0, err
+	}
+
 	cols, vals := datastore.GetInsertStatement(
 		StudentConsultation{},
 		[]string{"created_at", "updated_at"},
@@ -1832,7 +1864,7 @@ func (r *Repository) upsertStudentConsultationTx(
 	query := fmt.Sprintf(`
 		INSERT INTO student_consultations (%s) VALUES (%s) ON DUPLICATE KEY UPDATE %s
 	`, cols, vals, updateCols)
-	result, err := tx.NamedExecContext(ctx, query, sc)
+	result, err := tx.NamedExecContext(ctx, query, &encrypted)
 	if err != nil {
 		return 0, fmt.Errorf("failed to upsert student consultation: %w", err)
 	}
@@ -0,0 +1,27 @@
+package twofactor
+
+import "time"
+
+type StatusDTO struct {
+	Enabled           bool       `json:"enabled"`
+	Required          bool       `json:"required"`
+	EnabledAt         *time.Time `json:"enabledAt,omitempty"`
+	RecoveryCodesLeft int        `json:"recoveryCodesLeft"`
+}
+
+// EnrollmentDTO is shown once while enrolling. QRCode is a PNG data URL of
+// OTPAuthURL for authenticator apps; Secret is for manual entry.
+type EnrollmentDTO struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauthUrl"`
+	QRCode     string `json:"qrCode"`
+}
+
+type CodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// RecoveryCodesDTO lists new recovery codes. They are only shown once.
+type RecoveryCodesDTO struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
@@ -0,0 +1,305 @@
+package twofactor
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/audit"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/response"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/sessions"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/structs"
+	"github.com/olazo-johnalbert/duckload-api/internal/features/logs"
+)
+
+type Handler struct {
+	service        ServiceInterface
+	sessionService *sessions.Service
+	logService     logs.ServiceInterface
+}
+
+func NewHandler(
+	service ServiceInterface,
+	sessionService *sessions.Service,
+	logService logs.ServiceInterface,
+) *Handler {
+	return &Handler{
+		service:        service,
+		sessionService: sessionService,
+		logService:     logService,
+	}
+}
+
+// GetStatus godoc
+// @Summary      Two-factor status
+// @Description  Reports whether two-factor authentication is enabled for the current user and whether their role requires it.
+// @Tags         Auth
+// @Produce      json
+// @Success      200     {object}  StatusDTO
+// @Router       /auth/2fa [get]
+func (h *Handler) GetStatus(c *gin.Context) {
+	status, err := h.service.GetStatus(
+		c.Request.Context(),
+		c.MustGet("userID").(string),
+		c.GetInt("roleID"),
+	)
+	if err != nil {
+		log.Printf("[GetStatus] {Database Query}: %v", err)
+		response.SendError(
+			c,
+			"Failed to fetch two-factor status",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	response.SendSuccess(c, status)
+}
+
+// PostEnroll godoc
+// @Summary      Start two-factor enrollment
+// @Description  Creates a TOTP secret and returns it with a QR code for authenticator apps. It is enabled once confirmed with a code.
+// @Tags         Auth
+// @Produce      json
+// @Success      200     {object}  EnrollmentDTO
+// @Failure      400     {object}  map[string]string
+// @Failure      409     {object}  map[string]string
+// @Router       /auth/2fa/enroll [post]
+func (h *Handler) PostEnroll(c *gin.Context) {
+	if c.GetString("tokenType") != string(constants.AuthTypeNative) {
+		response.SendFail(c, gin.H{
+			"error": "Two-factor authentication applies to password logins only",
+		})
+		return
+	}
+
+	enrollment, err := h.service.BeginEnrollment(
+		c.Request.Context(),
+		c.MustGet("userID").(string),
+		c.GetString("userEmail"),
+	)
+	if errors.Is(err, ErrAlreadyEnabled) {
+		response.SendFail(c, gin.H{"error": err.Error()}, http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("[PostEnroll] {BeginEnrollment}: %v", err)
+		response.SendError(
+			c,
+			"Failed to start two-factor enrollment",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	response.SendSuccess(c, enrollment)
+}
+
+// PostConfirmEnroll godoc
+// @Summary      Confirm two-factor enrollment
+// @Description  Enables two-factor authentication with a code from the authenticator app and returns one-time recovery codes.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body      CodeRequest true "Authenticator Code"
+// @Success      200     {object}  RecoveryCodesDTO
+// @Failure      400     {object}  map[string]string
+// @Failure      409     {object}  map[string]string
+// @Router       /auth/2fa/enroll/confirm [post]
+func (h *Handler) PostConfirmEnroll(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	userEmail := c.GetString("userEmail")
+
+	var req CodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendFail(c, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	codes, err := h.service.ConfirmEnrollment(
+		c.Request.Context(),
+		userID,
+		req.Code,
+	)
+	switch {
+	case errors.Is(err, ErrInvalidCode), errors.Is(err, ErrNotEnrolled):
+		response.SendFail(c, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrAlreadyEnabled):
+		response.SendFail(c, gin.H{"error": err.Error()}, http.StatusConflict)
+		return
+	case err != nil:
+		log.Printf("[PostConfirmEnroll] {ConfirmEnrollment}: %v", err)
+		response.SendError(
+			c,
+			"Failed to enable two-factor authentication",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	// Sessions restricted until enrollment may now use the rest of the API
+	err = h.sessionService.UnsetUserSessionField(
+		c.Request.Context(),
+		userID,
+		constants.SessionTwoFactorSetupRequired,
+	)
+	if err != nil {
+		log.Printf("[PostConfirmEnroll] {Update Sessions}: %v", err)
+	}
+
+	h.recordSecurity(
+		c,
+		audit.ActionTwoFactorEnabled,
+		fmt.Sprintf("User %s enabled two-factor authentication", userEmail),
+	)
+
+	response.SendSuccess(c, RecoveryCodesDTO{RecoveryCodes: codes})
+}
+
+// PostRecoveryCodes godoc
+// @Summary      Regenerate recovery codes
+// @Description  Replaces all recovery codes. Requires a code from the authenticator app.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body      CodeRequest true "Authenticator Code"
+// @Success      200     {object}  RecoveryCodesDTO
+// @Failure      400     {object}  map[string]string
+// @Router       /auth/2fa/recovery-codes [post]
+func (h *Handler) PostRecoveryCodes(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	userEmail := c.GetString("userEmail")
+
+	var req CodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendFail(c, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(
+		c.Request.Context(),
+		userID,
+		req.Code,
+	)
+	switch {
+	case errors.Is(err, ErrInvalidCode):
+		h.recordSecurity(
+			c,
+			audit.ActionTwoFactorFailed,
+			fmt.Sprintf(
+				"Wrong two-factor code from %s while regenerating recovery codes",
+				userEmail,
+			),
+		)
+		response.SendFail(c, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrNotEnabled):
+		response.SendFail(c, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("[PostRecoveryCodes] {RegenerateRecoveryCodes}: %v", err)
+		response.SendError(
+			c,
+			"Failed to regenerate recovery codes",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	h.recordSecurity(
+		c,
+		audit.ActionRecoveryCodesRegenerated,
+		fmt.Sprintf("User %s regenerated their recovery codes", userEmail),
+	)
+
+	response.SendSuccess(c, RecoveryCodesDTO{RecoveryCodes: codes})
+}
+
+// DeleteTwoFactor godoc
+// @Summary      Disable two-factor authentication
+// @Description  Turns two-factor authentication off with an authenticator or recovery code. Not allowed for roles that require it.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body      CodeRequest true "Authenticator or Recovery Code"
+// @Success      200     {object}  map[string]string
+// @Failure      400     {object}  map[string]string
+// @Failure      403     {object}  map[string]string
+// @Router       /auth/2fa [delete]
+func (h *Handler) DeleteTwoFactor(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	userEmail := c.GetString("userEmail")
+
+	var req CodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.SendFail(c, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	err := h.service.Disable(
+		c.Request.Context(),
+		userID,
+		c.GetInt("roleID"),
+		req.Code,
+	)
+	switch {
+	case errors.Is(err, ErrRequired):
+		response.SendFail(c, gin.H{"error": err.Error()}, http.StatusForbidden)
+		return
+	case errors.Is(err, ErrInvalidCode):
+		h.recordSecurity(
+			c,
+			audit.ActionTwoFactorFailed,
+			fmt.Sprintf(
+				"Wrong two-factor code from %s while disabling two-factor authentication",
+				userEmail,
+			),
+		)
+		response.SendFail(c, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrNotEnabled):
+		response.SendFail(c, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("[DeleteTwoFactor] {Disable}: %v", err)
+		response.SendError(
+			c,
+			"Failed to disable two-factor authentication",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	h.recordSecurity(
+		c,
+		audit.ActionTwoFactorDisabled,
+		fmt.Sprintf("User %s disabled two-factor authentication", userEmail),
+	)
+
+	response.SendSuccess(
+		c,
+		gin.H{"message": "Two-factor authentication disabled"},
+	)
+}
+
+func (h *Handler) recordSecurity(c *gin.Context, action, message string) {
+	h.logService.RecordSecurity(
+		c.Request.Context(),
+		h.logService.GetDB(),
+		action,
+		message,
+		structs.StringToNullableString(c.GetString("userEmail")),
+		structs.StringToNullableString(c.GetString("userID")),
+		structs.StringToNullableString(c.ClientIP()),
+		structs.StringToNullableString(c.Request.UserAgent()),
+	)
+}
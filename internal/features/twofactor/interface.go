@@ -0,0 +1,59 @@
+package twofactor
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
+)
+
+// ServiceInterface defines the business logic for two-factor
+// authentication.
+type ServiceInterface interface {
+	IsRequired(roleID int) bool
+	IsEnabled(ctx context.Context, userID string) (bool, error)
+	GetStatus(
+		ctx context.Context,
+		userID string,
+		roleID int,
+	) (*StatusDTO, error)
+	BeginEnrollment(
+		ctx context.Context,
+		userID string,
+		email string,
+	) (*EnrollmentDTO, error)
+	ConfirmEnrollment(
+		ctx context.Context,
+		userID string,
+		code string,
+	) ([]string, error)
+	RegenerateRecoveryCodes(
+		ctx context.Context,
+		userID string,
+		code string,
+	) ([]string, error)
+	Disable(ctx context.Context, userID string, roleID int, code string) error
+	Verify(ctx context.Context, userID string, code string) (bool, error)
+}
+
+// RepositoryInterface defines the data access layer for two-factor
+// authentication.
+type RepositoryInterface interface {
+	GetDB() *sqlx.DB
+	Get(ctx context.Context, userID string) (*TwoFactorModel, error)
+	SavePending(ctx context.Context, userID string, secret string) error
+	Enable(ctx context.Context, tx datastore.DB, userID string) (bool, error)
+	Delete(ctx context.Context, tx datastore.DB, userID string) error
+	ReplaceRecoveryCodes(
+		ctx context.Context,
+		tx datastore.DB,
+		userID string,
+		hashes []string,
+	) error
+	UseRecoveryCode(
+		ctx context.Context,
+		userID string,
+		hash string,
+	) (bool, error)
+	CountRecoveryCodes(ctx context.Context, userID string) (int, error)
+}
@@ -0,0 +1,17 @@
+package twofactor
+
+import (
+	"database/sql"
+	"time"
+)
+
+// TwoFactorModel is a user's TOTP enrollment. EnabledAt is null until the
+// user confirms it with a code from their authenticator app.
+type TwoFactorModel struct {
+	ID        int64        `db:"id"         json:"id"`
+	UserID    string       `db:"user_id"    json:"userId"`
+	Secret    string       `db:"secret"     json:"-"`
+	EnabledAt sql.NullTime `db:"enabled_at" json:"enabledAt"`
+	CreatedAt time.Time    `db:"created_at" json:"createdAt"`
+	UpdatedAt time.Time    `db:"updated_at" json:"updatedAt"`
+}
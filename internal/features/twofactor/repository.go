@@ -0,0 +1,209 @@
+package twofactor
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/encryption"
+)
+
+// secretField names the encrypted column in ciphertexts.
+const secretField = "user_two_factor.secret"
+
+type Repository struct {
+	db     *sqlx.DB
+	cipher *encryption.Cipher
+}
+
+func NewRepository(db *sqlx.DB, cipher *encryption.Cipher) *Repository {
+	return &Repository{db: db, cipher: cipher}
+}
+
+func (r *Repository) GetDB() *sqlx.DB {
+	return r.db
+}
+
+// Get returns the user's enrollment, or nil when they never enrolled.
+func (r *Repository) Get(
+	ctx context.Context,
+	userID string,
+) (*TwoFactorModel, error) {
+	var model TwoFactorModel
+	query := fmt.Sprintf(
+		"SELECT %s FROM user_two_factor WHERE user_id = ?",
+		datastore.GetColumns(TwoFactorModel{}),
+	)
+	err := r.db.GetContext(ctx, &model, query, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch two-factor enrollment: %w", err)
+	}
+
+	model.Secret, err = r.cipher.Decrypt(ctx, secretField, model.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt two-factor secret: %w", err)
+	}
+
+	return &model, nil
+}
+
+// SavePending stores a secret awaiting confirmation. The secret of an
+// enabled enrollment is never replaced.
+func (r *Repository) SavePending(
+	ctx context.Context,
+	userID string,
+	secret string,
+) error {
+	encrypted, err := r.cipher.Encrypt(ctx, secretField, secret)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt two-factor secret: %w", err)
+	}
+
+	_, err = r.db.ExecContext(
+		ctx,
+		`INSERT INTO user_two_factor (user_id, secret)
+		VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE
+			secret = IF(enabled_at IS NULL, VALUES(secret), secret)`,
+		userID,
+		encrypted,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save two-factor secret: %w", err)
+	}
+
+	return nil
+}
+
+// Enable confirms a pending enrollment. It returns false when there is
+// none.
+func (r *Repository) Enable(
+	ctx context.Context,
+	tx datastore.DB,
+	userID string,
+) (bool, error) {
+	res, err := tx.ExecContext(
+		ctx,
+		`UPDATE user_two_factor SET enabled_at = NOW()
+		WHERE user_id = ? AND enabled_at IS NULL`,
+		userID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to enable two-factor: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to enable two-factor: %w", err)
+	}
+
+	return rows == 1, nil
+}
+
+// Delete removes the enrollment and its recovery codes.
+func (r *Repository) Delete(
+	ctx context.Context,
+	tx datastore.DB,
+	userID string,
+) error {
+	_, err := tx.ExecContext(
+		ctx,
+		"DELETE FROM user_recovery_codes WHERE user_id = ?",
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"DELETE FROM user_two_factor WHERE user_id = ?",
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete two-factor enrollment: %w", err)
+	}
+
+	return nil
+}
+
+// ReplaceRecoveryCodes swaps the user's recovery codes for new ones.
+func (r *Repository) ReplaceRecoveryCodes(
+	ctx context.Context,
+	tx datastore.DB,
+	userID string,
+	hashes []string,
+) error {
+	_, err := tx.ExecContext(
+		ctx,
+		"DELETE FROM user_recovery_codes WHERE user_id = ?",
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	for _, hash := range hashes {
+		_, err := tx.ExecContext(
+			ctx,
+			"INSERT INTO user_recovery_codes (user_id, code_hash) VALUES (?, ?)",
+			userID,
+			hash,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save recovery code: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// UseRecoveryCode marks an unused code as used. It returns false when the
+// code is unknown or was already used.
+func (r *Repository) UseRecoveryCode(
+	ctx context.Context,
+	userID string,
+	hash string,
+) (bool, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		`UPDATE user_recovery_codes SET used_at = NOW()
+		WHERE user_id = ? AND code_hash = ? AND used_at IS NULL`,
+		userID,
+		hash,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	return rows == 1, nil
+}
+
+func (r *Repository) CountRecoveryCodes(
+	ctx context.Context,
+	userID string,
+) (int, error) {
+	var count int
+	err := r.db.GetContext(
+		ctx,
+		&count,
+		`SELECT COUNT(*) FROM user_recovery_codes
+		WHERE user_id = ? AND used_at IS NULL`,
+		userID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,24 @@
+package twofactor
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/middleware"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/tokens"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
+)
+
+func RegisterRoutes(
+	rg *gin.RouterGroup,
+	h *Handler,
+	redis *datastore.RedisClient,
+	tokenService *tokens.Service,
+) {
+	twoFactorRoutes := rg.Group("/auth/2fa")
+	twoFactorRoutes.Use(middleware.AuthMiddleware(redis, tokenService))
+
+	twoFactorRoutes.GET("", h.GetStatus)
+	twoFactorRoutes.DELETE("", h.DeleteTwoFactor)
+	twoFactorRoutes.POST("/enroll", h.PostEnroll)
+	twoFactorRoutes.POST("/enroll/confirm", h.PostConfirmEnroll)
+	twoFactorRoutes.POST("/recovery-codes", h.PostRecoveryCodes)
+}
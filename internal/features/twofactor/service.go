@@ -0,0 +1,384 @@
+package twofactor
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+var (
+	ErrAlreadyEnabled = errors.New(
+		"two-factor authentication is already enabled",
+	)
+	ErrNotEnrolled = errors.New("two-factor enrollment has not been started")
+	ErrNotEnabled  = errors.New("two-factor authentication is not enabled")
+	ErrInvalidCode = errors.New("invalid authentication code")
+	ErrRequired    = errors.New(
+		"two-factor authentication is required for your role",
+	)
+)
+
+const (
+	// issuer is the account label shown in authenticator apps
+	issuer = "PUPT-OGOS"
+
+	recoveryCodeCount = 10
+	// recoveryAlphabet leaves out characters that are easy to misread
+	recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+	// totpSkew accepts codes from one period either side of now, so the
+	// window a code stays valid in is three periods
+	totpSkew   = 1
+	totpPeriod = 30
+)
+
+type Service struct {
+	repo          RepositoryInterface
+	redis         *datastore.RedisClient
+	requiredRoles map[int]bool
+}
+
+// NewService creates the two-factor service. Users with one of
+// requiredRoles must enroll before they can use the API beyond /auth.
+func NewService(
+	repo RepositoryInterface,
+	redis *datastore.RedisClient,
+	requiredRoles []int,
+) *Service {
+	required := make(map[int]bool, len(requiredRoles))
+	for _, roleID := range requiredRoles {
+		required[roleID] = true
+	}
+
+	return &Service{repo: repo, redis: redis, requiredRoles: required}
+}
+
+func (s *Service) IsRequired(roleID int) bool {
+	return s.requiredRoles[roleID]
+}
+
+func (s *Service) IsEnabled(ctx context.Context, userID string) (bool, error) {
+	enrollment, err := s.repo.Get(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return enrollment != nil && enrollment.EnabledAt.Valid, nil
+}
+
+func (s *Service) GetStatus(
+	ctx context.Context,
+	userID string,
+	roleID int,
+) (*StatusDTO, error) {
+	status := &StatusDTO{Required: s.IsRequired(roleID)}
+
+	enrollment, err := s.repo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment == nil || !enrollment.EnabledAt.Valid {
+		return status, nil
+	}
+
+	status.Enabled = true
+	status.EnabledAt = &enrollment.EnabledAt.Time
+	status.RecoveryCodesLeft, err = s.repo.CountRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// BeginEnrollment creates a new secret for the user to add to their
+// authenticator app. Starting again replaces an unconfirmed secret.
+func (s *Service) BeginEnrollment(
+	ctx context.Context,
+	userID string,
+	email string,
+) (*EnrollmentDTO, error) {
+	enabled, err := s.IsEnabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrAlreadyEnabled
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      issuer,
+		AccountName: email,
+		Period:      totpPeriod,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate two-factor secret: %w", err)
+	}
+
+	if err := s.repo.SavePending(ctx, userID, key.Secret()); err != nil {
+		return nil, err
+	}
+
+	img, err := key.Image(256, 256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render QR code: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to render QR code: %w", err)
+	}
+
+	return &EnrollmentDTO{
+		Secret:     key.Secret(),
+		OTPAuthURL: key.URL(),
+		QRCode: "data:image/png;base64," +
+			base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}, nil
+}
+
+// ConfirmEnrollment enables two-factor authentication once the user
+// proves their app produces valid codes, and returns their recovery codes.
+func (s *Service) ConfirmEnrollment(
+	ctx context.Context,
+	userID string,
+	code string,
+) ([]string, error) {
+	enrollment, err := s.repo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment == nil {
+		return nil, ErrNotEnrolled
+	}
+	if enrollment.EnabledAt.Valid {
+		return nil, ErrAlreadyEnabled
+	}
+	if !validTOTP(enrollment.Secret, code) {
+		return nil, ErrInvalidCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = datastore.RunInTransaction(
+		ctx,
+		s.repo.GetDB(),
+		func(tx datastore.DB) error {
+			enabled, err := s.repo.Enable(ctx, tx, userID)
+			if err != nil {
+				return err
+			}
+			if !enabled {
+				return ErrAlreadyEnabled
+			}
+
+			return s.repo.ReplaceRecoveryCodes(ctx, tx, userID, hashes)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes. It needs a code
+// from the authenticator app, not a recovery code.
+func (s *Service) RegenerateRecoveryCodes(
+	ctx context.Context,
+	userID string,
+	code string,
+) ([]string, error) {
+	enrollment, err := s.enabledEnrollment(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !validTOTP(enrollment.Secret, code) {
+		return nil, ErrInvalidCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = datastore.RunInTransaction(
+		ctx,
+		s.repo.GetDB(),
+		func(tx datastore.DB) error {
+			return s.repo.ReplaceRecoveryCodes(ctx, tx, userID, hashes)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Disable turns two-factor authentication off. Users whose role requires
+// it cannot.
+func (s *Service) Disable(
+	ctx context.Context,
+	userID string,
+	roleID int,
+	code string,
+) error {
+	if s.IsRequired(roleID) {
+		return ErrRequired
+	}
+
+	if _, err := s.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+
+	return datastore.RunInTransaction(
+		ctx,
+		s.repo.GetDB(),
+		func(tx datastore.DB) error {
+			return s.repo.Delete(ctx, tx, userID)
+		},
+	)
+}
+
+// Verify checks a code from the authenticator app or an unused recovery
+// code, and reports whether a recovery code was used. Each code is
+// accepted only once.
+func (s *Service) Verify(
+	ctx context.Context,
+	userID string,
+	code string,
+) (bool, error) {
+	enrollment, err := s.enabledEnrollment(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	code = strings.TrimSpace(code)
+	if isTOTPCode(code) {
+		if !validTOTP(enrollment.Secret, code) {
+			return false, ErrInvalidCode
+		}
+
+		// A code seen by a shoulder surfer or a phishing proxy must not
+		// work a second time while it is still valid
+		fresh, err := s.redis.Client.SetNX(
+			ctx,
+			constants.RedisTOTPUsedKeyPrefix+userID+":"+code,
+			1,
+			time.Duration((2*totpSkew+1)*totpPeriod)*time.Second,
+		).Result()
+		if err != nil {
+			return false, fmt.Errorf("failed to record used code: %w", err)
+		}
+		if !fresh {
+			return false, ErrInvalidCode
+		}
+
+		return false, nil
+	}
+
+	used, err := s.repo.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+	if err != nil {
+		return false, err
+	}
+	if !used {
+		return false, ErrInvalidCode
+	}
+
+	return true, nil
+}
+
+func (s *Service) enabledEnrollment(
+	ctx context.Context,
+	userID string,
+) (*TwoFactorModel, error) {
+	enrollment, err := s.repo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment == nil || !enrollment.EnabledAt.Valid {
+		return nil, ErrNotEnabled
+	}
+
+	return enrollment, nil
+}
+
+func validTOTP(secret, code string) bool {
+	valid, err := totp.ValidateCustom(
+		strings.TrimSpace(code),
+		secret,
+		time.Now().UTC(),
+		totp.ValidateOpts{
+			Period:    totpPeriod,
+			Skew:      totpSkew,
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		},
+	)
+
+	return err == nil && valid
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != int(otp.DigitsSix) {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newRecoveryCodes returns codes formatted as xxxxx-xxxxx and their
+// hashes.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+
+	raw := make([]byte, 10)
+	for i := range codes {
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+
+		var code strings.Builder
+		for j, b := range raw {
+			if j == 5 {
+				code.WriteByte('-')
+			}
+			code.WriteByte(recoveryAlphabet[int(b)%len(recoveryAlphabet)])
+		}
+		codes[i] = code.String()
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode ignores case and dashes. Codes are random enough that a
+// fast hash is safe, and it lets a code be looked up directly.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(code, "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -1,6 +1,8 @@
 package users
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -223,6 +225,53 @@ func (h *Handler) PostUnblockUser(c *gin.Context) {
 	response.SendSuccess(c, gin.H{"message": "User unblocked successfully"})
 }
 
+// PostUnlockUser godoc
+// @Summary      Unlock user login
+// @Description  Lifts a lockout caused by repeated failed logins.
+// @Tags         Users
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Success      200  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /users/{id}/unlock [post]
+func (h *Handler) PostUnlockUser(c *gin.Context) {
+	userID := c.Param("id")
+
+	userEmail, err := h.service.UnlockUser(c.Request.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		response.SendFail(
+			c,
+			gin.H{"error": "User not found"},
+			http.StatusNotFound,
+		)
+		return
+	}
+	if err != nil {
+		log.Printf("[PostUnlockUser] {UnlockUser}: %v", err)
+		response.SendError(
+			c,
+			"Failed to unlock user",
+			http.StatusInternalServerError,
+			nil,
+		)
+		return
+	}
+
+	adminEmail := c.GetString("userEmail")
+	h.logService.RecordSecurity(
+		c.Request.Context(),
+		h.logService.GetDB(),
+		audit.ActionLoginUnlocked,
+		fmt.Sprintf("%s unlocked logins to %s", adminEmail, userEmail),
+		structs.StringToNullableString(adminEmail),
+		structs.StringToNullableString(c.GetString("userID")),
+		structs.StringToNullableString(c.ClientIP()),
+		structs.StringToNullableString(c.Request.UserAgent()),
+	)
+
+	response.SendSuccess(c, gin.H{"message": "User unlocked successfully"})
+}
+
 func (h *Handler) GetUserSessions(c *gin.Context) {
 	targetUserID := c.Param("id")
 	if targetUserID == "" {
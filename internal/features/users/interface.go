@@ -22,6 +22,7 @@ type ServiceInterface interface {
 	GetRoleDistribution(ctx context.Context) ([]RoleDistributionDTO, error)
 	BlockUser(ctx context.Context, userID string) error
 	UnblockUser(ctx context.Context, userID string) error
+	UnlockUser(ctx context.Context, userID string) (string, error)
 }
 
 type RepositoryInterface interface {
@@ -140,6 +140,17 @@ func (r *Repository) UnblockUser(
 	return err
 }
 
+func (r *Repository) UpdatePassword(
+	ctx context.Context,
+	tx datastore.DB,
+	userID string,
+	passwordHash string,
+) error {
+	query := `UPDATE users SET password_hash = ? WHERE id = ?`
+	_, err := tx.ExecContext(ctx, query, passwordHash, userID)
+	return err
+}
+
 func (r *Repository) GetUserIDsByRole(
 	ctx context.Context,
 	roleID int,
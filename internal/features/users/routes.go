@@ -5,6 +5,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/middleware"
+	"github.com/olazo-johnalbert/duckload-api/internal/core/tokens"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
 )
 
@@ -13,9 +14,10 @@ func RegisterRoutes(
 	rg *gin.RouterGroup,
 	h *Handler,
 	redis *datastore.RedisClient,
+	tokenService *tokens.Service,
 ) {
 	userRoutes := rg.Group("/users")
-	userRoutes.Use(middleware.AuthMiddleware(redis))
+	userRoutes.Use(middleware.AuthMiddleware(redis, tokenService))
 	userRoutes.Use(middleware.RoleMiddleware(
 		int(constants.SuperAdminRoleID),
 		int(constants.AdminRoleID),
@@ -40,6 +42,13 @@ func RegisterRoutes(
 		middleware.RoleMiddleware(int(constants.SuperAdminRoleID)),
 		h.PostUnblockUser,
 	)
+	userRoutes.POST("/:id/unlock",
+		middleware.RoleMiddleware(
+			int(constants.SuperAdminRoleID),
+			int(constants.AdminRoleID),
+		),
+		h.PostUnlockUser,
+	)
 
 	// Session & Activity Audit (Super Admin only)
 	userRoutes.GET("/:id/sessions",
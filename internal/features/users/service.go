@@ -3,17 +3,22 @@ package users
 import (
 	"context"
 
+	"github.com/olazo-johnalbert/duckload-api/internal/core/lockout"
 	"github.com/olazo-johnalbert/duckload-api/internal/core/structs"
 	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/datastore"
 )
 
 type Service struct {
-	repo RepositoryInterface
+	repo    RepositoryInterface
+	lockout *lockout.Service
 }
 
 // NewService creates a new users service.
-func NewService(repo RepositoryInterface) *Service {
-	return &Service{repo: repo}
+func NewService(
+	repo RepositoryInterface,
+	lockoutService *lockout.Service,
+) *Service {
+	return &Service{repo: repo, lockout: lockoutService}
 }
 
 // GetUserByID retrieves a user by their ID.
@@ -125,3 +130,17 @@ func (s *Service) UnblockUser(ctx context.Context, userID string) error {
 		},
 	)
 }
+
+// UnlockUser lifts a lockout left by failed logins and returns the user's
+// email. Lockouts of client IPs expire on their own.
+func (s *Service) UnlockUser(
+	ctx context.Context,
+	userID string,
+) (string, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	return user.Email, s.lockout.Unlock(ctx, user.Email)
+}
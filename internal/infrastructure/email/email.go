@@ -2,7 +2,19 @@ package email
 
 import "context"
 
+// Attachment is a file sent along with an email.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
 type Emailer interface {
 	SendEmail(ctx context.Context, to, subject, body string) (bool, error)
+	SendEmailWithAttachments(
+		ctx context.Context,
+		to, subject, body string,
+		attachments []Attachment,
+	) (bool, error)
 	SendOTP(ctx context.Context, to, otp string) (bool, error)
 }
@@ -1,10 +1,14 @@
 package email
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"fmt"
+	"mime/multipart"
 	"net"
 	"net/smtp"
+	"net/textproto"
 
 	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
 )
@@ -37,7 +41,76 @@ func (m *MailPit) SendEmail(
 		"\r\n" +
 		body
 
-	err := smtp.SendMail(addr, nil, from, []string{to}, []byte(msg))
+	return m.send(addr, from, to, []byte(msg))
+}
+
+// SendEmailWithAttachments sends the HTML body and attachments as a
+// multipart/mixed message.
+func (m *MailPit) SendEmailWithAttachments(
+	ctx context.Context,
+	to, subject, body string,
+	attachments []Attachment,
+) (bool, error) {
+	addr := fmt.Sprintf("%s:%d", m.host, m.port)
+	from := constants.FromEmail()
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	part, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"text/html; charset=\"UTF-8\""},
+	})
+	if err != nil {
+		return false, fmt.Errorf("[MailPit] Build Message: %w", err)
+	}
+	if _, err := part.Write([]byte(body)); err != nil {
+		return false, fmt.Errorf("[MailPit] Build Message: %w", err)
+	}
+
+	for _, a := range attachments {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {a.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition": {
+				fmt.Sprintf("attachment; filename=%q", a.Filename),
+			},
+		})
+		if err != nil {
+			return false, fmt.Errorf("[MailPit] Build Message: %w", err)
+		}
+		// RFC 2045 limits encoded lines to 76 characters
+		encoded := base64.StdEncoding.EncodeToString(a.Content)
+		for len(encoded) > 76 {
+			if _, err := part.Write([]byte(encoded[:76] + "\r\n")); err != nil {
+				return false, fmt.Errorf("[MailPit] Build Message: %w", err)
+			}
+			encoded = encoded[76:]
+		}
+		if _, err := part.Write([]byte(encoded)); err != nil {
+			return false, fmt.Errorf("[MailPit] Build Message: %w", err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return false, fmt.Errorf("[MailPit] Build Message: %w", err)
+	}
+
+	msg := fmt.Sprintf("To: %s\r\n", to) +
+		fmt.Sprintf("From: %s\r\n", from) +
+		fmt.Sprintf("Subject: %s\r\n", subject) +
+		"MIME-version: 1.0\r\n" +
+		fmt.Sprintf(
+			"Content-Type: multipart/mixed; boundary=%q\r\n",
+			writer.Boundary(),
+		) +
+		"\r\n" +
+		buf.String()
+
+	return m.send(addr, from, to, []byte(msg))
+}
+
+func (m *MailPit) send(addr, from, to string, msg []byte) (bool, error) {
+	err := smtp.SendMail(addr, nil, from, []string{to}, msg)
 	if err != nil {
 		// Check for Network Timeouts/Connection errors
 		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
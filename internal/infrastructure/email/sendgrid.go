@@ -2,6 +2,7 @@ package email
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 
 	"github.com/olazo-johnalbert/duckload-api/internal/core/constants"
@@ -29,6 +30,32 @@ func (s *SendGrid) SendEmail(
 
 	message := mail.NewSingleEmail(fromAddress, subject, toAddress, "", body)
 
+	return s.send(message)
+}
+
+func (s *SendGrid) SendEmailWithAttachments(
+	ctx context.Context,
+	to, subject, body string,
+	attachments []Attachment,
+) (bool, error) {
+	from := constants.FromEmail()
+	fromAddress := mail.NewEmail("PUPT-OGOS", from)
+	toAddress := mail.NewEmail("", to)
+
+	message := mail.NewSingleEmail(fromAddress, subject, toAddress, "", body)
+	for _, a := range attachments {
+		attachment := mail.NewAttachment()
+		attachment.SetContent(base64.StdEncoding.EncodeToString(a.Content))
+		attachment.SetType(a.ContentType)
+		attachment.SetFilename(a.Filename)
+		attachment.SetDisposition("attachment")
+		message.AddAttachment(attachment)
+	}
+
+	return s.send(message)
+}
+
+func (s *SendGrid) send(message *mail.SGMailV3) (bool, error) {
 	response, err := s.client.Send(message)
 	if err != nil {
 		return false, fmt.Errorf("[SendGrid] Network Error: %w", err)
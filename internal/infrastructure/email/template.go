@@ -110,3 +110,123 @@ func OTP_TEMPLATE(otp string) string {
 </html>
 `
 }
+
+func WAITLIST_OFFER_TEMPLATE(name, date, time, category, expiresAt string) string {
+	return `
+	<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Appointment Slot Available</title>
+</head>
+<body style="font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; background-color: #f4f4f9; margin: 0; padding: 40px 0;">
+    <div style="background-color: #ffffff; padding: 40px; border-radius: 12px; max-width: 480px; margin: 0 auto; border-top: 8px solid #630b0b;">
+        <div style="font-size: 20px; font-weight: 600; color: #2c3e50;">PUPT-OGOS</div>
+        <p style="color: #2c3e50;">Hi ` + name + `,</p>
+        <p style="color: #2c3e50;">A guidance appointment slot you were waiting for has opened up and is being held for you.</p>
+        <table style="margin: 20px 0; color: #2c3e50;">
+            <tr><td style="padding-right: 16px;"><strong>Date</strong></td><td>` + date + `</td></tr>
+            <tr><td style="padding-right: 16px;"><strong>Time</strong></td><td>` + time + `</td></tr>
+            <tr><td style="padding-right: 16px;"><strong>Category</strong></td><td>` + category + `</td></tr>
+        </table>
+        <p style="color: #2c3e50;">Confirm it in the portal before <strong>` + expiresAt + `</strong>, otherwise it will be offered to the next student.</p>
+        <p style="color: #95a5a6; font-size: 12px; border-top: 1px solid #e9ecef; padding-top: 20px;">
+            You can leave the waitlist at any time through the portal.
+        </p>
+    </div>
+</body>
+</html>
+`
+}
+
+func NOTIFICATION_TEMPLATE(name, title, message string) string {
+	return `
+	<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>` + title + `</title>
+</head>
+<body style="font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; background-color: #f4f4f9; margin: 0; padding: 40px 0;">
+    <div style="background-color: #ffffff; padding: 40px; border-radius: 12px; max-width: 480px; margin: 0 auto; border-top: 8px solid #630b0b;">
+        <div style="font-size: 20px; font-weight: 600; color: #2c3e50;">PUPT-OGOS</div>
+        <p style="color: #2c3e50;">Hi ` + name + `,</p>
+        <p style="color: #2c3e50;"><strong>` + title + `</strong></p>
+        <p style="color: #2c3e50;">` + message + `</p>
+        <p style="color: #95a5a6; font-size: 12px; border-top: 1px solid #e9ecef; padding-top: 20px;">
+            You can change which notifications are emailed to you in the portal.
+        </p>
+    </div>
+</body>
+</html>
+`
+}
+
+// DigestItem is one notification listed in a digest email.
+type DigestItem struct {
+	Title   string
+	Message string
+	Time    string
+}
+
+func NOTIFICATION_DIGEST_TEMPLATE(name string, items []DigestItem) string {
+	rows := ""
+	for _, item := range items {
+		rows += `
+            <tr><td style="padding: 12px 0; border-bottom: 1px solid #e9ecef;">
+                <div style="font-weight: 600;">` + item.Title + `</div>
+                <div>` + item.Message + `</div>
+                <div style="color: #95a5a6; font-size: 12px;">` + item.Time + `</div>
+            </td></tr>`
+	}
+
+	return `
+	<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Your Daily Notification Digest</title>
+</head>
+<body style="font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; background-color: #f4f4f9; margin: 0; padding: 40px 0;">
+    <div style="background-color: #ffffff; padding: 40px; border-radius: 12px; max-width: 480px; margin: 0 auto; border-top: 8px solid #630b0b;">
+        <div style="font-size: 20px; font-weight: 600; color: #2c3e50;">PUPT-OGOS</div>
+        <p style="color: #2c3e50;">Hi ` + name + `,</p>
+        <p style="color: #2c3e50;">Here is what you have not read since your last digest.</p>
+        <table style="width: 100%; margin: 20px 0; color: #2c3e50; border-collapse: collapse;">` + rows + `
+        </table>
+        <p style="color: #95a5a6; font-size: 12px; border-top: 1px solid #e9ecef; padding-top: 20px;">
+            You can change which notifications are included in the portal.
+        </p>
+    </div>
+</body>
+</html>
+`
+}
+
+func PASSWORD_RESET_TEMPLATE(name, otp, validFor string) string {
+	return `
+	<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Password Reset Code</title>
+</head>
+<body style="font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; background-color: #f4f4f9; margin: 0; padding: 40px 0;">
+    <div style="background-color: #ffffff; padding: 40px; border-radius: 12px; max-width: 480px; margin: 0 auto; border-top: 8px solid #630b0b;">
+        <div style="font-size: 20px; font-weight: 600; color: #2c3e50;">PUPT-OGOS</div>
+        <p style="color: #2c3e50;">Hi ` + name + `,</p>
+        <p style="color: #2c3e50;">We received a request to reset your password. Enter this code to choose a new one:</p>
+        <div style="font-size: 32px; font-weight: 700; letter-spacing: 8px; color: #630b0b; margin: 24px 0; text-align: center;">` + otp + `</div>
+        <p style="color: #2c3e50;">The code expires in ` + validFor + `. Resetting your password signs you out on every device.</p>
+        <p style="color: #95a5a6; font-size: 12px; border-top: 1px solid #e9ecef; padding-top: 20px;">
+            If you did not request a password reset, you can ignore this email.
+        </p>
+    </div>
+</body>
+</html>
+`
+}
@@ -0,0 +1,191 @@
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// prefix marks encrypted column values. Values without it are plaintext
+// written before encryption was enabled and are returned as they are.
+const prefix = "enc:v1:"
+
+var ErrMalformedValue = errors.New("malformed encrypted value")
+
+// Cipher encrypts column values with envelope encryption: every value gets
+// its own AES-256-GCM data key, which is stored next to it wrapped by the
+// provider's active key. Rotating keys only changes which key wraps new
+// data keys.
+//
+// Stored values look like enc:v1:<key id>:<wrapped data key>:<ciphertext>.
+// field, e.g. "significant_notes.note", is authenticated with the value so
+// ciphertext copied into another column does not decrypt.
+type Cipher struct {
+	provider KeyProvider
+}
+
+func NewCipher(provider KeyProvider) *Cipher {
+	return &Cipher{provider: provider}
+}
+
+// Encrypt seals plaintext for field. Empty strings are stored as they are.
+func (c *Cipher) Encrypt(
+	ctx context.Context,
+	field string,
+	plaintext string,
+) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(field))
+
+	keyID, wrapped, err := c.provider.WrapKey(ctx, dataKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	return prefix + strings.Join([]string{
+		keyID,
+		base64.RawStdEncoding.EncodeToString(wrapped),
+		base64.RawStdEncoding.EncodeToString(sealed),
+	}, ":"), nil
+}
+
+// Decrypt opens a value sealed for field. Plaintext values are returned
+// unchanged so rows can be encrypted gradually.
+func (c *Cipher) Decrypt(
+	ctx context.Context,
+	field string,
+	value string,
+) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	keyID, wrapped, sealed, err := parse(value)
+	if err != nil {
+		return "", err
+	}
+
+	dataKey, err := c.provider.UnwrapKey(ctx, keyID, wrapped)
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", ErrMalformedValue
+	}
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, []byte(field))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt %s: %w", field, err)
+	}
+
+	return string(plaintext), nil
+}
+
+func (c *Cipher) EncryptNullString(
+	ctx context.Context,
+	field string,
+	value sql.NullString,
+) (sql.NullString, error) {
+	if !value.Valid {
+		return value, nil
+	}
+
+	encrypted, err := c.Encrypt(ctx, field, value.String)
+	return sql.NullString{String: encrypted, Valid: true}, err
+}
+
+func (c *Cipher) DecryptNullString(
+	ctx context.Context,
+	field string,
+	value sql.NullString,
+) (sql.NullString, error) {
+	if !value.Valid {
+		return value, nil
+	}
+
+	decrypted, err := c.Decrypt(ctx, field, value.String)
+	return sql.NullString{String: decrypted, Valid: true}, err
+}
+
+// NeedsReencrypt reports whether a stored value is still plaintext or was
+// wrapped with a key other than the active one.
+func (c *Cipher) NeedsReencrypt(
+	ctx context.Context,
+	value string,
+) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	if !IsEncrypted(value) {
+		return true, nil
+	}
+
+	keyID, _, _, err := parse(value)
+	if err != nil {
+		return false, err
+	}
+	active, err := c.provider.ActiveKeyID(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return keyID != active, nil
+}
+
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+func parse(value string) (string, []byte, []byte, error) {
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return "", nil, nil, ErrMalformedValue
+	}
+
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, nil, ErrMalformedValue
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, nil, ErrMalformedValue
+	}
+
+	return parts[0], wrapped, sealed, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data key: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,310 @@
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultKeysFile is where the key file is kept when ENCRYPTION_KEYS_FILE
+// is not set.
+const DefaultKeysFile = "keys/field-encryption.json"
+
+const (
+	keySize = 32
+	// reloadInterval bounds how long a running server keeps wrapping with
+	// a key after the file names a new active key
+	reloadInterval = 30 * time.Second
+)
+
+// keyFile is the JSON layout of the local key file:
+//
+//	{"active": "k20260101000000", "keys": {"k20260101000000": "<base64>"}}
+type keyFile struct {
+	Active string            `json:"active"`
+	Keys   map[string]string `json:"keys"`
+}
+
+// FileKeyProvider keeps key-encryption keys in a local JSON file. The file
+// is reloaded when it changes, so a key added by `rotate` is picked up by
+// running servers without a restart.
+type FileKeyProvider struct {
+	path string
+
+	mu        sync.RWMutex
+	active    string
+	keys      map[string]cipher.AEAD
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func NewFileKeyProvider(path string) (*FileKeyProvider, error) {
+	p := &FileKeyProvider{path: path}
+	if err := p.load(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *FileKeyProvider) ActiveKeyID(ctx context.Context) (string, error) {
+	p.refresh(false)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.active, nil
+}
+
+func (p *FileKeyProvider) WrapKey(
+	ctx context.Context,
+	dataKey []byte,
+) (string, []byte, error) {
+	p.refresh(false)
+
+	p.mu.RLock()
+	keyID, aead := p.active, p.keys[p.active]
+	p.mu.RUnlock()
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	// The key ID is authenticated so a wrapped key cannot be relabelled
+	return keyID, aead.Seal(nonce, nonce, dataKey, []byte(keyID)), nil
+}
+
+func (p *FileKeyProvider) UnwrapKey(
+	ctx context.Context,
+	keyID string,
+	wrapped []byte,
+) ([]byte, error) {
+	p.refresh(false)
+
+	p.mu.RLock()
+	aead, ok := p.keys[keyID]
+	p.mu.RUnlock()
+
+	// Another server may have started using a key this one has not loaded
+	if !ok {
+		p.refresh(true)
+
+		p.mu.RLock()
+		aead, ok = p.keys[keyID]
+		p.mu.RUnlock()
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+
+	if len(wrapped) < aead.NonceSize() {
+		return nil, errors.New("wrapped key is too short")
+	}
+	nonce, sealed := wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():]
+	dataKey, err := aead.Open(nil, nonce, sealed, []byte(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+
+	return dataKey, nil
+}
+
+// refresh reloads the key file when it changed. Unless forced, the file is
+// checked at most once per reloadInterval. A file that fails to load keeps
+// the previous keys in use.
+func (p *FileKeyProvider) refresh(force bool) {
+	p.mu.RLock()
+	due := force || time.Since(p.checkedAt) >= reloadInterval
+	p.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	info, err := os.Stat(p.path)
+
+	p.mu.Lock()
+	p.checkedAt = time.Now()
+	changed := err == nil && !info.ModTime().Equal(p.modTime)
+	p.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	if err := p.load(); err != nil {
+		log.Printf("[FileKeyProvider] {Reload Keys}: %v", err)
+	}
+}
+
+func (p *FileKeyProvider) load() error {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	file, err := readKeyFile(p.path)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]cipher.AEAD, len(file.Keys))
+	for id, encoded := range file.Keys {
+		aead, err := newKeyAEAD(id, encoded)
+		if err != nil {
+			return err
+		}
+		keys[id] = aead
+	}
+	if _, ok := keys[file.Active]; !ok {
+		return fmt.Errorf("active key %q is not in the key file", file.Active)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.active = file.Active
+	p.keys = keys
+	p.modTime = info.ModTime()
+	p.checkedAt = time.Now()
+
+	return nil
+}
+
+func newKeyAEAD(id string, encoded string) (cipher.AEAD, error) {
+	// Key IDs are stored inside encrypted values, which use ':' as the
+	// separator
+	if id == "" || strings.Contains(id, ":") {
+		return nil, fmt.Errorf("invalid key id %q", id)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != keySize {
+		return nil, fmt.Errorf("key %q must be %d base64-encoded bytes", id, keySize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func readKeyFile(path string) (*keyFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	var file keyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse key file: %w", err)
+	}
+
+	return &file, nil
+}
+
+// AddKey generates a new key, stores it in the key file and makes it the
+// active one. Older keys are kept so existing values stay readable until
+// they are re-encrypted. The file is created when it does not exist.
+func AddKey(path string) (string, error) {
+	file, err := readKeyFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		file, err = &keyFile{}, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if file.Keys == nil {
+		file.Keys = map[string]string{}
+	}
+
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	id := "k" + time.Now().UTC().Format("20060102150405")
+	if _, exists := file.Keys[id]; exists {
+		return "", fmt.Errorf("key %q already exists", id)
+	}
+	file.Keys[id] = base64.StdEncoding.EncodeToString(key)
+	file.Active = id
+
+	if err := writeKeyFile(path, file); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// RemoveKey drops a retired key from the key file. The active key cannot
+// be removed.
+func RemoveKey(path string, id string) error {
+	file, err := readKeyFile(path)
+	if err != nil {
+		return err
+	}
+	if id == file.Active {
+		return fmt.Errorf("key %q is the active key", id)
+	}
+	if _, ok := file.Keys[id]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+	delete(file.Keys, id)
+
+	return writeKeyFile(path, file)
+}
+
+// EnsureKeyFile creates a key file with a fresh key when none exists yet.
+// It is meant for local development; production keys are provisioned.
+func EnsureKeyFile(path string) error {
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	_, err := AddKey(path)
+	return err
+}
+
+// writeKeyFile replaces the key file in one rename so servers reloading it
+// never read a partial file.
+func writeKeyFile(path string, file *keyFile) error {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create key directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".keys-*")
+	if err != nil {
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o600); err != nil {
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
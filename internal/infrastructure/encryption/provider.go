@@ -0,0 +1,22 @@
+package encryption
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrUnknownKey = errors.New("unknown encryption key")
+
+// KeyProvider holds the key-encryption keys that wrap the per-value data
+// keys. Several keys can be known at once so values written before a
+// rotation stay readable.
+// Implementations: FileKeyProvider (local key file).
+type KeyProvider interface {
+	// ActiveKeyID names the key new data keys are wrapped with.
+	ActiveKeyID(ctx context.Context) (string, error)
+	// WrapKey encrypts a data key with the active key and reports which
+	// key was used.
+	WrapKey(ctx context.Context, dataKey []byte) (string, []byte, error)
+	// UnwrapKey decrypts a data key wrapped with keyID.
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
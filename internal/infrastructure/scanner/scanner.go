@@ -0,0 +1,19 @@
+package scanner
+
+import (
+	"context"
+	"io"
+)
+
+// Result is the verdict of a malware scan.
+type Result struct {
+	Infected bool
+	// Signature names the threat found; empty when the file is clean.
+	Signature string
+}
+
+// Scanner checks file contents for malware.
+// Implementations: ClamAV (prod), Stub (dev and tests).
+type Scanner interface {
+	Scan(ctx context.Context, reader io.Reader) (*Result, error)
+}
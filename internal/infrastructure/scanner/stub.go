@@ -0,0 +1,33 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+)
+
+// eicarSignature is the industry-standard antivirus test string.
+const eicarSignature = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// Stub stands in for a real scanner during local development. It only
+// flags files containing the EICAR test string, so the quarantine flow can
+// be exercised without running clamd.
+type Stub struct{}
+
+func NewStub() *Stub {
+	return &Stub{}
+}
+
+func (s *Stub) Scan(ctx context.Context, reader io.Reader) (*Result, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	if bytes.Contains(data, []byte(eicarSignature)) {
+		return &Result{Infected: true, Signature: "Eicar-Test-Signature"}, nil
+	}
+
+	return &Result{}, nil
+}
@@ -2,16 +2,29 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"strings"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
 )
 
+// sasClockSkew backdates SAS start times so clients whose clocks run
+// slightly behind can still use a fresh URL.
+const sasClockSkew = 5 * time.Minute
+
 type BlobStorage struct {
 	client        *azblob.Client
 	containerName string
+	// credential signs SAS tokens; nil when the connection string has no
+	// account key.
+	credential *azblob.SharedKeyCredential
 }
 
 func NewBlobStorage(
@@ -22,12 +35,44 @@ func NewBlobStorage(
 		return nil, fmt.Errorf("failed to create Azure Blob client: %w", err)
 	}
 
+	credential, err := sharedKeyFromConnectionString(connectionString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azure credential: %w", err)
+	}
+
 	return &BlobStorage{
 		client:        client,
 		containerName: containerName,
+		credential:    credential,
 	}, nil
 }
 
+// sharedKeyFromConnectionString reads AccountName and AccountKey from a
+// connection string. It returns nil when either is missing, e.g. for
+// connection strings that carry a SAS token instead.
+func sharedKeyFromConnectionString(
+	connectionString string,
+) (*azblob.SharedKeyCredential, error) {
+	var accountName, accountKey string
+	for _, part := range strings.Split(connectionString, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "AccountName":
+			accountName = value
+		case "AccountKey":
+			accountKey = value
+		}
+	}
+	if accountName == "" || accountKey == "" {
+		return nil, nil
+	}
+
+	return azblob.NewSharedKeyCredential(accountName, accountKey)
+}
+
 // EnsureContainer creates the container if it does not already exist.
 func (b *BlobStorage) EnsureContainer(ctx context.Context) error {
 	_, err := b.client.CreateContainer(ctx, b.containerName, nil)
@@ -69,6 +114,9 @@ func (b *BlobStorage) Download(
 	writer io.Writer,
 ) error {
 	resp, err := b.client.DownloadStream(ctx, b.containerName, blobPath, nil)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return fmt.Errorf("failed to download blob %q: %w", blobPath, ErrNotFound)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to download blob %q: %w", blobPath, err)
 	}
@@ -102,6 +150,47 @@ func (b *BlobStorage) GetContentType(
 	return "application/octet-stream", nil
 }
 
+// SignedURL returns a read-only SAS URL for the blob.
+func (b *BlobStorage) SignedURL(
+	ctx context.Context,
+	blobPath string,
+	opts SignedURLOptions,
+) (string, error) {
+	if b.credential == nil {
+		return "", errors.New(
+			"signed URLs require an account key in the storage connection string",
+		)
+	}
+
+	now := time.Now().UTC()
+	values := sas.BlobSignatureValues{
+		Protocol:      sas.ProtocolHTTPS,
+		StartTime:     now.Add(-sasClockSkew),
+		ExpiryTime:    now.Add(opts.Expiry),
+		Permissions:   (&sas.BlobPermissions{Read: true}).String(),
+		ContainerName: b.containerName,
+		BlobName:      blobPath,
+	}
+	if opts.FileName != "" {
+		values.ContentDisposition = mime.FormatMediaType(
+			"attachment",
+			map[string]string{"filename": opts.FileName},
+		)
+	}
+
+	params, err := values.SignWithSharedKey(b.credential)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign blob %q: %w", blobPath, err)
+	}
+
+	blobURL := b.client.ServiceClient().
+		NewContainerClient(b.containerName).
+		NewBlobClient(blobPath).
+		URL()
+
+	return blobURL + "?" + params.Encode(), nil
+}
+
 // Delete removes the blob from the container.
 func (b *BlobStorage) Delete(ctx context.Context, blobPath string) error {
 	_, err := b.client.DeleteBlob(ctx, b.containerName, blobPath, nil)
@@ -2,19 +2,36 @@ package storage
 
 import (
 	"context"
+	"crypto/hmac"
 	"fmt"
 	"io"
+	"mime"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olazo-johnalbert/duckload-api/internal/core/hash"
 )
 
 // DiskStorage implements FileStorage using the local filesystem.
 type DiskStorage struct {
 	baseDir string
+	// baseURL is where ServeHTTP is mounted, e.g.
+	// http://localhost:8080/api/v1/files.
+	baseURL    string
+	signingKey string
 }
 
-func NewDiskStorage(baseDir string) *DiskStorage {
-	return &DiskStorage{baseDir: baseDir}
+func NewDiskStorage(baseDir, baseURL, signingKey string) *DiskStorage {
+	return &DiskStorage{
+		baseDir:    baseDir,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		signingKey: signingKey,
+	}
 }
 
 func (d *DiskStorage) Upload(
@@ -50,6 +67,9 @@ func (d *DiskStorage) Download(
 	fullPath := filepath.Join(d.baseDir, filepath.FromSlash(path))
 
 	f, err := os.Open(fullPath)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("failed to open file: %w", ErrNotFound)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to open file: %w", err)
 	}
@@ -69,3 +89,72 @@ func (d *DiskStorage) Delete(ctx context.Context, path string) error {
 	}
 	return nil
 }
+
+// SignedURL returns a URL to ServeHTTP signed with HMAC-SHA256 over the
+// path, expiry and download name.
+func (d *DiskStorage) SignedURL(
+	ctx context.Context,
+	path string,
+	opts SignedURLOptions,
+) (string, error) {
+	path = strings.TrimPrefix(path, "/")
+	expires := time.Now().Add(opts.Expiry).Unix()
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	if opts.FileName != "" {
+		query.Set("name", opts.FileName)
+	}
+	query.Set("signature", d.sign(path, expires, opts.FileName))
+
+	escaped := (&url.URL{Path: path}).EscapedPath()
+
+	return d.baseURL + "/" + escaped + "?" + query.Encode(), nil
+}
+
+// ServeHTTP serves files behind URLs made by SignedURL. Mount it with the
+// route prefix stripped so the request path is the storage path.
+func (d *DiskStorage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	query := r.URL.Query()
+
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		http.Error(w, "link expired", http.StatusForbidden)
+		return
+	}
+
+	name := query.Get("name")
+	expected := d.sign(path, expires, name)
+	if !hmac.Equal([]byte(expected), []byte(query.Get("signature"))) {
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+
+	fullPath := filepath.Join(d.baseDir, filepath.FromSlash(path))
+	rel, err := filepath.Rel(d.baseDir, fullPath)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		http.Error(w, "invalid path", http.StatusBadRequest)
+		return
+	}
+
+	if name != "" {
+		w.Header().Set(
+			"Content-Disposition",
+			mime.FormatMediaType(
+				"attachment",
+				map[string]string{"filename": name},
+			),
+		)
+	}
+	w.Header().Set("Cache-Control", "private, no-store")
+
+	http.ServeFile(w, r, fullPath)
+}
+
+func (d *DiskStorage) sign(path string, expires int64, name string) string {
+	return hash.GetHMACSHA256(
+		d.signingKey,
+		fmt.Sprintf("%s|%d|%s", path, expires, name),
+	)
+}
@@ -2,9 +2,14 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"io"
+	"time"
 )
 
+// ErrNotFound is returned by Download when the file does not exist.
+var ErrNotFound = errors.New("file not found")
+
 // FileStorage defines the interface for file upload/download operations.
 // Implementations: azure.BlobStorage (prod), local.DiskStorage (dev).
 type FileStorage interface {
@@ -16,4 +21,19 @@ type FileStorage interface {
 	) error
 	Download(ctx context.Context, path string, writer io.Writer) error
 	Delete(ctx context.Context, path string) error
+	// SignedURL returns a short-lived URL that lets the holder download the
+	// file directly, without going through the API.
+	SignedURL(
+		ctx context.Context,
+		path string,
+		opts SignedURLOptions,
+	) (string, error)
+}
+
+// SignedURLOptions controls a URL returned by SignedURL.
+type SignedURLOptions struct {
+	// Expiry is how long the URL stays valid.
+	Expiry time.Duration
+	// FileName, when set, makes browsers save the file under this name.
+	FileName string
 }
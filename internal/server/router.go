@@ -15,6 +15,8 @@ import (
 	"github.com/olazo-johnalbert/duckload-api/internal/features/analytics"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/appointments"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/auth"
+	"github.com/olazo-johnalbert/duckload-api/internal/features/calendar"
+	"github.com/olazo-johnalbert/duckload-api/internal/features/counselors"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/locations"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/logs"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/m2mclients"
@@ -23,6 +25,7 @@ import (
 	"github.com/olazo-johnalbert/duckload-api/internal/features/slips"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/students"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/students/integrations"
+	"github.com/olazo-johnalbert/duckload-api/internal/features/twofactor"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/users"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -86,6 +89,9 @@ func NewRouter(
 	limiter := middleware.NewIPRateLimiter(5, 30)
 	g.Use(middleware.RateLimitMiddleware(limiter))
 
+	// Partners verify our tokens against these public keys
+	g.GET("/.well-known/jwks.json", handlers.AuthHandler.GetJWKS)
+
 	apiV1Routes := g.Group("/api/v1")
 
 	apiV1Routes.GET("/docs/internal/*any", func(c *gin.Context) {
@@ -101,8 +107,12 @@ func NewRouter(
 		)(c)
 	})
 
-	if !cfg.IsProduction {
-		apiV1Routes.Static("./uploads", "./uploads")
+	// Downloads from local storage are authorized by the URL signature, so
+	// the route sits outside the auth middleware.
+	if handlers.LocalFiles != nil {
+		apiV1Routes.GET("/files/*path", gin.WrapH(
+			http.StripPrefix("/api/v1/files", handlers.LocalFiles),
+		))
 	}
 
 	apiV1Routes.GET("/", func(c *gin.Context) {
@@ -115,8 +125,25 @@ func NewRouter(
 		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
 	})
 
-	auth.RegisterRoutes(apiV1Routes, handlers.AuthHandler, handlers.Redis)
-	users.RegisterRoutes(db, apiV1Routes, handlers.UserHandler, handlers.Redis)
+	auth.RegisterRoutes(
+		apiV1Routes,
+		handlers.AuthHandler,
+		handlers.Redis,
+		handlers.Tokens,
+	)
+	twofactor.RegisterRoutes(
+		apiV1Routes,
+		handlers.TwoFactorHandler,
+		handlers.Redis,
+		handlers.Tokens,
+	)
+	users.RegisterRoutes(
+		db,
+		apiV1Routes,
+		handlers.UserHandler,
+		handlers.Redis,
+		handlers.Tokens,
+	)
 	locations.RegisterRoutes(
 		apiV1Routes,
 		handlers.LocationsHandler,
@@ -127,37 +154,72 @@ func NewRouter(
 		apiV1Routes,
 		handlers.StudentHandler,
 		handlers.Redis,
+		handlers.Tokens,
 	)
 	appointments.RegisterRoutes(
 		db,
 		apiV1Routes,
 		handlers.AppointmentHandler,
 		handlers.Redis,
+		handlers.Tokens,
+	)
+	counselors.RegisterRoutes(
+		apiV1Routes,
+		handlers.CounselorHandler,
+		handlers.Redis,
+		handlers.Tokens,
+	)
+	calendar.RegisterRoutes(
+		apiV1Routes,
+		handlers.CalendarHandler,
+		handlers.Redis,
+		handlers.Tokens,
+	)
+	slips.RegisterRoutes(
+		db,
+		apiV1Routes,
+		handlers.SlipHandler,
+		handlers.Redis,
+		handlers.Tokens,
 	)
-	slips.RegisterRoutes(db, apiV1Routes, handlers.SlipHandler, handlers.Redis)
 	analytics.RegisterRoutes(
 		apiV1Routes,
 		handlers.AnalyticsHandler,
 		handlers.Redis,
+		handlers.Tokens,
 	)
 	m2mclients.RegisterRoutes(
 		apiV1Routes,
 		handlers.M2MClientHandler,
 		handlers.Redis,
+		handlers.Tokens,
 	)
 	notifications.RegisterRoutes(
 		db,
 		apiV1Routes,
 		handlers.NotificationsHandler,
 		handlers.Redis,
+		handlers.Tokens,
+	)
+	logs.RegisterRoutes(
+		apiV1Routes,
+		handlers.SystemLogHandler,
+		handlers.Redis,
+		handlers.Tokens,
+	)
+	notes.RegisterRoutes(
+		db,
+		apiV1Routes,
+		handlers.NoteHandler,
+		handlers.Redis,
+		handlers.Tokens,
 	)
-	logs.RegisterRoutes(apiV1Routes, handlers.SystemLogHandler, handlers.Redis)
-	notes.RegisterRoutes(db, apiV1Routes, handlers.NoteHandler, handlers.Redis)
 
 	integrations.RegisterRoutes(
 		apiV1Routes,
 		handlers.IntegrationStudentHandler,
 		handlers.Redis,
+		handlers.Tokens,
 	)
 	return g
 }
@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"os"
 	"sync"
 	"time"
 
@@ -17,6 +18,7 @@ import (
 	"github.com/olazo-johnalbert/duckload-api/internal/features/slips"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/students"
 	"github.com/olazo-johnalbert/duckload-api/internal/features/users"
+	"github.com/olazo-johnalbert/duckload-api/internal/infrastructure/encryption"
 )
 
 func main() {
@@ -48,8 +50,20 @@ func main() {
 	}
 	defer db.Close()
 
+	keysFile := os.Getenv("ENCRYPTION_KEYS_FILE")
+	if keysFile == "" {
+		keysFile = encryption.DefaultKeysFile
+	}
+	if err := encryption.EnsureKeyFile(keysFile); err != nil {
+		log.Fatal("failed to create encryption keys:", err)
+	}
+	keyProvider, err := encryption.NewFileKeyProvider(keysFile)
+	if err != nil {
+		log.Fatal("failed to load encryption keys:", err)
+	}
+
 	usersRepo = users.NewRepository(db)
-	studentsRepo = students.NewRepository(db)
+	studentsRepo = students.NewRepository(db, encryption.NewCipher(keyProvider))
 	appointmentsRepo = appointments.NewRepository(db)
 	slipsRepo = slips.NewRepository(db)
 	locationsRepo = locations.NewRepository(db)
@@ -0,0 +1,22 @@
+-- ============================================================================
+-- PARTNER STUDENT LINKS
+-- ============================================================================
+
+CREATE TABLE partner_student_links (
+    id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    iir_id CHAR(36) NOT NULL,
+    client_id VARCHAR(36) NOT NULL,
+    external_id VARCHAR(100) NOT NULL,
+    linked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_partner_student_links_iir
+        FOREIGN KEY (iir_id) REFERENCES iir_records(id) ON DELETE CASCADE,
+    CONSTRAINT fk_partner_student_links_client
+        FOREIGN KEY (client_id) REFERENCES m2m_clients(client_id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci;
+
+CREATE UNIQUE INDEX unique_idx_partner_student_links_client_iir
+    ON partner_student_links(client_id ASC, iir_id ASC);
+-- A partner's external ID identifies exactly one student
+CREATE UNIQUE INDEX unique_idx_partner_student_links_client_external
+    ON partner_student_links(client_id ASC, external_id ASC);
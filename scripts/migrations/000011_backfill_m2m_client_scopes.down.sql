@@ -0,0 +1,2 @@
+-- Backfilled scopes are indistinguishable from granted ones; nothing to undo.
+SELECT 1;
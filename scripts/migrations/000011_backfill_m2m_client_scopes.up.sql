@@ -0,0 +1,12 @@
+-- ============================================================================
+-- M2M CLIENT SCOPES
+-- ============================================================================
+
+-- Clients created before scopes were enforced keep their read access.
+UPDATE m2m_clients
+SET scopes = JSON_ARRAY(
+    'students:read',
+    'students:personal-info',
+    'students:addresses'
+)
+WHERE scopes IS NULL OR JSON_LENGTH(scopes) = 0;
@@ -0,0 +1,6 @@
+ALTER TABLE appointments DROP FOREIGN KEY fk_appointments_counselor;
+DROP INDEX idx_appointments_counselor_id ON appointments;
+ALTER TABLE appointments DROP COLUMN counselor_id;
+
+DROP INDEX idx_counselor_profiles_is_available ON counselor_profiles;
+ALTER TABLE counselor_profiles DROP COLUMN updated_at;
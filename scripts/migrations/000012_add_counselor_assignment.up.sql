@@ -0,0 +1,17 @@
+-- ============================================================================
+-- COUNSELOR ASSIGNMENT
+-- ============================================================================
+
+ALTER TABLE counselor_profiles
+    ADD COLUMN updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        ON UPDATE CURRENT_TIMESTAMP AFTER created_at;
+
+CREATE INDEX idx_counselor_profiles_is_available
+    ON counselor_profiles(is_available ASC);
+
+ALTER TABLE appointments
+    ADD COLUMN counselor_id CHAR(36) NULL DEFAULT NULL AFTER iir_id,
+    ADD CONSTRAINT fk_appointments_counselor
+        FOREIGN KEY (counselor_id) REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_appointments_counselor_id ON appointments(counselor_id ASC);
@@ -0,0 +1,8 @@
+-- Restoring the unique keys fails if a slot already holds several bookings;
+-- resolve those rows before rolling back.
+DROP INDEX idx_appointments_date_slot ON appointments;
+
+ALTER TABLE appointments
+    ADD UNIQUE KEY unique_appointment (when_date, time_slot_id);
+CREATE UNIQUE INDEX unique_idx_appointment
+    ON appointments(when_date ASC, time_slot_id ASC);
@@ -0,0 +1,12 @@
+-- ============================================================================
+-- APPOINTMENT SLOT CAPACITY
+-- ============================================================================
+-- A time slot can now hold more than one booking (one per counselor), so the
+-- global (when_date, time_slot_id) uniqueness is replaced by a plain index.
+-- Capacity is enforced by the application under a time_slots row lock.
+
+ALTER TABLE appointments DROP INDEX unique_appointment;
+DROP INDEX unique_idx_appointment ON appointments;
+
+CREATE INDEX idx_appointments_date_slot
+    ON appointments(when_date ASC, time_slot_id ASC);
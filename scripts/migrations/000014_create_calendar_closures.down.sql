@@ -0,0 +1,6 @@
+DROP INDEX idx_appointments_flagged_at ON appointments;
+ALTER TABLE appointments
+    DROP COLUMN flag_reason,
+    DROP COLUMN flagged_at;
+
+DROP TABLE IF EXISTS calendar_closures;
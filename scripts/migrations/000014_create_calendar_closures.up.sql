@@ -0,0 +1,33 @@
+-- ============================================================================
+-- CALENDAR CLOSURES
+-- ============================================================================
+-- Office holidays and ad-hoc closures block the whole office; leave entries
+-- carry a counselor_id and only take that counselor out of the rotation.
+
+CREATE TABLE calendar_closures (
+    id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    closure_type ENUM('holiday', 'leave', 'closure') NOT NULL,
+    counselor_id CHAR(36) NULL DEFAULT NULL,
+    reason VARCHAR(255) NOT NULL,
+    created_by CHAR(36) NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_calendar_closures_counselor
+        FOREIGN KEY (counselor_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_calendar_closures_created_by
+        FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci;
+
+CREATE INDEX idx_calendar_closures_dates
+    ON calendar_closures(start_date ASC, end_date ASC);
+CREATE INDEX idx_calendar_closures_counselor_id
+    ON calendar_closures(counselor_id ASC);
+
+-- Appointments that land on a newly closed day are flagged for follow-up
+ALTER TABLE appointments
+    ADD COLUMN flagged_at TIMESTAMP NULL DEFAULT NULL AFTER counselor_id,
+    ADD COLUMN flag_reason VARCHAR(255) NULL DEFAULT NULL AFTER flagged_at;
+
+CREATE INDEX idx_appointments_flagged_at ON appointments(flagged_at ASC);
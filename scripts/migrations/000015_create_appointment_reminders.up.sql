@@ -0,0 +1,17 @@
+-- ============================================================================
+-- APPOINTMENT REMINDERS
+-- ============================================================================
+-- One row per (appointment, offset) reminder. The unique key is the claim:
+-- whichever scheduler instance inserts the row first sends the reminder.
+
+CREATE TABLE appointment_reminders (
+    id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    appointment_id CHAR(36) NOT NULL,
+    offset_minutes INT NOT NULL,
+    sent_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_appointment_reminders_appointment
+        FOREIGN KEY (appointment_id) REFERENCES appointments(id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci;
+
+CREATE UNIQUE INDEX unique_idx_appointment_reminders_offset
+    ON appointment_reminders(appointment_id ASC, offset_minutes ASC);
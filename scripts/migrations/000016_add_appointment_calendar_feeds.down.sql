@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS calendar_feed_tokens;
+
+ALTER TABLE appointments DROP COLUMN ical_sequence;
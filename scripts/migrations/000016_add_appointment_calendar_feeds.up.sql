@@ -0,0 +1,21 @@
+-- ============================================================================
+-- APPOINTMENT CALENDAR FEEDS
+-- ============================================================================
+-- ical_sequence is the iCalendar SEQUENCE of the appointment event; it is
+-- bumped on every update so calendar clients replace the old version.
+
+ALTER TABLE appointments
+    ADD COLUMN ical_sequence INT NOT NULL DEFAULT 0 AFTER flag_reason;
+
+-- One subscription token per user; only its SHA-256 hash is stored
+CREATE TABLE calendar_feed_tokens (
+    user_id CHAR(36) NOT NULL PRIMARY KEY,
+    token_hash CHAR(64) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_calendar_feed_tokens_user
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci;
+
+CREATE UNIQUE INDEX unique_idx_calendar_feed_tokens_hash
+    ON calendar_feed_tokens(token_hash ASC);
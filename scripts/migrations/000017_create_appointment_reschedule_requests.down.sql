@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS appointment_reschedule_options;
+DROP TABLE IF EXISTS appointment_reschedule_requests;
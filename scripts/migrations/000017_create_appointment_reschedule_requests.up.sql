@@ -0,0 +1,46 @@
+-- ============================================================================
+-- APPOINTMENT RESCHEDULE REQUESTS
+-- ============================================================================
+-- A student proposes one or more new schedules for an appointment; the
+-- counselor approves one of the options or declines the request. Requests
+-- are never deleted so the appointment keeps its reschedule history.
+
+CREATE TABLE appointment_reschedule_requests (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    appointment_id CHAR(36) NOT NULL,
+    requested_by CHAR(36) NOT NULL,
+    reason TEXT NOT NULL,
+    status ENUM('pending', 'approved', 'declined') NOT NULL DEFAULT 'pending',
+    chosen_option_id CHAR(36) NULL,
+    decision_note TEXT NULL,
+    decided_by CHAR(36) NULL,
+    decided_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_reschedule_requests_appointment
+        FOREIGN KEY (appointment_id) REFERENCES appointments(id) ON DELETE CASCADE,
+    CONSTRAINT fk_reschedule_requests_requested_by
+        FOREIGN KEY (requested_by) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_reschedule_requests_decided_by
+        FOREIGN KEY (decided_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci;
+
+CREATE INDEX idx_reschedule_requests_appointment
+    ON appointment_reschedule_requests(appointment_id ASC, status ASC);
+
+-- The proposed schedules of a request, in the student's order of preference
+CREATE TABLE appointment_reschedule_options (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    request_id CHAR(36) NOT NULL,
+    position TINYINT NOT NULL,
+    when_date DATE NOT NULL,
+    time_slot_id INT NOT NULL,
+    CONSTRAINT fk_reschedule_options_request
+        FOREIGN KEY (request_id) REFERENCES appointment_reschedule_requests(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_reschedule_options_time_slot
+        FOREIGN KEY (time_slot_id) REFERENCES time_slots(id)
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci;
+
+CREATE UNIQUE INDEX unique_idx_reschedule_options_position
+    ON appointment_reschedule_options(request_id ASC, position ASC);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS appointment_waitlist_offers;
+DROP TABLE IF EXISTS appointment_waitlist;
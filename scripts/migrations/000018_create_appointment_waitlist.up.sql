@@ -0,0 +1,58 @@
+-- ============================================================================
+-- APPOINTMENT WAITLIST
+-- ============================================================================
+-- Students waiting for any slot in a date range. When a booking frees a
+-- slot, the oldest matching entry is offered it for a limited time; an
+-- unconfirmed offer expires and moves on to the next entry.
+
+CREATE TABLE appointment_waitlist (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    iir_id CHAR(36) NOT NULL,
+    appointment_category_id INT NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    reason TEXT NULL,
+    status ENUM('waiting', 'offered', 'booked', 'cancelled', 'expired')
+        NOT NULL DEFAULT 'waiting',
+    appointment_id CHAR(36) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_waitlist_iir
+        FOREIGN KEY (iir_id) REFERENCES iir_records(id) ON DELETE CASCADE,
+    CONSTRAINT fk_waitlist_category
+        FOREIGN KEY (appointment_category_id)
+        REFERENCES appointment_categories(id),
+    CONSTRAINT fk_waitlist_appointment
+        FOREIGN KEY (appointment_id) REFERENCES appointments(id)
+        ON DELETE SET NULL
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci;
+
+CREATE INDEX idx_waitlist_queue
+    ON appointment_waitlist(status ASC, start_date ASC, end_date ASC, created_at ASC);
+
+CREATE INDEX idx_waitlist_iir ON appointment_waitlist(iir_id ASC);
+
+-- A slot is offered to an entry at most once, so a declined or expired
+-- offer is never repeated.
+CREATE TABLE appointment_waitlist_offers (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    waitlist_id CHAR(36) NOT NULL,
+    when_date DATE NOT NULL,
+    time_slot_id INT NOT NULL,
+    status ENUM('pending', 'accepted', 'declined', 'expired')
+        NOT NULL DEFAULT 'pending',
+    expires_at DATETIME NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_waitlist_offers_waitlist
+        FOREIGN KEY (waitlist_id) REFERENCES appointment_waitlist(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_waitlist_offers_time_slot
+        FOREIGN KEY (time_slot_id) REFERENCES time_slots(id)
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci;
+
+CREATE UNIQUE INDEX unique_idx_waitlist_offers_slot
+    ON appointment_waitlist_offers(waitlist_id ASC, when_date ASC, time_slot_id ASC);
+
+CREATE INDEX idx_waitlist_offers_hold
+    ON appointment_waitlist_offers(status ASC, when_date ASC, time_slot_id ASC, expires_at ASC);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS slip_urgency_category_weights;
+DROP TABLE IF EXISTS slip_urgency_policy;
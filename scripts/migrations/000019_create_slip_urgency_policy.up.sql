@@ -0,0 +1,56 @@
+-- ============================================================================
+-- SLIP URGENCY POLICY
+-- ============================================================================
+-- Weights used to rank pending admission slips. There is a single policy
+-- row; each factor contributes points that are added up into the score:
+--   needed:     (needed_horizon_days - days until date_needed) * needed_weight
+--   absence:    days since the absence, capped at absence_horizon_days,
+--               * absence_weight
+--   repeat:     other slips by the same IIR within repeat_lookback_days of
+--               the absence * repeat_weight
+--   attachment: attachment_weight when at least one file is attached
+--   category:   the weight in slip_urgency_category_weights
+
+CREATE TABLE slip_urgency_policy (
+    id TINYINT NOT NULL PRIMARY KEY DEFAULT 1,
+    needed_horizon_days INT NOT NULL,
+    needed_weight INT NOT NULL,
+    absence_horizon_days INT NOT NULL,
+    absence_weight INT NOT NULL,
+    repeat_lookback_days INT NOT NULL,
+    repeat_weight INT NOT NULL,
+    attachment_weight INT NOT NULL,
+    updated_by CHAR(36) NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT chk_slip_urgency_policy_single CHECK (id = 1),
+    CONSTRAINT fk_slip_urgency_policy_updated_by
+        FOREIGN KEY (updated_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci;
+
+-- Keeps the previous days-until-needed scoring and adds the new factors
+INSERT INTO slip_urgency_policy (
+    id,
+    needed_horizon_days,
+    needed_weight,
+    absence_horizon_days,
+    absence_weight,
+    repeat_lookback_days,
+    repeat_weight,
+    attachment_weight
+) VALUES (1, 1000, 10, 30, 5, 90, 100, 200);
+
+-- Categories without a row score 0
+CREATE TABLE slip_urgency_category_weights (
+    category_id INT NOT NULL PRIMARY KEY,
+    weight INT NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_slip_urgency_category_weights_category
+        FOREIGN KEY (category_id) REFERENCES admission_slip_categories(id)
+        ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci;
+
+-- Medical slips (category 1) keep the bonus they had under the hard-coded
+-- score. On a fresh database the categories are seeded after migrations, so
+-- the slip urgency seed adds this row there.
+INSERT INTO slip_urgency_category_weights (category_id, weight)
+SELECT id, 500 FROM admission_slip_categories WHERE id = 1;
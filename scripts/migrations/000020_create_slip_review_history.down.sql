@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS slip_comments;
+DROP TABLE IF EXISTS slip_status_history;
+DROP TABLE IF EXISTS slip_revisions;
+
+DROP INDEX idx_slip_attachments_round ON slip_attachments;
+ALTER TABLE slip_attachments DROP COLUMN revision_round;
+ALTER TABLE admission_slips DROP COLUMN revision_round;
@@ -0,0 +1,118 @@
+-- ============================================================================
+-- SLIP REVIEW HISTORY
+-- ============================================================================
+-- Every submission of a slip is a revision round. Sending a slip back
+-- "For Revision" and resubmitting it starts a new round; earlier rounds
+-- keep their details and attachments.
+
+ALTER TABLE admission_slips
+    ADD COLUMN revision_round INT NOT NULL DEFAULT 1 AFTER status_id;
+
+ALTER TABLE slip_attachments
+    ADD COLUMN revision_round INT NOT NULL DEFAULT 1 AFTER file_url;
+
+CREATE INDEX idx_slip_attachments_round
+    ON slip_attachments(admission_slip_id ASC, revision_round ASC);
+
+-- What the student submitted in each round
+CREATE TABLE slip_revisions (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    admission_slip_id CHAR(36) NOT NULL,
+    revision_round INT NOT NULL,
+    reason TEXT NOT NULL,
+    date_of_absence DATE NOT NULL,
+    date_needed DATE NOT NULL,
+    category_id INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT uq_slip_revisions_round UNIQUE (admission_slip_id, revision_round),
+    CONSTRAINT fk_slip_revisions_slip
+        FOREIGN KEY (admission_slip_id) REFERENCES admission_slips(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_slip_revisions_category
+        FOREIGN KEY (category_id) REFERENCES admission_slip_categories(id)
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci;
+
+INSERT INTO slip_revisions (
+    id,
+    admission_slip_id,
+    revision_round,
+    reason,
+    date_of_absence,
+    date_needed,
+    category_id,
+    created_at
+)
+SELECT UUID(), id, 1, reason, date_of_absence, date_needed, category_id, created_at
+FROM admission_slips;
+
+-- Append-only log of status changes; from_status_id is NULL for the
+-- initial submission
+CREATE TABLE slip_status_history (
+    id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    admission_slip_id CHAR(36) NOT NULL,
+    revision_round INT NOT NULL,
+    from_status_id INT NULL,
+    to_status_id INT NOT NULL,
+    changed_by CHAR(36) NULL,
+    note TEXT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_slip_status_history_slip
+        FOREIGN KEY (admission_slip_id) REFERENCES admission_slips(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_slip_status_history_from
+        FOREIGN KEY (from_status_id) REFERENCES statuses(id),
+    CONSTRAINT fk_slip_status_history_to
+        FOREIGN KEY (to_status_id) REFERENCES statuses(id),
+    CONSTRAINT fk_slip_status_history_changed_by
+        FOREIGN KEY (changed_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci;
+
+CREATE INDEX idx_slip_status_history_slip
+    ON slip_status_history(admission_slip_id ASC, id ASC);
+
+INSERT INTO slip_status_history (
+    admission_slip_id,
+    revision_round,
+    from_status_id,
+    to_status_id,
+    changed_by,
+    created_at
+)
+SELECT slp.id, 1, NULL, 1, ir.user_id, slp.created_at
+FROM admission_slips slp
+JOIN iir_records ir ON slp.iir_id = ir.id;
+
+INSERT INTO slip_status_history (
+    admission_slip_id,
+    revision_round,
+    from_status_id,
+    to_status_id,
+    note,
+    created_at
+)
+SELECT id, 1, 1, status_id, admin_notes, updated_at
+FROM admission_slips
+WHERE status_id <> 1;
+
+-- Counselor and student discussion on a slip; replies point at parent_id
+CREATE TABLE slip_comments (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    admission_slip_id CHAR(36) NOT NULL,
+    parent_id CHAR(36) NULL,
+    author_id CHAR(36) NOT NULL,
+    revision_round INT NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_slip_comments_slip
+        FOREIGN KEY (admission_slip_id) REFERENCES admission_slips(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_slip_comments_parent
+        FOREIGN KEY (parent_id) REFERENCES slip_comments(id) ON DELETE CASCADE,
+    CONSTRAINT fk_slip_comments_author
+        FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci;
+
+CREATE INDEX idx_slip_comments_slip
+    ON slip_comments(admission_slip_id ASC, created_at ASC);
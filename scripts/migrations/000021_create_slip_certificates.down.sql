@@ -0,0 +1 @@
+DROP TABLE IF EXISTS slip_certificates;
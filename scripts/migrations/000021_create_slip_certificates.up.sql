@@ -0,0 +1,25 @@
+-- ============================================================================
+-- SLIP CERTIFICATES
+-- ============================================================================
+-- The printable PDF issued when a slip is approved. The verification code
+-- is printed on the PDF and encoded in its QR code; the signature is an
+-- HMAC over the slip details it certifies so edits made after issuance
+-- fail verification.
+
+CREATE TABLE slip_certificates (
+    id CHAR(36) NOT NULL PRIMARY KEY,
+    admission_slip_id CHAR(36) NOT NULL,
+    verification_code CHAR(10) NOT NULL,
+    signature CHAR(64) NOT NULL,
+    file_url VARCHAR(255) NOT NULL,
+    issued_by CHAR(36) NULL,
+    issued_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_slip_certificates_slip UNIQUE (admission_slip_id),
+    CONSTRAINT uq_slip_certificates_code UNIQUE (verification_code),
+    CONSTRAINT fk_slip_certificates_slip
+        FOREIGN KEY (admission_slip_id) REFERENCES admission_slips(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_slip_certificates_issued_by
+        FOREIGN KEY (issued_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci;
@@ -0,0 +1,9 @@
+DROP INDEX idx_slip_attachments_scan_status ON slip_attachments;
+
+ALTER TABLE slip_attachments
+    DROP COLUMN scanned_at,
+    DROP COLUMN last_scan_at,
+    DROP COLUMN scan_attempts,
+    DROP COLUMN scan_result,
+    DROP COLUMN scan_status,
+    DROP COLUMN content_type;
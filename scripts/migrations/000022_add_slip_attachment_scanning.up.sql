@@ -0,0 +1,21 @@
+-- ============================================================================
+-- SLIP ATTACHMENT SCANNING
+-- ============================================================================
+-- Uploads are validated on the way in and then held in quarantine until the
+-- malware scanner clears them. Only clean attachments can be downloaded.
+-- Files uploaded before scanning existed start in quarantine too, so the
+-- scan job checks them before they are served again. Files that cannot be
+-- read for scanning are marked failed instead of blocking the queue.
+
+ALTER TABLE slip_attachments
+    ADD COLUMN content_type VARCHAR(100) NULL AFTER file_url,
+    ADD COLUMN scan_status
+        ENUM('quarantined', 'clean', 'infected', 'failed')
+        NOT NULL DEFAULT 'quarantined' AFTER revision_round,
+    ADD COLUMN scan_result VARCHAR(255) NULL AFTER scan_status,
+    ADD COLUMN scan_attempts INT NOT NULL DEFAULT 0 AFTER scan_result,
+    ADD COLUMN last_scan_at TIMESTAMP NULL AFTER scan_attempts,
+    ADD COLUMN scanned_at TIMESTAMP NULL AFTER last_scan_at;
+
+CREATE INDEX idx_slip_attachments_scan_status
+    ON slip_attachments(scan_status ASC, last_scan_at ASC);
@@ -0,0 +1,14 @@
+DROP TRIGGER IF EXISTS trg_sig_note_versions_no_delete;
+DROP TRIGGER IF EXISTS trg_sig_note_versions_no_update;
+
+DROP TABLE IF EXISTS significant_note_versions;
+
+ALTER TABLE significant_notes
+    DROP FOREIGN KEY fk_sig_notes_deleted_by,
+    DROP FOREIGN KEY fk_sig_notes_created_by,
+    DROP COLUMN delete_reason,
+    DROP COLUMN deleted_by,
+    DROP COLUMN deleted_at,
+    DROP COLUMN created_by,
+    DROP COLUMN version,
+    DROP COLUMN confidentiality;
@@ -0,0 +1,70 @@
+-- ============================================================================
+-- SIGNIFICANT NOTE VERSIONS AND ACCESS
+-- ============================================================================
+-- Notes can be edited; every saved state is kept in significant_note_versions,
+-- which rejects updates and deletes. Deleting a note only hides it and
+-- records why. A note links to an appointment or an admission slip, never
+-- both; that rule lives in the service because MySQL does not allow CHECK
+-- constraints on columns with foreign key actions.
+--
+-- Confidentiality decides who may read a note:
+--   standard      counselors and super admins
+--   confidential  counselors only
+--   private       only the counselor who wrote it
+
+ALTER TABLE significant_notes
+    ADD COLUMN confidentiality ENUM('standard', 'confidential', 'private')
+        NOT NULL DEFAULT 'standard' AFTER remarks,
+    ADD COLUMN version INT NOT NULL DEFAULT 1 AFTER confidentiality,
+    ADD COLUMN created_by CHAR(36) NULL AFTER version,
+    ADD COLUMN deleted_at TIMESTAMP NULL AFTER created_by,
+    ADD COLUMN deleted_by CHAR(36) NULL AFTER deleted_at,
+    ADD COLUMN delete_reason TEXT NULL AFTER deleted_by,
+    ADD CONSTRAINT fk_sig_notes_created_by
+        FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL,
+    ADD CONSTRAINT fk_sig_notes_deleted_by
+        FOREIGN KEY (deleted_by) REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE TABLE significant_note_versions (
+    id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    note_id CHAR(36) NOT NULL,
+    version INT NOT NULL,
+    note TEXT DEFAULT NULL,
+    remarks TEXT DEFAULT NULL,
+    confidentiality ENUM('standard', 'confidential', 'private') NOT NULL,
+    appointment_id CHAR(36) NULL,
+    admission_slip_id CHAR(36) NULL,
+    edited_by CHAR(36) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_sig_note_versions UNIQUE (note_id, version),
+    CONSTRAINT fk_sig_note_versions_note
+        FOREIGN KEY (note_id) REFERENCES significant_notes(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_sig_note_versions_edited_by
+        FOREIGN KEY (edited_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci;
+
+INSERT INTO significant_note_versions (
+    note_id,
+    version,
+    note,
+    remarks,
+    confidentiality,
+    appointment_id,
+    admission_slip_id,
+    created_at
+)
+SELECT id, 1, note, remarks, 'standard', appointment_id, admission_slip_id, created_at
+FROM significant_notes;
+
+CREATE TRIGGER trg_sig_note_versions_no_update
+    BEFORE UPDATE ON significant_note_versions
+    FOR EACH ROW
+    SIGNAL SQLSTATE '45000'
+        SET MESSAGE_TEXT = 'significant note versions cannot be changed';
+
+CREATE TRIGGER trg_sig_note_versions_no_delete
+    BEFORE DELETE ON significant_note_versions
+    FOR EACH ROW
+    SIGNAL SQLSTATE '45000'
+        SET MESSAGE_TEXT = 'significant note versions cannot be deleted';
@@ -0,0 +1,28 @@
+-- Run `go run ./cmd/encryption decrypt` first; ciphertext does not fit the
+-- original column sizes.
+
+DROP TRIGGER IF EXISTS trg_sig_note_versions_no_update;
+
+CREATE TRIGGER trg_sig_note_versions_no_update
+    BEFORE UPDATE ON significant_note_versions
+    FOR EACH ROW
+    SIGNAL SQLSTATE '45000'
+        SET MESSAGE_TEXT = 'significant note versions cannot be changed';
+
+ALTER TABLE student_consultations
+    MODIFY COLUMN when_date VARCHAR(100) DEFAULT NULL,
+    MODIFY COLUMN for_what TEXT DEFAULT NULL;
+
+ALTER TABLE student_health_records
+    MODIFY COLUMN vision_details VARCHAR(255) DEFAULT NULL,
+    MODIFY COLUMN hearing_details VARCHAR(255) DEFAULT NULL,
+    MODIFY COLUMN speech_details VARCHAR(255) DEFAULT NULL,
+    MODIFY COLUMN general_health_details VARCHAR(255) DEFAULT NULL;
+
+ALTER TABLE significant_note_versions
+    MODIFY COLUMN note TEXT DEFAULT NULL,
+    MODIFY COLUMN remarks TEXT DEFAULT NULL;
+
+ALTER TABLE significant_notes
+    MODIFY COLUMN note TEXT DEFAULT NULL,
+    MODIFY COLUMN remarks TEXT DEFAULT NULL;
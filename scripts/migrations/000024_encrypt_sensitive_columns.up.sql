@@ -0,0 +1,64 @@
+-- ============================================================================
+-- FIELD-LEVEL ENCRYPTION
+-- ============================================================================
+-- Counseling notes, health details and consultation history are encrypted
+-- by the application before they are stored. Ciphertext is base64 encoded
+-- and longer than the plaintext, so the columns are widened. Existing rows
+-- stay readable as plaintext until `go run ./cmd/encryption reencrypt`
+-- encrypts them.
+
+ALTER TABLE significant_notes
+    MODIFY COLUMN note MEDIUMTEXT DEFAULT NULL,
+    MODIFY COLUMN remarks MEDIUMTEXT DEFAULT NULL;
+
+ALTER TABLE significant_note_versions
+    MODIFY COLUMN note MEDIUMTEXT DEFAULT NULL,
+    MODIFY COLUMN remarks MEDIUMTEXT DEFAULT NULL;
+
+ALTER TABLE student_health_records
+    MODIFY COLUMN vision_details TEXT DEFAULT NULL,
+    MODIFY COLUMN hearing_details TEXT DEFAULT NULL,
+    MODIFY COLUMN speech_details TEXT DEFAULT NULL,
+    MODIFY COLUMN general_health_details TEXT DEFAULT NULL;
+
+ALTER TABLE student_consultations
+    MODIFY COLUMN when_date TEXT DEFAULT NULL,
+    MODIFY COLUMN for_what MEDIUMTEXT DEFAULT NULL;
+
+-- Note versions stay immutable, except that the encryption command may
+-- re-encrypt the note and remarks, or decrypt them before a rollback. It
+-- sets @allow_version_rewrite on its connection; other writers cannot.
+DROP TRIGGER IF EXISTS trg_sig_note_versions_no_update;
+
+CREATE TRIGGER trg_sig_note_versions_no_update
+    BEFORE UPDATE ON significant_note_versions
+    FOR EACH ROW
+BEGIN
+    IF NOT (
+        NEW.id = OLD.id
+        AND NEW.note_id = OLD.note_id
+        AND NEW.version = OLD.version
+        AND NEW.confidentiality = OLD.confidentiality
+        AND NEW.appointment_id <=> OLD.appointment_id
+        AND NEW.admission_slip_id <=> OLD.admission_slip_id
+        AND NEW.edited_by <=> OLD.edited_by
+        AND NEW.created_at <=> OLD.created_at
+        AND (
+            NEW.note <=> OLD.note
+            OR (
+                @allow_version_rewrite <=> 1
+                AND (NEW.note LIKE 'enc:v1:%' OR OLD.note LIKE 'enc:v1:%')
+            )
+        )
+        AND (
+            NEW.remarks <=> OLD.remarks
+            OR (
+                @allow_version_rewrite <=> 1
+                AND (NEW.remarks LIKE 'enc:v1:%' OR OLD.remarks LIKE 'enc:v1:%')
+            )
+        )
+    ) THEN
+        SIGNAL SQLSTATE '45000'
+            SET MESSAGE_TEXT = 'significant note versions cannot be changed';
+    END IF;
+END;
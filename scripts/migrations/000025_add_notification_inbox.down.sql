@@ -0,0 +1,5 @@
+DROP INDEX idx_notifications_unread ON notifications;
+DROP INDEX idx_notifications_inbox ON notifications;
+
+ALTER TABLE notifications
+    DROP COLUMN archived_at;
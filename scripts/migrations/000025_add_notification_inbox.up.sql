@@ -0,0 +1,14 @@
+-- ============================================================================
+-- NOTIFICATION INBOX
+-- ============================================================================
+-- Archived notifications leave the inbox but are kept. The inbox is read
+-- newest first with (created_at, id) as the cursor, which the composite
+-- index serves without a filesort.
+
+ALTER TABLE notifications
+    ADD COLUMN archived_at TIMESTAMP NULL DEFAULT NULL AFTER is_read;
+
+CREATE INDEX idx_notifications_inbox
+    ON notifications(receiver_id, archived_at, created_at DESC, id DESC);
+CREATE INDEX idx_notifications_unread
+    ON notifications(receiver_id, is_read);
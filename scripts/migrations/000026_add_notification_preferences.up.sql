@@ -0,0 +1,51 @@
+-- ============================================================================
+-- NOTIFICATION PREFERENCES AND DIGESTS
+-- ============================================================================
+-- Each user chooses, per notification type, whether it shows in the in-app
+-- inbox and whether it is emailed right away, collected into a daily
+-- digest, or not emailed. Users without a row get the inbox and no email.
+--
+-- Digest entries wait in notification_digest_queue. An entry linked to an
+-- inbox notification is dropped once that notification is read or
+-- deleted. notification_digests records each user's digest per day so
+-- replicas never send the same digest twice.
+
+CREATE TABLE notification_preferences (
+    user_id CHAR(36) NOT NULL,
+    type ENUM('Appointment', 'Slip', 'Guidance', 'System', 'General') NOT NULL,
+    in_app TINYINT(1) NOT NULL DEFAULT 1,
+    email ENUM('off', 'immediate', 'digest') NOT NULL DEFAULT 'off',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, type),
+    CONSTRAINT fk_notification_preferences_user
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci;
+
+CREATE TABLE notification_digest_queue (
+    id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    user_id CHAR(36) NOT NULL,
+    notification_id CHAR(36) NULL DEFAULT NULL,
+    type ENUM('Appointment', 'Slip', 'Guidance', 'System', 'General') NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    message TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_notification_digest_queue_user
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_notification_digest_queue_notification
+        FOREIGN KEY (notification_id) REFERENCES notifications(id)
+        ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci;
+
+CREATE INDEX idx_notification_digest_queue_user
+    ON notification_digest_queue(user_id, created_at);
+
+CREATE TABLE notification_digests (
+    user_id CHAR(36) NOT NULL,
+    digest_date DATE NOT NULL,
+    sent_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, digest_date),
+    CONSTRAINT fk_notification_digests_user
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci;
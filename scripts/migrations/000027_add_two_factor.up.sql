@@ -0,0 +1,31 @@
+-- ============================================================================
+-- TWO-FACTOR AUTHENTICATION
+-- ============================================================================
+-- One TOTP secret per user. enabled_at stays NULL until the user confirms
+-- enrollment with a code from their authenticator app. The secret is
+-- stored with field-level encryption, like counseling notes.
+--
+-- Recovery codes are stored as SHA-256 hashes; each works once.
+
+CREATE TABLE user_two_factor (
+    id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    user_id CHAR(36) NOT NULL,
+    secret TEXT NOT NULL,
+    enabled_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT uq_user_two_factor_user UNIQUE (user_id),
+    CONSTRAINT fk_user_two_factor_user
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci;
+
+CREATE TABLE user_recovery_codes (
+    id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    user_id CHAR(36) NOT NULL,
+    code_hash CHAR(64) NOT NULL,
+    used_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_user_recovery_codes_hash UNIQUE (user_id, code_hash),
+    CONSTRAINT fk_user_recovery_codes_user
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_0900_ai_ci;
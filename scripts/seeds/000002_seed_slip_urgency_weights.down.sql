@@ -0,0 +1 @@
+DELETE FROM slip_urgency_category_weights WHERE category_id = 1;
@@ -0,0 +1,4 @@
+-- Medical slips keep the bonus they had under the hard-coded score. The
+-- 000019 migration already added it where the category existed.
+INSERT IGNORE INTO slip_urgency_category_weights (category_id, weight) VALUES
+    (1, 500);